@@ -1,15 +1,31 @@
 package app
 
 import (
+	"github.com/pkg/errors"
+
 	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/history"
 	"github.com/edouardparis/lntop/logging"
 	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/backend/record"
 )
 
 type App struct {
-	Config  *config.Config
-	Logger  logging.Logger
+	Config *config.Config
+	Logger logging.Logger
+	// Network is the first configured node.
 	Network *network.Network
+	// Networks are all the monitored nodes.
+	Networks []*network.Network
+	// History is the history of the nodes, it is nil when the history is
+	// disabled.
+	History *history.Store
+	// Recording is the recording of the session of the nodes, it is nil
+	// when the session is not recorded.
+	Recording *record.Recorder
+	// Unreachable are the names of the nodes that did not answer at start,
+	// they are reconnected by their pubsub.
+	Unreachable map[string]bool
 }
 
 func New(cfg *config.Config) (*App, error) {
@@ -18,14 +34,61 @@ func New(cfg *config.Config) (*App, error) {
 		return nil, err
 	}
 
-	network, err := network.New(&cfg.Network, logger)
-	if err != nil {
-		return nil, err
+	nodes := cfg.Nodes()
+	if len(nodes) == 0 {
+		return nil, errors.New("no network configured")
+	}
+
+	names := make(map[string]bool, len(nodes))
+	unreachable := make(map[string]bool)
+	networks := make([]*network.Network, len(nodes))
+	for i := range nodes {
+		if names[nodes[i].Name] {
+			return nil, errors.Errorf("network name %q is used more than once", nodes[i].Name)
+		}
+		names[nodes[i].Name] = true
+
+		networks[i], err = network.New(nodes[i], logger)
+		if err != nil {
+			return nil, err
+		}
+
+		// a node down does not prevent the others from being monitored.
+		err = networks[i].Ping()
+		if err != nil {
+			logger.Error("node unreachable",
+				logging.String("node", nodes[i].Name),
+				logging.Error(err))
+			unreachable[nodes[i].Name] = true
+		}
 	}
 
 	return &App{
-		Config:  cfg,
-		Logger:  logger,
-		Network: network,
+		Config:      cfg,
+		Logger:      logger,
+		Network:     networks[0],
+		Networks:    networks,
+		Unreachable: unreachable,
 	}, nil
 }
+
+// Close closes the networks, the history and the recording of the app.
+func (a *App) Close() error {
+	var err error
+	for _, n := range a.Networks {
+		if cerr := n.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if a.History != nil {
+		if cerr := a.History.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if a.Recording != nil {
+		if cerr := a.Recording.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	cli "gopkg.in/urfave/cli.v2"
 
 	"github.com/edouardparis/lntop/app"
 	"github.com/edouardparis/lntop/config"
 	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/history"
 	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend/record"
 	"github.com/edouardparis/lntop/pubsub"
 	"github.com/edouardparis/lntop/ui"
 )
@@ -34,68 +39,227 @@ func New(version string) *cli.App {
 				Aliases: []string{"c"},
 				Usage:   "path to config file",
 			},
+			&cli.StringFlag{
+				Name:    "connect",
+				Usage:   "lndconnect uri of the lnd node, replaces the network section",
+				EnvVars: []string{"LND_CONNECT"},
+			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "record the session of the nodes to a file",
+			},
+			&cli.StringFlag{
+				Name:  "replay",
+				Usage: "replay a recorded session instead of connecting to the nodes",
+			},
+			&cli.Float64Flag{
+				Name:  "replay-speed",
+				Usage: "speed factor of the replay",
+				Value: 1,
+			},
 		},
-		Commands: []*cli.Command{
+		Commands: append([]*cli.Command{
 			{
 				Name:    "pubsub",
 				Aliases: []string{""},
 				Usage:   "run the pubsub only",
 				Action:  pubsubRun,
 			},
-		},
+			exporterCommand(),
+			reportCommand(),
+		}, exportCommands()...),
 	}
 }
 
 func run(c *cli.Context) error {
-	cfg, err := config.Load(c.String("config"))
-	if err != nil {
-		return err
-	}
-
-	app, err := app.New(cfg)
+	app, err := newApp(c)
 	if err != nil {
 		return err
 	}
+	defer app.Close()
 
 	ctx := context.Background()
 
 	events := make(chan *events.Event)
-	ps := pubsub.New(app.Logger, app.Network)
+	pubsubs := newPubSubs(app)
+
+	// the events are recorded in the history before they reach the ui.
+	sub := events
+	var recorder *history.Recorder
+	if app.Config.History.Path != "" && c.String("replay") == "" {
+		recorder, err = openHistory(app)
+		if err != nil {
+			return err
+		}
+		sub = recorder.Run(ctx, events)
+	}
 
 	go func() {
-		err := ui.Run(ctx, app, events)
+		err := ui.Run(ctx, app, sub)
 		if err != nil {
 			app.Logger.Debug("ui", logging.String("error", err.Error()))
 		}
-		ps.Stop()
+		for i := range pubsubs {
+			pubsubs[i].Stop()
+		}
 	}()
 
-	ps.Run(ctx, events)
+	runPubSubs(ctx, pubsubs, events)
 	close(events)
+	if recorder != nil {
+		recorder.Wait()
+	}
 
 	return nil
 }
 
-func pubsubRun(c *cli.Context) error {
+// openHistory opens the history of the app and creates its recorder.
+func openHistory(app *app.App) (*history.Recorder, error) {
+	interval := 10 * time.Minute
+	if app.Config.History.SnapshotInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(app.Config.History.SnapshotInterval)
+		if err != nil {
+			return nil, errors.Wrap(err, "history snapshot_interval")
+		}
+		if interval <= 0 {
+			return nil, errors.New("history snapshot_interval must be positive")
+		}
+	}
+
+	store, err := history.Open(app.Config.History.Path)
+	if err != nil {
+		return nil, err
+	}
+	app.History = store
+	return history.NewRecorder(store, app.Networks, interval, app.Logger), nil
+}
+
+// newApp loads the config and creates the app, the session of the nodes is
+// recorded with --record.
+func newApp(c *cli.Context) (*app.App, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	app, err := app.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if path := c.String("record"); path != "" {
+		recorder, err := record.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		app.Recording = recorder
+		for i := range app.Networks {
+			app.Networks[i].Backend = record.New(app.Networks[i].Backend, recorder,
+				app.Logger.With(logging.String("logger", "record")))
+		}
+	}
+
+	return app, nil
+}
+
+// loadConfig loads the config, the node of the network section is replaced
+// by the lnd node of --connect and the nodes are replaced by the ones of the
+// recording with --replay.
+func loadConfig(c *cli.Context) (*config.Config, error) {
 	cfg, err := config.Load(c.String("config"))
+	if err != nil {
+		return nil, err
+	}
+
+	if uri := c.String("connect"); uri != "" {
+		cfg.Network.Type = "lnd"
+		cfg.Network.Connect = uri
+		if cfg.Network.Name == "" {
+			cfg.Network.Name = "lnd"
+		}
+	}
+
+	if path := c.String("replay"); path != "" {
+		err = replayNodes(cfg, path, c.Float64("replay-speed"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// replayNodes replaces the nodes of the config by the nodes of the
+// recording, their aliases are kept.
+func replayNodes(cfg *config.Config, path string, speed float64) error {
+	records, err := record.Load(path)
 	if err != nil {
 		return err
 	}
 
-	app, err := app.New(cfg)
+	aliases := make(map[string]config.Aliases)
+	for _, node := range cfg.Nodes() {
+		aliases[node.Name] = node.Aliases
+	}
+
+	cfg.Network = config.Network{}
+	cfg.Networks = nil
+	for _, name := range record.Nodes(records) {
+		cfg.Networks = append(cfg.Networks, config.Network{
+			Name:        name,
+			Type:        "replay",
+			Address:     path,
+			ReplaySpeed: speed,
+			Aliases:     aliases[name],
+		})
+	}
+	return nil
+}
+
+// newPubSubs creates a pubsub for each node of the app.
+func newPubSubs(app *app.App) []*pubsub.PubSub {
+	pubsubs := make([]*pubsub.PubSub, len(app.Networks))
+	for i := range app.Networks {
+		pubsubs[i] = pubsub.New(app.Logger, app.Networks[i])
+		if app.Unreachable[app.Networks[i].NodeName()] {
+			pubsubs[i].Down()
+		}
+	}
+	return pubsubs
+}
+
+// runPubSubs runs the pubsubs until they are all stopped.
+func runPubSubs(ctx context.Context, pubsubs []*pubsub.PubSub, events chan *events.Event) {
+	wg := &sync.WaitGroup{}
+	wg.Add(len(pubsubs))
+	for i := range pubsubs {
+		go func(ps *pubsub.PubSub) {
+			ps.Run(ctx, events)
+			wg.Done()
+		}(pubsubs[i])
+	}
+	wg.Wait()
+}
+
+func pubsubRun(c *cli.Context) error {
+	app, err := newApp(c)
 	if err != nil {
 		return err
 	}
+	defer app.Close()
 
 	events := make(chan *events.Event)
-	ps := pubsub.New(app.Logger, app.Network)
-	ps.Run(context.Background(), events)
+	pubsubs := newPubSubs(app)
+	runPubSubs(context.Background(), pubsubs, events)
 
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
 	go func() {
 		<-sig
-		ps.Stop()
+		for i := range pubsubs {
+			pubsubs[i].Stop()
+		}
 	}()
 
 	return nil
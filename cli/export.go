@@ -0,0 +1,243 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v2"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/export"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+// exportFlags are the flags of every headless command.
+var exportFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "format",
+		Aliases: []string{"f"},
+		Usage:   "output format: table, json or csv",
+		Value:   export.FormatTable,
+	},
+	&cli.StringFlag{
+		Name:  "node",
+		Usage: "name of the node, the first configured node by default",
+	},
+}
+
+// exportCommands are the headless commands printing the state of a node.
+func exportCommands() []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:   "info",
+			Usage:  "print the node information and balances",
+			Flags:  exportFlags,
+			Action: infoRun,
+		},
+		{
+			Name:   "channels",
+			Usage:  "print the channels with the columns of the channels view",
+			Flags:  exportFlags,
+			Action: channelsRun,
+		},
+		{
+			Name:   "transactions",
+			Usage:  "print the on-chain transactions with the columns of the transactions view",
+			Flags:  exportFlags,
+			Action: transactionsRun,
+		},
+		{
+			Name:  "fwdinghist",
+			Usage: "print the forwarding history with the columns of the fwdinghist view",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "start",
+					Usage: "start of the history relative to now, e.g. -7d, the fwdinghist view option by default",
+				},
+				&cli.UintFlag{
+					Name:  "max",
+					Usage: "maximum number of events, the fwdinghist view option by default",
+				},
+			}, exportFlags...),
+			Action: fwdinghistRun,
+		},
+	}
+}
+
+// newExportNetwork connects to the node selected by the --node flag, only
+// this node is connected to. The logger is the one of the config.
+func newExportNetwork(c *cli.Context) (*config.Config, *network.Network, logging.Logger, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nodes := cfg.Nodes()
+	if len(nodes) == 0 {
+		return nil, nil, nil, errors.New("no network configured")
+	}
+	node := nodes[0]
+	if name := c.String("node"); name != "" {
+		node = nil
+		for i := range nodes {
+			if nodes[i].Name == name {
+				node = nodes[i]
+				break
+			}
+		}
+		if node == nil {
+			return nil, nil, nil, errors.Errorf("unknown node %q", name)
+		}
+	}
+
+	logger, err := logging.New(cfg.Logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	net, err := network.New(node, logger)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = net.Ping()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cfg, net, logger, nil
+}
+
+func infoRun(c *cli.Context) error {
+	_, net, _, err := newExportNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	info, err := net.Info(ctx)
+	if err != nil {
+		return err
+	}
+
+	channelsBalance, err := net.GetChannelsBalance(ctx)
+	if err != nil {
+		return err
+	}
+
+	walletBalance, err := net.GetWalletBalance(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := export.Info(net.NodeName(), info, channelsBalance, walletBalance)
+	return export.Write(os.Stdout, c.String("format"), table)
+}
+
+func channelsRun(c *cli.Context) error {
+	cfg, net, logger, err := newExportNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	info, err := net.Info(ctx)
+	if err != nil {
+		return err
+	}
+
+	channels, err := net.ListChannels(ctx, options.WithChannelPending)
+	if err != nil {
+		return err
+	}
+
+	// the channels are completed as in the channels view.
+	for i := range channels {
+		if channels[i].ID > 0 {
+			channels[i].Age = info.BlockHeight - uint32(channels[i].ID>>40)
+		}
+
+		// a channel whose info cannot be fetched is exported without its
+		// policies.
+		err = net.GetChannelInfo(ctx, channels[i])
+		if err != nil {
+			logger.Error("channel info",
+				logging.String("channel_point", channels[i].ChannelPoint),
+				logging.Error(err))
+		}
+
+		if channels[i].Node == nil {
+			channels[i].Node, err = net.GetNode(ctx, channels[i].RemotePubKey, false)
+			if err != nil {
+				channels[i].Node = nil
+			}
+		}
+	}
+
+	table, err := export.Channels(cfg.Views.Channels, channels)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, c.String("format"), table)
+}
+
+func transactionsRun(c *cli.Context) error {
+	cfg, net, _, err := newExportNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	transactions, err := net.GetTransactions(context.Background())
+	if err != nil {
+		return err
+	}
+
+	table, err := export.Transactions(cfg.Views.Transactions, transactions)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, c.String("format"), table)
+}
+
+func fwdinghistRun(c *cli.Context) error {
+	cfg, net, _, err := newExportNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	start, max := "-12h", uint32(0)
+	if cfg.Views.FwdingHist != nil {
+		if s := cfg.Views.FwdingHist.Options.GetOption("START_TIME", "start_time"); s != "" {
+			start = s
+		}
+	}
+	if c.IsSet("start") {
+		start = c.String("start")
+	}
+	if c.IsSet("max") {
+		max = uint32(c.Uint("max"))
+	} else if cfg.Views.FwdingHist != nil {
+		if m := cfg.Views.FwdingHist.Options.GetOption("MAX_NUM_EVENTS", "max_num_events"); m != "" {
+			n, err := strconv.ParseUint(m, 10, 32)
+			if err != nil {
+				return errors.Wrap(err, "max_num_events")
+			}
+			max = uint32(n)
+		}
+	}
+
+	events, err := net.GetForwardingHistory(context.Background(), start, max)
+	if err != nil {
+		return err
+	}
+
+	table, err := export.FwdingHist(cfg.Views.FwdingHist, events)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, c.String("format"), table)
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	cli "gopkg.in/urfave/cli.v2"
+
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/exporter"
+	"github.com/edouardparis/lntop/logging"
+)
+
+// exporterCommand serves the metrics of the nodes to prometheus.
+func exporterCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "exporter",
+		Usage: "serve the metrics of the nodes to prometheus",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "address of the metrics http server",
+				Value: ":9100",
+			},
+		},
+		Action: exporterRun,
+	}
+}
+
+func exporterRun(c *cli.Context) error {
+	app, err := newApp(c)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	exp := exporter.New(app)
+	go exp.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp.Handler())
+	server := &http.Server{Addr: c.String("listen"), Handler: mux}
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	events := make(chan *events.Event)
+	pubsubs := newPubSubs(app)
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			app.Logger.Error("exporter", logging.Error(err))
+		}
+		for i := range pubsubs {
+			pubsubs[i].Stop()
+		}
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		exp.Listen(ctx, events)
+		close(done)
+	}()
+
+	app.Logger.Info("exporter listening", logging.String("address", listener.Addr().String()))
+	runPubSubs(ctx, pubsubs, events)
+	close(events)
+	<-done
+
+	return nil
+}
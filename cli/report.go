@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	cli "gopkg.in/urfave/cli.v2"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/export"
+	"github.com/edouardparis/lntop/history"
+	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/options"
+	"github.com/edouardparis/lntop/report"
+)
+
+// reportCommand prints the reports of a node.
+func reportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "report",
+		Usage: "print the reports of a node",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "profit",
+				Usage: "print the profit and loss of the channels with the columns of the profit view",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "since",
+						Usage: "start of the report relative to now, e.g. -30d, the profit view option by default",
+					},
+				}, exportFlags...),
+				Action: profitRun,
+			},
+		},
+	}
+}
+
+func profitRun(c *cli.Context) error {
+	cfg, net, _, err := newExportNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	start := "-30d"
+	if cfg.Views.Profit != nil {
+		if s := cfg.Views.Profit.Options.GetOption("START_TIME", "start_time"); s != "" {
+			start = s
+		}
+	}
+	if c.IsSet("since") {
+		start = c.String("since")
+	}
+	now := time.Now()
+	since, err := options.ParseTime(start, now)
+	if err != nil {
+		return err
+	}
+
+	data := &report.ProfitData{Since: time.Unix(int64(since), 0), Now: now}
+	err = profitData(context.Background(), cfg, net, data)
+	if err != nil {
+		return err
+	}
+
+	table, err := export.Profit(cfg.Views.Profit, report.Profit(data))
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, c.String("format"), table)
+}
+
+// profitData fetches the data of the profit report from the node. The
+// forwarding history and the channel balances are read from the history
+// when it can be opened, the forwarding history is fetched from the node
+// otherwise.
+func profitData(ctx context.Context, cfg *config.Config, net *network.Network, data *report.ProfitData) error {
+	info, err := net.Info(ctx)
+	if err != nil {
+		return err
+	}
+	data.PubKey, data.BlockHeight = info.PubKey, info.BlockHeight
+
+	data.Channels, err = net.ListChannels(ctx, options.WithChannelPending)
+	if err != nil {
+		return err
+	}
+	for i := range data.Channels {
+		data.Channels[i].Node, _ = net.GetNode(ctx, data.Channels[i].RemotePubKey, false)
+	}
+
+	data.ClosedChannels, err = net.ClosedChannels(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range data.ClosedChannels {
+		// the peers of the closed channels may have left the graph.
+		data.ClosedChannels[i].Node, _ = net.GetNode(ctx, data.ClosedChannels[i].RemotePubKey, false)
+	}
+
+	data.Transactions, err = net.GetTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	data.Payments, err = report.Payments(ctx, net)
+	if err != nil {
+		return err
+	}
+
+	if cfg.History.Path != "" {
+		// the history is locked while lntop is running.
+		store, err := history.Open(cfg.History.Path)
+		if err == nil {
+			defer store.Close()
+			return historyProfitData(ctx, store, net, data)
+		}
+	}
+
+	data.Forwards, err = report.ForwardingHistory(ctx, net, data.Since)
+	return err
+}
+
+// historyProfitData reads the forwarding history and the channel balances
+// of the profit report from the history, once synchronized with the node.
+func historyProfitData(ctx context.Context, store *history.Store, net *network.Network, data *report.ProfitData) error {
+	err := store.SyncForwards(ctx, net)
+	if err != nil {
+		return err
+	}
+
+	data.Forwards, err = store.ForwardingEvents(net.NodeName(), data.Since, 0)
+	if err != nil {
+		return err
+	}
+
+	data.Snapshots, err = store.Snapshots(net.NodeName(), data.Since)
+	return err
+}
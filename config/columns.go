@@ -0,0 +1,138 @@
+package config
+
+// The default columns of the views, in order. They are used by the views
+// and the exports when no columns are configured.
+
+var DefaultChannelsColumns = []string{
+	"STATUS",
+	"ALIAS",
+	"GAUGE",
+	"LOCAL",
+	"CAP",
+	"SENT",
+	"RECEIVED",
+	"HTLC",
+	"UNSETTLED",
+	"CFEE",
+	"LAST UPDATE",
+	"PRIVATE",
+	"ID",
+}
+
+var DefaultPendingColumns = []string{
+	"STATUS",
+	"ALIAS",
+	"CAP",
+	"TXID",
+	"CONFIR",
+	"COMMIT_FEE",
+	"ANCHOR",
+	"LIMBO",
+	"RECOVERED",
+	"MATURITY",
+	"ETA",
+}
+
+var DefaultClosedColumns = []string{
+	"ALIAS",
+	"TYPE",
+	"CAP",
+	"SETTLED",
+	"TIME_LOCKED",
+	"ROUTED",
+	"LIFETIME",
+	"HEIGHT",
+	"CLOSING_TX",
+}
+
+var DefaultProfitColumns = []string{
+	"ALIAS",
+	"STATUS",
+	"CAP",
+	"FEES_OUT",
+	"FEES_IN",
+	"VOLUME_OUT",
+	"VOLUME_IN",
+	"REBALANCE",
+	"OPEN_COST",
+	"CLOSE_COST",
+	"CAPITAL_DAYS",
+	"YIELD_PPM",
+	"NET",
+}
+
+var DefaultTransactionsColumns = []string{
+	"DATE",
+	"HEIGHT",
+	"CONFIR",
+	"AMOUNT",
+	"FEE",
+	"ADDRESSES",
+}
+
+var DefaultRoutingColumns = []string{
+	"DIR",
+	"STATUS",
+	"IN_CHANNEL",
+	"IN_ALIAS",
+	"OUT_CHANNEL",
+	"OUT_ALIAS",
+	"AMOUNT",
+	"FEE",
+	"LAST UPDATE",
+	"DETAIL",
+}
+
+var DefaultFwdinghistColumns = []string{
+	"ALIAS_IN",
+	"ALIAS_OUT",
+	"AMT_IN",
+	"AMT_OUT",
+	"FEE",
+	"TIMESTAMP_NS",
+	"CHAN_ID_IN",
+	"CHAN_ID_OUT",
+}
+
+var DefaultInvoicesColumns = []string{
+	"DATE",
+	"AMOUNT",
+	"PAID",
+	"STATE",
+	"MEMO",
+	"SETTLED",
+	"EXPIRY",
+}
+
+var DefaultPaymentsColumns = []string{
+	"DATE",
+	"STATUS",
+	"AMOUNT",
+	"FEE",
+	"FEE_PPM",
+	"ATTEMPTS",
+	"DURATION",
+	"FAILURE",
+}
+
+var DefaultPeersColumns = []string{
+	"ALIAS",
+	"ADDRESS",
+	"DIR",
+	"PING",
+	"BYTES_SENT",
+	"BYTES_RECV",
+	"SENT",
+	"RECEIVED",
+	"FLAPS",
+	"CHANNELS",
+}
+
+var DefaultWalletColumns = []string{
+	"SELECTED",
+	"OUTPOINT",
+	"AMOUNT",
+	"CONFIRMATIONS",
+	"TYPE",
+	"ADDRESS",
+}
@@ -12,9 +12,24 @@ import (
 )
 
 type Config struct {
-	Logger  Logger  `toml:"logger"`
-	Network Network `toml:"network"`
-	Views   Views   `toml:"views"`
+	Logger   Logger    `toml:"logger"`
+	Network  Network   `toml:"network"`
+	Networks []Network `toml:"networks"`
+	Views    Views     `toml:"views"`
+	History  History   `toml:"history"`
+}
+
+// Nodes returns the configuration of every monitored node, the node of the
+// network section comes first followed by the networks entries.
+func (c *Config) Nodes() []*Network {
+	nodes := []*Network{}
+	if c.Network.Name != "" || c.Network.Type != "" || c.Network.Address != "" {
+		nodes = append(nodes, &c.Network)
+	}
+	for i := range c.Networks {
+		nodes = append(nodes, &c.Networks[i])
+	}
+	return nodes
 }
 
 type Logger struct {
@@ -22,14 +37,29 @@ type Logger struct {
 	Dest string `toml:"dest"`
 }
 
+// History is the local database keeping the history of the nodes, it is
+// disabled when the path is empty.
+type History struct {
+	Path string `toml:"path"`
+	// SnapshotInterval is the duration between two snapshots of the
+	// channel balances and synchronizations of the forwarding history.
+	SnapshotInterval string `toml:"snapshot_interval"`
+}
+
 type Network struct {
 	Name            string  `toml:"name"`
 	Type            string  `toml:"type"`
+	Transport       string  `toml:"transport"`
 	Address         string  `toml:"address"`
+	Connect         string  `toml:"connect"`
+	Proxy           string  `toml:"proxy"`
 	Cert            string  `toml:"cert"`
 	Macaroon        string  `toml:"macaroon"`
 	MacaroonTimeOut int64   `toml:"macaroon_timeout"`
 	MacaroonIP      string  `toml:"macaroon_ip"`
+	Password        string  `toml:"password"`
+	Scenario        string  `toml:"scenario"`
+	ReplaySpeed     float64 `toml:"replay_speed"`
 	MaxMsgRecvSize  int     `toml:"max_msg_recv_size"`
 	ConnTimeout     int     `toml:"conn_timeout"`
 	PoolCapacity    int     `toml:"pool_capacity"`
@@ -39,8 +69,15 @@ type Network struct {
 type Views struct {
 	Channels     *View `toml:"channels"`
 	Transactions *View `toml:"transactions"`
+	Invoices     *View `toml:"invoices"`
+	Payments     *View `toml:"payments"`
 	Routing      *View `toml:"routing"`
 	FwdingHist   *View `toml:"fwdinghist"`
+	Peers        *View `toml:"peers"`
+	Wallet       *View `toml:"wallet"`
+	Pending      *View `toml:"pending"`
+	Closed       *View `toml:"closed"`
+	Profit       *View `toml:"profit"`
 }
 
 type ColumnOptions map[string]map[string]string
@@ -76,9 +113,25 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	c.Network.loadEnv()
 	return c, nil
 }
 
+// loadEnv replaces the cert and the macaroon of an lnd node by the inline
+// values of the LND_CERT and LND_MACAROON environment variables, they are
+// only kept in memory.
+func (n *Network) loadEnv() {
+	if n.Type != "" && n.Type != "lnd" {
+		return
+	}
+	if cert, present := os.LookupEnv("LND_CERT"); present {
+		n.Cert = cert
+	}
+	if macaroon, present := os.LookupEnv("LND_MACAROON"); present {
+		n.Macaroon = macaroon
+	}
+}
+
 // loadFromPath loads the configuration from configuration file path.
 func loadFromPath(path string, out interface{}) error {
 	var err error
@@ -119,7 +172,7 @@ func getAppDir() (string, error) {
 				return "", err
 			}
 			err = ioutil.WriteFile(dir+"/config.toml",
-				[]byte(DefaultFileContent()), 0644)
+				[]byte(DefaultFileContent()), 0600)
 			if err != nil {
 				return "", err
 			}
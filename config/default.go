@@ -18,13 +18,25 @@ dest = "%[2]s"
 name = "%[3]s"
 type = "%[4]s"
 address = "%[5]s"
-cert = "%[6]s"
-macaroon = "%[7]s"
+# connect = "lndconnect://host:port?cert=...&macaroon=..."
+connect = %[12]q
+# cert is the path of the tls certificate or the PEM certificate.
+cert = %[6]q
+# macaroon is the path of the macaroon or the hex or base64 macaroon.
+macaroon = %[7]q
 macaroon_timeout = %[8]d
 max_msg_recv_size = %[9]d
 conn_timeout = %[10]d
 pool_capacity = %[11]d
 
+[history]
+# path of the database keeping the routing events, the forwards, the channel
+# balances, the policy changes and the peer connections across restarts,
+# remove it to disable the history.
+path = %[13]q
+# interval between two snapshots of the channel balances.
+snapshot_interval = %[14]q
+
 [views]
 # views.channels is the view displaying channel list.
 [views.channels]
@@ -34,6 +46,7 @@ columns = [
 	"STATUS",      # status of the channel
 	"ALIAS",       # alias of the channel node
 	"GAUGE",       # ascii bar with percent local/capacity
+	# "SPARK",     # local/capacity over the last period, needs the history
 	"LOCAL",       # the local amount of the channel
 	# "REMOTE",    # the remote amount of the channel
 	"CAP",         # the total capacity of the channel
@@ -51,12 +64,16 @@ columns = [
 ]
 
 [views.channels.options]
-# Currently only one option for the AGE column. If enabled, uses multiple colors
-# from green to orange to indicate the channel age using 256 color scheme in
-# supported terminals
+# If enabled, the AGE column uses multiple colors from green to orange to
+# indicate the channel age using 256 color scheme in supported terminals
 
 # AGE = { color = "color" }
 
+# The SPARK column displays the local balance over the period, one of "24h",
+# "7d" or "30d". It is red when the channel is draining and green when it is
+# filling.
+# SPARK = { period = "24h" }
+
 [views.fwdinghist.options]
 # The forwarding history options determine how many forwarding events the 
 # forwarding history tab is displaying. The higher the number of fetched 
@@ -77,6 +94,31 @@ columns = [
 	"ADDRESSES", # number of transaction output addresses
 ]
 
+[views.invoices]
+columns = [
+	"DATE",       # creation date of the invoice
+	"AMOUNT",     # amount requested by the invoice
+	"PAID",       # amount paid
+	"STATE",      # one of: open, settled, canceled, accepted
+	"MEMO",       # description of the invoice
+	"SETTLED",    # settlement date of the invoice
+	"EXPIRY",     # expiry of the invoice
+	# "HASH",     # payment hash of the invoice
+]
+
+[views.payments]
+columns = [
+	"DATE",       # creation date of the payment
+	"STATUS",     # one of: in flight, succeeded, failed
+	"AMOUNT",     # amount sent to the destination
+	"FEE",        # routing fee paid
+	"FEE_PPM",    # routing fee in parts per million of the amount
+	"ATTEMPTS",   # number of htlc attempts
+	"DURATION",   # time between the creation and the resolution of the payment
+	"FAILURE",    # reason of the failure of the payment
+	# "HASH",     # payment hash of the payment
+]
+
 [views.routing]
 columns = [
 	"DIR",            # event type:  send, receive, forward
@@ -96,6 +138,92 @@ columns = [
 	"LAST UPDATE",    # last update
 	"DETAIL",         # error description
 ]
+
+[views.peers]
+columns = [
+	"ALIAS",        # alias of the peer node
+	"ADDRESS",      # network address of the peer
+	"DIR",          # direction of the connection: in or out
+	"PING",         # ping time
+	"BYTES_SENT",   # bytes sent to the peer
+	"BYTES_RECV",   # bytes received from the peer
+	"SENT",         # amount sent to the peer over the channels
+	"RECEIVED",     # amount received from the peer over the channels
+	"FLAPS",        # number of disconnections and reconnections
+	"CHANNELS",     # number of channels with the peer
+	# "PUBKEY",     # public key of the peer
+	# "LAST_FLAP",  # time of the last flap
+]
+
+[views.wallet]
+columns = [
+	"SELECTED",      # output selected to be spent by the next transaction
+	"OUTPOINT",      # outpoint of the output
+	"AMOUNT",        # amount of the output
+	"CONFIRMATIONS", # number of confirmations
+	"TYPE",          # type of the address: p2wpkh, np2wkh or p2tr
+	"ADDRESS",       # address of the output
+	# "TXID",        # transaction id of the output
+]
+
+[views.pending]
+columns = [
+	"STATUS",      # opening, closing, force closing or waiting close
+	"ALIAS",       # alias of the channel node
+	"CAP",         # the total capacity of the channel
+	"TXID",        # closing transaction, funding transaction while opening
+	"CONFIR",      # confirmations of the transaction
+	"COMMIT_FEE",  # fee of the commitment transaction
+	"ANCHOR",      # state of the anchor output: limbo, recovered or lost
+	"LIMBO",       # amount waiting for the maturity of the outputs
+	"RECOVERED",   # amount already swept back to the wallet
+	"MATURITY",    # blocks until the maturity of the outputs
+	"ETA",         # estimated time of the maturity
+	# "LOCAL",     # the local amount of the channel
+	# "CHANNEL_POINT", # channel point
+	# "PUBKEY",    # public key of the channel node
+]
+
+[views.closed]
+columns = [
+	"ALIAS",        # alias of the channel node
+	"TYPE",         # close type: coop, local force, remote force, breach, canceled or abandoned
+	"CAP",          # the total capacity of the channel
+	"SETTLED",      # amount returned to the wallet
+	"TIME_LOCKED",  # amount waiting for a time lock before it is returned
+	"ROUTED",       # amount forwarded in and out of the channel
+	"LIFETIME",     # time between the funding and the closing of the channel
+	"HEIGHT",       # block height of the closing
+	"CLOSING_TX",   # closing transaction
+	# "SCID",       # short channel id
+	# "CHANNEL_POINT", # channel point
+	# "PUBKEY",     # public key of the channel node
+]
+
+[views.profit]
+# The amounts are in satoshis over the period of the START_TIME option.
+columns = [
+	"ALIAS",        # alias of the channel node
+	"STATUS",       # open or closed
+	"CAP",          # the total capacity of the channel
+	"FEES_OUT",     # fees earned by the forwards leaving the channel
+	"FEES_IN",      # fees earned by the forwards entering the channel
+	"VOLUME_OUT",   # amount forwarded out of the channel
+	"VOLUME_IN",    # amount forwarded into the channel
+	# "FORWARDS",   # number of forwards in and out of the channel
+	"REBALANCE",    # fees paid to bring liquidity back to the channel
+	"OPEN_COST",    # on-chain fee of the funding transaction
+	"CLOSE_COST",   # on-chain fee of the closing transaction
+	"CAPITAL_DAYS", # local balance times the days it was deployed
+	"YIELD_PPM",    # annualized fees out minus rebalancing per capital deployed
+	"NET",          # fees out minus rebalancing and on-chain costs
+	# "SCID",       # short channel id
+	# "CHANNEL_POINT", # channel point
+	# "PUBKEY",     # public key of the channel node
+]
+
+[views.profit.options]
+START_TIME = { start_time = "-30d" }
 `,
 		cfg.Logger.Type,
 		cfg.Logger.Dest,
@@ -108,6 +236,9 @@ columns = [
 		cfg.Network.MaxMsgRecvSize,
 		cfg.Network.ConnTimeout,
 		cfg.Network.PoolCapacity,
+		cfg.Network.Connect,
+		cfg.History.Path,
+		cfg.History.SnapshotInterval,
 	)
 }
 
@@ -141,5 +272,9 @@ func NewDefault() *Config {
 			ConnTimeout:     1000000,
 			PoolCapacity:    4,
 		},
+		History: History{
+			Path:             path.Join(usr.HomeDir, ".lntop/history.db"),
+			SnapshotInterval: "10m",
+		},
 	}
 }
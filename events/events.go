@@ -4,20 +4,26 @@ const (
 	BlockReceived         = "block.received"
 	ChannelActive         = "channel.active"
 	ChannelBalanceUpdated = "channel.balance.updated"
+	ChannelClosed         = "channel.closed"
 	ChannelInactive       = "channel.inactive"
 	ChannelPending        = "channel.pending"
 	InvoiceCreated        = "invoice.created"
 	InvoiceSettled        = "invoice.settled"
+	InvoiceUpdated        = "invoice.updated"
+	PaymentUpdated        = "payment.updated"
 	PeerUpdated           = "peer.updated"
 	TransactionCreated    = "transaction.created"
 	WalletBalanceUpdated  = "wallet.balance.updated"
 	RoutingEventUpdated   = "routing.event.updated"
 	GraphUpdated          = "graph.updated"
+	ConnectionUpdated     = "connection.updated"
 )
 
 type Event struct {
 	Type string
 	ID   string
+	// Node is the name of the node the event comes from.
+	Node string
 	Data interface{}
 }
 
@@ -0,0 +1,131 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// channelsColumns are the values of the columns of the channels view.
+var channelsColumns = map[string]func(*models.Channel) interface{}{
+	"STATUS": func(c *models.Channel) interface{} { return channelStatus(c.Status) },
+	"ALIAS":  func(c *models.Channel) interface{} { return channelAlias(c) },
+	"GAUGE": func(c *models.Channel) interface{} {
+		if c.Capacity == 0 {
+			return 0
+		}
+		return c.LocalBalance * 100 / c.Capacity
+	},
+	"LOCAL":       func(c *models.Channel) interface{} { return c.LocalBalance },
+	"REMOTE":      func(c *models.Channel) interface{} { return c.RemoteBalance },
+	"CAP":         func(c *models.Channel) interface{} { return c.Capacity },
+	"SENT":        func(c *models.Channel) interface{} { return c.TotalAmountSent },
+	"RECEIVED":    func(c *models.Channel) interface{} { return c.TotalAmountReceived },
+	"HTLC":        func(c *models.Channel) interface{} { return len(c.PendingHTLC) },
+	"UNSETTLED":   func(c *models.Channel) interface{} { return c.UnsettledBalance },
+	"CFEE":        func(c *models.Channel) interface{} { return c.CommitFee },
+	"LAST UPDATE": func(c *models.Channel) interface{} { return c.LastUpdate },
+	"PRIVATE":     func(c *models.Channel) interface{} { return c.Private },
+	"ID":          func(c *models.Channel) interface{} { return c.ID },
+	"SCID": func(c *models.Channel) interface{} {
+		if c.ID == 0 {
+			return ""
+		}
+		return backend.FormatShortChannelID(c.ID)
+	},
+	"NUPD": func(c *models.Channel) interface{} { return c.UpdatesCount },
+	"BASE_OUT": func(c *models.Channel) interface{} {
+		if c.LocalPolicy == nil {
+			return nil
+		}
+		return c.LocalPolicy.FeeBaseMsat
+	},
+	"RATE_OUT": func(c *models.Channel) interface{} {
+		if c.LocalPolicy == nil {
+			return nil
+		}
+		return c.LocalPolicy.FeeRateMilliMsat
+	},
+	"BASE_IN": func(c *models.Channel) interface{} {
+		if c.RemotePolicy == nil {
+			return nil
+		}
+		return c.RemotePolicy.FeeBaseMsat
+	},
+	"RATE_IN": func(c *models.Channel) interface{} {
+		if c.RemotePolicy == nil {
+			return nil
+		}
+		return c.RemotePolicy.FeeRateMilliMsat
+	},
+	"AGE": func(c *models.Channel) interface{} { return c.Age },
+}
+
+// channelsViewColumns are the columns of the channels view that are not
+// exported, the sparkline of the balance is computed from the balances
+// sampled by the interface.
+var channelsViewColumns = map[string]bool{
+	"SPARK": true,
+}
+
+// Channels exports the channels with the columns of the channels view.
+func Channels(cfg *config.View, channels []*models.Channel) (*Table, error) {
+	configured := config.DefaultChannelsColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		configured = cfg.Columns
+	}
+	columns := []string{}
+	for _, column := range configured {
+		if !channelsViewColumns[column] {
+			columns = append(columns, column)
+		}
+	}
+
+	table := &Table{Columns: columns}
+	for _, c := range channels {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			value, ok := channelsColumns[columns[i]]
+			if !ok {
+				return nil, errors.Errorf("unknown channels column %q", columns[i])
+			}
+			row[i] = value(c)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// channelAlias returns the alias of the remote node, the configured alias
+// first, or its public key when it has none.
+func channelAlias(c *models.Channel) string {
+	if c.Node != nil && c.Node.ForcedAlias != "" {
+		return c.Node.ForcedAlias
+	}
+	if c.Node != nil && c.Node.Alias != "" {
+		return c.Node.Alias
+	}
+	return c.RemotePubKey
+}
+
+func channelStatus(status int) string {
+	switch status {
+	case models.ChannelActive:
+		return "active"
+	case models.ChannelInactive:
+		return "inactive"
+	case models.ChannelOpening:
+		return "opening"
+	case models.ChannelClosing:
+		return "closing"
+	case models.ChannelForceClosing:
+		return "force_closing"
+	case models.ChannelWaitingClose:
+		return "waiting_close"
+	case models.ChannelClosed:
+		return "closed"
+	}
+	return ""
+}
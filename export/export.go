@@ -0,0 +1,164 @@
+// Package export writes the state of a node as JSON, CSV or an aligned text
+// table for the headless commands.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+// Table is the exported data, the values of the rows are in the order of the
+// columns.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+	// Record is set for a table of a single row describing one object, it
+	// is written as a JSON object instead of an array and as name and value
+	// lines in a text table.
+	Record bool
+}
+
+// Write writes the table in the format.
+func Write(w io.Writer, format string, t *Table) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, t)
+	case FormatCSV:
+		return writeCSV(w, t)
+	case FormatTable, "":
+		return writeTable(w, t)
+	}
+	return errors.Errorf("unknown format %q, must be table, json or csv", format)
+}
+
+// key returns the JSON key of the column, "LAST UPDATE" is last_update.
+func key(column string) string {
+	return strings.ToLower(strings.ReplaceAll(column, " ", "_"))
+}
+
+// text returns the value as written in the CSV and text tables.
+func text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return text(*v)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeJSON writes the rows as objects keeping the order of the columns.
+func writeJSON(w io.Writer, t *Table) error {
+	var buffer bytes.Buffer
+	object := func(row []interface{}) error {
+		buffer.WriteString("{")
+		for i := range t.Columns {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			k, err := json.Marshal(key(t.Columns[i]))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			v, err := json.Marshal(row[i])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			buffer.Write(k)
+			buffer.WriteString(":")
+			buffer.Write(v)
+		}
+		buffer.WriteString("}")
+		return nil
+	}
+
+	if t.Record && len(t.Rows) == 1 {
+		err := object(t.Rows[0])
+		if err != nil {
+			return err
+		}
+	} else {
+		buffer.WriteString("[")
+		for i := range t.Rows {
+			if i > 0 {
+				buffer.WriteString(",")
+			}
+			err := object(t.Rows[i])
+			if err != nil {
+				return err
+			}
+		}
+		buffer.WriteString("]")
+	}
+
+	var out bytes.Buffer
+	err := json.Indent(&out, buffer.Bytes(), "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	out.WriteString("\n")
+	_, err = out.WriteTo(w)
+	return errors.WithStack(err)
+}
+
+func writeCSV(w io.Writer, t *Table) error {
+	writer := csv.NewWriter(w)
+	err := writer.Write(t.Columns)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i := range row {
+			record[i] = text(row[i])
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	writer.Flush()
+	return errors.WithStack(writer.Error())
+}
+
+func writeTable(w io.Writer, t *Table) error {
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if t.Record && len(t.Rows) == 1 {
+		for i := range t.Columns {
+			fmt.Fprintf(writer, "%s\t%s\n", t.Columns[i], text(t.Rows[0][i]))
+		}
+		return errors.WithStack(writer.Flush())
+	}
+
+	fmt.Fprintln(writer, strings.Join(t.Columns, "\t"))
+	for _, row := range t.Rows {
+		values := make([]string, len(row))
+		for i := range row {
+			values[i] = text(row[i])
+		}
+		fmt.Fprintln(writer, strings.Join(values, "\t"))
+	}
+	return errors.WithStack(writer.Flush())
+}
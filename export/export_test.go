@@ -0,0 +1,149 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	updated := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	rows := &Table{
+		Columns: []string{"ALIAS", "CAPACITY", "LAST UPDATE"},
+		Rows: [][]interface{}{
+			{"alice", int64(1000000), &updated},
+			{"bob, carol", int64(25), nil},
+		},
+	}
+	record := &Table{
+		Columns: []string{"ALIAS", "SYNCED"},
+		Rows:    [][]interface{}{{"node", true}},
+		Record:  true,
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		table  *Table
+		want   string
+	}{
+		{
+			name:   "table",
+			format: FormatTable,
+			table:  rows,
+			want: "ALIAS       CAPACITY  LAST UPDATE\n" +
+				"alice       1000000   2021-06-01T12:00:00Z\n" +
+				"bob, carol  25        \n",
+		},
+		{
+			name:   "default",
+			format: "",
+			table:  rows,
+			want: "ALIAS       CAPACITY  LAST UPDATE\n" +
+				"alice       1000000   2021-06-01T12:00:00Z\n" +
+				"bob, carol  25        \n",
+		},
+		{
+			name:   "table record",
+			format: FormatTable,
+			table:  record,
+			want:   "ALIAS   node\nSYNCED  true\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			table:  rows,
+			want: `[
+  {
+    "alias": "alice",
+    "capacity": 1000000,
+    "last_update": "2021-06-01T12:00:00Z"
+  },
+  {
+    "alias": "bob, carol",
+    "capacity": 25,
+    "last_update": null
+  }
+]
+`,
+		},
+		{
+			name:   "json record",
+			format: FormatJSON,
+			table:  record,
+			want: `{
+  "alias": "node",
+  "synced": true
+}
+`,
+		},
+		{
+			name:   "json empty",
+			format: FormatJSON,
+			table:  &Table{Columns: []string{"ALIAS"}},
+			want:   "[]\n",
+		},
+		{
+			name:   "csv",
+			format: FormatCSV,
+			table:  rows,
+			want: "ALIAS,CAPACITY,LAST UPDATE\n" +
+				"alice,1000000,2021-06-01T12:00:00Z\n" +
+				"\"bob, carol\",25,\n",
+		},
+		{
+			name:   "csv record",
+			format: FormatCSV,
+			table:  record,
+			want:   "ALIAS,SYNCED\nnode,true\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tt.format, tt.table)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, "xml", &Table{Columns: []string{"ALIAS"}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q", buf.String())
+	}
+}
+
+func TestText(t *testing.T) {
+	var zero time.Time
+	var none *time.Time
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"nil", nil, ""},
+		{"zero time", zero, ""},
+		{"nil time", none, ""},
+		{"time", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), "2021-06-01T00:00:00Z"},
+		{"number", 1.5, "1.5"},
+		{"bool", false, "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := text(tt.value); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// fwdinghistColumns are the values of the columns of the forwarding history
+// view.
+var fwdinghistColumns = map[string]func(*models.ForwardingEvent) interface{}{
+	"ALIAS_IN":     func(e *models.ForwardingEvent) interface{} { return e.PeerAliasIn },
+	"ALIAS_OUT":    func(e *models.ForwardingEvent) interface{} { return e.PeerAliasOut },
+	"CHAN_ID_IN":   func(e *models.ForwardingEvent) interface{} { return e.ChanIdIn },
+	"CHAN_ID_OUT":  func(e *models.ForwardingEvent) interface{} { return e.ChanIdOut },
+	"AMT_IN":       func(e *models.ForwardingEvent) interface{} { return e.AmtIn },
+	"AMT_OUT":      func(e *models.ForwardingEvent) interface{} { return e.AmtOut },
+	"FEE":          func(e *models.ForwardingEvent) interface{} { return e.Fee },
+	"TIMESTAMP_NS": func(e *models.ForwardingEvent) interface{} { return e.EventTime.UnixNano() },
+}
+
+// FwdingHist exports the forwarding events with the columns of the
+// forwarding history view.
+func FwdingHist(cfg *config.View, events []*models.ForwardingEvent) (*Table, error) {
+	columns := config.DefaultFwdinghistColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	table := &Table{Columns: columns}
+	for _, e := range events {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			value, ok := fwdinghistColumns[columns[i]]
+			if !ok {
+				return nil, errors.Errorf("unknown fwdinghist column %q", columns[i])
+			}
+			row[i] = value(e)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
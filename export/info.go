@@ -0,0 +1,33 @@
+package export
+
+import (
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// Info exports the information and the balances of the node.
+func Info(name string, info *models.Info, channels *models.ChannelsBalance, wallet *models.WalletBalance) *Table {
+	chain := ""
+	if len(info.Chains) > 0 {
+		chain = info.Chains[0]
+	}
+	network := "mainnet"
+	if info.Testnet {
+		network = "testnet"
+	}
+
+	return &Table{
+		Columns: []string{
+			"NODE", "ALIAS", "PUBKEY", "VERSION", "CHAIN", "NETWORK", "SYNCED",
+			"BLOCK_HEIGHT", "PEERS", "ACTIVE_CHANNELS", "PENDING_CHANNELS",
+			"INACTIVE_CHANNELS", "CHANNELS_BALANCE", "PENDING_OPEN_BALANCE",
+			"WALLET_CONFIRMED", "WALLET_UNCONFIRMED",
+		},
+		Rows: [][]interface{}{{
+			name, info.Alias, info.PubKey, info.Version, chain, network, info.Synced,
+			info.BlockHeight, info.NumPeers, info.NumActiveChannels, info.NumPendingChannels,
+			info.NumInactiveChannels, channels.Balance, channels.PendingOpenBalance,
+			wallet.ConfirmedBalance, wallet.UnconfirmedBalance,
+		}},
+		Record: true,
+	}
+}
@@ -0,0 +1,66 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// profitColumns are the values of the columns of the profit view, the
+// amounts are in satoshis.
+var profitColumns = map[string]func(*models.ChannelProfit) interface{}{
+	"ALIAS": func(p *models.ChannelProfit) interface{} {
+		return channelAlias(&models.Channel{Node: p.Node, RemotePubKey: p.RemotePubKey})
+	},
+	"STATUS": func(p *models.ChannelProfit) interface{} {
+		if p.Closed {
+			return "closed"
+		}
+		return "open"
+	},
+	"CAP":          func(p *models.ChannelProfit) interface{} { return p.Capacity },
+	"FEES_OUT":     func(p *models.ChannelProfit) interface{} { return p.FeesOutMsat / 1000 },
+	"FEES_IN":      func(p *models.ChannelProfit) interface{} { return p.FeesInMsat / 1000 },
+	"VOLUME_OUT":   func(p *models.ChannelProfit) interface{} { return p.VolumeOutMsat / 1000 },
+	"VOLUME_IN":    func(p *models.ChannelProfit) interface{} { return p.VolumeInMsat / 1000 },
+	"FORWARDS":     func(p *models.ChannelProfit) interface{} { return p.Forwards },
+	"REBALANCE":    func(p *models.ChannelProfit) interface{} { return p.RebalanceMsat / 1000 },
+	"OPEN_COST":    func(p *models.ChannelProfit) interface{} { return p.OpenCost },
+	"CLOSE_COST":   func(p *models.ChannelProfit) interface{} { return p.CloseCost },
+	"CAPITAL_DAYS": func(p *models.ChannelProfit) interface{} { return int64(p.CapitalDays) },
+	"YIELD_PPM":    func(p *models.ChannelProfit) interface{} { return p.YieldPPM() },
+	"NET":          func(p *models.ChannelProfit) interface{} { return p.NetMsat() / 1000 },
+	"SCID": func(p *models.ChannelProfit) interface{} {
+		if p.ID == 0 {
+			return ""
+		}
+		return backend.FormatShortChannelID(p.ID)
+	},
+	"CHANNEL_POINT": func(p *models.ChannelProfit) interface{} { return p.ChannelPoint },
+	"PUBKEY":        func(p *models.ChannelProfit) interface{} { return p.RemotePubKey },
+}
+
+// Profit exports the profit of the channels with the columns of the profit
+// view.
+func Profit(cfg *config.View, profit []*models.ChannelProfit) (*Table, error) {
+	columns := config.DefaultProfitColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	table := &Table{Columns: columns}
+	for _, p := range profit {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			value, ok := profitColumns[columns[i]]
+			if !ok {
+				return nil, errors.Errorf("unknown profit column %q", columns[i])
+			}
+			row[i] = value(p)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
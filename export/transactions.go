@@ -0,0 +1,43 @@
+package export
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// transactionsColumns are the values of the columns of the transactions view.
+var transactionsColumns = map[string]func(*models.Transaction) interface{}{
+	"DATE":      func(tx *models.Transaction) interface{} { return tx.Date },
+	"HEIGHT":    func(tx *models.Transaction) interface{} { return tx.BlockHeight },
+	"CONFIR":    func(tx *models.Transaction) interface{} { return tx.NumConfirmations },
+	"AMOUNT":    func(tx *models.Transaction) interface{} { return tx.Amount },
+	"FEE":       func(tx *models.Transaction) interface{} { return tx.TotalFees },
+	"ADDRESSES": func(tx *models.Transaction) interface{} { return len(tx.DestAddresses) },
+	"TXHASH":    func(tx *models.Transaction) interface{} { return tx.TxHash },
+	"BLOCKHASH": func(tx *models.Transaction) interface{} { return tx.BlockHash },
+}
+
+// Transactions exports the transactions with the columns of the transactions
+// view.
+func Transactions(cfg *config.View, transactions []*models.Transaction) (*Table, error) {
+	columns := config.DefaultTransactionsColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	table := &Table{Columns: columns}
+	for _, tx := range transactions {
+		row := make([]interface{}, len(columns))
+		for i := range columns {
+			value, ok := transactionsColumns[columns[i]]
+			if !ok {
+				return nil, errors.Errorf("unknown transactions column %q", columns[i])
+			}
+			row[i] = value(tx)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
@@ -0,0 +1,249 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+	uimodels "github.com/edouardparis/lntop/ui/models"
+)
+
+var (
+	channelLabels = []string{"node", "chan_id", "chan_point", "remote_pubkey", "alias"}
+	policyLabels  = append(append([]string{}, channelLabels...), "direction")
+
+	upDesc = newDesc("up",
+		"Whether the last refresh of the node succeeded.", "node")
+	blockHeightDesc = newDesc("block_height",
+		"Block height of the node.", "node")
+	syncedDesc = newDesc("synced",
+		"Whether the node is synced to the chain.", "node")
+	peersDesc = newDesc("peers",
+		"Number of peers of the node.", "node")
+	channelsDesc = newDesc("channels",
+		"Number of channels of the node by status.", "node", "status")
+	walletBalanceDesc = newDesc("wallet_balance_sat",
+		"On-chain balance of the wallet in sat.", "node", "status")
+	channelsBalanceDesc = newDesc("channels_balance_sat",
+		"Balance of the channels in sat.", "node", "status")
+
+	channelActiveDesc = newDesc("channel_active",
+		"Whether the channel is active.", channelLabels...)
+	channelCapacityDesc = newDesc("channel_capacity_sat",
+		"Capacity of the channel in sat.", channelLabels...)
+	channelLocalBalanceDesc = newDesc("channel_local_balance_sat",
+		"Local balance of the channel in sat.", channelLabels...)
+	channelRemoteBalanceDesc = newDesc("channel_remote_balance_sat",
+		"Remote balance of the channel in sat.", channelLabels...)
+	channelPendingHTLCsDesc = newDesc("channel_pending_htlcs",
+		"Number of pending htlcs of the channel.", channelLabels...)
+	channelUnsettledBalanceDesc = newDesc("channel_unsettled_balance_sat",
+		"Balance of the pending htlcs of the channel in sat.", channelLabels...)
+
+	policyFeeBaseDesc = newDesc("channel_fee_base_msat",
+		"Base fee of the channel policy in msat, out is the local policy.", policyLabels...)
+	policyFeeRateDesc = newDesc("channel_fee_rate_ppm",
+		"Fee rate of the channel policy in ppm, out is the local policy.", policyLabels...)
+	policyTimeLockDeltaDesc = newDesc("channel_time_lock_delta",
+		"Time lock delta of the channel policy, out is the local policy.", policyLabels...)
+	policyMinHTLCDesc = newDesc("channel_min_htlc_msat",
+		"Minimum htlc of the channel policy in msat, out is the local policy.", policyLabels...)
+	policyMaxHTLCDesc = newDesc("channel_max_htlc_msat",
+		"Maximum htlc of the channel policy in msat, out is the local policy.", policyLabels...)
+	policyDisabledDesc = newDesc("channel_disabled",
+		"Whether the channel policy is disabled, out is the local policy.", policyLabels...)
+)
+
+func newDesc(name, help string, labels ...string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		upDesc, blockHeightDesc, syncedDesc, peersDesc, channelsDesc,
+		walletBalanceDesc, channelsBalanceDesc,
+		channelActiveDesc, channelCapacityDesc, channelLocalBalanceDesc,
+		channelRemoteBalanceDesc, channelPendingHTLCsDesc, channelUnsettledBalanceDesc,
+		policyFeeBaseDesc, policyFeeRateDesc, policyTimeLockDeltaDesc,
+		policyMinHTLCDesc, policyMaxHTLCDesc, policyDisabledDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector, the metrics of the nodes are the
+// ones of their last refresh.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	metrics := []prometheus.Metric{}
+	for _, m := range e.nodes.List() {
+		nodeMetrics, ok := e.metrics[m.Name()]
+		if !ok {
+			// the node is not refreshed yet.
+			nodeMetrics = []prometheus.Metric{gauge(upDesc, 0, m.Name())}
+		}
+		metrics = append(metrics, nodeMetrics...)
+	}
+	e.mu.Unlock()
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+// Run refreshes the nodes at each refresh interval until the context is
+// done.
+func (e *Exporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		e.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// refresh refreshes the nodes concurrently, a node that does not answer
+// does not delay the others.
+func (e *Exporter) refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, m := range e.nodes.List() {
+		wg.Add(1)
+		go func(m *uimodels.Models) {
+			defer wg.Done()
+			e.refreshNode(ctx, m)
+		}(m)
+	}
+	wg.Wait()
+}
+
+// refreshNode refreshes the models of the node and keeps their metrics, only
+// the up metric is kept when the refresh fails.
+func (e *Exporter) refreshNode(ctx context.Context, m *uimodels.Models) {
+	update := e.updates[m.Name()]
+	update.Lock()
+	metrics := []prometheus.Metric{gauge(upDesc, 0, m.Name())}
+	err := refreshModels(ctx, m)
+	if err == nil {
+		metrics = append([]prometheus.Metric{gauge(upDesc, 1, m.Name())}, collectNode(m)...)
+	}
+	update.Unlock()
+
+	if err != nil && ctx.Err() == nil {
+		e.logger.Error("refresh node",
+			logging.String("node", m.Name()),
+			logging.Error(err))
+	}
+
+	e.mu.Lock()
+	e.metrics[m.Name()] = metrics
+	e.mu.Unlock()
+}
+
+func refreshModels(ctx context.Context, m *uimodels.Models) error {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	for _, fn := range []func(context.Context) error{
+		m.RefreshInfo,
+		m.RefreshWalletBalance,
+		m.RefreshChannelsBalance,
+		m.RefreshChannels,
+	} {
+		err := fn(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectNode(m *uimodels.Models) []prometheus.Metric {
+	node := m.Name()
+
+	metrics := []prometheus.Metric{
+		gauge(blockHeightDesc, float64(m.Info.BlockHeight), node),
+		gauge(syncedDesc, boolValue(m.Info.Synced), node),
+		gauge(peersDesc, float64(m.Info.NumPeers), node),
+		gauge(channelsDesc, float64(m.Info.NumActiveChannels), node, "active"),
+		gauge(channelsDesc, float64(m.Info.NumInactiveChannels), node, "inactive"),
+		gauge(channelsDesc, float64(m.Info.NumPendingChannels), node, "pending"),
+		gauge(walletBalanceDesc, float64(m.WalletBalance.ConfirmedBalance), node, "confirmed"),
+		gauge(walletBalanceDesc, float64(m.WalletBalance.UnconfirmedBalance), node, "unconfirmed"),
+		gauge(channelsBalanceDesc, float64(m.ChannelsBalance.Balance), node, "open"),
+		gauge(channelsBalanceDesc, float64(m.ChannelsBalance.PendingOpenBalance), node, "pending_open"),
+	}
+
+	for _, c := range m.Channels.List() {
+		// the closed channels are kept by the models but are not
+		// exported, their series would never go stale.
+		if c.Status == models.ChannelClosed {
+			continue
+		}
+
+		labels := channelLabelValues(node, c)
+		metrics = append(metrics,
+			gauge(channelActiveDesc, boolValue(c.Status == models.ChannelActive), labels...),
+			gauge(channelCapacityDesc, float64(c.Capacity), labels...),
+			gauge(channelLocalBalanceDesc, float64(c.LocalBalance), labels...),
+			gauge(channelRemoteBalanceDesc, float64(c.RemoteBalance), labels...),
+			gauge(channelPendingHTLCsDesc, float64(len(c.PendingHTLC)), labels...),
+			gauge(channelUnsettledBalanceDesc, float64(c.UnsettledBalance), labels...),
+		)
+
+		metrics = append(metrics, collectPolicy(c.LocalPolicy, append(labels, "out"))...)
+		metrics = append(metrics, collectPolicy(c.RemotePolicy, append(labels, "in"))...)
+	}
+	return metrics
+}
+
+func collectPolicy(policy *models.RoutingPolicy, labels []string) []prometheus.Metric {
+	if policy == nil {
+		return nil
+	}
+	return []prometheus.Metric{
+		gauge(policyFeeBaseDesc, float64(policy.FeeBaseMsat), labels...),
+		gauge(policyFeeRateDesc, float64(policy.FeeRateMilliMsat), labels...),
+		gauge(policyTimeLockDeltaDesc, float64(policy.TimeLockDelta), labels...),
+		gauge(policyMinHTLCDesc, float64(policy.MinHtlc), labels...),
+		gauge(policyMaxHTLCDesc, float64(policy.MaxHtlc), labels...),
+		gauge(policyDisabledDesc, boolValue(policy.Disabled), labels...),
+	}
+}
+
+// channelLabelValues returns the values of the channel labels, the alias is
+// the configured alias of the remote node first.
+func channelLabelValues(node string, c *models.Channel) []string {
+	chanID := ""
+	if c.ID > 0 {
+		chanID = backend.FormatShortChannelID(c.ID)
+	}
+	alias := ""
+	if c.Node != nil {
+		alias = c.Node.Alias
+		if c.Node.ForcedAlias != "" {
+			alias = c.Node.ForcedAlias
+		}
+	}
+	return []string{node, chanID, c.ChannelPoint, c.RemotePubKey, alias}
+}
+
+func gauge(desc *prometheus.Desc, value float64, labels ...string) prometheus.Metric {
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,184 @@
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/edouardparis/lntop/app"
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+	uimodels "github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	namespace = "lntop"
+
+	// refreshInterval is the interval of the refreshes of the nodes.
+	refreshInterval = 15 * time.Second
+
+	// refreshTimeout is the maximum duration of the refresh of a node.
+	refreshTimeout = 10 * time.Second
+)
+
+// Exporter exposes the metrics of the nodes to prometheus. The node, wallet
+// and channels metrics are refreshed from the nodes in the background and
+// the scrapes are served from the last refresh, the forwarding counters are
+// updated with the routing events of the pubsubs.
+type Exporter struct {
+	logger logging.Logger
+
+	nodes *uimodels.Nodes
+	// updates serialize the updates of the models of each node.
+	updates map[string]*sync.Mutex
+
+	// mu guards forwards, the active forwards of each node whose amount
+	// and fee are only known when they are settled, and metrics, the
+	// metrics of each node collected after its last refresh.
+	mu       sync.Mutex
+	forwards map[string][]*models.RoutingEvent
+	metrics  map[string][]prometheus.Metric
+
+	forwardsTotal      *prometheus.CounterVec
+	forwardAmountTotal *prometheus.CounterVec
+	forwardFeesTotal   *prometheus.CounterVec
+	channelForwards    *prometheus.CounterVec
+	channelForwardFees *prometheus.CounterVec
+}
+
+// New creates an exporter for the nodes of the app.
+func New(app *app.App) *Exporter {
+	nodes := uimodels.NewNodes(app)
+	updates := make(map[string]*sync.Mutex)
+	for _, m := range nodes.List() {
+		updates[m.Name()] = &sync.Mutex{}
+	}
+
+	return &Exporter{
+		logger:   app.Logger.With(logging.String("logger", "exporter")),
+		nodes:    nodes,
+		updates:  updates,
+		forwards: make(map[string][]*models.RoutingEvent),
+		metrics:  make(map[string][]prometheus.Metric),
+		forwardsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "forwards_total",
+			Help:      "Number of forwarded htlcs by status.",
+		}, []string{"node", "status"}),
+		forwardAmountTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "forward_amount_msat_total",
+			Help:      "Amount of the settled forwards in msat.",
+		}, []string{"node"}),
+		forwardFeesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "forward_fees_msat_total",
+			Help:      "Fees earned by the settled forwards in msat.",
+		}, []string{"node"}),
+		channelForwards: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "channel_forwards_total",
+			Help:      "Number of settled forwards of the channel by direction.",
+		}, []string{"node", "chan_id", "direction"}),
+		channelForwardFees: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "channel_forward_fees_msat_total",
+			Help:      "Fees earned by the settled forwards going out of the channel in msat.",
+		}, []string{"node", "chan_id"}),
+	}
+}
+
+// Handler returns the http handler serving the metrics.
+func (e *Exporter) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		e,
+		e.forwardsTotal,
+		e.forwardAmountTotal,
+		e.forwardFeesTotal,
+		e.channelForwards,
+		e.channelForwardFees,
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Listen updates the exporter with the events of the pubsubs until the
+// events channel is closed.
+func (e *Exporter) Listen(ctx context.Context, sub chan *events.Event) {
+	for event := range sub {
+		switch event.Type {
+		case events.RoutingEventUpdated:
+			routingEvent, ok := event.Data.(*models.RoutingEvent)
+			if !ok {
+				e.logger.Error("invalid routing event data")
+				continue
+			}
+			e.mu.Lock()
+			e.onRoutingEvent(event.Node, routingEvent)
+			e.mu.Unlock()
+		case events.GraphUpdated:
+			m := e.nodes.GetByName(event.Node)
+			if m == nil {
+				continue
+			}
+			// the policies are exported from the next refresh.
+			update := e.updates[m.Name()]
+			update.Lock()
+			err := m.RefreshPolicies(event.Data)(ctx)
+			update.Unlock()
+			if err != nil {
+				e.logger.Error("refresh policies", logging.Error(err))
+			}
+		}
+	}
+}
+
+// onRoutingEvent counts the forwards once they are settled or failed.
+func (e *Exporter) onRoutingEvent(node string, event *models.RoutingEvent) {
+	if event.Direction != models.RoutingForward {
+		return
+	}
+
+	var active *models.RoutingEvent
+	forwards := e.forwards[node]
+	for i := range forwards {
+		if forwards[i].Equals(event) {
+			active = forwards[i]
+			forwards = append(forwards[:i], forwards[i+1:]...)
+			break
+		}
+	}
+
+	switch event.Status {
+	case models.RoutingStatusActive:
+		if len(forwards) == uimodels.MaxRoutingEvents {
+			forwards = forwards[1:]
+		}
+		e.forwards[node] = append(forwards, event)
+		return
+	case models.RoutingStatusSettled:
+		amount, fee := event.AmountMsat, event.FeeMsat
+		if active != nil && amount == 0 {
+			amount, fee = active.AmountMsat, active.FeeMsat
+		}
+		chanIn := backend.FormatShortChannelID(event.IncomingChannelId)
+		chanOut := backend.FormatShortChannelID(event.OutgoingChannelId)
+		e.forwardsTotal.WithLabelValues(node, "settled").Inc()
+		e.forwardAmountTotal.WithLabelValues(node).Add(float64(amount))
+		e.forwardFeesTotal.WithLabelValues(node).Add(float64(fee))
+		e.channelForwards.WithLabelValues(node, chanIn, "in").Inc()
+		e.channelForwards.WithLabelValues(node, chanOut, "out").Inc()
+		e.channelForwardFees.WithLabelValues(node, chanOut).Add(float64(fee))
+	case models.RoutingStatusFailed:
+		e.forwardsTotal.WithLabelValues(node, "failed").Inc()
+	case models.RoutingStatusLinkFailed:
+		e.forwardsTotal.WithLabelValues(node, "link_failed").Inc()
+	}
+	e.forwards[node] = forwards
+}
@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/edouardparis/lntop/app"
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// hangingNode is a mock node whose info is never answered.
+type hangingNode struct {
+	*network.Network
+}
+
+func (n hangingNode) Info(ctx context.Context) (*models.Info, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newTestExporter(t *testing.T, names ...string) *Exporter {
+	t.Helper()
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &app.App{Config: &config.Config{}, Logger: logger}
+	for _, name := range names {
+		n, err := network.New(&config.Network{Name: name, Type: "mock"}, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name == "hanging" {
+			n = &network.Network{Backend: hangingNode{n}}
+		}
+		a.Networks = append(a.Networks, n)
+	}
+	return New(a)
+}
+
+func TestOnRoutingEvent(t *testing.T) {
+	e := newTestExporter(t, "alice")
+	forward := func(htlc uint64, status int, amount, fee uint64) *models.RoutingEvent {
+		return &models.RoutingEvent{
+			IncomingChannelId: 800000<<40 | 1<<16,
+			OutgoingChannelId: 800000<<40 | 2<<16,
+			IncomingHtlcId:    htlc,
+			Direction:         models.RoutingForward,
+			Status:            status,
+			AmountMsat:        amount,
+			FeeMsat:           fee,
+		}
+	}
+
+	for _, event := range []*models.RoutingEvent{
+		// the amount of the settled forward is the one of the active
+		// event.
+		forward(1, models.RoutingStatusActive, 1000000, 1000),
+		forward(1, models.RoutingStatusSettled, 0, 0),
+		// the amount of the settled event is kept when it is known.
+		forward(2, models.RoutingStatusActive, 1000000, 1000),
+		forward(2, models.RoutingStatusSettled, 2000000, 2000),
+		// a forward settled without active event.
+		forward(3, models.RoutingStatusSettled, 3000000, 3000),
+		forward(4, models.RoutingStatusActive, 1000000, 1000),
+		forward(4, models.RoutingStatusFailed, 0, 0),
+		forward(5, models.RoutingStatusLinkFailed, 0, 0),
+		// the payments are not forwards.
+		{Direction: models.RoutingSend, Status: models.RoutingStatusSettled, AmountMsat: 1000},
+	} {
+		e.onRoutingEvent("alice", event)
+	}
+
+	tests := []struct {
+		name      string
+		collector prometheus.Collector
+		want      float64
+	}{
+		{"settled", e.forwardsTotal.WithLabelValues("alice", "settled"), 3},
+		{"failed", e.forwardsTotal.WithLabelValues("alice", "failed"), 1},
+		{"link failed", e.forwardsTotal.WithLabelValues("alice", "link_failed"), 1},
+		{"amount", e.forwardAmountTotal.WithLabelValues("alice"), 6000000},
+		{"fees", e.forwardFeesTotal.WithLabelValues("alice"), 6000},
+		{"in", e.channelForwards.WithLabelValues("alice", "800000x1x0", "in"), 3},
+		{"out", e.channelForwards.WithLabelValues("alice", "800000x2x0", "out"), 3},
+		{"channel fees", e.channelForwardFees.WithLabelValues("alice", "800000x2x0"), 6000},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(tt.collector); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if len(e.forwards["alice"]) != 0 {
+		t.Errorf("got %d active forwards, want none", len(e.forwards["alice"]))
+	}
+}
+
+func TestCollectLastRefresh(t *testing.T) {
+	e := newTestExporter(t, "alice", "hanging")
+
+	// the nodes are down until they are refreshed.
+	if up := collectUp(t, e); up["alice"] != 0 || up["hanging"] != 0 {
+		t.Errorf("got up %v before the refresh", up)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	e.refresh(ctx)
+	if up := collectUp(t, e); up["alice"] != 1 || up["hanging"] != 0 {
+		t.Errorf("got up %v, want only alice up", up)
+	}
+
+	// the scrapes do not wait for a refresh.
+	refreshing := make(chan struct{})
+	go func() {
+		e.refresh(context.Background())
+		close(refreshing)
+	}()
+	scraped := make(chan map[string]float64)
+	go func() { scraped <- collectUp(t, e) }()
+	select {
+	case up := <-scraped:
+		if up["alice"] != 1 {
+			t.Errorf("got up %v, want the last refresh", up)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the scrape waits for the refresh")
+	}
+	select {
+	case <-refreshing:
+		t.Error("the refresh of the hanging node ended")
+	default:
+	}
+}
+
+// collectUp returns the up metric of each node.
+func collectUp(t *testing.T, e *Exporter) map[string]float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		e.Collect(ch)
+		close(ch)
+	}()
+	up := map[string]float64{}
+	for metric := range ch {
+		if metric.Desc() != upDesc {
+			continue
+		}
+		m := &dto.Metric{}
+		err := metric.Write(m)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		up[m.Label[0].GetValue()] = m.Gauge.GetValue()
+	}
+	return up
+}
@@ -5,14 +5,23 @@ go 1.16
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/awesome-gocui/gocui v1.1.0
+	github.com/btcsuite/btcd v0.23.3
+	github.com/btcsuite/btcd/btcutil v1.1.2
 	github.com/gofrs/uuid v4.0.0+incompatible
 	github.com/gookit/color v1.5.2
+	github.com/gorilla/websocket v1.4.2
 	github.com/lightningnetwork/lnd v0.15.4-beta
 	github.com/mattn/go-runewidth v0.0.13
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	go.etcd.io/bbolt v1.3.6
 	go.uber.org/zap v1.17.0
+	golang.org/x/net v0.0.0-20211015210444-4f30a5c0130f
 	golang.org/x/text v0.3.7
 	google.golang.org/grpc v1.38.0
+	google.golang.org/protobuf v1.26.0
 	gopkg.in/macaroon-bakery.v2 v2.1.0 // indirect
 	gopkg.in/macaroon.v2 v2.1.0
 	gopkg.in/urfave/cli.v2 v2.0.0-20180128182452-d3ae77c26ac8
@@ -0,0 +1,164 @@
+// Package history keeps the history of the nodes in a local bbolt database:
+// the routing events, the forwarding events, the snapshots of the channel
+// balances, the changes of the fee policies and the peer connections. Each
+// node has its own bucket holding a bucket per kind of record, the records
+// are keyed by their time so that they are iterated in order.
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	routingBucket  = "routing"
+	forwardsBucket = "forwards"
+	balancesBucket = "balances"
+	policiesBucket = "policies"
+	peersBucket    = "peers"
+	stateBucket    = "state"
+	// lastPoliciesBucket holds the last policy of each direction of the
+	// channels, the policies are only recorded when they change.
+	lastPoliciesBucket = "last_policies"
+)
+
+// Store is the history database.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the database at the path, it is created if it does not exist.
+// The database is locked while it is open.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err == bolt.ErrTimeout {
+		return nil, errors.Errorf("history %s is used by another process", path)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return errors.WithStack(s.db.Close())
+}
+
+// timeKey returns the key of a record at the time, the ids are appended to
+// tell the records of the same time apart.
+func timeKey(t time.Time, ids ...uint64) []byte {
+	key := make([]byte, 8*(len(ids)+1))
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	for i := range ids {
+		binary.BigEndian.PutUint64(key[8*(i+1):], ids[i])
+	}
+	return key
+}
+
+// keyTime returns the time of a record key.
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
+
+// bucket returns the bucket of the node, it is nil when the node or the
+// bucket does not exist in a read only transaction.
+func bucket(tx *bolt.Tx, node, name string) (*bolt.Bucket, error) {
+	if !tx.Writable() {
+		root := tx.Bucket([]byte(node))
+		if root == nil {
+			return nil, nil
+		}
+		return root.Bucket([]byte(name)), nil
+	}
+
+	root, err := tx.CreateBucketIfNotExists([]byte(node))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b, err := root.CreateBucketIfNotExists([]byte(name))
+	return b, errors.WithStack(err)
+}
+
+// put stores the records at their keys in the bucket of the node.
+func (s *Store) put(node, name string, keys [][]byte, values []interface{}) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, name)
+		if err != nil {
+			return err
+		}
+		for i := range keys {
+			data, err := json.Marshal(values[i])
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			err = b.Put(keys[i], data)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+// each calls fn with the records of the bucket of the node since the time,
+// from the oldest. With a limit, only the last records are read.
+func (s *Store) each(node, name string, since time.Time, limit int, fn func([]byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, name)
+		if err != nil || b == nil {
+			return err
+		}
+
+		c := b.Cursor()
+		first := c.First
+		if !since.IsZero() {
+			min := timeKey(since)
+			first = func() ([]byte, []byte) { return c.Seek(min) }
+		}
+
+		if limit > 0 {
+			// the cursor is moved back from the last record to the first
+			// of the limit.
+			start, _ := first()
+			if start == nil {
+				return nil
+			}
+			k, _ := c.Last()
+			for n := 1; n < limit && k != nil && bytes.Compare(k, start) > 0; n++ {
+				k, _ = c.Prev()
+			}
+			first = func() ([]byte, []byte) { return c.Seek(k) }
+		}
+
+		for k, v := first(); k != nil; k, v = c.Next() {
+			err := fn(v)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		return nil
+	})
+}
+
+// last returns the time of the last record of the bucket of the node, it is
+// zero when the bucket is empty.
+func (s *Store) last(node, name string) (time.Time, error) {
+	var t time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, name)
+		if err != nil || b == nil {
+			return err
+		}
+		k, _ := b.Cursor().Last()
+		if k != nil {
+			t = keyTime(k)
+		}
+		return nil
+	})
+	return t, err
+}
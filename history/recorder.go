@@ -0,0 +1,350 @@
+package history
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+const (
+	// queueSize is the number of events waiting to be recorded, the events
+	// are dropped when the recorder is late.
+	queueSize = 1024
+
+	// requestTimeout is the maximum duration of a request to a node.
+	requestTimeout = 30 * time.Second
+)
+
+// Recorder records the history of the nodes from the events of their
+// pubsubs, the channel balances are recorded at each interval with the
+// forwarding events that happened since the last one.
+type Recorder struct {
+	logger   logging.Logger
+	store    *Store
+	networks []*network.Network
+	interval time.Duration
+
+	queue chan *events.Event
+	// active are the routing events of each node waiting for their end,
+	// the amount and fee of a forward are only known when it is active.
+	active map[string][]*models.RoutingEvent
+
+	// mu guards channels, the channels of each node of the last snapshot
+	// indexed by their channel point, and syncing, the nodes whose
+	// forwarding history is being synchronized.
+	mu       sync.Mutex
+	channels map[string]map[string]*models.Channel
+	syncing  map[string]bool
+	wg       sync.WaitGroup
+}
+
+func NewRecorder(store *Store, networks []*network.Network, interval time.Duration, logger logging.Logger) *Recorder {
+	return &Recorder{
+		logger:   logger.With(logging.String("logger", "history")),
+		store:    store,
+		networks: networks,
+		interval: interval,
+		queue:    make(chan *events.Event, queueSize),
+		active:   make(map[string][]*models.RoutingEvent),
+		channels: make(map[string]map[string]*models.Channel),
+		syncing:  make(map[string]bool),
+	}
+}
+
+// Run records the events of the pubsubs and forwards them to the returned
+// channel, it is closed with the events channel.
+func (r *Recorder) Run(ctx context.Context, sub chan *events.Event) chan *events.Event {
+	out := make(chan *events.Event)
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		for event := range sub {
+			select {
+			case r.queue <- recorded(event):
+			default:
+				r.logger.Error("history is late, event dropped",
+					logging.String("type", event.Type),
+					logging.String("node", event.Node))
+			}
+			out <- event
+		}
+		cancel()
+		close(r.queue)
+		close(out)
+	}()
+
+	r.wg.Add(2)
+	go func() {
+		r.listen(ctx)
+		r.wg.Done()
+	}()
+	go func() {
+		r.snapshots(ctx)
+		r.wg.Done()
+	}()
+
+	return out
+}
+
+// recorded returns the event queued for the recorder, the routing events
+// are copied as the ui updates the ones it keeps.
+func recorded(event *events.Event) *events.Event {
+	routingEvent, ok := event.Data.(*models.RoutingEvent)
+	if !ok {
+		return event
+	}
+	e := *routingEvent
+	copied := *event
+	copied.Data = &e
+	return &copied
+}
+
+// Wait waits for the recording to end once the events channel is closed.
+func (r *Recorder) Wait() {
+	r.wg.Wait()
+}
+
+// listen records the events until the queue is closed.
+func (r *Recorder) listen(ctx context.Context) {
+	for event := range r.queue {
+		r.record(ctx, event)
+	}
+}
+
+// snapshots records the snapshots at each interval until the context is
+// done, they do not delay the events.
+func (r *Recorder) snapshots(ctx context.Context) {
+	r.snapshot(ctx)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.snapshot(ctx)
+		}
+	}
+}
+
+func (r *Recorder) network(name string) *network.Network {
+	for i := range r.networks {
+		if r.networks[i].NodeName() == name {
+			return r.networks[i]
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) record(ctx context.Context, event *events.Event) {
+	var err error
+	switch event.Type {
+	case events.RoutingEventUpdated:
+		routingEvent, ok := event.Data.(*models.RoutingEvent)
+		if !ok {
+			return
+		}
+		err = r.onRoutingEvent(event.Node, routingEvent)
+	case events.PeerUpdated:
+		peerEvent, ok := event.Data.(*models.PeerEvent)
+		if !ok {
+			return
+		}
+		err = r.store.PutPeerEvent(event.Node, &PeerEvent{
+			Time:   time.Now(),
+			PubKey: peerEvent.PubKey,
+			Online: peerEvent.Online,
+		})
+	case events.GraphUpdated:
+		update, ok := event.Data.(*models.ChannelEdgeUpdate)
+		if !ok {
+			return
+		}
+		n := r.network(event.Node)
+		if n == nil {
+			return
+		}
+		for _, chanPoint := range update.ChanPoints {
+			r.mu.Lock()
+			channel, ok := r.channels[event.Node][chanPoint]
+			r.mu.Unlock()
+			if !ok {
+				continue
+			}
+			// the snapshots share the channel, its policies are updated
+			// on a copy.
+			c := *channel
+			err = r.recordPolicies(ctx, n, &c)
+			if err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		r.logger.Error("record event",
+			logging.String("type", event.Type),
+			logging.String("node", event.Node),
+			logging.Error(err))
+	}
+}
+
+// onRoutingEvent records the routing events once they are settled or
+// failed, the event is the copy of the recorder.
+func (r *Recorder) onRoutingEvent(node string, event *models.RoutingEvent) error {
+	var active *models.RoutingEvent
+	list := r.active[node]
+	for i := range list {
+		if list[i].Equals(event) {
+			active = list[i]
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+
+	if event.Status == models.RoutingStatusActive {
+		if len(list) == queueSize {
+			list = list[1:]
+		}
+		r.active[node] = append(list, event)
+		return nil
+	}
+	r.active[node] = list
+
+	if event.LastUpdate.IsZero() {
+		event.LastUpdate = time.Now()
+	}
+	if active != nil {
+		e := *active
+		e.Update(event)
+		if event.AmountMsat != 0 {
+			e.AmountMsat, e.FeeMsat = event.AmountMsat, event.FeeMsat
+		}
+		event = &e
+	}
+	return r.store.PutRoutingEvent(node, event)
+}
+
+// snapshot records the balances of the channels of the nodes and the
+// policies of the new channels, the forwarding events are synchronized in
+// the background.
+func (r *Recorder) snapshot(ctx context.Context) {
+	for _, n := range r.networks {
+		err := r.snapshotNode(ctx, n)
+		if err != nil {
+			r.logger.Error("snapshot",
+				logging.String("node", n.NodeName()),
+				logging.Error(err))
+		}
+
+		r.mu.Lock()
+		syncing := r.syncing[n.NodeName()]
+		r.syncing[n.NodeName()] = true
+		r.mu.Unlock()
+		if syncing {
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(n *network.Network) {
+			defer r.wg.Done()
+			err := r.store.SyncForwards(ctx, n)
+			r.mu.Lock()
+			delete(r.syncing, n.NodeName())
+			r.mu.Unlock()
+			if err != nil {
+				r.logger.Error("sync forwarding history",
+					logging.String("node", n.NodeName()),
+					logging.Error(err))
+			}
+		}(n)
+	}
+}
+
+func (r *Recorder) snapshotNode(ctx context.Context, n *network.Network) error {
+	listCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+	channels, err := n.ListChannels(listCtx, options.WithChannelPending)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	node := n.NodeName()
+	r.mu.Lock()
+	known := r.channels[node]
+	r.mu.Unlock()
+	index := make(map[string]*models.Channel, len(channels))
+	snapshot := &Snapshot{Time: time.Now()}
+	for _, c := range channels {
+		index[c.ChannelPoint] = c
+		snapshot.Channels = append(snapshot.Channels, &ChannelBalance{
+			ID:            c.ID,
+			ChannelPoint:  c.ChannelPoint,
+			Capacity:      c.Capacity,
+			LocalBalance:  c.LocalBalance,
+			RemoteBalance: c.RemoteBalance,
+		})
+	}
+	// the channels are shared with the events once their policies are
+	// recorded, GetChannelInfo updates them.
+	defer func() {
+		r.mu.Lock()
+		r.channels[node] = index
+		r.mu.Unlock()
+	}()
+
+	err = r.store.PutSnapshot(node, snapshot)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range channels {
+		// the policies of the channels are recorded once they are open.
+		if k, ok := known[c.ChannelPoint]; ok && k.ID != 0 {
+			continue
+		}
+		err = r.recordPolicies(ctx, n, c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordPolicies records the policies of the channel that changed.
+func (r *Recorder) recordPolicies(ctx context.Context, n *network.Network, channel *models.Channel) error {
+	if channel.ID == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	err := n.GetChannelInfo(ctx, channel)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, local := range []bool{true, false} {
+		policy := channel.RemotePolicy
+		if local {
+			policy = channel.LocalPolicy
+		}
+		_, err := r.store.PutPolicy(n.NodeName(), &PolicyChange{
+			Time:         now,
+			ID:           channel.ID,
+			ChannelPoint: channel.ChannelPoint,
+			Local:        local,
+			Policy:       policy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
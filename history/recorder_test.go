@@ -0,0 +1,62 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+func TestRecorderRoutingEvents(t *testing.T) {
+	store := openStore(t)
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRecorder(store, nil, time.Minute, logger)
+
+	active := &models.RoutingEvent{
+		IncomingChannelId: 1,
+		IncomingHtlcId:    2,
+		Status:            models.RoutingStatusActive,
+		AmountMsat:        1000,
+		FeeMsat:           10,
+	}
+	settled := &models.RoutingEvent{
+		IncomingChannelId: 1,
+		IncomingHtlcId:    2,
+		Status:            models.RoutingStatusSettled,
+	}
+	for _, e := range []*models.RoutingEvent{active, settled} {
+		event := events.NewWithData(events.RoutingEventUpdated, e)
+		event.Node = "alice"
+		r.record(context.Background(), recorded(event))
+	}
+
+	// the events of the pubsub are shared with the ui, they are not
+	// changed.
+	if !settled.LastUpdate.IsZero() {
+		t.Error("got the time of the settled event changed")
+	}
+
+	recordedEvents, err := store.RoutingEvents("alice", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordedEvents) != 1 {
+		t.Fatalf("got %d events, want the settled one", len(recordedEvents))
+	}
+	e := recordedEvents[0]
+	if e.Status != models.RoutingStatusSettled || e.AmountMsat != 1000 || e.FeeMsat != 10 {
+		t.Errorf("got %+v, want the settled event with the amount of the active one", e)
+	}
+	if e.LastUpdate.IsZero() {
+		t.Error("got no time")
+	}
+	if len(r.active["alice"]) != 0 {
+		t.Errorf("got %d active events, want none", len(r.active["alice"]))
+	}
+}
@@ -0,0 +1,198 @@
+package history
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// Snapshot is the state of the channels of a node at a time.
+type Snapshot struct {
+	Time     time.Time
+	Channels []*ChannelBalance
+}
+
+// ChannelBalance is the balance of a channel in a snapshot.
+type ChannelBalance struct {
+	ID            uint64
+	ChannelPoint  string
+	Capacity      int64
+	LocalBalance  int64
+	RemoteBalance int64
+}
+
+// PolicyChange is a new routing policy of a direction of a channel, Local
+// is true for the policy of the node.
+type PolicyChange struct {
+	Time         time.Time
+	ID           uint64
+	ChannelPoint string
+	Local        bool
+	Policy       *models.RoutingPolicy
+}
+
+// PeerEvent is a connection or a disconnection of a peer.
+type PeerEvent struct {
+	Time   time.Time
+	PubKey string
+	Online bool
+}
+
+// PutRoutingEvent records a routing event at the time of its last update.
+func (s *Store) PutRoutingEvent(node string, e *models.RoutingEvent) error {
+	key := timeKey(e.LastUpdate, e.IncomingChannelId, e.IncomingHtlcId)
+	return s.put(node, routingBucket, [][]byte{key}, []interface{}{e})
+}
+
+// RoutingEvents returns the last routing events since the time, from the
+// oldest.
+func (s *Store) RoutingEvents(node string, since time.Time, limit int) ([]*models.RoutingEvent, error) {
+	events := []*models.RoutingEvent{}
+	err := s.each(node, routingBucket, since, limit, func(data []byte) error {
+		e := &models.RoutingEvent{}
+		events = append(events, e)
+		return json.Unmarshal(data, e)
+	})
+	return events, err
+}
+
+// PutForwardingEvents records the forwarding events, the events already
+// recorded are replaced.
+func (s *Store) PutForwardingEvents(node string, events []*models.ForwardingEvent) error {
+	keys := make([][]byte, len(events))
+	values := make([]interface{}, len(events))
+	for i, e := range events {
+		keys[i] = timeKey(e.EventTime, e.ChanIdIn, e.ChanIdOut)
+		values[i] = e
+	}
+	return s.put(node, forwardsBucket, keys, values)
+}
+
+// LastForwardingEvent returns the time of the last recorded forwarding
+// event, it is zero when none is recorded.
+func (s *Store) LastForwardingEvent(node string) (time.Time, error) {
+	return s.last(node, forwardsBucket)
+}
+
+// ForwardingEvents returns the last forwarding events since the time, from
+// the oldest.
+func (s *Store) ForwardingEvents(node string, since time.Time, limit int) ([]*models.ForwardingEvent, error) {
+	events := []*models.ForwardingEvent{}
+	err := s.each(node, forwardsBucket, since, limit, func(data []byte) error {
+		e := &models.ForwardingEvent{}
+		events = append(events, e)
+		return json.Unmarshal(data, e)
+	})
+	return events, err
+}
+
+// PutSnapshot records a snapshot of the channel balances.
+func (s *Store) PutSnapshot(node string, snapshot *Snapshot) error {
+	return s.put(node, balancesBucket, [][]byte{timeKey(snapshot.Time)}, []interface{}{snapshot})
+}
+
+// Snapshots returns the snapshots of the channel balances since the time,
+// from the oldest.
+func (s *Store) Snapshots(node string, since time.Time) ([]*Snapshot, error) {
+	snapshots := []*Snapshot{}
+	err := s.each(node, balancesBucket, since, 0, func(data []byte) error {
+		snapshot := &Snapshot{}
+		snapshots = append(snapshots, snapshot)
+		return json.Unmarshal(data, snapshot)
+	})
+	return snapshots, err
+}
+
+// PutPolicy records the policy if it differs from the last recorded policy
+// of the direction of the channel, it returns true if it is recorded.
+func (s *Store) PutPolicy(node string, change *PolicyChange) (bool, error) {
+	if change.Policy == nil {
+		return false, nil
+	}
+	policy, err := json.Marshal(change.Policy)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	data, err := json.Marshal(change)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	direction := "remote"
+	if change.Local {
+		direction = "local"
+	}
+	lastKey := []byte(change.ChannelPoint + "/" + direction)
+
+	changed := false
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		last, err := bucket(tx, node, lastPoliciesBucket)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(last.Get(lastKey), policy) {
+			return nil
+		}
+
+		b, err := bucket(tx, node, policiesBucket)
+		if err != nil {
+			return err
+		}
+		err = b.Put(timeKey(change.Time, change.ID), data)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		changed = true
+		return errors.WithStack(last.Put(lastKey, policy))
+	})
+	return changed, err
+}
+
+// PolicyChanges returns the changes of the routing policies since the time,
+// from the oldest.
+func (s *Store) PolicyChanges(node string, since time.Time) ([]*PolicyChange, error) {
+	changes := []*PolicyChange{}
+	err := s.each(node, policiesBucket, since, 0, func(data []byte) error {
+		change := &PolicyChange{}
+		changes = append(changes, change)
+		return json.Unmarshal(data, change)
+	})
+	return changes, err
+}
+
+// PutPeerEvent records a connection or a disconnection of a peer.
+func (s *Store) PutPeerEvent(node string, e *PeerEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, peersBucket)
+		if err != nil {
+			return err
+		}
+		// the events of several peers may have the same time.
+		seq, err := b.NextSequence()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(b.Put(timeKey(e.Time, seq), data))
+	})
+}
+
+// PeerEvents returns the connections and disconnections of the peers since
+// the time, from the oldest.
+func (s *Store) PeerEvents(node string, since time.Time) ([]*PeerEvent, error) {
+	events := []*PeerEvent{}
+	err := s.each(node, peersBucket, since, 0, func(data []byte) error {
+		e := &PeerEvent{}
+		events = append(events, e)
+		return json.Unmarshal(data, e)
+	})
+	return events, err
+}
@@ -0,0 +1,253 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestRoutingEvents(t *testing.T) {
+	store := openStore(t)
+	start := time.Unix(1600000000, 0)
+	// the events are recorded out of order, two of them at the same time.
+	for _, i := range []int{3, 0, 4, 1, 2} {
+		err := store.PutRoutingEvent("alice", &models.RoutingEvent{
+			IncomingChannelId: uint64(i),
+			LastUpdate:        start.Add(time.Duration(i/2) * time.Minute),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		since time.Time
+		limit int
+		want  []uint64
+	}{
+		{name: "all", want: []uint64{0, 1, 2, 3, 4}},
+		{name: "limit", limit: 2, want: []uint64{3, 4}},
+		{name: "limit above the count", limit: 10, want: []uint64{0, 1, 2, 3, 4}},
+		{name: "since", since: start.Add(time.Minute), want: []uint64{2, 3, 4}},
+		{name: "since and limit", since: start.Add(time.Minute), limit: 1, want: []uint64{4}},
+		{name: "limit after since", since: start.Add(time.Minute), limit: 5, want: []uint64{2, 3, 4}},
+		{name: "since the end", since: start.Add(time.Hour), want: []uint64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.RoutingEvents("alice", tt.since, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []uint64{}
+			for _, e := range events {
+				got = append(got, e.IncomingChannelId)
+			}
+			if !equalIDs(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	events, err := store.RoutingEvents("bob", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d events of an unknown node", len(events))
+	}
+}
+
+func TestForwardingEvents(t *testing.T) {
+	store := openStore(t)
+	start := time.Unix(1600000000, 0)
+	forwards := []*models.ForwardingEvent{
+		{ChanIdIn: 2, ChanIdOut: 3, EventTime: start.Add(time.Minute)},
+		{ChanIdIn: 1, ChanIdOut: 2, EventTime: start},
+		{ChanIdIn: 1, ChanIdOut: 3, EventTime: start},
+	}
+	err := store.PutForwardingEvents("alice", forwards)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the events already recorded are replaced.
+	err = store.PutForwardingEvents("alice", []*models.ForwardingEvent{
+		{ChanIdIn: 2, ChanIdOut: 3, EventTime: start.Add(time.Minute), Fee: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := store.ForwardingEvents("alice", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []uint64{}
+	for _, e := range events {
+		got = append(got, e.ChanIdIn*10+e.ChanIdOut)
+	}
+	if want := []uint64{12, 13, 23}; !equalIDs(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if events[2].Fee != 1 {
+		t.Errorf("got fee %d, want the replaced event", events[2].Fee)
+	}
+
+	events, err = store.ForwardingEvents("alice", time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ChanIdIn != 2 {
+		t.Errorf("got %v, want the last event", events)
+	}
+
+	last, err := store.LastForwardingEvent("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !last.Equal(start.Add(time.Minute)) {
+		t.Errorf("got last event at %s, want %s", last, start.Add(time.Minute))
+	}
+}
+
+func TestSnapshotsAndPeerEvents(t *testing.T) {
+	store := openStore(t)
+	start := time.Unix(1600000000, 0)
+	for _, i := range []int{2, 0, 1} {
+		err := store.PutSnapshot("alice", &Snapshot{
+			Time:     start.Add(time.Duration(i) * time.Hour),
+			Channels: []*ChannelBalance{{ID: uint64(i)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the events of several peers have the same time.
+		for _, pubkey := range []string{"b", "a"} {
+			err = store.PutPeerEvent("alice", &PeerEvent{
+				Time:   start.Add(time.Duration(i) * time.Hour),
+				PubKey: pubkey,
+				Online: i%2 == 0,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	snapshots, err := store.Snapshots("alice", start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []uint64{}
+	for _, s := range snapshots {
+		got = append(got, s.Channels[0].ID)
+	}
+	if want := []uint64{1, 2}; !equalIDs(got, want) {
+		t.Errorf("got snapshots %v, want %v", got, want)
+	}
+
+	peers, err := store.PeerEvents("alice", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peers) != 6 {
+		t.Fatalf("got %d peer events, want 6", len(peers))
+	}
+	for i := 1; i < len(peers); i++ {
+		if peers[i].Time.Before(peers[i-1].Time) {
+			t.Errorf("peer event %d at %s is before %s", i, peers[i].Time, peers[i-1].Time)
+		}
+	}
+	if peers[0].PubKey != "b" || peers[1].PubKey != "a" {
+		t.Errorf("got peers %s, %s, want them in the recorded order", peers[0].PubKey, peers[1].PubKey)
+	}
+}
+
+func TestPutPolicy(t *testing.T) {
+	store := openStore(t)
+	start := time.Unix(1600000000, 0)
+	policy := &models.RoutingPolicy{FeeBaseMsat: 1000, FeeRateMilliMsat: 1}
+	changed := &models.RoutingPolicy{FeeBaseMsat: 1000, FeeRateMilliMsat: 2}
+
+	tests := []struct {
+		name   string
+		change *PolicyChange
+		want   bool
+	}{
+		{
+			name:   "first local policy",
+			change: &PolicyChange{ID: 1, ChannelPoint: "a:0", Local: true, Policy: policy},
+			want:   true,
+		},
+		{
+			name:   "same local policy",
+			change: &PolicyChange{ID: 1, ChannelPoint: "a:0", Local: true, Policy: policy},
+		},
+		{
+			name:   "same policy in the remote direction",
+			change: &PolicyChange{ID: 1, ChannelPoint: "a:0", Policy: policy},
+			want:   true,
+		},
+		{
+			name:   "same policy of another channel",
+			change: &PolicyChange{ID: 2, ChannelPoint: "b:0", Local: true, Policy: policy},
+			want:   true,
+		},
+		{
+			name:   "changed local policy",
+			change: &PolicyChange{ID: 1, ChannelPoint: "a:0", Local: true, Policy: changed},
+			want:   true,
+		},
+		{
+			name:   "unknown policy",
+			change: &PolicyChange{ID: 1, ChannelPoint: "a:0"},
+		},
+	}
+	for i, tt := range tests {
+		tt.change.Time = start.Add(time.Duration(i) * time.Minute)
+		got, err := store.PutPolicy("alice", tt.change)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got recorded %t, want %t", tt.name, got, tt.want)
+		}
+	}
+
+	changes, err := store.PolicyChanges("alice", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 4 {
+		t.Fatalf("got %d changes, want 4", len(changes))
+	}
+	last := changes[3]
+	if last.ChannelPoint != "a:0" || !last.Local || last.Policy.FeeRateMilliMsat != 2 {
+		t.Errorf("got last change %+v, want the changed local policy", last)
+	}
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
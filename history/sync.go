@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+const (
+	// forwardsPageSize is the number of forwarding events fetched at once
+	// to synchronize the history.
+	forwardsPageSize = 1000
+
+	// forwardsSyncedKey is the key of the time of the last complete
+	// synchronization of the forwarding history in the state bucket.
+	forwardsSyncedKey = "forwards_synced"
+)
+
+// forwardingHistory is a node giving its forwarding history.
+type forwardingHistory interface {
+	NodeName() string
+	GetForwardingHistory(context.Context, string, uint32) ([]*models.ForwardingEvent, error)
+}
+
+// SyncForwards records the forwarding events of the node since the last
+// recorded one, they are fetched by pages from the oldest. The first
+// synchronization fetches the whole history.
+func (s *Store) SyncForwards(ctx context.Context, n forwardingHistory) error {
+	node := n.NodeName()
+	for {
+		last, err := s.LastForwardingEvent(node)
+		if err != nil {
+			return err
+		}
+
+		// the events of the second of the last event are fetched again,
+		// they replace the recorded ones.
+		start := "0"
+		if !last.IsZero() {
+			start = strconv.FormatInt(last.Unix(), 10)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+		forwards, err := n.GetForwardingHistory(ctx, start, forwardsPageSize)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		err = s.PutForwardingEvents(node, forwards)
+		if err != nil {
+			return err
+		}
+
+		if len(forwards) < forwardsPageSize ||
+			forwards[len(forwards)-1].EventTime.Unix() <= last.Unix() {
+			return s.setState(node, forwardsSyncedKey, time.Now())
+		}
+	}
+}
+
+// ForwardsSynced returns the time of the last complete synchronization of
+// the forwarding history, it is zero before the first one ends.
+func (s *Store) ForwardsSynced(node string) (time.Time, error) {
+	return s.state(node, forwardsSyncedKey)
+}
+
+func (s *Store) setState(node, key string, t time.Time) error {
+	data, err := t.MarshalBinary()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, stateBucket)
+		if err != nil {
+			return err
+		}
+		return errors.WithStack(b.Put([]byte(key), data))
+	})
+}
+
+func (s *Store) state(node, key string) (time.Time, error) {
+	var t time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b, err := bucket(tx, node, stateBucket)
+		if err != nil || b == nil {
+			return err
+		}
+		data := b.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		return errors.WithStack(t.UnmarshalBinary(data))
+	})
+	return t, err
+}
@@ -0,0 +1,101 @@
+package history
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// fakeHistory gives its forwarding events from the start time like the
+// nodes, the requests are counted.
+type fakeHistory struct {
+	forwards []*models.ForwardingEvent
+	requests int
+}
+
+func (h *fakeHistory) NodeName() string {
+	return "alice"
+}
+
+func (h *fakeHistory) GetForwardingHistory(_ context.Context, start string, max uint32) ([]*models.ForwardingEvent, error) {
+	h.requests++
+	since, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	forwards := []*models.ForwardingEvent{}
+	for _, f := range h.forwards {
+		if f.EventTime.Unix() >= since && len(forwards) < int(max) {
+			forwards = append(forwards, f)
+		}
+	}
+	return forwards, nil
+}
+
+func (h *fakeHistory) add(start time.Time, n int) {
+	for i := 0; i < n; i++ {
+		h.forwards = append(h.forwards, &models.ForwardingEvent{
+			ChanIdIn:  uint64(len(h.forwards)),
+			ChanIdOut: 1,
+			EventTime: start.Add(time.Duration(len(h.forwards)) * time.Second),
+		})
+	}
+}
+
+func TestSyncForwards(t *testing.T) {
+	store := openStore(t)
+	start := time.Unix(1600000000, 0)
+	h := &fakeHistory{}
+
+	synced, err := store.ForwardsSynced("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synced.IsZero() {
+		t.Errorf("got synced at %s before the first synchronization", synced)
+	}
+
+	// the history is fetched by pages.
+	h.add(start, forwardsPageSize+10)
+	err = store.SyncForwards(context.Background(), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.requests != 2 {
+		t.Errorf("got %d requests, want 2", h.requests)
+	}
+	synced, err = store.ForwardsSynced("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if synced.IsZero() {
+		t.Error("got no synchronization time")
+	}
+
+	// the last events are fetched again without being duplicated.
+	h.add(start, 5)
+	h.requests = 0
+	err = store.SyncForwards(context.Background(), h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.requests != 1 {
+		t.Errorf("got %d requests, want 1", h.requests)
+	}
+
+	events, err := store.ForwardingEvents("alice", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != len(h.forwards) {
+		t.Fatalf("got %d events, want %d", len(events), len(h.forwards))
+	}
+	for i, e := range events {
+		if e.ChanIdIn != uint64(i) {
+			t.Fatalf("got event %d at %d, want the events in order", e.ChanIdIn, i)
+		}
+	}
+}
@@ -39,6 +39,10 @@ func Uint64(k string, i uint64) Field {
 	return zap.Uint64(k, i)
 }
 
+func Bool(k string, b bool) Field {
+	return zap.Bool(k, b)
+}
+
 func Error(v error) Field {
 	return zap.Error(v)
 }
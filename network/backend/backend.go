@@ -22,19 +22,78 @@ type Backend interface {
 
 	GetWalletBalance(context.Context) (*models.WalletBalance, error)
 
+	// NewAddress returns a new address of the on-chain wallet.
+	NewAddress(context.Context) (string, error)
+
+	// ListUnspent returns the unspent outputs of the on-chain wallet.
+	ListUnspent(context.Context) ([]*models.Utxo, error)
+
+	// EstimateFee returns the fee rate in sat/vbyte of a transaction
+	// confirmed within the given number of blocks.
+	EstimateFee(context.Context, int32) (uint64, error)
+
+	// SendCoins sends an on-chain payment, it returns the transaction id.
+	SendCoins(context.Context, *models.SendCoinsRequest) (string, error)
+
+	// BumpFee bumps the fee of an unconfirmed transaction with a CPFP or a
+	// RBF.
+	BumpFee(context.Context, *models.BumpFeeRequest) error
+
 	GetChannelsBalance(context.Context) (*models.ChannelsBalance, error)
 
 	ListChannels(context.Context, ...options.Channel) ([]*models.Channel, error)
 
+	// ClosedChannels returns the closed channels with the amount routed
+	// through them over the forwarding history of the node.
+	ClosedChannels(context.Context) ([]*models.ClosedChannel, error)
+
 	GetChannelInfo(context.Context, *models.Channel) error
 
+	// UpdateChannelPolicy sets the routing policy of the local side of the
+	// channel.
+	UpdateChannelPolicy(context.Context, *models.Channel, *models.RoutingPolicy) error
+
+	// OpenChannel opens a channel, it returns the funding transaction id.
+	OpenChannel(context.Context, *models.OpenChannelRequest) (string, error)
+
+	// CloseChannel closes a channel, it returns the closing transaction id
+	// when it is known.
+	CloseChannel(context.Context, *models.Channel, *models.CloseChannelRequest) (string, error)
+
+	ListPeers(context.Context) ([]*models.Peer, error)
+
+	// ConnectPeer connects to a node given as pubkey@host.
+	ConnectPeer(context.Context, string) error
+
+	DisconnectPeer(context.Context, string) error
+
+	SubscribePeerEvents(context.Context, chan *models.PeerEvent) error
+
 	CreateInvoice(context.Context, int64, string) (*models.Invoice, error)
 
 	GetInvoice(context.Context, string) (*models.Invoice, error)
 
+	// ListInvoices returns at most max invoices with an index greater than
+	// the offset, ordered by index.
+	ListInvoices(context.Context, uint64, uint64) ([]*models.Invoice, error)
+
 	DecodePayReq(context.Context, string) (*models.PayReq, error)
 
-	SendPayment(context.Context, *models.PayReq) (*models.Payment, error)
+	// EstimateRouteFee returns the fee in millisatoshis of a route paying
+	// the payment request.
+	EstimateRouteFee(context.Context, *models.PayReq) (int64, error)
+
+	// SendPayment pays the payment request with a fee limited to the given
+	// amount of satoshis, the default limit of the node applies when it is 0.
+	SendPayment(context.Context, *models.PayReq, int64) (*models.Payment, error)
+
+	// ListPayments returns at most max outgoing payments with an index
+	// greater than the offset, ordered by index.
+	ListPayments(context.Context, uint64, uint64) ([]*models.Payment, error)
+
+	// TrackPayments sends the outgoing payments when they are created or
+	// updated.
+	TrackPayments(context.Context, chan *models.Payment) error
 
 	GetTransactions(context.Context) ([]*models.Transaction, error)
 
@@ -0,0 +1,1304 @@
+package cln
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+// lightningd has no streaming RPC for most of the events,
+// subscriptions are done by polling at this interval.
+var clnPollInterval = 3 * time.Second
+
+const (
+	clnDefaultInvoiceExpiry = 3600
+	// clnRiskFactor is the riskfactor of getroute, the one used by pay.
+	clnRiskFactor = 10
+	// clnWaitTimedOut is the error code of waitanyinvoice when no invoice
+	// is paid before the timeout.
+	clnWaitTimedOut = 904
+)
+
+type Backend struct {
+	cfg    *config.Network
+	logger logging.Logger
+	client *client
+	// payments are the payments of the first page listed, the next pages
+	// are listed from them.
+	payments *paymentsCache
+}
+
+// paymentsCache keeps the payments grouped from the parts of listsendpays,
+// which is not paginated by payment.
+type paymentsCache struct {
+	mu   sync.Mutex
+	list []*models.Payment
+}
+
+func (b Backend) NodeName() string {
+	return b.cfg.Name
+}
+
+func (b Backend) Ping() error {
+	conn, err := b.client.dial(context.Background())
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (b Backend) getInfo(ctx context.Context) (*getInfoResponse, error) {
+	resp := &getInfoResponse{}
+	err := b.client.call(ctx, "getinfo", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (b Backend) Info(ctx context.Context) (*models.Info, error) {
+	resp, err := b.getInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return infoToInfo(resp), nil
+}
+
+func (b Backend) listPeerChannels(ctx context.Context) ([]*peerChannel, error) {
+	resp := &listPeerChannelsResponse{}
+	err := b.client.call(ctx, "listpeerchannels", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Channels, nil
+}
+
+func (b Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, error) {
+	b.logger.Debug("Retrieve wallet balance...")
+
+	resp := &listFundsResponse{}
+	err := b.client.call(ctx, "listfunds", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.WalletBalance{}
+	for _, o := range resp.Outputs {
+		switch o.Status {
+		case "confirmed":
+			balance.ConfirmedBalance += o.AmountMsat.sat()
+		case "unconfirmed":
+			balance.UnconfirmedBalance += o.AmountMsat.sat()
+		}
+	}
+	balance.TotalBalance = balance.ConfirmedBalance + balance.UnconfirmedBalance
+
+	b.logger.Debug("Wallet balance retrieved", logging.Object("wallet", balance))
+
+	return balance, nil
+}
+
+func (b Backend) NewAddress(ctx context.Context) (string, error) {
+	b.logger.Debug("New address...")
+
+	resp := &newAddrResponse{}
+	err := b.client.call(ctx, "newaddr", map[string]interface{}{"addresstype": "bech32"}, resp)
+	if err != nil {
+		return "", err
+	}
+
+	b.logger.Debug("Address retrieved", logging.String("address", resp.Bech32))
+
+	return resp.Bech32, nil
+}
+
+func (b Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	b.logger.Debug("List unspent...")
+
+	info, err := b.getInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &listFundsResponse{}
+	err = b.client.call(ctx, "listfunds", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := []*models.Utxo{}
+	for _, o := range resp.Outputs {
+		// the reserved outputs are spent by a transaction being built.
+		if o.Status == "spent" || o.Reserved {
+			continue
+		}
+		utxo := &models.Utxo{
+			TxID:        o.TxID,
+			OutputIndex: o.Output,
+			Amount:      o.AmountMsat.sat(),
+			Address:     o.Address,
+			AddressType: scriptToAddressType(o.ScriptPubKey, o.RedeemScript),
+		}
+		if o.Status == "confirmed" && o.BlockHeight > 0 && info.BlockHeight >= o.BlockHeight {
+			utxo.Confirmations = int64(info.BlockHeight-o.BlockHeight) + 1
+		}
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+func (b Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	b.logger.Debug("Estimate fee...", logging.Int("target_conf", int(targetConf)))
+
+	resp := &feeratesResponse{}
+	err := b.client.call(ctx, "feerates", map[string]interface{}{"style": "perkb"}, resp)
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.feeRate(targetConf), nil
+}
+
+func (b Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	b.logger.Debug("Send coins...",
+		logging.String("address", req.Address),
+		logging.Int64("amount", req.Amount),
+		logging.Bool("send_all", req.SendAll))
+
+	params := map[string]interface{}{
+		"destination": req.Address,
+		"satoshi":     req.Amount,
+	}
+	if req.SendAll {
+		params["satoshi"] = "all"
+	}
+	if req.SatPerVByte > 0 {
+		params["feerate"] = fmt.Sprintf("%dperkb", req.SatPerVByte*1000)
+	} else if req.TargetConf > 0 {
+		params["feerate"] = fmt.Sprintf("%dblocks", req.TargetConf)
+	}
+	if len(req.Outpoints) > 0 {
+		params["utxos"] = req.Outpoints
+		params["minconf"] = 0
+	}
+
+	resp := &withdrawResponse{}
+	err := b.client.call(ctx, "withdraw", params, resp)
+	if err != nil {
+		return "", err
+	}
+
+	b.logger.Debug("Coins sent", logging.String("txid", resp.TxID))
+
+	return resp.TxID, nil
+}
+
+// BumpFee bumps the fee with a CPFP withdrawing the output to a new address
+// of the wallet, the transactions cannot be replaced.
+func (b Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	b.logger.Debug("Bump fee...",
+		logging.String("txid", req.TxID),
+		logging.String("outpoint", req.Outpoint),
+		logging.Uint64("sat_per_vbyte", req.SatPerVByte))
+
+	if req.Method != models.BumpFeeCPFP {
+		return errors.New("the transactions cannot be replaced with Core Lightning")
+	}
+
+	address, err := b.NewAddress(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"destination": address,
+		"satoshi":     "all",
+		"feerate":     fmt.Sprintf("%dperkb", req.SatPerVByte*1000),
+		"utxos":       []string{req.Outpoint},
+		"minconf":     0,
+	}
+	resp := &withdrawResponse{}
+	err = b.client.call(ctx, "withdraw", params, resp)
+	if err != nil {
+		return err
+	}
+
+	b.logger.Debug("Fee bumped", logging.String("txid", resp.TxID))
+
+	return nil
+}
+
+func (b Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
+	b.logger.Debug("Retrieve channel balance...")
+
+	channels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.ChannelsBalance{}
+	for _, c := range channels {
+		switch channelStatus(c) {
+		case models.ChannelActive, models.ChannelInactive:
+			balance.Balance += c.ToUsMsat.sat()
+		case models.ChannelOpening:
+			balance.PendingOpenBalance += c.ToUsMsat.sat()
+		}
+	}
+
+	b.logger.Debug("Channel balance retrieved", logging.Object("balance", balance))
+
+	return balance, nil
+}
+
+func (b Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*models.Channel, error) {
+	b.logger.Debug("List channels")
+
+	resp, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.NewChannelOptions(opt...)
+	channels := []*models.Channel{}
+	for _, c := range resp {
+		channel := peerChannelToChannel(c)
+		if isPending(channel.Status) {
+			if opts.Pending && channel.Status != models.ChannelClosed {
+				channels = append(channels, channel)
+			}
+			continue
+		}
+		if opts.Active && channel.Status != models.ChannelActive ||
+			opts.Inactive && channel.Status != models.ChannelInactive ||
+			opts.Public && channel.Private ||
+			opts.Private && !channel.Private {
+			continue
+		}
+		channels = append(channels, channel)
+	}
+
+	fields := make([]logging.Field, len(channels))
+	for i := range channels {
+		fields[i] = logging.Object(fmt.Sprintf("channel_%d", i), channels[i])
+	}
+
+	b.logger.Debug("Channels retrieved", fields...)
+
+	return channels, nil
+}
+
+func (b Backend) listChannels(ctx context.Context, params map[string]interface{}) ([]*channelEdge, error) {
+	resp := &listChannelsResponse{}
+	err := b.client.call(ctx, "listchannels", params, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Channels, nil
+}
+
+// ClosedChannels lists the channels closed with listclosedchannels, Core
+// Lightning does not report the close height.
+func (b Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	b.logger.Debug("Closed channels...")
+
+	resp := &listClosedChannelsResponse{}
+	err := b.client.call(ctx, "listclosedchannels", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ClosedChannels) == 0 {
+		return []*models.ClosedChannel{}, nil
+	}
+
+	forwards := &listForwardsResponse{}
+	err = b.client.call(ctx, "listforwards", map[string]interface{}{"status": "settled"}, forwards)
+	if err != nil {
+		return nil, err
+	}
+	routed := make(map[uint64]int64)
+	for _, f := range forwards.Forwards {
+		routed[backend.ParseShortChannelID(f.InChannel)] += f.InMsat.sat()
+		routed[backend.ParseShortChannelID(f.OutChannel)] += f.OutMsat.sat()
+	}
+
+	channels := make([]*models.ClosedChannel, len(resp.ClosedChannels))
+	for i, c := range resp.ClosedChannels {
+		channels[i] = closedChannelToClosedChannel(c)
+		channels[i].TotalRouted = routed[channels[i].ID]
+	}
+
+	b.logger.Debug("Closed channels retrieved", logging.Int("count", len(channels)))
+
+	return channels, nil
+}
+
+func (b Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
+	b.logger.Debug("GetChannelInfo")
+
+	// If channel does not have ID (pending), information cannot be retrieved
+	if channel.ID == 0 {
+		return nil
+	}
+
+	edges, err := b.listChannels(ctx, map[string]interface{}{
+		"short_channel_id": backend.FormatShortChannelID(channel.ID),
+	})
+	if err != nil {
+		return err
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	info, err := b.getInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	var lastUpdate int64
+	for _, e := range edges {
+		if e.LastUpdate > lastUpdate {
+			lastUpdate = e.LastUpdate
+		}
+		if e.Source == info.ID {
+			channel.LocalPolicy = edgeToRoutingPolicy(e)
+		} else {
+			channel.RemotePolicy = edgeToRoutingPolicy(e)
+		}
+	}
+
+	t := time.Unix(lastUpdate, 0)
+	channel.LastUpdate = &t
+
+	return nil
+}
+
+func (b Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	b.logger.Debug("Update channel policy...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	if channel.ID == 0 {
+		return errors.New("channel is not confirmed")
+	}
+
+	// the time lock delta is a setting of lightningd and channels cannot be
+	// disabled by rpc.
+	if current := channel.LocalPolicy; current != nil {
+		if current.TimeLockDelta != policy.TimeLockDelta {
+			return errors.New("time lock delta cannot be set per channel by core lightning")
+		}
+		if current.Disabled != policy.Disabled {
+			return errors.New("channels cannot be disabled by core lightning")
+		}
+	}
+
+	return b.client.call(ctx, "setchannel", map[string]interface{}{
+		"id":      backend.FormatShortChannelID(channel.ID),
+		"feebase": policy.FeeBaseMsat,
+		"feeppm":  policy.FeeRateMilliMsat,
+		"htlcmin": policy.MinHtlc,
+		"htlcmax": policy.MaxHtlc,
+	}, nil)
+}
+
+func (b Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
+	b.logger.Debug("GetNode")
+
+	resp := &listNodesResponse{}
+	err := b.client.call(ctx, "listnodes", map[string]interface{}{"id": pubkey}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Nodes) == 0 {
+		return nil, errors.Errorf("node %s not found", pubkey)
+	}
+
+	n := resp.Nodes[0]
+	addresses := make([]*models.NodeAddress, len(n.Addresses))
+	for i := range n.Addresses {
+		addresses[i] = &models.NodeAddress{
+			Network: n.Addresses[i].Type,
+			Addr:    fmt.Sprintf("%s:%d", n.Addresses[i].Address, n.Addresses[i].Port),
+		}
+	}
+
+	edges, err := b.listChannels(ctx, map[string]interface{}{"source": pubkey})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Node{
+		NumChannels: uint32(len(edges)),
+		LastUpdate:  time.Unix(n.LastTimestamp, 0),
+		PubKey:      n.NodeID,
+		Alias:       n.Alias,
+		Addresses:   addresses,
+		Channels:    []*models.Channel{},
+	}
+
+	for _, e := range edges {
+		result.TotalCapacity += e.AmountMsat.sat()
+		if includeChannels {
+			// edges are listed from the node point of view, the
+			// remote policy is unknown without a second lookup.
+			result.Channels = append(result.Channels, &models.Channel{
+				ID:          backend.ParseShortChannelID(e.ShortChannelID),
+				Capacity:    e.AmountMsat.sat(),
+				LocalPolicy: edgeToRoutingPolicy(e),
+			})
+		}
+	}
+
+	if forcedAlias, ok := b.cfg.Aliases[result.PubKey]; ok {
+		result.ForcedAlias = forcedAlias
+	}
+	return result, nil
+}
+
+func (b Backend) GetTransactions(ctx context.Context) ([]*models.Transaction, error) {
+	b.logger.Debug("Get transactions...")
+
+	info, err := b.getInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &listTransactionsResponse{}
+	err = b.client.call(ctx, "listtransactions", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(resp.Transactions))
+	for i, tx := range resp.Transactions {
+		transaction := &models.Transaction{
+			TxHash:      tx.Hash,
+			BlockHeight: tx.BlockHeight,
+			VSize:       models.RawTxVSize(tx.RawTx),
+		}
+		for _, in := range tx.Inputs {
+			transaction.Inputs = append(transaction.Inputs, fmt.Sprintf("%s:%d", in.TxID, in.Index))
+		}
+		for _, o := range tx.Outputs {
+			transaction.Amount += o.AmountMsat.sat()
+		}
+		if tx.BlockHeight > 0 {
+			transaction.NumConfirmations = int32(info.BlockHeight) - tx.BlockHeight + 1
+		}
+		transactions[i] = transaction
+	}
+
+	return transactions, nil
+}
+
+func (b Backend) GetForwardingHistory(ctx context.Context, startTime string, maxNumEvents uint32) ([]*models.ForwardingEvent, error) {
+	b.logger.Debug("GetForwardingHistory")
+
+	start, err := options.ParseTime(startTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &listForwardsResponse{}
+	err = b.client.call(ctx, "listforwards", map[string]interface{}{"status": "settled"}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(resp.Forwards, func(i, j int) bool {
+		return resp.Forwards[i].ReceivedTime < resp.Forwards[j].ReceivedTime
+	})
+
+	result := []*models.ForwardingEvent{}
+	for _, f := range resp.Forwards {
+		if uint64(f.ReceivedTime) < start {
+			continue
+		}
+		if maxNumEvents > 0 && uint32(len(result)) >= maxNumEvents {
+			break
+		}
+		result = append(result, forwardToForwardingEvent(f))
+	}
+
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	// Enrich peer alias names from the peers of our channels, each peer
+	// of the events is looked up once.
+	channels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[uint64]bool)
+	for _, f := range result {
+		used[f.ChanIdIn] = true
+		used[f.ChanIdOut] = true
+	}
+
+	aliases := make(map[uint64]string)
+	peers := make(map[string]string)
+	for _, c := range channels {
+		id := backend.ParseShortChannelID(c.ShortChannelID)
+		if id == 0 || !used[id] {
+			continue
+		}
+		alias, ok := peers[c.PeerID]
+		if !ok {
+			alias, err = b.alias(ctx, c.PeerID)
+			if err != nil {
+				return nil, err
+			}
+			peers[c.PeerID] = alias
+		}
+		aliases[id] = alias
+	}
+
+	for i := range result {
+		result[i].PeerAliasIn = aliases[result[i].ChanIdIn]
+		result[i].PeerAliasOut = aliases[result[i].ChanIdOut]
+	}
+
+	return result, nil
+}
+
+// alias returns the alias of the node, it is empty when the node is not in
+// the graph.
+func (b Backend) alias(ctx context.Context, pubkey string) (string, error) {
+	resp := &listNodesResponse{}
+	err := b.client.call(ctx, "listnodes", map[string]interface{}{"id": pubkey}, resp)
+	if err != nil || len(resp.Nodes) == 0 {
+		return "", err
+	}
+	return resp.Nodes[0].Alias, nil
+}
+
+// listPeers returns the connected peers.
+func (b Backend) listPeers(ctx context.Context) ([]*peer, error) {
+	resp := &listPeersResponse{}
+	err := b.client.call(ctx, "listpeers", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*peer{}
+	for _, p := range resp.Peers {
+		if p.Connected {
+			peers = append(peers, p)
+		}
+	}
+	return peers, nil
+}
+
+func (b Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	peers, err := b.listPeers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the amounts sent and received are the payments fulfilled in the
+	// channels with the peer.
+	channels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sent := map[string]int64{}
+	received := map[string]int64{}
+	for _, c := range channels {
+		sent[c.PeerID] += c.OutFulfilledMsat.sat()
+		received[c.PeerID] += c.InFulfilledMsat.sat()
+	}
+
+	result := make([]*models.Peer, len(peers))
+	for i, p := range peers {
+		result[i] = &models.Peer{
+			PubKey:  p.ID,
+			SatSent: sent[p.ID],
+			SatRecv: received[p.ID],
+		}
+		if len(p.Netaddr) > 0 {
+			result[i].Address = p.Netaddr[0]
+		}
+	}
+	return result, nil
+}
+
+func (b Backend) ConnectPeer(ctx context.Context, node string) error {
+	b.logger.Debug("Connect peer...", logging.String("node", node))
+
+	return b.client.call(ctx, "connect", map[string]interface{}{"id": node}, nil)
+}
+
+func (b Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	b.logger.Debug("Disconnect peer...", logging.String("pubkey", pubkey))
+
+	// a peer with active channels is only disconnected when forced.
+	return b.client.call(ctx, "disconnect", map[string]interface{}{
+		"id":    pubkey,
+		"force": true,
+	}, nil)
+}
+
+func (b Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	b.logger.Debug("Open channel...",
+		logging.String("node", req.Node),
+		logging.Int64("amount", req.Amount))
+
+	if req.Host() != "" {
+		err := b.ConnectPeer(ctx, req.Node)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	params := map[string]interface{}{
+		"id":       req.PubKey(),
+		"amount":   req.Amount,
+		"announce": !req.Private,
+	}
+	if req.PushAmount > 0 {
+		params["push_msat"] = req.PushAmount * 1000
+	}
+	if req.SatPerVByte > 0 {
+		params["feerate"] = fmt.Sprintf("%dperkb", req.SatPerVByte*1000)
+	}
+
+	resp := &fundChannelResponse{}
+	err := b.client.call(ctx, "fundchannel", params, resp)
+	if err != nil {
+		return "", err
+	}
+
+	// the minimum htlc is not a parameter of the opening.
+	if req.MinHtlcMsat > 0 {
+		err = b.client.call(ctx, "setchannel", map[string]interface{}{
+			"id":      resp.ChannelID,
+			"htlcmin": req.MinHtlcMsat,
+		}, nil)
+		if err != nil {
+			return resp.TxID, err
+		}
+	}
+
+	b.logger.Debug("Channel opening", logging.String("txid", resp.TxID))
+
+	return resp.TxID, nil
+}
+
+func (b Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	b.logger.Debug("Close channel...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	channels, err := b.listPeerChannels(ctx)
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	for _, c := range channels {
+		if fmt.Sprintf("%s:%d", c.FundingTxID, c.FundingOutnum) == channel.ChannelPoint {
+			id = c.ChannelID
+		}
+	}
+	if id == "" {
+		return "", errors.Errorf("unknown channel %s", channel.ChannelPoint)
+	}
+
+	params := map[string]interface{}{"id": id}
+	if req.Force {
+		// the channel is closed unilaterally once the peer did not
+		// agree in time.
+		params["unilateraltimeout"] = 1
+	}
+	if req.DeliveryAddress != "" {
+		params["destination"] = req.DeliveryAddress
+	}
+	if req.SatPerVByte > 0 {
+		feerate := fmt.Sprintf("%dperkb", req.SatPerVByte*1000)
+		params["feerange"] = []string{feerate, feerate}
+	}
+
+	resp := &closeResponse{}
+	err = b.client.call(ctx, "close", params, resp)
+	if err != nil {
+		return "", err
+	}
+
+	b.logger.Debug("Channel closing", logging.String("txid", resp.TxID))
+
+	return resp.TxID, nil
+}
+
+func (b Backend) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
+	b.logger.Debug("Create invoice...",
+		logging.Int64("amount", amount),
+		logging.String("desc", desc))
+
+	creation := time.Now().Unix()
+	var amountMsat interface{} = amount * 1000
+	if amount == 0 {
+		amountMsat = "any"
+	}
+	resp := &invoiceResponse{}
+	err := b.client.call(ctx, "invoice", map[string]interface{}{
+		"amount_msat": amountMsat,
+		"label":       fmt.Sprintf("lntop-%d", time.Now().UnixNano()),
+		"description": desc,
+		"expiry":      clnDefaultInvoiceExpiry,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &models.Invoice{
+		Index:          resp.CreatedIndex,
+		Amount:         amount,
+		Description:    desc,
+		CreationDate:   creation,
+		Expiry:         clnDefaultInvoiceExpiry,
+		State:          models.InvoiceOpen,
+		PaymentRequest: resp.Bolt11,
+	}
+	invoice.RHash, _ = hex.DecodeString(resp.PaymentHash)
+
+	b.logger.Debug("Invoice retrieved", logging.Object("invoice", invoice))
+
+	return invoice, nil
+}
+
+func (b Backend) GetInvoice(ctx context.Context, RHash string) (*models.Invoice, error) {
+	b.logger.Debug("Retrieve invoice...", logging.String("r_hash", RHash))
+
+	resp := &listInvoicesResponse{}
+	err := b.client.call(ctx, "listinvoices", map[string]interface{}{"payment_hash": RHash}, resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Invoices) == 0 {
+		return nil, errors.New("unable to locate invoice")
+	}
+
+	invoice := invoiceToInvoice(resp.Invoices[0])
+
+	b.logger.Debug("Invoice retrieved", logging.Object("invoice", invoice))
+
+	return invoice, nil
+}
+
+func (b Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	b.logger.Debug("List invoices...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	resp := &listInvoicesResponse{}
+	err := b.client.call(ctx, "listinvoices", map[string]interface{}{
+		"index": "created",
+		"start": offset + 1,
+		"limit": max,
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices := make([]*models.Invoice, len(resp.Invoices))
+	for i := range resp.Invoices {
+		invoices[i] = invoiceToInvoice(resp.Invoices[i])
+	}
+
+	return invoices, nil
+}
+
+// ListPayments lists the payments from the parts sent, listsendpays is not
+// paginated by payment so the offset and the max are applied to the result.
+// The parts are listed for the first page only, the next pages are taken
+// from the payments of the first one.
+func (b Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	b.logger.Debug("List payments...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	b.payments.mu.Lock()
+	defer b.payments.mu.Unlock()
+	payments := b.payments.list
+	if offset == 0 || payments == nil {
+		var err error
+		payments, err = b.listPayments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.payments.list = payments
+	}
+
+	// the payments are copied, the cached ones are listed again.
+	result := []*models.Payment{}
+	for _, p := range payments {
+		if p.Index > offset && uint64(len(result)) < max {
+			payment := *p
+			result = append(result, &payment)
+		}
+	}
+
+	return result, nil
+}
+
+func (b Backend) listPayments(ctx context.Context) ([]*models.Payment, error) {
+	resp := &listSendPaysResponse{}
+	err := b.client.call(ctx, "listsendpays", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return sendPaysToPayments(resp.Payments), nil
+}
+
+func (b Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	var known map[string]string
+	return b.poll(ctx, "payments", func(ctx context.Context) error {
+		payments, err := b.listPayments(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]string, len(payments))
+		for _, p := range payments {
+			state := fmt.Sprintf("%d:%d", p.Status, len(p.HTLCs))
+			current[p.PaymentHash] = state
+			if known != nil && known[p.PaymentHash] != state {
+				select {
+				case channel <- p:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+func (b Backend) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
+	b.logger.Info("decode payreq", logging.String("payreq", payreq))
+
+	resp := &decodePayResponse{}
+	err := b.client.call(ctx, "decodepay", map[string]interface{}{"bolt11": payreq}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePayToPayReq(resp, payreq), nil
+}
+
+func (b Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	b.logger.Debug("Estimate route fee...",
+		logging.String("destination", payreq.Destination),
+		logging.Int64("amount", payreq.Amount),
+	)
+
+	resp := &getRouteResponse{}
+	err := b.client.call(ctx, "getroute", map[string]interface{}{
+		"id":          payreq.Destination,
+		"amount_msat": payreq.Amount * 1000,
+		"riskfactor":  clnRiskFactor,
+		"cltv":        payreq.CltvExpiry,
+	}, resp)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Route) == 0 {
+		return 0, errors.New("no route found")
+	}
+
+	// the first hop carries the amount and the fees of every other hop.
+	return int64(resp.Route[0].AmountMsat) - payreq.Amount*1000, nil
+}
+
+func (b Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	b.logger.Debug("Send payment...",
+		logging.String("destination", payreq.Destination),
+		logging.Int64("amount", payreq.Amount),
+		logging.Int64("fee_limit", feeLimit),
+	)
+
+	params := map[string]interface{}{"bolt11": payreq.String}
+	if feeLimit > 0 {
+		params["maxfee"] = feeLimit * 1000
+	}
+	resp := &payResponse{}
+	err := b.client.call(ctx, "pay", params, resp)
+	if err != nil {
+		// payment failures are reported in the payment like lnd does.
+		if rpcErr, ok := err.(*RPCError); ok {
+			return &models.Payment{PaymentError: rpcErr.Message, PayReq: payreq}, nil
+		}
+		return nil, err
+	}
+
+	payment := &models.Payment{PayReq: payreq}
+	payment.PaymentPreimage, _ = hex.DecodeString(resp.PaymentPreimage)
+	payment.Route = &models.Route{
+		Amount: resp.AmountSentMsat.sat(),
+		Fee:    resp.AmountSentMsat.sat() - resp.AmountMsat.sat(),
+	}
+
+	b.logger.Debug("Payment paid", logging.Object("payment", payment))
+
+	return payment, nil
+}
+
+func (b Backend) SubscribeInvoice(ctx context.Context, channelInvoice chan *models.Invoice) error {
+	lastPayIndex, err := b.lastPayIndex(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			b.logger.Debug("stopping subscribe invoice: context canceled")
+			return nil
+		}
+		return err
+	}
+
+	for {
+		inv := &invoice{}
+		err := b.client.call(ctx, "waitanyinvoice", map[string]interface{}{
+			"lastpay_index": lastPayIndex,
+		}, inv)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.logger.Debug("stopping subscribe invoice: context canceled")
+				return nil
+			}
+			return err
+		}
+
+		lastPayIndex = inv.PayIndex
+		select {
+		case channelInvoice <- invoiceToInvoice(inv):
+		case <-ctx.Done():
+			b.logger.Debug("stopping subscribe invoice: context canceled")
+			return nil
+		}
+	}
+}
+
+// lastPayIndex returns the pay index of the last paid invoice. The invoices
+// paid after an index are looked up with waitanyinvoice without waiting,
+// the index is doubled until none is paid after it, then the last one is
+// searched in between.
+func (b Backend) lastPayIndex(ctx context.Context) (uint64, error) {
+	// paidAfter returns the pay index of the first invoice paid after the
+	// index, it is 0 when there is none.
+	paidAfter := func(index uint64) (uint64, error) {
+		inv := &invoice{}
+		err := b.client.call(ctx, "waitanyinvoice", map[string]interface{}{
+			"lastpay_index": index,
+			"timeout":       0,
+		}, inv)
+		if rpcErr, ok := err.(*RPCError); ok && rpcErr.Code == clnWaitTimedOut {
+			return 0, nil
+		}
+		return inv.PayIndex, err
+	}
+
+	last, err := paidAfter(0)
+	if err != nil || last == 0 {
+		return 0, err
+	}
+
+	// the last index is between last and high.
+	var high uint64
+	for step := uint64(1); high == 0; step *= 2 {
+		next, err := paidAfter(last + step)
+		if err != nil {
+			return 0, err
+		}
+		if next == 0 {
+			high = last + step
+		} else {
+			last = next
+		}
+	}
+	for last < high {
+		mid := last + (high-last)/2
+		next, err := paidAfter(mid)
+		if err != nil {
+			return 0, err
+		}
+		if next == 0 {
+			high = mid
+		} else {
+			last = next
+		}
+	}
+	return last, nil
+}
+
+// poll runs fn at each poll interval until the context is canceled.
+func (b Backend) poll(ctx context.Context, name string, fn func(context.Context) error) error {
+	ticker := time.NewTicker(clnPollInterval)
+	defer ticker.Stop()
+	for {
+		err := fn(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.logger.Debug(fmt.Sprintf("stopping subscribe %s: context canceled", name))
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			b.logger.Debug(fmt.Sprintf("stopping subscribe %s: context canceled", name))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b Backend) SubscribeTransactions(ctx context.Context, channel chan *models.Transaction) error {
+	var known map[string]int32
+	return b.poll(ctx, "transactions", func(ctx context.Context) error {
+		transactions, err := b.GetTransactions(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]int32, len(transactions))
+		for _, tx := range transactions {
+			current[tx.TxHash] = tx.BlockHeight
+			if known == nil {
+				continue
+			}
+			if height, ok := known[tx.TxHash]; !ok || height != tx.BlockHeight {
+				select {
+				case channel <- tx:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+func (b Backend) SubscribePeerEvents(ctx context.Context, events chan *models.PeerEvent) error {
+	var known map[string]bool
+	return b.poll(ctx, "peers", func(ctx context.Context) error {
+		peers, err := b.listPeers(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]bool, len(peers))
+		for _, p := range peers {
+			current[p.ID] = true
+			if known != nil && !known[p.ID] {
+				select {
+				case events <- &models.PeerEvent{PubKey: p.ID, Online: true}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		for id := range known {
+			if !current[id] {
+				select {
+				case events <- &models.PeerEvent{PubKey: id}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+func (b Backend) SubscribeChannels(ctx context.Context, events chan *models.ChannelUpdate) error {
+	var known map[string]int
+	return b.poll(ctx, "channels", func(ctx context.Context) error {
+		channels, err := b.listPeerChannels(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]int, len(channels))
+		for _, c := range channels {
+			channelPoint := fmt.Sprintf("%s:%d", c.FundingTxID, c.FundingOutnum)
+			current[channelPoint] = channelStatus(c)
+			if status, ok := known[channelPoint]; known != nil && (!ok || status != current[channelPoint]) {
+				select {
+				case events <- &models.ChannelUpdate{ChannelPoint: channelPoint, Status: current[channelPoint]}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		for channelPoint := range known {
+			if _, ok := current[channelPoint]; !ok {
+				select {
+				case events <- &models.ChannelUpdate{ChannelPoint: channelPoint, Status: models.ChannelClosed}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+// SubscribeRoutingEvents polls the forwards created or updated since the
+// last poll, they are paged by their created and updated indexes.
+func (b Backend) SubscribeRoutingEvents(ctx context.Context, channelEvents chan *models.RoutingEvent) error {
+	created, err := b.waitIndex(ctx, "forwards", "created")
+	if err != nil {
+		return err
+	}
+	updated, err := b.waitIndex(ctx, "forwards", "updated")
+	if err != nil {
+		return err
+	}
+
+	return b.poll(ctx, "routing events", func(ctx context.Context) error {
+		sent := map[string]string{}
+		for _, index := range []string{"created", "updated"} {
+			last := &created
+			if index == "updated" {
+				last = &updated
+			}
+
+			resp := &listForwardsResponse{}
+			err := b.client.call(ctx, "listforwards", map[string]interface{}{
+				"index": index,
+				"start": *last + 1,
+			}, resp)
+			if err != nil {
+				return err
+			}
+
+			for _, f := range resp.Forwards {
+				i := f.CreatedIndex
+				if index == "updated" {
+					i = f.UpdatedIndex
+				}
+				if i > *last {
+					*last = i
+				}
+
+				// a forward created and resolved since the last poll is
+				// listed by both indexes.
+				key := fmt.Sprintf("%s:%d", f.InChannel, f.InHtlcID)
+				if status, ok := sent[key]; ok && status == f.Status {
+					continue
+				}
+				sent[key] = f.Status
+
+				select {
+				case channelEvents <- forwardToRoutingEvent(f):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// waitIndex returns the current value of the index of the subsystem, wait
+// returns at once for the next value 0.
+func (b Backend) waitIndex(ctx context.Context, subsystem, index string) (uint64, error) {
+	resp := &waitResponse{}
+	err := b.client.call(ctx, "wait", map[string]interface{}{
+		"subsystem": subsystem,
+		"indexname": index,
+		"nextvalue": 0,
+	}, resp)
+	if err != nil {
+		return 0, err
+	}
+	if index == "updated" {
+		return resp.Updated, nil
+	}
+	return resp.Created, nil
+}
+
+func (b Backend) SubscribeGraphEvents(ctx context.Context, events chan *models.ChannelEdgeUpdate) error {
+	var known map[string]int64
+	return b.poll(ctx, "graph", func(ctx context.Context) error {
+		info, err := b.getInfo(ctx)
+		if err != nil {
+			return err
+		}
+
+		channels, err := b.listPeerChannels(ctx)
+		if err != nil {
+			return err
+		}
+
+		chanPoints := make(map[string]string, len(channels))
+		for _, c := range channels {
+			chanPoints[c.ShortChannelID] = fmt.Sprintf("%s:%d", c.FundingTxID, c.FundingOutnum)
+		}
+
+		outgoing, err := b.listChannels(ctx, map[string]interface{}{"source": info.ID})
+		if err != nil {
+			return err
+		}
+
+		incoming, err := b.listChannels(ctx, map[string]interface{}{"destination": info.ID})
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]int64)
+		updated := map[string]bool{}
+		for _, e := range append(outgoing, incoming...) {
+			key := fmt.Sprintf("%s/%s", e.ShortChannelID, e.Source)
+			current[key] = e.LastUpdate
+			if known == nil {
+				continue
+			}
+			if last, ok := known[key]; !ok || last != e.LastUpdate {
+				if chanPoint, ok := chanPoints[e.ShortChannelID]; ok {
+					updated[chanPoint] = true
+				}
+			}
+		}
+		known = current
+
+		if len(updated) > 0 {
+			update := &models.ChannelEdgeUpdate{}
+			for chanPoint := range updated {
+				update.ChanPoints = append(update.ChanPoints, chanPoint)
+			}
+			select {
+			case events <- update:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+func New(c *config.Network, logger logging.Logger) (*Backend, error) {
+	return &Backend{
+		cfg:      c,
+		logger:   logger.With(logging.String("name", c.Name)),
+		client:   newClient(c.Address),
+		payments: &paymentsCache{},
+	}, nil
+}
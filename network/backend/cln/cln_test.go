@@ -0,0 +1,536 @@
+package cln
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+// handler answers a JSON-RPC method with a result or an error.
+type handler func(params json.RawMessage) (interface{}, *RPCError)
+
+// fakeLightningd answers the JSON-RPC calls of the unix socket with the
+// handlers of their methods.
+type fakeLightningd struct {
+	listener net.Listener
+	mu       sync.Mutex
+	handlers map[string]handler
+}
+
+func newFakeLightningd(t *testing.T, handlers map[string]handler) *fakeLightningd {
+	path := filepath.Join(t.TempDir(), "lightning-rpc")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &fakeLightningd{
+		listener: listener,
+		handlers: handlers,
+	}
+	t.Cleanup(func() { listener.Close() })
+	go n.serve()
+	return n
+}
+
+func (n *fakeLightningd) serve() {
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			return
+		}
+		go n.handle(conn)
+	}
+}
+
+func (n *fakeLightningd) handle(conn net.Conn) {
+	defer conn.Close()
+	req := struct {
+		ID     uint64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}{}
+	err := json.NewDecoder(conn).Decode(&req)
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	h, ok := n.handlers[req.Method]
+	n.mu.Unlock()
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if !ok {
+		resp["error"] = &RPCError{Code: -32601, Message: "Unknown command " + req.Method}
+	} else if result, rpcErr := h(req.Params); rpcErr != nil {
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+func (n *fakeLightningd) backend(t *testing.T) *Backend {
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(&config.Network{Name: "cln", Address: "unix://" + n.listener.Addr().String()}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func result(v string) handler {
+	return func(json.RawMessage) (interface{}, *RPCError) {
+		return json.RawMessage(v), nil
+	}
+}
+
+func TestInfoBitcoindWarning(t *testing.T) {
+	n := newFakeLightningd(t, map[string]handler{
+		"getinfo": result(`{
+			"id": "02aaaa",
+			"alias": "satoshi",
+			"num_peers": 3,
+			"num_pending_channels": 1,
+			"num_active_channels": 2,
+			"num_inactive_channels": 0,
+			"blockheight": 800000,
+			"version": "v23.11",
+			"network": "testnet",
+			"warning_bitcoind_sync": "Bitcoind is not up-to-date"
+		}`),
+	})
+
+	info, err := n.backend(t).Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PubKey != "02aaaa" || info.Alias != "satoshi" || info.BlockHeight != 800000 {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.NumPeers != 3 || info.NumPendingChannels != 1 || info.NumActiveChannels != 2 {
+		t.Errorf("unexpected counts %+v", info)
+	}
+	if info.Synced {
+		t.Error("info synced with a bitcoind sync warning")
+	}
+	if !info.Testnet {
+		t.Error("info not on testnet")
+	}
+}
+
+func TestListPeerChannelsMsatFormats(t *testing.T) {
+	n := newFakeLightningd(t, map[string]handler{
+		"listpeerchannels": result(`{"channels": [
+			{
+				"peer_id": "02bbbb",
+				"peer_connected": true,
+				"state": "CHANNELD_NORMAL",
+				"short_channel_id": "800000x1x0",
+				"funding_txid": "aa",
+				"funding_outnum": 1,
+				"to_us_msat": 400000000,
+				"total_msat": "1000000000msat",
+				"our_to_self_delay": 144
+			},
+			{
+				"peer_id": "02cccc",
+				"peer_connected": true,
+				"state": "CHANNELD_AWAITING_LOCKIN",
+				"funding_txid": "bb",
+				"funding_outnum": 0,
+				"to_us_msat": 500000000,
+				"total_msat": 500000000
+			}
+		]}`),
+	})
+	b := n.backend(t)
+
+	channels, err := b.ListChannels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 1 {
+		t.Fatalf("got %d channels, want 1", len(channels))
+	}
+	c := channels[0]
+	if c.ChannelPoint != "aa:1" || c.RemotePubKey != "02bbbb" || c.Status != models.ChannelActive {
+		t.Errorf("unexpected channel %+v", c)
+	}
+	if c.ID != 800000<<40|1<<16 {
+		t.Errorf("got channel id %d", c.ID)
+	}
+	if c.Capacity != 1000000 || c.LocalBalance != 400000 || c.RemoteBalance != 600000 || c.CSVDelay != 144 {
+		t.Errorf("unexpected balances %+v", c)
+	}
+
+	pending, err := b.ListChannels(context.Background(), options.WithChannelPending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[1].ChannelPoint != "bb:0" || pending[1].Status != models.ChannelOpening {
+		t.Errorf("unexpected channels with the pending ones %+v", pending)
+	}
+}
+
+func TestPayFailure(t *testing.T) {
+	n := newFakeLightningd(t, map[string]handler{
+		"pay": func(params json.RawMessage) (interface{}, *RPCError) {
+			p := struct {
+				Bolt11 string `json:"bolt11"`
+				MaxFee uint64 `json:"maxfee"`
+			}{}
+			json.Unmarshal(params, &p)
+			if p.Bolt11 == "lnbc-unpayable" {
+				return nil, &RPCError{Code: 210, Message: "Ran out of routes to try"}
+			}
+			if p.MaxFee != 5000 {
+				return nil, &RPCError{Code: -32602, Message: "unexpected maxfee"}
+			}
+			return json.RawMessage(`{
+				"payment_preimage": "0102",
+				"amount_msat": 100000000,
+				"amount_sent_msat": 100003000,
+				"status": "complete"
+			}`), nil
+		},
+	})
+	b := n.backend(t)
+
+	payment, err := b.SendPayment(context.Background(), &models.PayReq{String: "lnbc-payable", Amount: 100000}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.PaymentError != "" {
+		t.Fatalf("payment failed: %s", payment.PaymentError)
+	}
+	if len(payment.PaymentPreimage) != 2 || payment.PaymentPreimage[1] != 2 {
+		t.Errorf("got preimage %x", payment.PaymentPreimage)
+	}
+	if payment.Route.Amount != 100003 || payment.Route.Fee != 3 {
+		t.Errorf("unexpected route %+v", payment.Route)
+	}
+
+	payment, err = b.SendPayment(context.Background(), &models.PayReq{String: "lnbc-unpayable"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.PaymentError != "Ran out of routes to try" {
+		t.Errorf("got payment error %q", payment.PaymentError)
+	}
+}
+
+func TestSubscribeRoutingEventsIndexes(t *testing.T) {
+	interval := clnPollInterval
+	clnPollInterval = 10 * time.Millisecond
+	defer func() { clnPollInterval = interval }()
+
+	// the forward 3 is created and settled after the subscription, the
+	// forward 2 created before is failed after.
+	forwards := map[string]string{
+		"created": `{"forwards": [{"in_channel": "800000x1x0", "in_htlc_id": 3, "out_channel": "800000x2x1",
+			"out_msat": 1000000, "fee_msat": 1000, "status": "settled", "created_index": 3, "updated_index": 5}]}`,
+		"updated": `{"forwards": [
+			{"in_channel": "800000x1x0", "in_htlc_id": 2, "out_channel": "800000x2x1",
+				"status": "failed", "created_index": 2, "updated_index": 4},
+			{"in_channel": "800000x1x0", "in_htlc_id": 3, "out_channel": "800000x2x1",
+				"out_msat": 1000000, "fee_msat": 1000, "status": "settled", "created_index": 3, "updated_index": 5}
+		]}`,
+	}
+	n := newFakeLightningd(t, map[string]handler{
+		"wait": result(`{"subsystem": "forwards", "created": 2, "updated": 3}`),
+		"listforwards": func(params json.RawMessage) (interface{}, *RPCError) {
+			p := struct {
+				Index string `json:"index"`
+				Start uint64 `json:"start"`
+			}{}
+			json.Unmarshal(params, &p)
+			if p.Index == "created" && p.Start == 3 || p.Index == "updated" && p.Start == 4 {
+				return json.RawMessage(forwards[p.Index]), nil
+			}
+			return json.RawMessage(`{"forwards": []}`), nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *models.RoutingEvent)
+	done := make(chan error)
+	go func() { done <- n.backend(t).SubscribeRoutingEvents(ctx, events) }()
+
+	got := []*models.RoutingEvent{}
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-time.After(time.Second):
+			t.Fatalf("got %d routing events, want 2", len(got))
+		}
+	}
+	if got[0].IncomingHtlcId != 3 || got[0].Status != models.RoutingStatusSettled || got[0].FeeMsat != 1000 {
+		t.Errorf("unexpected settled event %+v", got[0])
+	}
+	if got[1].IncomingHtlcId != 2 || got[1].Status != models.RoutingStatusFailed {
+		t.Errorf("unexpected failed event %+v", got[1])
+	}
+
+	// the next polls start after the last indexes and the subscription
+	// stops on cancel even while nobody reads the events.
+	time.Sleep(5 * clnPollInterval)
+	select {
+	case e := <-events:
+		t.Errorf("unexpected routing event %+v", e)
+	default:
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("subscription stopped with %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not stopped on cancel")
+	}
+}
+
+func TestListSendPaysParts(t *testing.T) {
+	calls := 0
+	n := newFakeLightningd(t, map[string]handler{
+		"listsendpays": func(json.RawMessage) (interface{}, *RPCError) {
+			calls++
+			return json.RawMessage(`{"payments": [
+				{"id": 1, "groupid": 1, "partid": 1, "payment_hash": "aa", "status": "complete",
+					"amount_msat": 60000000, "amount_sent_msat": 60001000},
+				{"id": 2, "groupid": 1, "partid": 1, "payment_hash": "bb", "status": "failed",
+					"amount_msat": 10000000, "amount_sent_msat": 10000500},
+				{"id": 3, "groupid": 1, "partid": 2, "payment_hash": "aa", "status": "complete",
+					"amount_msat": 40000000, "amount_sent_msat": 40001000}
+			]}`), nil
+		},
+	})
+	b := n.backend(t)
+
+	first, err := b.ListPayments(context.Background(), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 1 || first[0].PaymentHash != "aa" || first[0].AmountMsat != 100000000 ||
+		first[0].FeeMsat != 2000 || len(first[0].HTLCs) != 2 {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	// the next page is taken from the parts listed for the first one.
+	next, err := b.ListPayments(context.Background(), first[0].Index, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next) != 1 || next[0].PaymentHash != "bb" || next[0].Status != models.PaymentFailed {
+		t.Fatalf("unexpected next page %+v", next)
+	}
+	if calls != 1 {
+		t.Errorf("got %d listsendpays calls, want 1", calls)
+	}
+}
+
+func TestMsatUnmarshal(t *testing.T) {
+	tests := []struct {
+		json string
+		want msat
+		err  bool
+	}{
+		{json: `1000`, want: 1000},
+		{json: `"1000msat"`, want: 1000},
+		{json: `"1000"`, want: 1000},
+		{json: `null`},
+		{json: `""`},
+		{json: `"1btc"`, err: true},
+		{json: `-1`, err: true},
+	}
+	for _, tt := range tests {
+		var got msat
+		err := json.Unmarshal([]byte(tt.json), &got)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: got %d, want an error", tt.json, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.json, got, tt.want)
+		}
+	}
+}
+
+func TestListInvoicesPages(t *testing.T) {
+	n := newFakeLightningd(t, map[string]handler{
+		"listinvoices": func(params json.RawMessage) (interface{}, *RPCError) {
+			p := struct {
+				Index string `json:"index"`
+				Start uint64 `json:"start"`
+				Limit uint64 `json:"limit"`
+			}{}
+			json.Unmarshal(params, &p)
+			if p.Index != "created" {
+				return nil, &RPCError{Code: -32602, Message: "unexpected index " + p.Index}
+			}
+			invoices := []string{}
+			for i := p.Start; i < p.Start+p.Limit && i <= 5; i++ {
+				invoices = append(invoices, fmt.Sprintf(
+					`{"label": "%d", "created_index": %d, "status": "paid", "amount_msat": "%dmsat"}`, i, i, i*1000))
+			}
+			return json.RawMessage(`{"invoices": [` + strings.Join(invoices, ",") + `]}`), nil
+		},
+	})
+	b := n.backend(t)
+
+	pages := [][]uint64{}
+	offset := uint64(0)
+	for {
+		invoices, err := b.ListInvoices(context.Background(), offset, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(invoices) == 0 {
+			break
+		}
+		page := []uint64{}
+		for _, i := range invoices {
+			page = append(page, i.Index)
+			if i.Amount != int64(i.Index) {
+				t.Errorf("invoice %d: got amount %d", i.Index, i.Amount)
+			}
+		}
+		pages = append(pages, page)
+		offset = invoices[len(invoices)-1].Index
+	}
+	if fmt.Sprint(pages) != "[[1 2] [3 4] [5]]" {
+		t.Errorf("got pages %v", pages)
+	}
+}
+
+func TestForwardingHistoryAliases(t *testing.T) {
+	lookups := map[string]int{}
+	n := newFakeLightningd(t, map[string]handler{
+		"listforwards": result(`{"forwards": [
+			{"in_channel": "800000x1x0", "out_channel": "800000x2x0", "in_msat": 1001000,
+				"out_msat": 1000000, "fee_msat": 1000, "status": "settled", "received_time": 1700000000.5},
+			{"in_channel": "800000x2x0", "out_channel": "800000x3x0", "in_msat": "2002000msat",
+				"out_msat": "2000000msat", "fee_msat": "2000msat", "status": "settled", "received_time": 1700000001}
+		]}`),
+		"listpeerchannels": result(`{"channels": [
+			{"peer_id": "02bbbb", "short_channel_id": "800000x1x0"},
+			{"peer_id": "02bbbb", "short_channel_id": "800000x3x0"},
+			{"peer_id": "02cccc", "short_channel_id": "800000x2x0"},
+			{"peer_id": "02dddd", "short_channel_id": "800000x4x0"}
+		]}`),
+		"listnodes": func(params json.RawMessage) (interface{}, *RPCError) {
+			p := struct {
+				ID string `json:"id"`
+			}{}
+			json.Unmarshal(params, &p)
+			lookups[p.ID]++
+			if p.ID == "02cccc" {
+				return json.RawMessage(`{"nodes": []}`), nil
+			}
+			return json.RawMessage(`{"nodes": [{"nodeid": "` + p.ID + `", "alias": "bob"}]}`), nil
+		},
+	})
+
+	forwards, err := n.backend(t).GetForwardingHistory(context.Background(), "0", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forwards) != 2 {
+		t.Fatalf("got %d forwards, want 2", len(forwards))
+	}
+	if f := forwards[1]; f.AmtInMsat != 2002000 || f.FeeMsat != 2000 || f.Fee != 2 {
+		t.Errorf("unexpected amounts %+v", f)
+	}
+	if forwards[0].PeerAliasIn != "bob" || forwards[0].PeerAliasOut != "" || forwards[1].PeerAliasOut != "bob" {
+		t.Errorf("unexpected aliases %+v %+v", forwards[0], forwards[1])
+	}
+
+	// the peers are looked up once, the ones without forward are not.
+	if lookups["02bbbb"] != 1 || lookups["02cccc"] != 1 || lookups["02dddd"] != 0 {
+		t.Errorf("unexpected lookups %v", lookups)
+	}
+}
+
+func TestSubscribeInvoiceLastPayIndex(t *testing.T) {
+	for _, paid := range []uint64{0, 1, 2, 7, 64, 1000} {
+		t.Run(fmt.Sprint(paid), func(t *testing.T) {
+			var mu sync.Mutex
+			calls := 0
+			waiting := make(chan uint64, 1)
+			n := newFakeLightningd(t, map[string]handler{
+				"listinvoices": func(json.RawMessage) (interface{}, *RPCError) {
+					return nil, &RPCError{Code: -32602, Message: "the invoices are not listed"}
+				},
+				"waitanyinvoice": func(params json.RawMessage) (interface{}, *RPCError) {
+					p := struct {
+						LastPayIndex uint64  `json:"lastpay_index"`
+						Timeout      *uint64 `json:"timeout"`
+					}{}
+					json.Unmarshal(params, &p)
+					if p.LastPayIndex < paid {
+						mu.Lock()
+						calls++
+						mu.Unlock()
+						return json.RawMessage(fmt.Sprintf(
+							`{"label": "paid", "status": "paid", "pay_index": %d}`, p.LastPayIndex+1)), nil
+					}
+					if p.Timeout != nil {
+						mu.Lock()
+						calls++
+						mu.Unlock()
+						return nil, &RPCError{Code: 904, Message: "Timed out"}
+					}
+					waiting <- p.LastPayIndex
+					return json.RawMessage(fmt.Sprintf(
+						`{"label": "new", "status": "paid", "pay_index": %d}`, paid+1)), nil
+				},
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			invoices := make(chan *models.Invoice, 1)
+			go n.backend(t).SubscribeInvoice(ctx, invoices)
+
+			select {
+			case index := <-waiting:
+				if index != paid {
+					t.Errorf("waiting after %d, want %d", index, paid)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the next invoice is not waited")
+			}
+			select {
+			case <-invoices:
+			case <-time.After(time.Second):
+				t.Fatal("no invoice")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if calls > 25 {
+				t.Errorf("got %d calls to find the last pay index", calls)
+			}
+		})
+	}
+}
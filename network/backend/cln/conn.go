@@ -0,0 +1,120 @@
+package cln
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// rpcRequest is a JSON-RPC 2.0 request as expected by lightningd.
+type rpcRequest struct {
+	Version string      `json:"jsonrpc"`
+	ID      uint64      `json:"id"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// RPCError is the error returned by lightningd.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("cln: %s (code %d)", e.Message, e.Code)
+}
+
+// client is a JSON-RPC client dialing the lightning-rpc unix socket.
+// A new connection is opened for each call so that long polling methods
+// like waitanyinvoice do not block the other requests.
+type client struct {
+	path    string
+	counter uint64
+}
+
+func newClient(address string) *client {
+	return &client{path: strings.TrimPrefix(address, "unix://")}
+}
+
+func (c *client) dial(ctx context.Context) (net.Conn, error) {
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return conn, nil
+}
+
+// call sends the request to lightningd and decodes the result in out.
+func (c *client) call(ctx context.Context, method string, params interface{}, out interface{}) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// unblock the decoder if the context is canceled while waiting.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	req := rpcRequest{
+		Version: "2.0",
+		ID:      atomic.AddUint64(&c.counter, 1),
+		Method:  method,
+		Params:  params,
+	}
+
+	err = json.NewEncoder(conn).Encode(&req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	dec := json.NewDecoder(conn)
+	for {
+		resp := rpcResponse{}
+		err = dec.Decode(&resp)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.WithStack(err)
+		}
+
+		// lightningd may interleave notifications, skip them.
+		if resp.ID != req.ID {
+			continue
+		}
+
+		if resp.Error != nil {
+			return resp.Error
+		}
+
+		if out == nil {
+			return nil
+		}
+
+		return errors.WithStack(json.Unmarshal(resp.Result, out))
+	}
+}
@@ -0,0 +1,624 @@
+package cln
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// msat is an amount in millisatoshis. Older lightningd versions encode
+// amounts as strings like "1000msat", newer ones as plain integers.
+type msat uint64
+
+func (m *msat) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	s = strings.TrimSuffix(s, "msat")
+	if s == "" || s == "null" {
+		*m = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = msat(v)
+	return nil
+}
+
+func (m msat) sat() int64 {
+	return int64(m / 1000)
+}
+
+type getInfoResponse struct {
+	ID                    string `json:"id"`
+	Alias                 string `json:"alias"`
+	NumPeers              uint32 `json:"num_peers"`
+	NumPendingChannels    uint32 `json:"num_pending_channels"`
+	NumActiveChannels     uint32 `json:"num_active_channels"`
+	NumInactiveChannels   uint32 `json:"num_inactive_channels"`
+	BlockHeight           uint32 `json:"blockheight"`
+	Version               string `json:"version"`
+	Network               string `json:"network"`
+	WarningBitcoindSync   string `json:"warning_bitcoind_sync"`
+	WarningLightningdSync string `json:"warning_lightningd_sync"`
+}
+
+type listFundsResponse struct {
+	Outputs []struct {
+		TxID         string `json:"txid"`
+		Output       uint32 `json:"output"`
+		AmountMsat   msat   `json:"amount_msat"`
+		Address      string `json:"address"`
+		ScriptPubKey string `json:"scriptpubkey"`
+		RedeemScript string `json:"redeemscript"`
+		Status       string `json:"status"`
+		BlockHeight  uint32 `json:"blockheight"`
+		Reserved     bool   `json:"reserved"`
+	} `json:"outputs"`
+}
+
+// scriptToAddressType returns the type of the address of an output script
+// given in hex, the nested segwit outputs have a redeem script.
+func scriptToAddressType(script, redeemScript string) int {
+	switch {
+	case len(script) == 44 && strings.HasPrefix(script, "0014"):
+		return models.AddressP2WPKH
+	case len(script) == 68 && strings.HasPrefix(script, "5120"):
+		return models.AddressP2TR
+	case strings.HasPrefix(script, "a914") && redeemScript != "":
+		return models.AddressNP2WKH
+	default:
+		return models.AddressUnknown
+	}
+}
+
+type feeratesResponse struct {
+	PerKb struct {
+		Opening   uint64 `json:"opening"`
+		Estimates []struct {
+			BlockCount int32  `json:"blockcount"`
+			FeeRate    uint64 `json:"feerate"`
+		} `json:"estimates"`
+	} `json:"perkb"`
+}
+
+// feeRate returns the fee rate in sat/vbyte of the estimate with the largest
+// block count within the target, the estimates are ordered by block count.
+// The opening fee rate is used by the nodes without estimates.
+func (r feeratesResponse) feeRate(targetConf int32) uint64 {
+	rate := r.PerKb.Opening
+	for i, e := range r.PerKb.Estimates {
+		if i == 0 || e.BlockCount <= targetConf {
+			rate = e.FeeRate
+		}
+	}
+	return (rate + 999) / 1000
+}
+
+type withdrawResponse struct {
+	TxID string `json:"txid"`
+}
+
+type htlc struct {
+	Direction   string `json:"direction"`
+	ID          uint64 `json:"id"`
+	AmountMsat  msat   `json:"amount_msat"`
+	Expiry      uint32 `json:"expiry"`
+	PaymentHash string `json:"payment_hash"`
+	State       string `json:"state"`
+}
+
+type peerChannel struct {
+	PeerID             string `json:"peer_id"`
+	PeerConnected      bool   `json:"peer_connected"`
+	State              string `json:"state"`
+	ShortChannelID     string `json:"short_channel_id"`
+	ChannelID          string `json:"channel_id"`
+	FundingTxID        string `json:"funding_txid"`
+	FundingOutnum      uint32 `json:"funding_outnum"`
+	Private            bool   `json:"private"`
+	Opener             string `json:"opener"`
+	Closer             string `json:"closer"`
+	ToUsMsat           msat   `json:"to_us_msat"`
+	TotalMsat          msat   `json:"total_msat"`
+	LastTxFeeMsat      msat   `json:"last_tx_fee_msat"`
+	InFulfilledMsat    msat   `json:"in_fulfilled_msat"`
+	OutFulfilledMsat   msat   `json:"out_fulfilled_msat"`
+	InPaymentsOffered  uint64 `json:"in_payments_offered"`
+	OutPaymentsOffered uint64 `json:"out_payments_offered"`
+	OurToSelfDelay     uint32 `json:"our_to_self_delay"`
+	Feerate            struct {
+		PerKw int64 `json:"perkw"`
+	} `json:"feerate"`
+	Htlcs  []htlc   `json:"htlcs"`
+	Status []string `json:"status"`
+}
+
+type peer struct {
+	ID        string   `json:"id"`
+	Connected bool     `json:"connected"`
+	Netaddr   []string `json:"netaddr"`
+}
+
+type listPeersResponse struct {
+	Peers []*peer `json:"peers"`
+}
+
+type fundChannelResponse struct {
+	TxID      string `json:"txid"`
+	ChannelID string `json:"channel_id"`
+}
+
+type closeResponse struct {
+	Type string `json:"type"`
+	TxID string `json:"txid"`
+}
+
+type listPeerChannelsResponse struct {
+	Channels []*peerChannel `json:"channels"`
+}
+
+type channelEdge struct {
+	Source              string `json:"source"`
+	Destination         string `json:"destination"`
+	ShortChannelID      string `json:"short_channel_id"`
+	Public              bool   `json:"public"`
+	AmountMsat          msat   `json:"amount_msat"`
+	Active              bool   `json:"active"`
+	LastUpdate          int64  `json:"last_update"`
+	BaseFeeMillisatoshi int64  `json:"base_fee_millisatoshi"`
+	FeePerMillionth     int64  `json:"fee_per_millionth"`
+	Delay               uint32 `json:"delay"`
+	HtlcMinimumMsat     msat   `json:"htlc_minimum_msat"`
+	HtlcMaximumMsat     msat   `json:"htlc_maximum_msat"`
+}
+
+type listChannelsResponse struct {
+	Channels []*channelEdge `json:"channels"`
+}
+
+type listNodesResponse struct {
+	Nodes []struct {
+		NodeID        string `json:"nodeid"`
+		Alias         string `json:"alias"`
+		LastTimestamp int64  `json:"last_timestamp"`
+		Addresses     []struct {
+			Type    string `json:"type"`
+			Address string `json:"address"`
+			Port    int    `json:"port"`
+		} `json:"addresses"`
+	} `json:"nodes"`
+}
+
+type forward struct {
+	InChannel    string  `json:"in_channel"`
+	InHtlcID     uint64  `json:"in_htlc_id"`
+	OutChannel   string  `json:"out_channel"`
+	OutHtlcID    uint64  `json:"out_htlc_id"`
+	InMsat       msat    `json:"in_msat"`
+	OutMsat      msat    `json:"out_msat"`
+	FeeMsat      msat    `json:"fee_msat"`
+	Status       string  `json:"status"`
+	FailCode     int32   `json:"failcode"`
+	FailReason   string  `json:"failreason"`
+	ReceivedTime float64 `json:"received_time"`
+	ResolvedTime float64 `json:"resolved_time"`
+	CreatedIndex uint64  `json:"created_index"`
+	UpdatedIndex uint64  `json:"updated_index"`
+}
+
+type closedChannel struct {
+	PeerID             string `json:"peer_id"`
+	ShortChannelID     string `json:"short_channel_id"`
+	FundingTxID        string `json:"funding_txid"`
+	FundingOutnum      uint32 `json:"funding_outnum"`
+	TotalMsat          msat   `json:"total_msat"`
+	FinalToUsMsat      msat   `json:"final_to_us_msat"`
+	LastCommitmentTxID string `json:"last_commitment_txid"`
+	CloseCause         string `json:"close_cause"`
+}
+
+type listClosedChannelsResponse struct {
+	ClosedChannels []*closedChannel `json:"closedchannels"`
+}
+
+type listForwardsResponse struct {
+	Forwards []*forward `json:"forwards"`
+}
+
+// waitResponse is the current index of a subsystem, only the index waited
+// for is set.
+type waitResponse struct {
+	Subsystem string `json:"subsystem"`
+	Created   uint64 `json:"created"`
+	Updated   uint64 `json:"updated"`
+}
+
+type invoice struct {
+	Label              string `json:"label"`
+	Bolt11             string `json:"bolt11"`
+	PaymentHash        string `json:"payment_hash"`
+	PaymentPreimage    string `json:"payment_preimage"`
+	AmountMsat         msat   `json:"amount_msat"`
+	AmountReceivedMsat msat   `json:"amount_received_msat"`
+	Status             string `json:"status"`
+	Description        string `json:"description"`
+	ExpiresAt          int64  `json:"expires_at"`
+	PaidAt             int64  `json:"paid_at"`
+	PayIndex           uint64 `json:"pay_index"`
+	CreatedIndex       uint64 `json:"created_index"`
+}
+
+type listInvoicesResponse struct {
+	Invoices []*invoice `json:"invoices"`
+}
+
+type invoiceResponse struct {
+	Bolt11       string `json:"bolt11"`
+	PaymentHash  string `json:"payment_hash"`
+	ExpiresAt    int64  `json:"expires_at"`
+	CreatedIndex uint64 `json:"created_index"`
+}
+
+type newAddrResponse struct {
+	Bech32 string `json:"bech32"`
+}
+
+type decodePayResponse struct {
+	Payee              string `json:"payee"`
+	AmountMsat         msat   `json:"amount_msat"`
+	CreatedAt          int64  `json:"created_at"`
+	Expiry             int64  `json:"expiry"`
+	Description        string `json:"description"`
+	DescriptionHash    string `json:"description_hash"`
+	PaymentHash        string `json:"payment_hash"`
+	MinFinalCltvExpiry int64  `json:"min_final_cltv_expiry"`
+	Fallbacks          []struct {
+		Addr string `json:"addr"`
+	} `json:"fallbacks"`
+}
+
+type payResponse struct {
+	PaymentPreimage string `json:"payment_preimage"`
+	AmountMsat      msat   `json:"amount_msat"`
+	AmountSentMsat  msat   `json:"amount_sent_msat"`
+	Status          string `json:"status"`
+}
+
+type getRouteResponse struct {
+	Route []struct {
+		ID         string `json:"id"`
+		Channel    string `json:"channel"`
+		AmountMsat msat   `json:"amount_msat"`
+		Delay      uint32 `json:"delay"`
+	} `json:"route"`
+}
+
+type sendPay struct {
+	ID              uint64 `json:"id"`
+	GroupID         uint64 `json:"groupid"`
+	PartID          uint64 `json:"partid"`
+	PaymentHash     string `json:"payment_hash"`
+	Status          string `json:"status"`
+	AmountMsat      msat   `json:"amount_msat"`
+	AmountSentMsat  msat   `json:"amount_sent_msat"`
+	Destination     string `json:"destination"`
+	CreatedAt       int64  `json:"created_at"`
+	CompletedAt     int64  `json:"completed_at"`
+	Bolt11          string `json:"bolt11"`
+	PaymentPreimage string `json:"payment_preimage"`
+}
+
+type listSendPaysResponse struct {
+	Payments []*sendPay `json:"payments"`
+}
+
+type listTransactionsResponse struct {
+	Transactions []struct {
+		Hash        string `json:"hash"`
+		RawTx       string `json:"rawtx"`
+		BlockHeight int32  `json:"blockheight"`
+		Inputs      []struct {
+			TxID  string `json:"txid"`
+			Index uint32 `json:"index"`
+		} `json:"inputs"`
+		Outputs []struct {
+			Index      uint32 `json:"index"`
+			AmountMsat msat   `json:"amount_msat"`
+		} `json:"outputs"`
+	} `json:"transactions"`
+}
+
+func floatToTime(t float64) time.Time {
+	return time.Unix(0, int64(t*1e9))
+}
+
+func infoToInfo(resp *getInfoResponse) *models.Info {
+	if resp == nil {
+		return nil
+	}
+
+	return &models.Info{
+		PubKey:              resp.ID,
+		Alias:               resp.Alias,
+		NumPendingChannels:  resp.NumPendingChannels,
+		NumActiveChannels:   resp.NumActiveChannels,
+		NumInactiveChannels: resp.NumInactiveChannels,
+		NumPeers:            resp.NumPeers,
+		BlockHeight:         resp.BlockHeight,
+		Synced:              resp.WarningBitcoindSync == "" && resp.WarningLightningdSync == "",
+		Version:             resp.Version,
+		Chains:              []string{"bitcoin"},
+		Testnet:             resp.Network != "bitcoin",
+	}
+}
+
+func channelStatus(c *peerChannel) int {
+	switch c.State {
+	case "CHANNELD_NORMAL", "CHANNELD_AWAITING_SPLICE":
+		if c.PeerConnected {
+			return models.ChannelActive
+		}
+		return models.ChannelInactive
+	case "OPENINGD", "CHANNELD_AWAITING_LOCKIN",
+		"DUALOPEND_OPEN_INIT", "DUALOPEND_AWAITING_LOCKIN":
+		return models.ChannelOpening
+	case "CHANNELD_SHUTTING_DOWN", "CLOSINGD_SIGEXCHANGE":
+		return models.ChannelClosing
+	case "CLOSINGD_COMPLETE", "FUNDING_SPEND_SEEN":
+		return models.ChannelWaitingClose
+	case "AWAITING_UNILATERAL", "ONCHAIN":
+		return models.ChannelForceClosing
+	}
+	return models.ChannelClosed
+}
+
+func isPending(status int) bool {
+	return status != models.ChannelActive && status != models.ChannelInactive
+}
+
+func peerChannelToChannel(c *peerChannel) *models.Channel {
+	HTLCs := make([]*models.HTLC, len(c.Htlcs))
+	unsettled := int64(0)
+	for i := range c.Htlcs {
+		HTLCs[i] = htlcToHTLC(&c.Htlcs[i])
+		unsettled += HTLCs[i].Amount
+	}
+
+	return &models.Channel{
+		ID:                  backend.ParseShortChannelID(c.ShortChannelID),
+		Status:              channelStatus(c),
+		RemotePubKey:        c.PeerID,
+		ChannelPoint:        fmt.Sprintf("%s:%d", c.FundingTxID, c.FundingOutnum),
+		Capacity:            c.TotalMsat.sat(),
+		LocalBalance:        c.ToUsMsat.sat(),
+		RemoteBalance:       c.TotalMsat.sat() - c.ToUsMsat.sat(),
+		CommitFee:           c.LastTxFeeMsat.sat(),
+		FeePerKiloWeight:    c.Feerate.PerKw,
+		UnsettledBalance:    unsettled,
+		TotalAmountSent:     c.OutFulfilledMsat.sat(),
+		TotalAmountReceived: c.InFulfilledMsat.sat(),
+		UpdatesCount:        c.InPaymentsOffered + c.OutPaymentsOffered,
+		CSVDelay:            c.OurToSelfDelay,
+		Private:             c.Private,
+		PendingHTLC:         HTLCs,
+	}
+}
+
+func htlcToHTLC(h *htlc) *models.HTLC {
+	hashlock, _ := hex.DecodeString(h.PaymentHash)
+	return &models.HTLC{
+		Incoming:         h.Direction == "in",
+		Amount:           h.AmountMsat.sat(),
+		Hashlock:         hashlock,
+		ExpirationHeight: h.Expiry,
+	}
+}
+
+func edgeToRoutingPolicy(e *channelEdge) *models.RoutingPolicy {
+	if e == nil {
+		return nil
+	}
+	return &models.RoutingPolicy{
+		TimeLockDelta:    e.Delay,
+		MinHtlc:          int64(e.HtlcMinimumMsat),
+		MaxHtlc:          uint64(e.HtlcMaximumMsat),
+		FeeBaseMsat:      e.BaseFeeMillisatoshi,
+		FeeRateMilliMsat: e.FeePerMillionth,
+		Disabled:         !e.Active,
+	}
+}
+
+func invoiceToInvoice(i *invoice) *models.Invoice {
+	preimage, _ := hex.DecodeString(i.PaymentPreimage)
+	hash, _ := hex.DecodeString(i.PaymentHash)
+	return &models.Invoice{
+		Index:            i.CreatedIndex,
+		Amount:           i.AmountMsat.sat(),
+		AmountPaid:       i.AmountReceivedMsat.sat(),
+		AmountPaidInMSat: int64(i.AmountReceivedMsat),
+		Description:      i.Description,
+		RPreImage:        preimage,
+		RHash:            hash,
+		PaymentRequest:   i.Bolt11,
+		Settled:          i.Status == "paid",
+		SettleDate:       i.PaidAt,
+		State:            invoiceState(i),
+	}
+}
+
+func invoiceState(i *invoice) int {
+	switch i.Status {
+	case "paid":
+		return models.InvoiceSettled
+	case "expired":
+		return models.InvoiceCanceled
+	}
+	return models.InvoiceOpen
+}
+
+func decodePayToPayReq(resp *decodePayResponse, payreq string) *models.PayReq {
+	if resp == nil {
+		return nil
+	}
+	fallback := ""
+	if len(resp.Fallbacks) > 0 {
+		fallback = resp.Fallbacks[0].Addr
+	}
+	return &models.PayReq{
+		Destination:     resp.Payee,
+		PaymentHash:     resp.PaymentHash,
+		Amount:          resp.AmountMsat.sat(),
+		Timestamp:       resp.CreatedAt,
+		Expiry:          resp.Expiry,
+		Description:     resp.Description,
+		DescriptionHash: resp.DescriptionHash,
+		FallbackAddr:    fallback,
+		CltvExpiry:      resp.MinFinalCltvExpiry,
+		String:          payreq,
+	}
+}
+
+func forwardStatus(f *forward) int {
+	switch f.Status {
+	case "settled":
+		return models.RoutingStatusSettled
+	case "failed":
+		return models.RoutingStatusFailed
+	case "local_failed":
+		return models.RoutingStatusLinkFailed
+	}
+	return models.RoutingStatusActive
+}
+
+func forwardToRoutingEvent(f *forward) *models.RoutingEvent {
+	last := f.ResolvedTime
+	if last == 0 {
+		last = f.ReceivedTime
+	}
+	return &models.RoutingEvent{
+		IncomingChannelId: backend.ParseShortChannelID(f.InChannel),
+		OutgoingChannelId: backend.ParseShortChannelID(f.OutChannel),
+		IncomingHtlcId:    f.InHtlcID,
+		OutgoingHtlcId:    f.OutHtlcID,
+		LastUpdate:        floatToTime(last),
+		Direction:         models.RoutingForward,
+		Status:            forwardStatus(f),
+		AmountMsat:        uint64(f.OutMsat),
+		FeeMsat:           uint64(f.FeeMsat),
+		FailureCode:       f.FailCode,
+		FailureDetail:     f.FailReason,
+	}
+}
+
+// closedChannelToClosedChannel converts a closed channel, the close type is
+// derived from the cause of the close: the closes requested by a node are
+// counted as cooperative and the ones caused by an error as local force
+// closes. The closing transaction is only known for the latter, it is the
+// last commitment.
+func closedChannelToClosedChannel(c *closedChannel) *models.ClosedChannel {
+	closed := &models.ClosedChannel{
+		ID:             backend.ParseShortChannelID(c.ShortChannelID),
+		ChannelPoint:   fmt.Sprintf("%s:%d", c.FundingTxID, c.FundingOutnum),
+		RemotePubKey:   c.PeerID,
+		Capacity:       c.TotalMsat.sat(),
+		SettledBalance: c.FinalToUsMsat.sat(),
+	}
+	switch c.CloseCause {
+	case "user", "remote":
+		closed.CloseType = models.CloseCooperative
+	case "local", "protocol":
+		closed.CloseType = models.CloseLocalForce
+		closed.ClosingTxID = c.LastCommitmentTxID
+	case "onchain":
+		closed.CloseType = models.CloseRemoteForce
+	}
+	return closed
+}
+
+func forwardToForwardingEvent(f *forward) *models.ForwardingEvent {
+	return &models.ForwardingEvent{
+		ChanIdIn:   backend.ParseShortChannelID(f.InChannel),
+		ChanIdOut:  backend.ParseShortChannelID(f.OutChannel),
+		AmtIn:      uint64(f.InMsat.sat()),
+		AmtOut:     uint64(f.OutMsat.sat()),
+		Fee:        uint64(f.FeeMsat.sat()),
+		FeeMsat:    uint64(f.FeeMsat),
+		AmtInMsat:  uint64(f.InMsat),
+		AmtOutMsat: uint64(f.OutMsat),
+		EventTime:  floatToTime(f.ReceivedTime),
+	}
+}
+
+// sendPaysToPayments groups the parts sent by payment hash, the index of a
+// payment is the id of its first part. The amount and the fee are the ones of
+// the last group of parts, the previous groups are failed attempts.
+func sendPaysToPayments(pays []*sendPay) []*models.Payment {
+	sort.Slice(pays, func(i, j int) bool { return pays[i].ID < pays[j].ID })
+
+	payments := []*models.Payment{}
+	byHash := make(map[string]*models.Payment)
+	groups := make(map[string]uint64)
+	for _, s := range pays {
+		payment, ok := byHash[s.PaymentHash]
+		if !ok {
+			payment = &models.Payment{
+				Index:        s.ID,
+				PaymentHash:  s.PaymentHash,
+				Status:       models.PaymentFailed,
+				CreationDate: time.Unix(s.CreatedAt, 0),
+			}
+			if s.Bolt11 != "" {
+				payment.PayReq = &models.PayReq{
+					PaymentHash: s.PaymentHash,
+					Destination: s.Destination,
+					String:      s.Bolt11,
+				}
+			}
+			byHash[s.PaymentHash] = payment
+			payments = append(payments, payment)
+		}
+
+		if !ok || s.GroupID != groups[s.PaymentHash] {
+			groups[s.PaymentHash] = s.GroupID
+			payment.AmountMsat = 0
+			payment.FeeMsat = 0
+		}
+		payment.AmountMsat += int64(s.AmountMsat)
+		payment.FeeMsat += int64(s.AmountSentMsat) - int64(s.AmountMsat)
+
+		attempt := &models.HTLCAttempt{
+			Status:      models.HTLCAttemptFailed,
+			AttemptTime: time.Unix(s.CreatedAt, 0),
+			Route: &models.Route{
+				Amount: s.AmountSentMsat.sat(),
+				Fee:    s.AmountSentMsat.sat() - s.AmountMsat.sat(),
+			},
+		}
+		if s.CompletedAt != 0 {
+			attempt.ResolveTime = time.Unix(s.CompletedAt, 0)
+		}
+		switch s.Status {
+		case "complete":
+			attempt.Status = models.HTLCAttemptSucceeded
+			payment.Status = models.PaymentSucceeded
+			payment.PaymentPreimage, _ = hex.DecodeString(s.PaymentPreimage)
+		case "pending":
+			attempt.Status = models.HTLCAttemptInFlight
+			if payment.Status != models.PaymentSucceeded {
+				payment.Status = models.PaymentInFlight
+			}
+		default:
+			attempt.Failure = "failed"
+		}
+		payment.HTLCs = append(payment.HTLCs, attempt)
+	}
+
+	return payments
+}
@@ -0,0 +1,45 @@
+// Package dialer opens the network connections of the backends, directly or
+// through a SOCKS5 proxy.
+package dialer
+
+import (
+	"context"
+	"net"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// DialFunc opens a connection to the address on the named network.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// New returns a DialFunc connecting through the proxy, of the form
+// socks5://[user:password@]host:port, or directly when the proxy is empty.
+// The host names are resolved by the proxy so that .onion addresses can be
+// reached through Tor.
+func New(proxyURI string) (DialFunc, error) {
+	direct := &net.Dialer{}
+	if proxyURI == "" {
+		return direct.DialContext, nil
+	}
+
+	u, err := url.Parse(proxyURI)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Scheme != "socks5" && u.Scheme != "socks5h" {
+		return nil, errors.Errorf("proxy scheme must be socks5, got %q", u.Scheme)
+	}
+
+	d, err := proxy.FromURL(u, direct)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	contextDialer, ok := d.(proxy.ContextDialer)
+	if !ok {
+		return nil, errors.Errorf("proxy %s does not support contexts", u.Host)
+	}
+	return contextDialer.DialContext, nil
+}
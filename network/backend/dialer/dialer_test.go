@@ -0,0 +1,233 @@
+package dialer
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// socks5Proxy is a SOCKS5 proxy without authentication. It resolves the
+// host names with its hosts and records the addresses it connects to.
+type socks5Proxy struct {
+	listener net.Listener
+	hosts    map[string]string
+
+	mu      sync.Mutex
+	targets []string
+}
+
+func newSOCKS5Proxy(t *testing.T, hosts map[string]string) *socks5Proxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &socks5Proxy{listener: listener, hosts: hosts}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go p.handle(conn)
+		}
+	}()
+	return p
+}
+
+func (p *socks5Proxy) uri() string {
+	return "socks5://" + p.listener.Addr().String()
+}
+
+func (p *socks5Proxy) dialed() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string{}, p.targets...)
+}
+
+func (p *socks5Proxy) handle(conn net.Conn) {
+	defer conn.Close()
+
+	// greeting: version, methods, the no authentication method is chosen.
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil || header[0] != 5 {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// request: version, connect, reserved, address type.
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[1] != 1 {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 1:
+		ip := make([]byte, 4)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return
+		}
+		host = net.IP(ip).String()
+	case 3:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return
+		}
+		name := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return
+		}
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return
+	}
+	target := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+
+	p.mu.Lock()
+	p.targets = append(p.targets, target)
+	p.mu.Unlock()
+
+	address := target
+	if resolved, ok := p.hosts[target]; ok {
+		address = resolved
+	}
+	remote, err := net.Dial("tcp", address)
+	if err != nil {
+		// host unreachable.
+		conn.Write([]byte{5, 4, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer remote.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go io.Copy(remote, conn)
+	io.Copy(conn, remote)
+}
+
+func TestNewDirect(t *testing.T) {
+	dial, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	conn, err := dial(context.Background(), "tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestNewInvalidProxy(t *testing.T) {
+	for _, uri := range []string{"http://127.0.0.1:9050", "socks5://[::1"} {
+		_, err := New(uri)
+		if err == nil {
+			t.Errorf("proxy %s accepted", uri)
+		}
+	}
+}
+
+func TestNewREST(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	proxy := newSOCKS5Proxy(t, map[string]string{
+		"lnd.onion:8080": server.Listener.Addr().String(),
+	})
+	dial, err := New(proxy.uri())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Transport: &http.Transport{DialContext: dial}}
+	resp, err := client.Get("http://lnd.onion:8080/v1/getinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "lnd.onion:8080" {
+		t.Errorf("got host %q", body)
+	}
+
+	targets := proxy.dialed()
+	if len(targets) != 1 || targets[0] != "lnd.onion:8080" {
+		t.Errorf("got proxy targets %v", targets)
+	}
+}
+
+func TestNewGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+
+	proxy := newSOCKS5Proxy(t, map[string]string{
+		"lnd.onion:10009": listener.Addr().String(),
+	})
+	dial, err := New(proxy.uri())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := grpc.Dial("lnd.onion:10009",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+			return dial(ctx, "tcp", address)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	resp, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(),
+		&grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("got status %s", resp.Status)
+	}
+
+	targets := proxy.dialed()
+	if len(targets) == 0 || targets[0] != "lnd.onion:10009" {
+		t.Errorf("got proxy targets %v", targets)
+	}
+}
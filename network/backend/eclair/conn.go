@@ -0,0 +1,177 @@
+package eclair
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/backend/dialer"
+)
+
+// client calls the eclair HTTP API. Eclair authenticates with basic auth
+// using an empty user and the api password.
+type client struct {
+	address  string
+	password string
+	http     *http.Client
+	ws       *websocket.Dialer
+}
+
+func newClient(c *config.Network) (*client, error) {
+	clt := &client{
+		address:  strings.TrimSuffix(c.Address, "/"),
+		password: c.Password,
+		http:     &http.Client{},
+		ws:       websocket.DefaultDialer,
+	}
+
+	if c.Proxy != "" {
+		dial, err := dialer.New(c.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		clt.http.Transport = &http.Transport{DialContext: dial}
+		clt.ws = &websocket.Dialer{
+			NetDialContext:   dial,
+			HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		}
+	}
+
+	return clt, nil
+}
+
+// APIError is the error returned by eclair.
+type APIError struct {
+	Status  int
+	Message string `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("eclair: %s (status %d)", e.Message, e.Status)
+}
+
+// call posts the form encoded params to the method endpoint and decodes
+// the json response in out.
+func (c *client) call(ctx context.Context, method string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s", c.address, method),
+		strings.NewReader(params.Encode()))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("", c.password)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{Status: resp.StatusCode}
+		if json.Unmarshal(body, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return apiErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return errors.WithStack(json.Unmarshal(body, out))
+}
+
+// websocket opens the eclair event stream.
+func (c *client) websocket(ctx context.Context) (*websocket.Conn, error) {
+	u, err := url.Parse(c.address)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ws"
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.SetBasicAuth("", c.password)
+
+	conn, _, err := c.ws.DialContext(ctx, u.String(), req.Header)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return conn, nil
+}
+
+// subscribe reads the event stream until the context is canceled and
+// passes each raw event with its type to fn.
+func (c *client) subscribe(ctx context.Context, fn func(kind string, data []byte) error) error {
+	conn, err := c.websocket(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// unblock the reader if the context is canceled while waiting.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.WithStack(err)
+		}
+
+		event := struct {
+			Type string `json:"type"`
+		}{}
+		err = json.Unmarshal(data, &event)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		err = fn(event.Type, data)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
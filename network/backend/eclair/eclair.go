@@ -0,0 +1,1107 @@
+package eclair
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+const (
+	eclairDefaultInvoiceExpiry = 3600
+	// on-chain transactions and channel updates are not part of the
+	// websocket events, they are polled at this interval.
+	eclairPollInterval = 3 * time.Second
+)
+
+type Backend struct {
+	cfg    *config.Network
+	logger logging.Logger
+	client *client
+}
+
+func (b Backend) NodeName() string {
+	return b.cfg.Name
+}
+
+func (b Backend) Ping() error {
+	return b.client.call(context.Background(), "getinfo", nil, nil)
+}
+
+func (b Backend) getInfo(ctx context.Context) (*getInfoResponse, error) {
+	resp := &getInfoResponse{}
+	err := b.client.call(ctx, "getinfo", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (b Backend) channels(ctx context.Context) ([]*channel, error) {
+	resp := []*channel{}
+	err := b.client.call(ctx, "channels", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// peers returns the connected peers.
+func (b Backend) peers(ctx context.Context) ([]*peer, error) {
+	resp := []*peer{}
+	err := b.client.call(ctx, "peers", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	peers := []*peer{}
+	for _, p := range resp {
+		if p.State == "CONNECTED" {
+			peers = append(peers, p)
+		}
+	}
+	return peers, nil
+}
+
+// scids maps the eclair channel ids to the short channel ids.
+func (b Backend) scids(ctx context.Context) (map[string]uint64, error) {
+	channels, err := b.channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	scids := make(map[string]uint64, len(channels))
+	for _, c := range channels {
+		scids[c.ChannelID] = c.scid()
+	}
+	return scids, nil
+}
+
+func (b Backend) Info(ctx context.Context) (*models.Info, error) {
+	resp, err := b.getInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := b.peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	numPeers := uint32(len(peers))
+
+	channels, err := b.channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return infoToInfo(resp, numPeers, channels), nil
+}
+
+func (b Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, error) {
+	b.logger.Debug("Retrieve wallet balance...")
+
+	resp := &onChainBalance{}
+	err := b.client.call(ctx, "onchainbalance", nil, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.WalletBalance{
+		TotalBalance:       resp.Confirmed + resp.Unconfirmed,
+		ConfirmedBalance:   resp.Confirmed,
+		UnconfirmedBalance: resp.Unconfirmed,
+	}
+
+	b.logger.Debug("Wallet balance retrieved", logging.Object("wallet", balance))
+
+	return balance, nil
+}
+
+func (b Backend) NewAddress(ctx context.Context) (string, error) {
+	b.logger.Debug("New address...")
+
+	var address string
+	err := b.client.call(ctx, "getnewaddress", nil, &address)
+	if err != nil {
+		return "", err
+	}
+
+	b.logger.Debug("Address retrieved", logging.String("address", address))
+
+	return address, nil
+}
+
+// ListUnspent returns no outputs, the on-chain wallet of eclair is the one of
+// bitcoind and its outputs are not listed by the api.
+func (b Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	return []*models.Utxo{}, nil
+}
+
+func (b Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	return 0, errors.New("the fee rates are not estimated by eclair")
+}
+
+func (b Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	b.logger.Debug("Send coins...",
+		logging.String("address", req.Address),
+		logging.Int64("amount", req.Amount))
+
+	if req.SendAll || len(req.Outpoints) > 0 {
+		return "", errors.New("the outputs spent cannot be chosen with eclair")
+	}
+
+	params := url.Values{
+		"address":            {req.Address},
+		"amountSatoshis":     {strconv.FormatInt(req.Amount, 10)},
+		"confirmationTarget": {strconv.FormatInt(int64(req.TargetConf), 10)},
+	}
+
+	var txid string
+	err := b.client.call(ctx, "sendonchain", params, &txid)
+	if err != nil {
+		return "", err
+	}
+
+	b.logger.Debug("Coins sent", logging.String("txid", txid))
+
+	return txid, nil
+}
+
+// BumpFee bumps the fee with cpfpbumpfees, the transactions are not
+// replaced. eclair computes the fee of the child for the rate of the package,
+// the rate of the child is given as the one of the package so the child pays
+// at least the rate of the request.
+func (b Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	b.logger.Debug("Bump fee...",
+		logging.String("txid", req.TxID),
+		logging.Uint64("sat_per_vbyte", req.SatPerVByte))
+
+	if req.Method != models.BumpFeeCPFP {
+		return errors.New("the transactions cannot be replaced with eclair")
+	}
+
+	params := url.Values{
+		"outPoints":            {req.Outpoint},
+		"targetFeerateSatByte": {strconv.FormatUint(req.SatPerVByte, 10)},
+	}
+
+	var txid string
+	err := b.client.call(ctx, "cpfpbumpfees", params, &txid)
+	if err != nil {
+		return err
+	}
+
+	b.logger.Debug("Fee bumped", logging.String("txid", txid))
+
+	return nil
+}
+
+func (b Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
+	b.logger.Debug("Retrieve channel balance...")
+
+	channels, err := b.channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.ChannelsBalance{}
+	for _, c := range channels {
+		switch channelStatus(c.State) {
+		case models.ChannelActive, models.ChannelInactive:
+			balance.Balance += c.spec().ToLocal / 1000
+		case models.ChannelOpening:
+			balance.PendingOpenBalance += c.spec().ToLocal / 1000
+		}
+	}
+
+	b.logger.Debug("Channel balance retrieved", logging.Object("balance", balance))
+
+	return balance, nil
+}
+
+func (b Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*models.Channel, error) {
+	b.logger.Debug("List channels")
+
+	resp, err := b.channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.NewChannelOptions(opt...)
+	channels := []*models.Channel{}
+	for _, c := range resp {
+		channel := channelToChannel(c)
+		if isPending(channel.Status) {
+			if opts.Pending && channel.Status != models.ChannelClosed {
+				channels = append(channels, channel)
+			}
+			continue
+		}
+		if opts.Active && channel.Status != models.ChannelActive ||
+			opts.Inactive && channel.Status != models.ChannelInactive ||
+			opts.Public && channel.Private ||
+			opts.Private && !channel.Private {
+			continue
+		}
+		channels = append(channels, channel)
+	}
+
+	fields := make([]logging.Field, len(channels))
+	for i := range channels {
+		fields[i] = logging.Object(fmt.Sprintf("channel_%d", i), channels[i])
+	}
+
+	b.logger.Debug("Channels retrieved", fields...)
+
+	return channels, nil
+}
+
+func (b Backend) allUpdates(ctx context.Context, pubkey string) ([]*channelUpdate, error) {
+	updates := []*channelUpdate{}
+	err := b.client.call(ctx, "allupdates", url.Values{"nodeId": {pubkey}}, &updates)
+	if err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// ClosedChannels lists the channels closed with closedchannels, eclair does
+// not report the close height. The settled balance is the local balance of
+// the last commitment.
+func (b Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	b.logger.Debug("Closed channels...")
+
+	resp := []*channel{}
+	err := b.client.call(ctx, "closedchannels", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return []*models.ClosedChannel{}, nil
+	}
+
+	audit := &auditResponse{}
+	err = b.client.call(ctx, "audit", url.Values{
+		"from": {"0"},
+		"to":   {strconv.FormatInt(time.Now().Unix(), 10)},
+	}, audit)
+	if err != nil {
+		return nil, err
+	}
+	routed := make(map[string]int64)
+	for _, r := range audit.Relayed {
+		routed[r.FromChannelID] += int64(r.AmountIn / 1000)
+		routed[r.ToChannelID] += int64(r.AmountOut / 1000)
+	}
+
+	channels := make([]*models.ClosedChannel, len(resp))
+	for i, c := range resp {
+		channels[i] = channelToClosedChannel(c)
+		channels[i].TotalRouted = routed[c.ChannelID]
+	}
+
+	b.logger.Debug("Closed channels retrieved", logging.Int("count", len(channels)))
+
+	return channels, nil
+}
+
+func (b Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
+	b.logger.Debug("GetChannelInfo")
+
+	// If channel does not have ID (pending), information cannot be retrieved
+	if channel.ID == 0 {
+		return nil
+	}
+
+	info, err := b.getInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	scid := backend.FormatShortChannelID(channel.ID)
+	var lastUpdate timestamp
+	for pubkey, local := range map[string]bool{
+		info.NodeID:          true,
+		channel.RemotePubKey: false,
+	} {
+		updates, err := b.allUpdates(ctx, pubkey)
+		if err != nil {
+			return err
+		}
+		for _, u := range updates {
+			if u.ShortChannelID != scid {
+				continue
+			}
+			if u.Timestamp > lastUpdate {
+				lastUpdate = u.Timestamp
+			}
+			if local {
+				channel.LocalPolicy = updateToRoutingPolicy(u)
+			} else {
+				channel.RemotePolicy = updateToRoutingPolicy(u)
+			}
+		}
+	}
+
+	if lastUpdate > 0 {
+		t := lastUpdate.time()
+		channel.LastUpdate = &t
+	}
+
+	return nil
+}
+
+func (b Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	b.logger.Debug("Update channel policy...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	// eclair only updates the relay fees, they apply to all the channels
+	// with the peer.
+	if current := channel.LocalPolicy; current != nil {
+		if current.TimeLockDelta != policy.TimeLockDelta ||
+			current.MinHtlc != policy.MinHtlc ||
+			current.MaxHtlc != policy.MaxHtlc ||
+			current.Disabled != policy.Disabled {
+			return errors.New("only the relay fees can be updated by eclair")
+		}
+	}
+
+	return b.client.call(ctx, "updaterelayfee", url.Values{
+		"nodeId":                    {channel.RemotePubKey},
+		"feeBaseMsat":               {strconv.FormatInt(policy.FeeBaseMsat, 10)},
+		"feeProportionalMillionths": {strconv.FormatInt(policy.FeeRateMilliMsat, 10)},
+	}, nil)
+}
+
+func (b Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	peers, err := b.peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.Peer, len(peers))
+	for i, p := range peers {
+		result[i] = &models.Peer{PubKey: p.NodeID, Address: p.Address}
+	}
+	return result, nil
+}
+
+func (b Backend) ConnectPeer(ctx context.Context, node string) error {
+	b.logger.Debug("Connect peer...", logging.String("node", node))
+
+	return b.client.call(ctx, "connect", url.Values{"uri": {node}}, nil)
+}
+
+func (b Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	b.logger.Debug("Disconnect peer...", logging.String("pubkey", pubkey))
+
+	return b.client.call(ctx, "disconnect", url.Values{"nodeId": {pubkey}}, nil)
+}
+
+func (b Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	b.logger.Debug("Open channel...",
+		logging.String("node", req.Node),
+		logging.Int64("amount", req.Amount))
+
+	if req.MinHtlcMsat > 0 {
+		return "", errors.New("the min htlc of the opening cannot be set with eclair")
+	}
+
+	if req.Host() != "" {
+		err := b.ConnectPeer(ctx, req.Node)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	params := url.Values{
+		"nodeId":          {req.PubKey()},
+		"fundingSatoshis": {strconv.FormatInt(req.Amount, 10)},
+		"announceChannel": {strconv.FormatBool(!req.Private)},
+	}
+	if req.PushAmount > 0 {
+		params.Set("pushMsat", strconv.FormatInt(req.PushAmount*1000, 10))
+	}
+	if req.SatPerVByte > 0 {
+		params.Set("fundingFeerateSatByte", strconv.FormatUint(req.SatPerVByte, 10))
+	}
+
+	// eclair answers with a sentence:
+	// created channel <id> with fundingTxId=<txid> and fees=<fees>
+	var resp string
+	err := b.client.call(ctx, "open", params, &resp)
+	if err != nil {
+		return "", err
+	}
+	txid := ""
+	for _, word := range strings.Fields(resp) {
+		if strings.HasPrefix(word, "fundingTxId=") {
+			txid = strings.TrimPrefix(word, "fundingTxId=")
+		}
+	}
+
+	b.logger.Debug("Channel opening", logging.String("txid", txid))
+
+	return txid, nil
+}
+
+func (b Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	b.logger.Debug("Close channel...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	// eclair takes the script of the delivery address, not the address.
+	if req.DeliveryAddress != "" {
+		return "", errors.New("the delivery address of a close cannot be set with eclair")
+	}
+
+	channels, err := b.channels(ctx)
+	if err != nil {
+		return "", err
+	}
+	id := ""
+	for _, c := range channels {
+		if c.funding().OutPoint == channel.ChannelPoint {
+			id = c.ChannelID
+		}
+	}
+	if id == "" {
+		return "", errors.Errorf("unknown channel %s", channel.ChannelPoint)
+	}
+
+	params := url.Values{"channelId": {id}}
+	if req.Force {
+		// the closing transaction is the commitment transaction, its
+		// fee rate was negotiated with the peer.
+		return "", b.client.call(ctx, "forceclose", params, nil)
+	}
+
+	if req.SatPerVByte > 0 {
+		feerate := strconv.FormatUint(req.SatPerVByte, 10)
+		params.Set("preferredFeerateSatByte", feerate)
+		params.Set("minFeerateSatByte", feerate)
+		params.Set("maxFeerateSatByte", feerate)
+	}
+
+	// the closing transaction is only known once the fee is negotiated
+	// with the peer.
+	return "", b.client.call(ctx, "close", params, nil)
+}
+
+func (b Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
+	b.logger.Debug("GetNode")
+
+	nodes := []*node{}
+	err := b.client.call(ctx, "nodes", url.Values{"nodeIds": {pubkey}}, &nodes)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, errors.Errorf("node %s not found", pubkey)
+	}
+
+	n := nodes[0]
+	addresses := make([]*models.NodeAddress, len(n.Addresses))
+	for i := range n.Addresses {
+		addresses[i] = &models.NodeAddress{Network: "tcp", Addr: n.Addresses[i]}
+	}
+
+	result := &models.Node{
+		LastUpdate: n.Timestamp.time(),
+		PubKey:     n.NodeID,
+		Alias:      n.Alias,
+		Addresses:  addresses,
+		Channels:   []*models.Channel{},
+	}
+
+	updates, err := b.allUpdates(ctx, pubkey)
+	if err != nil {
+		return nil, err
+	}
+	result.NumChannels = uint32(len(updates))
+	if includeChannels {
+		for _, u := range updates {
+			result.Channels = append(result.Channels, &models.Channel{
+				ID:          backend.ParseShortChannelID(u.ShortChannelID),
+				LocalPolicy: updateToRoutingPolicy(u),
+			})
+		}
+	}
+
+	if forcedAlias, ok := b.cfg.Aliases[result.PubKey]; ok {
+		result.ForcedAlias = forcedAlias
+	}
+	return result, nil
+}
+
+func (b Backend) GetTransactions(ctx context.Context) ([]*models.Transaction, error) {
+	b.logger.Debug("Get transactions...")
+
+	resp := []*transaction{}
+	err := b.client.call(ctx, "onchaintransactions", url.Values{"count": {"1000"}}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions := make([]*models.Transaction, len(resp))
+	for i := range resp {
+		transactions[i] = transactionToTransaction(resp[i])
+	}
+
+	return transactions, nil
+}
+
+func (b Backend) GetForwardingHistory(ctx context.Context, startTime string, maxNumEvents uint32) ([]*models.ForwardingEvent, error) {
+	b.logger.Debug("GetForwardingHistory")
+
+	start, err := options.ParseTime(startTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &auditResponse{}
+	err = b.client.call(ctx, "audit", url.Values{
+		"from": {strconv.FormatUint(start, 10)},
+		"to":   {strconv.FormatInt(time.Now().Unix(), 10)},
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(resp.Relayed, func(i, j int) bool {
+		return resp.Relayed[i].Timestamp < resp.Relayed[j].Timestamp
+	})
+
+	channels, err := b.channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scids := make(map[string]uint64, len(channels))
+	peers := make(map[string]string, len(channels))
+	for _, c := range channels {
+		scids[c.ChannelID] = c.scid()
+		peers[c.ChannelID] = c.NodeID
+	}
+
+	aliases := map[string]string{}
+	alias := func(channelID string) string {
+		pubkey, ok := peers[channelID]
+		if !ok {
+			return ""
+		}
+		if a, ok := aliases[pubkey]; ok {
+			return a
+		}
+		node, err := b.GetNode(ctx, pubkey, false)
+		if err == nil {
+			aliases[pubkey] = node.Alias
+		}
+		return aliases[pubkey]
+	}
+
+	result := []*models.ForwardingEvent{}
+	for _, r := range resp.Relayed {
+		if maxNumEvents > 0 && uint32(len(result)) >= maxNumEvents {
+			break
+		}
+		event := relayedToForwardingEvent(r, scids)
+		event.PeerAliasIn = alias(r.FromChannelID)
+		event.PeerAliasOut = alias(r.ToChannelID)
+		result = append(result, event)
+	}
+
+	return result, nil
+}
+
+func (b Backend) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
+	b.logger.Debug("Create invoice...",
+		logging.Int64("amount", amount),
+		logging.String("desc", desc))
+
+	params := url.Values{
+		"description": {desc},
+		"expireIn":    {strconv.Itoa(eclairDefaultInvoiceExpiry)},
+	}
+	// an invoice without amount lets the payer choose it.
+	if amount > 0 {
+		params.Set("amountMsat", strconv.FormatInt(amount*1000, 10))
+	}
+	resp := &invoice{}
+	err := b.client.call(ctx, "createinvoice", params, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := invoiceToInvoice(resp)
+
+	b.logger.Debug("Invoice retrieved", logging.Object("invoice", invoice))
+
+	return invoice, nil
+}
+
+func (b Backend) GetInvoice(ctx context.Context, RHash string) (*models.Invoice, error) {
+	b.logger.Debug("Retrieve invoice...", logging.String("r_hash", RHash))
+
+	resp := &receivedInfo{}
+	err := b.client.call(ctx, "getreceivedinfo", url.Values{"paymentHash": {RHash}}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := receivedInfoToInvoice(resp)
+
+	b.logger.Debug("Invoice retrieved", logging.Object("invoice", invoice))
+
+	return invoice, nil
+}
+
+func (b Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	b.logger.Debug("List invoices...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	resp := []*invoice{}
+	err := b.client.call(ctx, "listinvoices", url.Values{
+		"skip":  {strconv.FormatUint(offset, 10)},
+		"count": {strconv.FormatUint(max, 10)},
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// eclair does not index the invoices, their position is used instead and
+	// their status is given by the received info.
+	invoices := make([]*models.Invoice, len(resp))
+	for i := range resp {
+		info := &receivedInfo{}
+		err := b.client.call(ctx, "getreceivedinfo", url.Values{
+			"paymentHash": {resp[i].PaymentHash},
+		}, info)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			info = &receivedInfo{PaymentRequest: *resp[i]}
+		}
+		invoices[i] = receivedInfoToInvoice(info)
+		invoices[i].Index = offset + uint64(i) + 1
+	}
+
+	return invoices, nil
+}
+
+// ListPayments lists the payments sent from the audit, eclair does not index
+// the payments, their position is used instead. The failed payments are not
+// audited.
+func (b Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	b.logger.Debug("List payments...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	resp := &auditResponse{}
+	err := b.client.call(ctx, "audit", url.Values{
+		"from": {"0"},
+		"to":   {strconv.FormatInt(time.Now().Unix(), 10)},
+	}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	scids, err := b.scids(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]*models.Payment, len(resp.Sent))
+	for i := range resp.Sent {
+		payments[i] = paymentResultToPayment(resp.Sent[i], scids)
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].CreationDate.Before(payments[j].CreationDate)
+	})
+
+	result := []*models.Payment{}
+	for i := range payments {
+		payments[i].Index = uint64(i) + 1
+		if payments[i].Index > offset && uint64(len(result)) < max {
+			result = append(result, payments[i])
+		}
+	}
+
+	return result, nil
+}
+
+func (b Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	scids, err := b.scids(ctx)
+	if err != nil {
+		return err
+	}
+
+	return b.client.subscribe(ctx, func(kind string, data []byte) error {
+		if kind != "payment-sent" && kind != "payment-failed" {
+			return nil
+		}
+
+		event := &paymentResult{}
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		select {
+		case channel <- paymentResultToPayment(event, scids):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+func (b Backend) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
+	b.logger.Info("decode payreq", logging.String("payreq", payreq))
+
+	resp := &invoice{}
+	err := b.client.call(ctx, "parseinvoice", url.Values{"invoice": {payreq}}, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return invoiceToPayReq(resp, payreq), nil
+}
+
+func (b Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	b.logger.Debug("Estimate route fee...",
+		logging.String("destination", payreq.Destination),
+		logging.Int64("amount", payreq.Amount),
+	)
+
+	resp := &routeResponse{}
+	err := b.client.call(ctx, "findroute", url.Values{
+		"invoice": {payreq.String},
+		"format":  {"full"},
+	}, resp)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp.Routes) == 0 {
+		return 0, errors.New("no route found")
+	}
+
+	// the fee of a hop is the one of the channel it forwards to, going
+	// back from the destination, the first channel is one of ours.
+	route := resp.Routes[0]
+	amount := route.Amount
+	for i := len(route.Hops) - 1; i > 0; i-- {
+		update := route.Hops[i].Source.ChannelUpdate
+		if update == nil {
+			continue
+		}
+		amount += update.FeeBaseMsat + amount*update.FeeProportionalMillionths/1000000
+	}
+	return amount - route.Amount, nil
+}
+
+func (b Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	b.logger.Debug("Send payment...",
+		logging.String("destination", payreq.Destination),
+		logging.Int64("amount", payreq.Amount),
+		logging.Int64("fee_limit", feeLimit),
+	)
+
+	params := url.Values{
+		"invoice":  {payreq.String},
+		"blocking": {"true"},
+	}
+	if feeLimit > 0 {
+		// the limit is the greatest of the flat and the proportional ones.
+		params.Set("maxFeeFlatSat", strconv.FormatInt(feeLimit, 10))
+		params.Set("maxFeePct", "0")
+	}
+	resp := &paymentResult{}
+	err := b.client.call(ctx, "payinvoice", params, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	payment := &models.Payment{PayReq: payreq}
+	if resp.Type != "payment-sent" {
+		payment.PaymentError = resp.Type
+		if len(resp.Failures) > 0 {
+			payment.PaymentError = resp.Failures[len(resp.Failures)-1].FailureMessage
+		}
+		return payment, nil
+	}
+
+	payment.PaymentPreimage, _ = hex.DecodeString(resp.PaymentPreimage)
+	payment.Route = &models.Route{Amount: resp.RecipientAmount / 1000}
+	for _, part := range resp.Parts {
+		payment.Route.Fee += part.FeesPaid / 1000
+	}
+
+	b.logger.Debug("Payment paid", logging.Object("payment", payment))
+
+	return payment, nil
+}
+
+func (b Backend) SubscribeInvoice(ctx context.Context, channelInvoice chan *models.Invoice) error {
+	return b.client.subscribe(ctx, func(kind string, data []byte) error {
+		if kind != "payment-received" {
+			return nil
+		}
+
+		event := &paymentReceived{}
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		invoice, err := b.GetInvoice(ctx, event.PaymentHash)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case channelInvoice <- invoice:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	})
+}
+
+func (b Backend) SubscribeRoutingEvents(ctx context.Context, channelEvents chan *models.RoutingEvent) error {
+	scids, err := b.scids(ctx)
+	if err != nil {
+		return err
+	}
+
+	return b.client.subscribe(ctx, func(kind string, data []byte) error {
+		switch kind {
+		case "payment-relayed":
+			event := &relayed{}
+			err := json.Unmarshal(data, event)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if _, ok := scids[event.FromChannelID]; !ok {
+				scids, err = b.scids(ctx)
+				if err != nil {
+					return err
+				}
+			}
+			select {
+			case channelEvents <- relayedToRoutingEvent(event, scids):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case "payment-sent", "payment-failed":
+			event := &paymentResult{}
+			err := json.Unmarshal(data, event)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			select {
+			case channelEvents <- paymentToRoutingEvent(event, scids):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+func (b Backend) SubscribeChannels(ctx context.Context, events chan *models.ChannelUpdate) error {
+	// the events only carry the eclair channel id, the channel points are
+	// kept to notify the closed channels which are not listed anymore.
+	channelPoints := map[string]string{}
+	channelPoint := func(ctx context.Context, id string) (string, error) {
+		if _, ok := channelPoints[id]; !ok {
+			channels, err := b.channels(ctx)
+			if err != nil {
+				return "", err
+			}
+			for _, c := range channels {
+				channelPoints[c.ChannelID] = c.funding().OutPoint
+			}
+		}
+		return channelPoints[id], nil
+	}
+
+	return b.client.subscribe(ctx, func(kind string, data []byte) error {
+		switch kind {
+		case "channel-opened", "channel-state-changed", "channel-closed":
+			event := &channelEvent{}
+			err := json.Unmarshal(data, event)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			point, err := channelPoint(ctx, event.ChannelID)
+			if err != nil {
+				return err
+			}
+
+			update := &models.ChannelUpdate{ChannelPoint: point}
+			switch kind {
+			case "channel-opened":
+				update.Status = models.ChannelActive
+			case "channel-state-changed":
+				update.Status = channelStatus(event.CurrentState)
+			case "channel-closed":
+				update.Status = models.ChannelClosed
+				delete(channelPoints, event.ChannelID)
+			}
+			select {
+			case events <- update:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+// SubscribePeerEvents polls the peers, the connections are not part of the
+// websocket events.
+func (b Backend) SubscribePeerEvents(ctx context.Context, events chan *models.PeerEvent) error {
+	var known map[string]bool
+	return b.poll(ctx, "peers", func(ctx context.Context) error {
+		peers, err := b.peers(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]bool, len(peers))
+		for _, p := range peers {
+			current[p.NodeID] = true
+			if known != nil && !known[p.NodeID] {
+				select {
+				case events <- &models.PeerEvent{PubKey: p.NodeID, Online: true}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		for id := range known {
+			if !current[id] {
+				select {
+				case events <- &models.PeerEvent{PubKey: id}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+// poll runs fn at each poll interval until the context is canceled.
+func (b Backend) poll(ctx context.Context, name string, fn func(context.Context) error) error {
+	ticker := time.NewTicker(eclairPollInterval)
+	defer ticker.Stop()
+	for {
+		err := fn(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				b.logger.Debug(fmt.Sprintf("stopping subscribe %s: context canceled", name))
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			b.logger.Debug(fmt.Sprintf("stopping subscribe %s: context canceled", name))
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b Backend) SubscribeTransactions(ctx context.Context, channel chan *models.Transaction) error {
+	var known map[string]int32
+	return b.poll(ctx, "transactions", func(ctx context.Context) error {
+		transactions, err := b.GetTransactions(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]int32, len(transactions))
+		for _, tx := range transactions {
+			current[tx.TxHash] = tx.NumConfirmations
+			if known == nil {
+				continue
+			}
+			// only new transactions and the first confirmation are notified.
+			if confirmations, ok := known[tx.TxHash]; !ok ||
+				(confirmations == 0 && tx.NumConfirmations > 0) {
+				select {
+				case channel <- tx:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		known = current
+		return nil
+	})
+}
+
+func (b Backend) SubscribeGraphEvents(ctx context.Context, events chan *models.ChannelEdgeUpdate) error {
+	var known map[string]timestamp
+	return b.poll(ctx, "graph", func(ctx context.Context) error {
+		channels, err := b.channels(ctx)
+		if err != nil {
+			return err
+		}
+
+		current := make(map[string]timestamp, len(channels))
+		update := &models.ChannelEdgeUpdate{}
+		for _, c := range channels {
+			if c.Data.ChannelUpdate == nil {
+				continue
+			}
+			chanPoint := c.funding().OutPoint
+			current[chanPoint] = c.Data.ChannelUpdate.Timestamp
+			if known == nil {
+				continue
+			}
+			if last, ok := known[chanPoint]; !ok || last != c.Data.ChannelUpdate.Timestamp {
+				update.ChanPoints = append(update.ChanPoints, chanPoint)
+			}
+		}
+		known = current
+
+		if len(update.ChanPoints) > 0 {
+			select {
+			case events <- update:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	})
+}
+
+func New(c *config.Network, logger logging.Logger) (*Backend, error) {
+	clt, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		cfg:    c,
+		logger: logger.With(logging.String("name", c.Name)),
+		client: clt,
+	}, nil
+}
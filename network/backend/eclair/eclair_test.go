@@ -0,0 +1,307 @@
+package eclair
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+const testPassword = "secret"
+
+// newFakeEclair serves the endpoints of an eclair API with the json
+// responses, the events are sent on the websocket once it is opened.
+func newFakeEclair(t *testing.T, responses map[string]string, events ...string) *Backend {
+	mux := http.NewServeMux()
+	for path, body := range responses {
+		body := body
+		mux.HandleFunc("/"+path, func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, `{"error":"method not allowed"}`, http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte(body))
+		})
+	}
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, event := range events {
+			err = conn.WriteMessage(websocket.TextMessage, []byte(event))
+			if err != nil {
+				return
+			}
+		}
+		// wait for the client to close the stream.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, password, ok := r.BasicAuth(); !ok || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid password"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(&config.Network{Name: "eclair", Address: server.URL, Password: testPassword}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+const testChannels = `[
+	{
+		"nodeId": "02bbbb",
+		"channelId": "c1",
+		"state": "NORMAL",
+		"data": {
+			"shortIds": {"real": {"status": "final", "realScid": "800000x1x0"}},
+			"commitments": {
+				"params": {
+					"channelFlags": {"announceChannel": true},
+					"localParams": {"toSelfDelay": 144}
+				},
+				"active": [{
+					"fundingTx": {"outPoint": "aa:1", "amountSatoshis": 1000000},
+					"localCommit": {"spec": {"commitTxFeerate": 253, "toLocal": 400000000, "toRemote": 600000000}}
+				}]
+			}
+		}
+	},
+	{
+		"nodeId": "02cccc",
+		"channelId": "c2",
+		"state": "WAIT_FOR_FUNDING_CONFIRMED",
+		"data": {
+			"commitments": {
+				"channelFlags": 0,
+				"commitInput": {"outPoint": "bb:0", "amountSatoshis": 500000},
+				"localCommit": {"spec": {"toLocal": 500000000, "toRemote": 0}}
+			}
+		}
+	}
+]`
+
+func TestInfoCountsPeersAndChannels(t *testing.T) {
+	b := newFakeEclair(t, map[string]string{
+		"getinfo": `{"version": "0.9.0", "nodeId": "02aaaa", "alias": "satoshi",
+			"network": "testnet", "blockHeight": 800000}`,
+		"peers":    `[{"nodeId": "02bbbb", "state": "CONNECTED"}, {"nodeId": "02cccc", "state": "DISCONNECTED"}]`,
+		"channels": testChannels,
+	})
+
+	info, err := b.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PubKey != "02aaaa" || info.Alias != "satoshi" || info.BlockHeight != 800000 || !info.Testnet {
+		t.Errorf("unexpected info %+v", info)
+	}
+	if info.NumPeers != 1 || info.NumActiveChannels != 1 || info.NumPendingChannels != 1 {
+		t.Errorf("unexpected counts %+v", info)
+	}
+}
+
+func TestBasicAuthPassword(t *testing.T) {
+	b := newFakeEclair(t, nil)
+	b.client.password = "wrong"
+
+	_, err := b.Info(context.Background())
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("got error %v, want an api error", err)
+	}
+	if apiErr.Status != http.StatusUnauthorized || apiErr.Message != "invalid password" {
+		t.Errorf("unexpected api error %+v", apiErr)
+	}
+
+	// the websocket is authenticated with the same password.
+	err = b.SubscribeInvoice(context.Background(), make(chan *models.Invoice))
+	if err == nil {
+		t.Error("got a websocket with a wrong password")
+	}
+}
+
+func TestListChannelsCommitmentFormats(t *testing.T) {
+	b := newFakeEclair(t, map[string]string{"channels": testChannels})
+
+	channels, err := b.ListChannels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 1 {
+		t.Fatalf("got %d channels, want 1", len(channels))
+	}
+	c := channels[0]
+	if c.ChannelPoint != "aa:1" || c.RemotePubKey != "02bbbb" || c.Status != models.ChannelActive || c.Private {
+		t.Errorf("unexpected channel %+v", c)
+	}
+	if c.ID != 800000<<40|1<<16 {
+		t.Errorf("got channel id %d", c.ID)
+	}
+	if c.Capacity != 1000000 || c.LocalBalance != 400000 || c.RemoteBalance != 600000 ||
+		c.FeePerKiloWeight != 253 || c.CSVDelay != 144 {
+		t.Errorf("unexpected balances %+v", c)
+	}
+}
+
+func TestPayInvoiceParts(t *testing.T) {
+	b := newFakeEclair(t, map[string]string{
+		"payinvoice": `{
+			"type": "payment-sent",
+			"paymentHash": "ff",
+			"paymentPreimage": "0102",
+			"recipientAmount": 100000000,
+			"parts": [
+				{"amount": 60000000, "feesPaid": 2000, "toChannelId": "c1"},
+				{"amount": 40000000, "feesPaid": 1000, "toChannelId": "c1"}
+			]
+		}`,
+	})
+
+	payment, err := b.SendPayment(context.Background(), &models.PayReq{String: "lnbc", Amount: 100000}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payment.PaymentError != "" {
+		t.Fatalf("payment failed: %s", payment.PaymentError)
+	}
+	if len(payment.PaymentPreimage) != 2 || payment.PaymentPreimage[1] != 2 {
+		t.Errorf("got preimage %x", payment.PaymentPreimage)
+	}
+	if payment.Route.Amount != 100000 || payment.Route.Fee != 3 {
+		t.Errorf("unexpected route %+v", payment.Route)
+	}
+}
+
+func TestWebsocketRoutingEvents(t *testing.T) {
+	b := newFakeEclair(t, map[string]string{"channels": testChannels},
+		`{"type": "channel-opened", "channelId": "c2"}`,
+		`{"type": "payment-relayed", "amountIn": 1001000, "amountOut": 1000000,
+			"fromChannelId": "c1", "toChannelId": "c2", "timestamp": {"iso": "", "unix": 1700000000}}`,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *models.RoutingEvent)
+	done := make(chan error)
+	go func() { done <- b.SubscribeRoutingEvents(ctx, events) }()
+
+	select {
+	case e := <-events:
+		if e.IncomingChannelId != 800000<<40|1<<16 || e.Status != models.RoutingStatusSettled ||
+			e.AmountMsat != 1000000 || e.FeeMsat != 1000 || e.LastUpdate.Unix() != 1700000000 {
+			t.Errorf("unexpected routing event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no routing event")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("subscription stopped with %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not stopped on cancel")
+	}
+}
+
+func TestSubscribeCanceledWhileSending(t *testing.T) {
+	b := newFakeEclair(t, map[string]string{"channels": testChannels},
+		`{"type": "payment-relayed", "amountIn": 1001000, "amountOut": 1000000,
+			"fromChannelId": "c1", "toChannelId": "c2", "timestamp": {"iso": "", "unix": 1700000000}}`,
+	)
+
+	// the events are not read, the subscription waits to send the first
+	// one.
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.SubscribeRoutingEvents(ctx, make(chan *models.RoutingEvent)) }()
+
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("subscription stopped with %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not stopped on cancel")
+	}
+}
+
+func TestWebsocketReconnect(t *testing.T) {
+	// each websocket sends one event then is closed by eclair.
+	var mu sync.Mutex
+	connections := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/channels" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		mu.Lock()
+		connections++
+		hash := fmt.Sprintf("%02x", connections)
+		mu.Unlock()
+		conn.WriteMessage(websocket.TextMessage, []byte(
+			`{"type": "payment-sent", "paymentHash": "`+hash+`", "recipientAmount": 1000, "parts": []}`))
+	}))
+	t.Cleanup(server.Close)
+
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(&config.Network{Name: "eclair", Address: server.URL, Password: testPassword}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the closed stream ends the subscription with an error so that it is
+	// subscribed again, the events of the new stream are received.
+	for _, hash := range []string{"01", "02"} {
+		payments := make(chan *models.Payment, 1)
+		err := b.TrackPayments(context.Background(), payments)
+		if err == nil {
+			t.Fatal("got no error when the stream is closed")
+		}
+		select {
+		case p := <-payments:
+			if p.PaymentHash != hash {
+				t.Errorf("got payment %s, want %s", p.PaymentHash, hash)
+			}
+		default:
+			t.Errorf("no payment %s", hash)
+		}
+	}
+}
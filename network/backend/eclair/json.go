@@ -0,0 +1,609 @@
+package eclair
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// timestamp is either a unix timestamp in milliseconds (older versions)
+// or an object with the unix timestamp in seconds.
+type timestamp int64
+
+func (t *timestamp) UnmarshalJSON(b []byte) error {
+	var ms int64
+	if err := json.Unmarshal(b, &ms); err == nil {
+		*t = timestamp(ms / 1000)
+		return nil
+	}
+	obj := struct {
+		Unix int64 `json:"unix"`
+	}{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	*t = timestamp(obj.Unix)
+	return nil
+}
+
+func (t timestamp) time() time.Time {
+	return time.Unix(int64(t), 0)
+}
+
+// channelFlags is either the raw flags byte (older versions) or an object.
+type channelFlags struct {
+	AnnounceChannel bool `json:"announceChannel"`
+}
+
+func (f *channelFlags) UnmarshalJSON(b []byte) error {
+	var raw int
+	if err := json.Unmarshal(b, &raw); err == nil {
+		f.AnnounceChannel = raw&1 == 1
+		return nil
+	}
+	obj := struct {
+		AnnounceChannel bool `json:"announceChannel"`
+	}{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return err
+	}
+	f.AnnounceChannel = obj.AnnounceChannel
+	return nil
+}
+
+type getInfoResponse struct {
+	Version     string `json:"version"`
+	NodeID      string `json:"nodeId"`
+	Alias       string `json:"alias"`
+	Network     string `json:"network"`
+	BlockHeight uint32 `json:"blockHeight"`
+}
+
+type peer struct {
+	NodeID  string `json:"nodeId"`
+	State   string `json:"state"`
+	Address string `json:"address"`
+}
+
+type onChainBalance struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+type spec struct {
+	Htlcs []struct {
+		Direction string `json:"direction"`
+		Add       struct {
+			AmountMsat  int64  `json:"amountMsat"`
+			CltvExpiry  uint32 `json:"cltvExpiry"`
+			PaymentHash string `json:"paymentHash"`
+		} `json:"add"`
+	} `json:"htlcs"`
+	CommitTxFeerate int64 `json:"commitTxFeerate"`
+	ToLocal         int64 `json:"toLocal"`
+	ToRemote        int64 `json:"toRemote"`
+}
+
+type fundingInput struct {
+	OutPoint       string `json:"outPoint"`
+	AmountSatoshis int64  `json:"amountSatoshis"`
+}
+
+type commitment struct {
+	FundingTx   fundingInput `json:"fundingTx"`
+	LocalCommit struct {
+		Spec spec `json:"spec"`
+	} `json:"localCommit"`
+}
+
+type channelUpdate struct {
+	ShortChannelID string    `json:"shortChannelId"`
+	Timestamp      timestamp `json:"timestamp"`
+	ChannelFlags   struct {
+		IsEnabled bool `json:"isEnabled"`
+		IsNode1   bool `json:"isNode1"`
+	} `json:"channelFlags"`
+	CltvExpiryDelta           uint32 `json:"cltvExpiryDelta"`
+	HtlcMinimumMsat           int64  `json:"htlcMinimumMsat"`
+	HtlcMaximumMsat           uint64 `json:"htlcMaximumMsat"`
+	FeeBaseMsat               int64  `json:"feeBaseMsat"`
+	FeeProportionalMillionths int64  `json:"feeProportionalMillionths"`
+}
+
+type channel struct {
+	NodeID    string `json:"nodeId"`
+	ChannelID string `json:"channelId"`
+	State     string `json:"state"`
+	Data      struct {
+		ShortIDs *struct {
+			Real struct {
+				RealScid string `json:"realScid"`
+			} `json:"real"`
+		} `json:"shortIds"`
+		ShortChannelID string `json:"shortChannelId"`
+		Commitments    struct {
+			// eclair >= 0.9
+			Params *struct {
+				ChannelFlags channelFlags `json:"channelFlags"`
+				LocalParams  struct {
+					ToSelfDelay uint32 `json:"toSelfDelay"`
+				} `json:"localParams"`
+			} `json:"params"`
+			Active []commitment `json:"active"`
+			// eclair < 0.9
+			ChannelFlags *channelFlags `json:"channelFlags"`
+			LocalParams  *struct {
+				ToSelfDelay uint32 `json:"toSelfDelay"`
+			} `json:"localParams"`
+			LocalCommit *struct {
+				Spec spec `json:"spec"`
+			} `json:"localCommit"`
+			CommitInput *fundingInput `json:"commitInput"`
+		} `json:"commitments"`
+		ChannelUpdate *channelUpdate `json:"channelUpdate"`
+		// the transactions published to close the channel.
+		MutualClosePublished      []publishedTx     `json:"mutualClosePublished"`
+		LocalCommitPublished      *commitPublished  `json:"localCommitPublished"`
+		RemoteCommitPublished     *commitPublished  `json:"remoteCommitPublished"`
+		NextRemoteCommitPublished *commitPublished  `json:"nextRemoteCommitPublished"`
+		RevokedCommitPublished    []commitPublished `json:"revokedCommitPublished"`
+	} `json:"data"`
+}
+
+type publishedTx struct {
+	TxID string `json:"txid"`
+}
+
+type commitPublished struct {
+	CommitTx publishedTx `json:"commitTx"`
+}
+
+func (c *channel) scid() uint64 {
+	if c.Data.ShortIDs != nil {
+		return backend.ParseShortChannelID(c.Data.ShortIDs.Real.RealScid)
+	}
+	return backend.ParseShortChannelID(c.Data.ShortChannelID)
+}
+
+// closing returns the close type and the closing transaction of a closed
+// channel from the transactions published by eclair.
+func (c *channel) closing() (int, string) {
+	d := c.Data
+	switch {
+	case len(d.MutualClosePublished) > 0:
+		return models.CloseCooperative, d.MutualClosePublished[len(d.MutualClosePublished)-1].TxID
+	case len(d.RevokedCommitPublished) > 0:
+		return models.CloseBreach, d.RevokedCommitPublished[0].CommitTx.TxID
+	case d.LocalCommitPublished != nil:
+		return models.CloseLocalForce, d.LocalCommitPublished.CommitTx.TxID
+	case d.RemoteCommitPublished != nil:
+		return models.CloseRemoteForce, d.RemoteCommitPublished.CommitTx.TxID
+	case d.NextRemoteCommitPublished != nil:
+		return models.CloseRemoteForce, d.NextRemoteCommitPublished.CommitTx.TxID
+	}
+	return models.CloseUnknown, ""
+}
+
+func (c *channel) spec() *spec {
+	commitments := c.Data.Commitments
+	if len(commitments.Active) > 0 {
+		return &commitments.Active[0].LocalCommit.Spec
+	}
+	if commitments.LocalCommit != nil {
+		return &commitments.LocalCommit.Spec
+	}
+	return &spec{}
+}
+
+func (c *channel) funding() *fundingInput {
+	commitments := c.Data.Commitments
+	if len(commitments.Active) > 0 {
+		return &commitments.Active[0].FundingTx
+	}
+	if commitments.CommitInput != nil {
+		return commitments.CommitInput
+	}
+	return &fundingInput{}
+}
+
+func (c *channel) private() bool {
+	commitments := c.Data.Commitments
+	if commitments.Params != nil {
+		return !commitments.Params.ChannelFlags.AnnounceChannel
+	}
+	if commitments.ChannelFlags != nil {
+		return !commitments.ChannelFlags.AnnounceChannel
+	}
+	return false
+}
+
+func (c *channel) toSelfDelay() uint32 {
+	commitments := c.Data.Commitments
+	if commitments.Params != nil {
+		return commitments.Params.LocalParams.ToSelfDelay
+	}
+	if commitments.LocalParams != nil {
+		return commitments.LocalParams.ToSelfDelay
+	}
+	return 0
+}
+
+type node struct {
+	NodeID    string    `json:"nodeId"`
+	Alias     string    `json:"alias"`
+	Timestamp timestamp `json:"timestamp"`
+	Addresses []string  `json:"addresses"`
+}
+
+type transaction struct {
+	Address       string    `json:"address"`
+	Amount        int64     `json:"amount"`
+	Fees          int64     `json:"fees"`
+	BlockHash     string    `json:"blockHash"`
+	Confirmations int32     `json:"confirmations"`
+	TxID          string    `json:"txid"`
+	Timestamp     timestamp `json:"timestamp"`
+}
+
+type invoice struct {
+	Serialized         string `json:"serialized"`
+	NodeID             string `json:"nodeId"`
+	Description        string `json:"description"`
+	DescriptionHash    string `json:"descriptionHash"`
+	PaymentHash        string `json:"paymentHash"`
+	Timestamp          int64  `json:"timestamp"`
+	Expiry             int64  `json:"expiry"`
+	MinFinalCltvExpiry int64  `json:"minFinalCltvExpiry"`
+	Amount             int64  `json:"amount"`
+}
+
+type receivedInfo struct {
+	PaymentRequest  invoice `json:"paymentRequest"`
+	PaymentPreimage string  `json:"paymentPreimage"`
+	Status          struct {
+		Type       string    `json:"type"`
+		Amount     int64     `json:"amount"`
+		ReceivedAt timestamp `json:"receivedAt"`
+	} `json:"status"`
+}
+
+type paymentPart struct {
+	ID          string    `json:"id"`
+	Amount      int64     `json:"amount"`
+	FeesPaid    int64     `json:"feesPaid"`
+	ToChannelID string    `json:"toChannelId"`
+	Timestamp   timestamp `json:"timestamp"`
+}
+
+type paymentResult struct {
+	Type            string        `json:"type"`
+	PaymentHash     string        `json:"paymentHash"`
+	PaymentPreimage string        `json:"paymentPreimage"`
+	RecipientAmount int64         `json:"recipientAmount"`
+	Parts           []paymentPart `json:"parts"`
+	Failures        []struct {
+		FailureMessage string `json:"failureMessage"`
+	} `json:"failures"`
+}
+
+type routeResponse struct {
+	Routes []struct {
+		Amount int64 `json:"amount"`
+		Hops   []struct {
+			NodeID     string `json:"nodeId"`
+			NextNodeID string `json:"nextNodeId"`
+			Source     struct {
+				ChannelUpdate *channelUpdate `json:"channelUpdate"`
+			} `json:"source"`
+		} `json:"hops"`
+	} `json:"routes"`
+}
+
+type relayed struct {
+	Type          string    `json:"type"`
+	AmountIn      uint64    `json:"amountIn"`
+	AmountOut     uint64    `json:"amountOut"`
+	PaymentHash   string    `json:"paymentHash"`
+	FromChannelID string    `json:"fromChannelId"`
+	ToChannelID   string    `json:"toChannelId"`
+	Timestamp     timestamp `json:"timestamp"`
+}
+
+type auditResponse struct {
+	Sent    []*paymentResult `json:"sent"`
+	Relayed []*relayed       `json:"relayed"`
+}
+
+type paymentReceived struct {
+	PaymentHash string `json:"paymentHash"`
+}
+
+type channelEvent struct {
+	ChannelID    string `json:"channelId"`
+	CurrentState string `json:"currentState"`
+}
+
+func infoToInfo(resp *getInfoResponse, numPeers uint32, channels []*channel) *models.Info {
+	if resp == nil {
+		return nil
+	}
+
+	info := &models.Info{
+		PubKey:      resp.NodeID,
+		Alias:       resp.Alias,
+		NumPeers:    numPeers,
+		BlockHeight: resp.BlockHeight,
+		Synced:      true,
+		Version:     resp.Version,
+		Chains:      []string{"bitcoin"},
+		Testnet:     resp.Network != "mainnet",
+	}
+
+	for _, c := range channels {
+		switch channelStatus(c.State) {
+		case models.ChannelActive:
+			info.NumActiveChannels++
+		case models.ChannelInactive:
+			info.NumInactiveChannels++
+		case models.ChannelClosed:
+		default:
+			info.NumPendingChannels++
+		}
+	}
+
+	return info
+}
+
+func channelStatus(state string) int {
+	switch state {
+	case "NORMAL":
+		return models.ChannelActive
+	case "OFFLINE", "SYNCING":
+		return models.ChannelInactive
+	case "WAIT_FOR_INIT_INTERNAL", "WAIT_FOR_OPEN_CHANNEL", "WAIT_FOR_ACCEPT_CHANNEL",
+		"WAIT_FOR_FUNDING_INTERNAL", "WAIT_FOR_FUNDING_CREATED", "WAIT_FOR_FUNDING_SIGNED",
+		"WAIT_FOR_FUNDING_CONFIRMED", "WAIT_FOR_FUNDING_LOCKED", "WAIT_FOR_CHANNEL_READY",
+		"WAIT_FOR_DUAL_FUNDING_CONFIRMED", "WAIT_FOR_DUAL_FUNDING_READY":
+		return models.ChannelOpening
+	case "SHUTDOWN", "NEGOTIATING":
+		return models.ChannelClosing
+	case "CLOSING":
+		return models.ChannelWaitingClose
+	}
+	return models.ChannelClosed
+}
+
+func isPending(status int) bool {
+	return status != models.ChannelActive && status != models.ChannelInactive
+}
+
+func channelToClosedChannel(c *channel) *models.ClosedChannel {
+	funding := c.funding()
+	closeType, txid := c.closing()
+	return &models.ClosedChannel{
+		ID:             c.scid(),
+		ChannelPoint:   funding.OutPoint,
+		RemotePubKey:   c.NodeID,
+		Capacity:       funding.AmountSatoshis,
+		CloseType:      closeType,
+		ClosingTxID:    txid,
+		SettledBalance: c.spec().ToLocal / 1000,
+	}
+}
+
+func channelToChannel(c *channel) *models.Channel {
+	s := c.spec()
+	HTLCs := make([]*models.HTLC, len(s.Htlcs))
+	unsettled := int64(0)
+	for i, h := range s.Htlcs {
+		hashlock, _ := hex.DecodeString(h.Add.PaymentHash)
+		HTLCs[i] = &models.HTLC{
+			Incoming:         h.Direction == "IN",
+			Amount:           h.Add.AmountMsat / 1000,
+			Hashlock:         hashlock,
+			ExpirationHeight: h.Add.CltvExpiry,
+		}
+		unsettled += HTLCs[i].Amount
+	}
+
+	funding := c.funding()
+	channel := &models.Channel{
+		ID:               c.scid(),
+		Status:           channelStatus(c.State),
+		RemotePubKey:     c.NodeID,
+		ChannelPoint:     funding.OutPoint,
+		Capacity:         funding.AmountSatoshis,
+		LocalBalance:     s.ToLocal / 1000,
+		RemoteBalance:    s.ToRemote / 1000,
+		FeePerKiloWeight: s.CommitTxFeerate,
+		UnsettledBalance: unsettled,
+		CSVDelay:         c.toSelfDelay(),
+		Private:          c.private(),
+		PendingHTLC:      HTLCs,
+	}
+	if channel.Status == models.ChannelWaitingClose {
+		_, txid := c.closing()
+		channel.Pending = &models.PendingChannel{ClosingTxID: txid}
+	}
+	return channel
+}
+
+func updateToRoutingPolicy(u *channelUpdate) *models.RoutingPolicy {
+	if u == nil {
+		return nil
+	}
+	return &models.RoutingPolicy{
+		TimeLockDelta:    u.CltvExpiryDelta,
+		MinHtlc:          u.HtlcMinimumMsat,
+		MaxHtlc:          u.HtlcMaximumMsat,
+		FeeBaseMsat:      u.FeeBaseMsat,
+		FeeRateMilliMsat: u.FeeProportionalMillionths,
+		Disabled:         !u.ChannelFlags.IsEnabled,
+	}
+}
+
+func transactionToTransaction(t *transaction) *models.Transaction {
+	tx := &models.Transaction{
+		TxHash:           t.TxID,
+		Amount:           t.Amount,
+		NumConfirmations: t.Confirmations,
+		BlockHash:        t.BlockHash,
+		Date:             t.Timestamp.time(),
+		TotalFees:        t.Fees,
+	}
+	if t.Address != "" {
+		tx.DestAddresses = []string{t.Address}
+	}
+	return tx
+}
+
+func invoiceToInvoice(i *invoice) *models.Invoice {
+	hash, _ := hex.DecodeString(i.PaymentHash)
+	descHash, _ := hex.DecodeString(i.DescriptionHash)
+	return &models.Invoice{
+		Amount:          i.Amount / 1000,
+		Description:     i.Description,
+		RHash:           hash,
+		PaymentRequest:  i.Serialized,
+		DescriptionHash: descHash,
+		CreationDate:    i.Timestamp,
+		Expiry:          i.Expiry,
+		CLTVExpiry:      uint64(i.MinFinalCltvExpiry),
+		State:           models.InvoiceOpen,
+	}
+}
+
+func receivedInfoToInvoice(r *receivedInfo) *models.Invoice {
+	invoice := invoiceToInvoice(&r.PaymentRequest)
+	invoice.RPreImage, _ = hex.DecodeString(r.PaymentPreimage)
+	switch r.Status.Type {
+	case "received":
+		invoice.Settled = true
+		invoice.State = models.InvoiceSettled
+		invoice.AmountPaid = r.Status.Amount / 1000
+		invoice.AmountPaidInMSat = r.Status.Amount
+		invoice.SettleDate = int64(r.Status.ReceivedAt)
+	case "expired":
+		invoice.State = models.InvoiceCanceled
+	}
+	return invoice
+}
+
+func invoiceToPayReq(i *invoice, payreq string) *models.PayReq {
+	if i == nil {
+		return nil
+	}
+	return &models.PayReq{
+		Destination:     i.NodeID,
+		PaymentHash:     i.PaymentHash,
+		Amount:          i.Amount / 1000,
+		Timestamp:       i.Timestamp,
+		Expiry:          i.Expiry,
+		Description:     i.Description,
+		DescriptionHash: i.DescriptionHash,
+		CltvExpiry:      i.MinFinalCltvExpiry,
+		String:          payreq,
+	}
+}
+
+// relayedToRoutingEvent converts a relayed payment, the scids map eclair
+// channel ids to short channel ids.
+func relayedToRoutingEvent(r *relayed, scids map[string]uint64) *models.RoutingEvent {
+	return &models.RoutingEvent{
+		IncomingChannelId: scids[r.FromChannelID],
+		OutgoingChannelId: scids[r.ToChannelID],
+		LastUpdate:        r.Timestamp.time(),
+		Direction:         models.RoutingForward,
+		Status:            models.RoutingStatusSettled,
+		AmountMsat:        r.AmountOut,
+		FeeMsat:           r.AmountIn - r.AmountOut,
+	}
+}
+
+func relayedToForwardingEvent(r *relayed, scids map[string]uint64) *models.ForwardingEvent {
+	return &models.ForwardingEvent{
+		ChanIdIn:   scids[r.FromChannelID],
+		ChanIdOut:  scids[r.ToChannelID],
+		AmtIn:      r.AmountIn / 1000,
+		AmtOut:     r.AmountOut / 1000,
+		Fee:        (r.AmountIn - r.AmountOut) / 1000,
+		FeeMsat:    r.AmountIn - r.AmountOut,
+		AmtInMsat:  r.AmountIn,
+		AmtOutMsat: r.AmountOut,
+		EventTime:  r.Timestamp.time(),
+	}
+}
+
+func paymentToRoutingEvent(p *paymentResult, scids map[string]uint64) *models.RoutingEvent {
+	event := &models.RoutingEvent{
+		LastUpdate: time.Now(),
+		Direction:  models.RoutingSend,
+		AmountMsat: uint64(p.RecipientAmount),
+	}
+	switch p.Type {
+	case "payment-sent":
+		event.Status = models.RoutingStatusSettled
+		for _, part := range p.Parts {
+			event.FeeMsat += uint64(part.FeesPaid)
+			event.OutgoingChannelId = scids[part.ToChannelID]
+			event.LastUpdate = part.Timestamp.time()
+		}
+	case "payment-failed":
+		event.Status = models.RoutingStatusFailed
+		if len(p.Failures) > 0 {
+			event.FailureDetail = p.Failures[len(p.Failures)-1].FailureMessage
+		}
+	default:
+		event.Status = models.RoutingStatusActive
+	}
+	return event
+}
+
+// paymentResultToPayment converts a payment sent or failed, the parts are the
+// succeeded attempts and the failures the failed ones. Eclair only gives the
+// first channel of the routes.
+func paymentResultToPayment(p *paymentResult, scids map[string]uint64) *models.Payment {
+	payment := &models.Payment{
+		PaymentHash:  p.PaymentHash,
+		Status:       models.PaymentFailed,
+		AmountMsat:   p.RecipientAmount,
+		CreationDate: time.Now(),
+	}
+	payment.PaymentPreimage, _ = hex.DecodeString(p.PaymentPreimage)
+
+	for _, f := range p.Failures {
+		payment.HTLCs = append(payment.HTLCs, &models.HTLCAttempt{
+			Status:  models.HTLCAttemptFailed,
+			Failure: f.FailureMessage,
+		})
+		payment.FailureReason = f.FailureMessage
+	}
+
+	for i, part := range p.Parts {
+		payment.Status = models.PaymentSucceeded
+		payment.FailureReason = ""
+		payment.FeeMsat += part.FeesPaid
+		if i == 0 || part.Timestamp.time().Before(payment.CreationDate) {
+			payment.CreationDate = part.Timestamp.time()
+		}
+		payment.HTLCs = append(payment.HTLCs, &models.HTLCAttempt{
+			Status:      models.HTLCAttemptSucceeded,
+			AttemptTime: part.Timestamp.time(),
+			ResolveTime: part.Timestamp.time(),
+			Route: &models.Route{
+				Amount: (part.Amount + part.FeesPaid) / 1000,
+				Fee:    part.FeesPaid / 1000,
+				Hops: []*models.Hop{{
+					ChanID: scids[part.ToChannelID],
+					Amount: part.Amount / 1000,
+					Fee:    part.FeesPaid / 1000,
+				}},
+			},
+		})
+	}
+
+	return payment
+}
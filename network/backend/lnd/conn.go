@@ -1,8 +1,10 @@
 package lnd
 
 import (
+	"context"
 	"crypto/tls"
-	"io/ioutil"
+	"crypto/x509"
+	"net"
 	"net/url"
 
 	"github.com/pkg/errors"
@@ -14,16 +16,17 @@ import (
 	"github.com/lightningnetwork/lnd/macaroons"
 
 	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/backend/dialer"
 )
 
-func newClientConn(c *config.Network) (*grpc.ClientConn, error) {
-	macaroonBytes, err := ioutil.ReadFile(c.Macaroon)
-	if err != nil {
-		return nil, err
-	}
+// defaultRPCPort is the grpc port of lnd.
+const defaultRPCPort = "10009"
 
+// loadMacaroon decodes the macaroon of the credentials and adds the
+// configured timeout and ip constraints.
+func loadMacaroon(c *config.Network, creds *nodeCredentials) (*macaroon.Macaroon, error) {
 	mac := &macaroon.Macaroon{}
-	err = mac.UnmarshalBinary(macaroonBytes)
+	err := mac.UnmarshalBinary(creds.macaroon)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -38,17 +41,27 @@ func newClientConn(c *config.Network) (*grpc.ClientConn, error) {
 		return nil, errors.WithStack(err)
 	}
 
-	var cred credentials.TransportCredentials
-	if c.Cert != "" {
-		cred, err = credentials.NewClientTLSFromFile(c.Cert, "")
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		cred = credentials.NewTLS(&tls.Config{})
+	return constrainedMac, nil
+}
+
+func newClientConn(c *config.Network) (*grpc.ClientConn, error) {
+	creds, err := loadCredentials(c)
+	if err != nil {
+		return nil, err
+	}
+
+	constrainedMac, err := loadMacaroon(c, creds)
+	if err != nil {
+		return nil, err
 	}
 
-	u, err := url.Parse(c.Address)
+	tlsConfig, err := newTLSConfig(creds)
+	if err != nil {
+		return nil, err
+	}
+	cred := credentials.NewTLS(tlsConfig)
+
+	u, err := url.Parse(creds.address)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +71,27 @@ func newClientConn(c *config.Network) (*grpc.ClientConn, error) {
 		return nil, err
 	}
 
+	contextDialer := lncfg.ClientAddressDialer(u.Port())
+	if c.Proxy != "" {
+		// the address is not resolved locally so that the proxy can
+		// reach onion hosts.
+		dial, err := dialer.New(c.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		port := u.Port()
+		if port == "" {
+			port = defaultRPCPort
+		}
+		contextDialer = func(ctx context.Context, host string) (net.Conn, error) {
+			return dial(ctx, "tcp", net.JoinHostPort(host, port))
+		}
+	}
+
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(cred),
 		grpc.WithPerRPCCredentials(macaroon),
-		grpc.WithContextDialer(lncfg.ClientAddressDialer(u.Port())),
+		grpc.WithContextDialer(contextDialer),
 		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(c.MaxMsgRecvSize)),
 	}
 
@@ -72,3 +102,16 @@ func newClientConn(c *config.Network) (*grpc.ClientConn, error) {
 
 	return conn, nil
 }
+
+// newTLSConfig trusts the certificate of the credentials, or the system roots
+// when there is none.
+func newTLSConfig(creds *nodeCredentials) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if creds.cert != nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(creds.cert) {
+			return nil, errors.New("failed to load the node certificate")
+		}
+	}
+	return tlsConfig, nil
+}
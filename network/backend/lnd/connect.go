@@ -0,0 +1,140 @@
+package lnd
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+)
+
+// nodeCredentials are the address, the certificate and the macaroon used to
+// connect to the node.
+type nodeCredentials struct {
+	address string
+	// cert is the PEM encoded certificate of the node, nil to use the system
+	// roots.
+	cert     []byte
+	macaroon []byte
+}
+
+// loadCredentials returns the credentials of the lndconnect uri if the
+// network has one, otherwise the address and the cert and macaroon options.
+// These options are either file paths or the inline values: a PEM
+// certificate and a hex or base64 encoded macaroon.
+func loadCredentials(c *config.Network) (*nodeCredentials, error) {
+	if c.Connect != "" {
+		return parseLndConnect(c.Connect)
+	}
+
+	creds := &nodeCredentials{address: c.Address}
+
+	var err error
+	if c.Cert != "" {
+		creds.cert, err = loadCert(c.Cert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	creds.macaroon, err = loadMacaroonBytes(c.Macaroon)
+	if err != nil {
+		return nil, err
+	}
+
+	return creds, nil
+}
+
+// parseLndConnect parses an uri of the form
+// lndconnect://host:port?cert=<base64url DER>&macaroon=<base64url>, the cert
+// parameter is optional for nodes with a certificate signed by a known
+// authority.
+func parseLndConnect(uri string) (*nodeCredentials, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if u.Scheme != "lndconnect" {
+		return nil, errors.Errorf("connect uri scheme must be lndconnect, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("connect uri without host")
+	}
+
+	creds := &nodeCredentials{address: "//" + u.Host}
+	query := u.Query()
+
+	if cert := query.Get("cert"); cert != "" {
+		der, err := decodeBase64(cert)
+		if err != nil {
+			return nil, errors.Wrap(err, "connect uri cert")
+		}
+		creds.cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	mac := query.Get("macaroon")
+	if mac == "" {
+		return nil, errors.New("connect uri without macaroon")
+	}
+	creds.macaroon, err = decodeBase64(mac)
+	if err != nil {
+		return nil, errors.Wrap(err, "connect uri macaroon")
+	}
+
+	return creds, nil
+}
+
+// loadCert returns the inline PEM certificate or reads it from the file.
+func loadCert(cert string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(cert), "-----BEGIN") {
+		return []byte(cert), nil
+	}
+	data, err := ioutil.ReadFile(cert)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
+
+// loadMacaroonBytes decodes the inline hex macaroon, reads the macaroon file
+// or decodes the inline base64 macaroon, in that order.
+func loadMacaroonBytes(mac string) ([]byte, error) {
+	if data, err := hex.DecodeString(mac); err == nil && len(data) > 0 {
+		return data, nil
+	}
+
+	if _, err := os.Stat(mac); err == nil {
+		data, err := ioutil.ReadFile(mac)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return data, nil
+	}
+
+	data, err := decodeBase64(mac)
+	if err != nil {
+		return nil, errors.New("macaroon is neither a file nor a hex or base64 macaroon")
+	}
+	return data, nil
+}
+
+// decodeBase64 decodes a base64 string with or without padding, in the
+// standard or the url alphabet.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimRight(strings.TrimSpace(s), "=")
+	encoding := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.RawURLEncoding
+	}
+	data, err := encoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return data, nil
+}
@@ -0,0 +1,114 @@
+package lnd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestParseLndConnect(t *testing.T) {
+	der := []byte{0x30, 0x82, 0xfb, 0xff, 0x01}
+	mac := []byte{0x02, 0x01, 0x03, 0xfe, 0xff}
+	cert := base64.RawURLEncoding.EncodeToString(der)
+	macaroon := base64.RawURLEncoding.EncodeToString(mac)
+
+	tests := []struct {
+		name    string
+		uri     string
+		address string
+		cert    bool
+		err     string
+	}{
+		{
+			name:    "cert and macaroon",
+			uri:     "lndconnect://10.0.0.2:10009?cert=" + cert + "&macaroon=" + macaroon,
+			address: "//10.0.0.2:10009",
+			cert:    true,
+		},
+		{
+			name:    "padded macaroon without cert",
+			uri:     "lndconnect://node.onion:10009?macaroon=" + base64.URLEncoding.EncodeToString(mac),
+			address: "//node.onion:10009",
+		},
+		{
+			name: "missing host",
+			uri:  "lndconnect:///?macaroon=" + macaroon,
+			err:  "without host",
+		},
+		{
+			name: "missing macaroon",
+			uri:  "lndconnect://10.0.0.2:10009?cert=" + cert,
+			err:  "without macaroon",
+		},
+		{
+			name: "bad cert encoding",
+			uri:  "lndconnect://10.0.0.2:10009?cert=%25%25&macaroon=" + macaroon,
+			err:  "connect uri cert",
+		},
+		{
+			name: "bad macaroon encoding",
+			uri:  "lndconnect://10.0.0.2:10009?macaroon=not*base64",
+			err:  "connect uri macaroon",
+		},
+		{
+			name: "wrong scheme",
+			uri:  "https://10.0.0.2:10009?macaroon=" + macaroon,
+			err:  "scheme",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, err := parseLndConnect(tt.uri)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if creds.address != tt.address {
+				t.Errorf("got address %q, want %q", creds.address, tt.address)
+			}
+			if !bytes.Equal(creds.macaroon, mac) {
+				t.Errorf("got macaroon %x, want %x", creds.macaroon, mac)
+			}
+			if !tt.cert {
+				if creds.cert != nil {
+					t.Errorf("got cert %q, want none", creds.cert)
+				}
+				return
+			}
+			block, _ := pem.Decode(creds.cert)
+			if block == nil || block.Type != "CERTIFICATE" || !bytes.Equal(block.Bytes, der) {
+				t.Errorf("got cert %q", creds.cert)
+			}
+		})
+	}
+}
+
+func TestLoadMacaroonBytes(t *testing.T) {
+	mac := []byte{0x02, 0x01, 0x03, 0xfe, 0xff}
+	for _, value := range []string{"020103feff", base64.StdEncoding.EncodeToString(mac)} {
+		data, err := loadMacaroonBytes(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, mac) {
+			t.Errorf("got macaroon %x from %q", data, value)
+		}
+	}
+
+	// the value is a secret, it is not part of the error.
+	secret := "not*a*macaroon"
+	_, err := loadMacaroonBytes(secret)
+	if err == nil {
+		t.Fatal("invalid macaroon accepted")
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Errorf("the error %q contains the macaroon", err)
+	}
+}
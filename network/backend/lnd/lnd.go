@@ -4,12 +4,13 @@ import (
 	"context"
 	"encoding/hex"
 	"fmt"
-	"regexp"
-	"strconv"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -25,10 +26,77 @@ import (
 const (
 	lndDefaultInvoiceExpiry = 3600
 	lndMinPoolCapacity      = 6
+	// lndPaymentsPollInterval is the interval at which the last payments are
+	// listed to track their updates.
+	lndPaymentsPollInterval = 3 * time.Second
+	// lndTrackedPayments is the number of last payments tracked.
+	lndTrackedPayments = 100
+	// lndLeaseDuration is the duration in seconds of the leases locking the
+	// outputs left out of a coin selection, they are released once the
+	// coins are sent.
+	lndLeaseDuration = 60
+	// lndForwardingPageSize is the number of forwarding events read at once
+	// to sum the amounts routed through the closed channels.
+	lndForwardingPageSize = 10000
 )
 
+// lndLeaseID identifies the leases of lntop.
+var lndLeaseID = []byte("lntop coin selection lease id 00")
+
+// lightningClient is the subset of lnrpc.LightningClient used by the
+// backend, it is implemented by both the grpc and the rest transports.
+type lightningClient interface {
+	GetInfo(ctx context.Context, in *lnrpc.GetInfoRequest, opts ...grpc.CallOption) (*lnrpc.GetInfoResponse, error)
+	WalletBalance(ctx context.Context, in *lnrpc.WalletBalanceRequest, opts ...grpc.CallOption) (*lnrpc.WalletBalanceResponse, error)
+	NewAddress(ctx context.Context, in *lnrpc.NewAddressRequest, opts ...grpc.CallOption) (*lnrpc.NewAddressResponse, error)
+	ListUnspent(ctx context.Context, in *lnrpc.ListUnspentRequest, opts ...grpc.CallOption) (*lnrpc.ListUnspentResponse, error)
+	SendCoins(ctx context.Context, in *lnrpc.SendCoinsRequest, opts ...grpc.CallOption) (*lnrpc.SendCoinsResponse, error)
+	ChannelBalance(ctx context.Context, in *lnrpc.ChannelBalanceRequest, opts ...grpc.CallOption) (*lnrpc.ChannelBalanceResponse, error)
+	GetTransactions(ctx context.Context, in *lnrpc.GetTransactionsRequest, opts ...grpc.CallOption) (*lnrpc.TransactionDetails, error)
+	ListChannels(ctx context.Context, in *lnrpc.ListChannelsRequest, opts ...grpc.CallOption) (*lnrpc.ListChannelsResponse, error)
+	ClosedChannels(ctx context.Context, in *lnrpc.ClosedChannelsRequest, opts ...grpc.CallOption) (*lnrpc.ClosedChannelsResponse, error)
+	PendingChannels(ctx context.Context, in *lnrpc.PendingChannelsRequest, opts ...grpc.CallOption) (*lnrpc.PendingChannelsResponse, error)
+	GetChanInfo(ctx context.Context, in *lnrpc.ChanInfoRequest, opts ...grpc.CallOption) (*lnrpc.ChannelEdge, error)
+	ListPeers(ctx context.Context, in *lnrpc.ListPeersRequest, opts ...grpc.CallOption) (*lnrpc.ListPeersResponse, error)
+	ConnectPeer(ctx context.Context, in *lnrpc.ConnectPeerRequest, opts ...grpc.CallOption) (*lnrpc.ConnectPeerResponse, error)
+	DisconnectPeer(ctx context.Context, in *lnrpc.DisconnectPeerRequest, opts ...grpc.CallOption) (*lnrpc.DisconnectPeerResponse, error)
+	OpenChannelSync(ctx context.Context, in *lnrpc.OpenChannelRequest, opts ...grpc.CallOption) (*lnrpc.ChannelPoint, error)
+	CloseChannel(ctx context.Context, in *lnrpc.CloseChannelRequest, opts ...grpc.CallOption) (lnrpc.Lightning_CloseChannelClient, error)
+	UpdateChannelPolicy(ctx context.Context, in *lnrpc.PolicyUpdateRequest, opts ...grpc.CallOption) (*lnrpc.PolicyUpdateResponse, error)
+	GetNodeInfo(ctx context.Context, in *lnrpc.NodeInfoRequest, opts ...grpc.CallOption) (*lnrpc.NodeInfo, error)
+	ForwardingHistory(ctx context.Context, in *lnrpc.ForwardingHistoryRequest, opts ...grpc.CallOption) (*lnrpc.ForwardingHistoryResponse, error)
+	AddInvoice(ctx context.Context, in *lnrpc.Invoice, opts ...grpc.CallOption) (*lnrpc.AddInvoiceResponse, error)
+	LookupInvoice(ctx context.Context, in *lnrpc.PaymentHash, opts ...grpc.CallOption) (*lnrpc.Invoice, error)
+	ListInvoices(ctx context.Context, in *lnrpc.ListInvoiceRequest, opts ...grpc.CallOption) (*lnrpc.ListInvoiceResponse, error)
+	ListPayments(ctx context.Context, in *lnrpc.ListPaymentsRequest, opts ...grpc.CallOption) (*lnrpc.ListPaymentsResponse, error)
+	DecodePayReq(ctx context.Context, in *lnrpc.PayReqString, opts ...grpc.CallOption) (*lnrpc.PayReq, error)
+	SendPaymentSync(ctx context.Context, in *lnrpc.SendRequest, opts ...grpc.CallOption) (*lnrpc.SendResponse, error)
+	QueryRoutes(ctx context.Context, in *lnrpc.QueryRoutesRequest, opts ...grpc.CallOption) (*lnrpc.QueryRoutesResponse, error)
+	SubscribeInvoices(ctx context.Context, in *lnrpc.InvoiceSubscription, opts ...grpc.CallOption) (lnrpc.Lightning_SubscribeInvoicesClient, error)
+	SubscribeTransactions(ctx context.Context, in *lnrpc.GetTransactionsRequest, opts ...grpc.CallOption) (lnrpc.Lightning_SubscribeTransactionsClient, error)
+	SubscribeChannelEvents(ctx context.Context, in *lnrpc.ChannelEventSubscription, opts ...grpc.CallOption) (lnrpc.Lightning_SubscribeChannelEventsClient, error)
+	SubscribeChannelGraph(ctx context.Context, in *lnrpc.GraphTopologySubscription, opts ...grpc.CallOption) (lnrpc.Lightning_SubscribeChannelGraphClient, error)
+	SubscribePeerEvents(ctx context.Context, in *lnrpc.PeerEventSubscription, opts ...grpc.CallOption) (lnrpc.Lightning_SubscribePeerEventsClient, error)
+}
+
+// routerClient is the subset of routerrpc.RouterClient used by the backend.
+type routerClient interface {
+	SubscribeHtlcEvents(ctx context.Context, in *routerrpc.SubscribeHtlcEventsRequest, opts ...grpc.CallOption) (routerrpc.Router_SubscribeHtlcEventsClient, error)
+	UpdateChanStatus(ctx context.Context, in *routerrpc.UpdateChanStatusRequest, opts ...grpc.CallOption) (*routerrpc.UpdateChanStatusResponse, error)
+}
+
+// walletClient is the subset of walletrpc.WalletKitClient used by the
+// backend.
+type walletClient interface {
+	EstimateFee(ctx context.Context, in *walletrpc.EstimateFeeRequest, opts ...grpc.CallOption) (*walletrpc.EstimateFeeResponse, error)
+	LeaseOutput(ctx context.Context, in *walletrpc.LeaseOutputRequest, opts ...grpc.CallOption) (*walletrpc.LeaseOutputResponse, error)
+	ReleaseOutput(ctx context.Context, in *walletrpc.ReleaseOutputRequest, opts ...grpc.CallOption) (*walletrpc.ReleaseOutputResponse, error)
+	PendingSweeps(ctx context.Context, in *walletrpc.PendingSweepsRequest, opts ...grpc.CallOption) (*walletrpc.PendingSweepsResponse, error)
+	BumpFee(ctx context.Context, in *walletrpc.BumpFeeRequest, opts ...grpc.CallOption) (*walletrpc.BumpFeeResponse, error)
+}
+
 type Client struct {
-	lnrpc.LightningClient
+	lightningClient
 	conn *pool.Conn
 }
 
@@ -37,7 +105,7 @@ func (c *Client) Close() error {
 }
 
 type RouterClient struct {
-	routerrpc.RouterClient
+	routerClient
 	conn *pool.Conn
 }
 
@@ -45,23 +113,39 @@ func (c *RouterClient) Close() error {
 	return c.conn.Close()
 }
 
+type WalletClient struct {
+	walletClient
+	conn *pool.Conn
+}
+
+func (c *WalletClient) Close() error {
+	return c.conn.Close()
+}
+
 type Backend struct {
 	cfg    *config.Network
 	logger logging.Logger
 	pool   *pool.Pool
+	// rest is set when the rest transport is configured, the pool is
+	// then unused.
+	rest *restClient
 }
 
 func (l Backend) NodeName() string {
 	return l.cfg.Name
 }
 
+// Ping calls GetInfo, the rest client is built without reaching the node.
 func (l Backend) Ping() error {
-	clt, err := l.Client(context.Background())
+	ctx := context.Background()
+	clt, err := l.Client(ctx)
 	if err != nil {
 		return err
 	}
 	defer clt.Close()
-	return nil
+
+	_, err = clt.GetInfo(ctx, &lnrpc.GetInfoRequest{})
+	return err
 }
 
 func (l Backend) Info(ctx context.Context) (*models.Info, error) {
@@ -169,22 +253,60 @@ func (l Backend) SubscribeChannels(ctx context.Context, events chan *models.Chan
 				}
 				return err
 			}
-			if event.Type == lnrpc.ChannelEventUpdate_FULLY_RESOLVED_CHANNEL {
-				events <- &models.ChannelUpdate{}
-			}
+			events <- protoToChannelUpdate(event)
+		}
+	}
+}
+
+func (l Backend) SubscribePeerEvents(ctx context.Context, events chan *models.PeerEvent) error {
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
 
+	peerEvents, err := clt.SubscribePeerEvents(ctx, &lnrpc.PeerEventSubscription{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			event, err := peerEvents.Recv()
+			if err != nil {
+				st, ok := status.FromError(err)
+				if ok && st.Code() == codes.Canceled {
+					l.logger.Debug("stopping subscribe peers: context canceled")
+					return nil
+				}
+				return err
+			}
+			events <- &models.PeerEvent{
+				PubKey: event.PubKey,
+				Online: event.Type == lnrpc.PeerEvent_PEER_ONLINE,
+			}
 		}
 	}
 }
 
 func chanpointToString(c *lnrpc.ChannelPoint) string {
-	hash := c.GetFundingTxidBytes()
-	for i := 0; i < len(hash)/2; i++ {
-		hash[i], hash[len(hash)-i-1] = hash[len(hash)-i-1], hash[i]
+	if txid := c.GetFundingTxidStr(); txid != "" {
+		return fmt.Sprintf("%s:%d", txid, c.OutputIndex)
+	}
+	return fmt.Sprintf("%s:%d", txidToString(c.GetFundingTxidBytes()), c.OutputIndex)
+}
+
+// txidToString returns the hex of a transaction id, its bytes are in the
+// reverse order.
+func txidToString(txid []byte) string {
+	hash := make([]byte, len(txid))
+	for i := range txid {
+		hash[i] = txid[len(txid)-i-1]
 	}
-	output := c.OutputIndex
-	result := fmt.Sprintf("%s:%d", hex.EncodeToString(hash), output)
-	return result
+	return hex.EncodeToString(hash)
 }
 
 func (l Backend) SubscribeGraphEvents(ctx context.Context, events chan *models.ChannelEdgeUpdate) error {
@@ -257,25 +379,49 @@ func (l Backend) SubscribeRoutingEvents(ctx context.Context, channelEvents chan
 }
 
 func (l Backend) Client(ctx context.Context) (*Client, error) {
+	if l.rest != nil {
+		return &Client{lightningClient: l.rest}, nil
+	}
+
 	conn, err := l.pool.Get(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Client{
-		LightningClient: lnrpc.NewLightningClient(conn.ClientConn),
+		lightningClient: lnrpc.NewLightningClient(conn.ClientConn),
 		conn:            conn,
 	}, nil
 }
 
 func (l Backend) RouterClient(ctx context.Context) (*RouterClient, error) {
+	if l.rest != nil {
+		return &RouterClient{routerClient: l.rest}, nil
+	}
+
 	conn, err := l.pool.Get(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	return &RouterClient{
-		RouterClient: routerrpc.NewRouterClient(conn.ClientConn),
+		routerClient: routerrpc.NewRouterClient(conn.ClientConn),
+		conn:         conn,
+	}, nil
+}
+
+func (l Backend) WalletClient(ctx context.Context) (*WalletClient, error) {
+	if l.rest != nil {
+		return &WalletClient{walletClient: l.rest}, nil
+	}
+
+	conn, err := l.pool.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalletClient{
+		walletClient: walletrpc.NewWalletKitClient(conn.ClientConn),
 		conn:         conn,
 	}, nil
 }
@@ -323,6 +469,240 @@ func (l Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, e
 	return balance, nil
 }
 
+func (l Backend) NewAddress(ctx context.Context) (string, error) {
+	l.logger.Debug("New address...")
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer clt.Close()
+
+	req := &lnrpc.NewAddressRequest{Type: lnrpc.AddressType_WITNESS_PUBKEY_HASH}
+	resp, err := clt.NewAddress(ctx, req)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	l.logger.Debug("Address retrieved", logging.String("address", resp.GetAddress()))
+
+	return resp.GetAddress(), nil
+}
+
+func (l Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	l.logger.Debug("List unspent...")
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	req := &lnrpc.ListUnspentRequest{MaxConfs: math.MaxInt32}
+	resp, err := clt.ListUnspent(ctx, req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return protoToUtxos(resp), nil
+}
+
+func (l Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	l.logger.Debug("Estimate fee...", logging.Int("target_conf", int(targetConf)))
+
+	clt, err := l.WalletClient(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer clt.Close()
+
+	resp, err := clt.EstimateFee(ctx, &walletrpc.EstimateFeeRequest{ConfTarget: targetConf})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	// a kiloweight is 250 vbytes, the rate is rounded up.
+	return uint64(resp.SatPerKw+249) / 250, nil
+}
+
+// SendCoins sends the coins with lnd choosing the outputs among the unlocked
+// ones, the outputs out of the selection are then leased for the time of
+// the call.
+func (l Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	l.logger.Debug("Send coins...",
+		logging.String("address", req.Address),
+		logging.Int64("amount", req.Amount),
+		logging.Bool("send_all", req.SendAll))
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer clt.Close()
+
+	spendUnconfirmed := false
+	if len(req.Outpoints) > 0 {
+		utxos, err := l.ListUnspent(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		unspent := make(map[string]*models.Utxo, len(utxos))
+		for _, utxo := range utxos {
+			unspent[utxo.Outpoint()] = utxo
+		}
+		selected := make(map[string]bool, len(req.Outpoints))
+		for _, outpoint := range req.Outpoints {
+			utxo, ok := unspent[outpoint]
+			if !ok {
+				return "", errors.Errorf("output %s is not an unspent output of the wallet", outpoint)
+			}
+			selected[outpoint] = true
+			spendUnconfirmed = spendUnconfirmed || utxo.Confirmations == 0
+		}
+
+		leased := []*lnrpc.OutPoint{}
+		defer func() {
+			err := l.releaseOutputs(leased)
+			if err != nil {
+				l.logger.Error("release outputs", logging.Error(err))
+			}
+		}()
+
+		for _, utxo := range utxos {
+			if selected[utxo.Outpoint()] {
+				continue
+			}
+			outpoint := &lnrpc.OutPoint{TxidStr: utxo.TxID, OutputIndex: utxo.OutputIndex}
+			err := l.leaseOutput(ctx, outpoint)
+			if err != nil {
+				return "", err
+			}
+			leased = append(leased, outpoint)
+		}
+	}
+
+	in := &lnrpc.SendCoinsRequest{
+		Addr:             req.Address,
+		Amount:           req.Amount,
+		SendAll:          req.SendAll,
+		SatPerVbyte:      req.SatPerVByte,
+		SpendUnconfirmed: spendUnconfirmed,
+	}
+	if req.SatPerVByte == 0 {
+		in.TargetConf = req.TargetConf
+	}
+	resp, err := clt.SendCoins(ctx, in)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	l.logger.Debug("Coins sent", logging.String("txid", resp.GetTxid()))
+
+	return resp.GetTxid(), nil
+}
+
+// BumpFee bumps the fee with the sweeper of lnd: the inputs it sweeps are
+// spent again by a replacement while an output of the wallet is spent by a
+// new transaction, a CPFP when it is unconfirmed.
+func (l Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	l.logger.Debug("Bump fee...",
+		logging.String("txid", req.TxID),
+		logging.Int("method", req.Method),
+		logging.Uint64("sat_per_vbyte", req.SatPerVByte))
+
+	clt, err := l.WalletClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	outpoints := []string{req.Outpoint}
+	if req.Method == models.BumpFeeRBF {
+		resp, err := clt.PendingSweeps(ctx, &walletrpc.PendingSweepsRequest{})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		swept := make(map[string]bool, len(resp.PendingSweeps))
+		for _, sweep := range resp.PendingSweeps {
+			if sweep.Outpoint != nil {
+				swept[protoToOutpoint(sweep.Outpoint)] = true
+			}
+		}
+		outpoints = []string{}
+		for _, input := range req.Inputs {
+			if swept[input] {
+				outpoints = append(outpoints, input)
+			}
+		}
+		if len(outpoints) == 0 {
+			return errors.New("the inputs of the transaction are not swept by lnd, it can only be bumped with a CPFP")
+		}
+	}
+
+	for _, outpoint := range outpoints {
+		in, err := outpointToProto(outpoint)
+		if err != nil {
+			return err
+		}
+		_, err = clt.BumpFee(ctx, &walletrpc.BumpFeeRequest{
+			Outpoint:    in,
+			SatPerVbyte: req.SatPerVByte,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	l.logger.Debug("Fee bumped", logging.String("txid", req.TxID))
+
+	return nil
+}
+
+func (l Backend) leaseOutput(ctx context.Context, outpoint *lnrpc.OutPoint) error {
+	clt, err := l.WalletClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	_, err = clt.LeaseOutput(ctx, &walletrpc.LeaseOutputRequest{
+		Id:                lndLeaseID,
+		Outpoint:          outpoint,
+		ExpirationSeconds: lndLeaseDuration,
+	})
+	return errors.WithStack(err)
+}
+
+// releaseOutputs releases the leases, even once the context of the call is
+// done, they would otherwise lock the outputs until they expire.
+func (l Backend) releaseOutputs(outpoints []*lnrpc.OutPoint) error {
+	if len(outpoints) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	clt, err := l.WalletClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	for _, outpoint := range outpoints {
+		_, err := clt.ReleaseOutput(ctx, &walletrpc.ReleaseOutputRequest{
+			Id:       lndLeaseID,
+			Outpoint: outpoint,
+		})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
 func (l Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
 	l.logger.Debug("Retrieve channel balance...")
 
@@ -389,6 +769,54 @@ func (l Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*m
 	return channels, nil
 }
 
+func (l Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	l.logger.Debug("Closed channels...")
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	resp, err := clt.ClosedChannels(ctx, &lnrpc.ClosedChannelsRequest{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	channels := protoToClosedChannels(resp)
+	if len(channels) == 0 {
+		return channels, nil
+	}
+
+	// the whole forwarding history is read by pages, the alias lookup of
+	// GetForwardingHistory is not needed.
+	routed := make(map[uint64]int64)
+	offset := uint32(0)
+	for {
+		resp, err := clt.ForwardingHistory(ctx, &lnrpc.ForwardingHistoryRequest{
+			IndexOffset:  offset,
+			NumMaxEvents: lndForwardingPageSize,
+		})
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, event := range resp.ForwardingEvents {
+			routed[event.ChanIdIn] += int64(event.AmtIn)
+			routed[event.ChanIdOut] += int64(event.AmtOut)
+		}
+		if len(resp.ForwardingEvents) < lndForwardingPageSize {
+			break
+		}
+		offset = resp.LastOffsetIndex
+	}
+	for _, c := range channels {
+		c.TotalRouted = routed[c.ID]
+	}
+
+	l.logger.Debug("Closed channels retrieved", logging.Int("count", len(channels)))
+
+	return channels, nil
+}
+
 func (l Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
 	l.logger.Debug("GetChannelInfo")
 
@@ -428,6 +856,51 @@ func (l Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) er
 	return nil
 }
 
+func (l Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	l.logger.Debug("Update channel policy...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	channelPoint, err := channelPointToProto(channel.ChannelPoint)
+	if err != nil {
+		return err
+	}
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	resp, err := clt.UpdateChannelPolicy(ctx, routingPolicyToProto(channelPoint, policy))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if failed := resp.GetFailedUpdates(); len(failed) > 0 {
+		return errors.Errorf("policy update failed: %s", failed[0].UpdateError)
+	}
+
+	// the channel is enabled or disabled by the router.
+	if channel.LocalPolicy != nil && channel.LocalPolicy.Disabled == policy.Disabled {
+		return nil
+	}
+
+	router, err := l.RouterClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer router.Close()
+
+	action := routerrpc.ChanStatusAction_ENABLE
+	if policy.Disabled {
+		action = routerrpc.ChanStatusAction_DISABLE
+	}
+	_, err = router.UpdateChanStatus(ctx, &routerrpc.UpdateChanStatusRequest{
+		ChanPoint: channelPoint,
+		Action:    action,
+	})
+	return errors.WithStack(err)
+}
+
 func (l Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
 	l.logger.Debug("GetNode")
 
@@ -457,7 +930,7 @@ func (l Backend) GetForwardingHistory(ctx context.Context, startTime string, max
 		return nil, err
 	}
 	defer clt.Close()
-	t, err := parseTime(startTime, time.Now())
+	t, err := options.ParseTime(startTime, time.Now())
 	req := &lnrpc.ForwardingHistoryRequest{
 		StartTime:    t,
 		NumMaxEvents: maxNumEvents,
@@ -536,6 +1009,143 @@ func (l Backend) GetForwardingHistory(ctx context.Context, startTime string, max
 	return result, nil
 }
 
+func (l Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	resp, err := clt.ListPeers(ctx, &lnrpc.ListPeersRequest{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return listPeersProtoToPeers(resp), nil
+}
+
+func (l Backend) ConnectPeer(ctx context.Context, node string) error {
+	l.logger.Debug("Connect peer...", logging.String("node", node))
+
+	parts := strings.SplitN(node, "@", 2)
+	if len(parts) != 2 {
+		return errors.Errorf("invalid node %q, expected pubkey@host", node)
+	}
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	_, err = clt.ConnectPeer(ctx, &lnrpc.ConnectPeerRequest{
+		Addr: &lnrpc.LightningAddress{Pubkey: parts[0], Host: parts[1]},
+	})
+	return errors.WithStack(err)
+}
+
+func (l Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	l.logger.Debug("Disconnect peer...", logging.String("pubkey", pubkey))
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+
+	_, err = clt.DisconnectPeer(ctx, &lnrpc.DisconnectPeerRequest{PubKey: pubkey})
+	return errors.WithStack(err)
+}
+
+func (l Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	l.logger.Debug("Open channel...",
+		logging.String("node", req.Node),
+		logging.Int64("amount", req.Amount))
+
+	pubkey, err := hex.DecodeString(req.PubKey())
+	if err != nil {
+		return "", errors.Errorf("invalid node public key %q", req.PubKey())
+	}
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer clt.Close()
+
+	if req.Host() != "" {
+		err := l.ConnectPeer(ctx, req.Node)
+		if err != nil && !strings.Contains(err.Error(), "already connected") {
+			return "", err
+		}
+	}
+
+	resp, err := clt.OpenChannelSync(ctx, &lnrpc.OpenChannelRequest{
+		NodePubkey:         pubkey,
+		LocalFundingAmount: req.Amount,
+		PushSat:            req.PushAmount,
+		SatPerVbyte:        req.SatPerVByte,
+		Private:            req.Private,
+		MinHtlcMsat:        req.MinHtlcMsat,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	channelPoint := chanpointToString(resp)
+
+	l.logger.Debug("Channel opening", logging.String("channel_point", channelPoint))
+
+	return strings.SplitN(channelPoint, ":", 2)[0], nil
+}
+
+func (l Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	l.logger.Debug("Close channel...",
+		logging.String("channel_point", channel.ChannelPoint))
+
+	channelPoint, err := channelPointToProto(channel.ChannelPoint)
+	if err != nil {
+		return "", err
+	}
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer clt.Close()
+
+	// the closing goes on once it is pending, the stream is only read
+	// until then.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := clt.CloseChannel(ctx, &lnrpc.CloseChannelRequest{
+		ChannelPoint:    channelPoint,
+		Force:           req.Force,
+		SatPerVbyte:     req.SatPerVByte,
+		DeliveryAddress: req.DeliveryAddress,
+	})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	update, err := stream.Recv()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	txid := ""
+	if pending := update.GetClosePending(); pending != nil {
+		txid = txidToString(pending.Txid)
+	} else if closed := update.GetChanClose(); closed != nil {
+		txid = txidToString(closed.ClosingTxid)
+	}
+
+	l.logger.Debug("Channel closing", logging.String("txid", txid))
+
+	return txid, nil
+}
+
 func (l Backend) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
 	l.logger.Debug("Create invoice...",
 		logging.Int64("amount", amount),
@@ -591,10 +1201,143 @@ func (l Backend) GetInvoice(ctx context.Context, RHash string) (*models.Invoice,
 	return invoice, nil
 }
 
-func (l Backend) SendPayment(ctx context.Context, payreq *models.PayReq) (*models.Payment, error) {
+func (l Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	l.logger.Debug("List invoices...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	req := &lnrpc.ListInvoiceRequest{
+		IndexOffset:    offset,
+		NumMaxInvoices: max,
+	}
+
+	resp, err := clt.ListInvoices(ctx, req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return listInvoicesProtoToInvoices(resp), nil
+}
+
+func (l Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	l.logger.Debug("List payments...",
+		logging.Uint64("offset", offset),
+		logging.Uint64("max", max))
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	req := &lnrpc.ListPaymentsRequest{
+		IncludeIncomplete: true,
+		IndexOffset:       offset,
+		MaxPayments:       max,
+	}
+
+	resp, err := clt.ListPayments(ctx, req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return listPaymentsProtoToPayments(resp), nil
+}
+
+// TrackPayments polls the last payments and sends the ones that are new or
+// whose status or attempts changed, the version of lnd used does not stream
+// the updates of all the payments.
+func (l Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	ticker := time.NewTicker(lndPaymentsPollInterval)
+	defer ticker.Stop()
+
+	var known map[uint64]string
+	for {
+		payments, err := l.lastPayments(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				l.logger.Debug("stopping track payments: context canceled")
+				return nil
+			}
+			return err
+		}
+
+		current := make(map[uint64]string, len(payments))
+		for _, p := range payments {
+			state := fmt.Sprintf("%d:%d", p.Status, len(p.HTLCs))
+			current[p.Index] = state
+			if known != nil && known[p.Index] != state {
+				channel <- p
+			}
+		}
+		known = current
+
+		select {
+		case <-ctx.Done():
+			l.logger.Debug("stopping track payments: context canceled")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l Backend) lastPayments(ctx context.Context) ([]*models.Payment, error) {
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer clt.Close()
+
+	resp, err := clt.ListPayments(ctx, &lnrpc.ListPaymentsRequest{
+		IncludeIncomplete: true,
+		MaxPayments:       lndTrackedPayments,
+		Reversed:          true,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return listPaymentsProtoToPayments(resp), nil
+}
+
+func (l Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	l.logger.Debug("Estimate route fee...",
+		logging.String("destination", payreq.Destination),
+		logging.Int64("amount", payreq.Amount),
+	)
+
+	clt, err := l.Client(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer clt.Close()
+
+	resp, err := clt.QueryRoutes(ctx, &lnrpc.QueryRoutesRequest{
+		PubKey:         payreq.Destination,
+		Amt:            payreq.Amount,
+		FinalCltvDelta: int32(payreq.CltvExpiry),
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if len(resp.GetRoutes()) == 0 {
+		return 0, errors.New("no route found")
+	}
+
+	return resp.GetRoutes()[0].GetTotalFeesMsat(), nil
+}
+
+func (l Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
 	l.logger.Debug("Send payment...",
 		logging.String("destination", payreq.Destination),
 		logging.Int64("amount", payreq.Amount),
+		logging.Int64("fee_limit", feeLimit),
 	)
 
 	clt, err := l.Client(ctx)
@@ -604,6 +1347,9 @@ func (l Backend) SendPayment(ctx context.Context, payreq *models.PayReq) (*model
 	defer clt.Close()
 
 	req := &lnrpc.SendRequest{PaymentRequest: payreq.String}
+	if feeLimit > 0 {
+		req.FeeLimit = &lnrpc.FeeLimit{Limit: &lnrpc.FeeLimit_Fixed{Fixed: feeLimit}}
+	}
 
 	resp, err := clt.SendPaymentSync(ctx, req)
 	if err != nil {
@@ -641,6 +1387,18 @@ func New(c *config.Network, logger logging.Logger) (*Backend, error) {
 		logger: logger.With(logging.String("name", c.Name)),
 	}
 
+	switch c.Transport {
+	case "rest":
+		backend.rest, err = newRestClient(c)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	case "", "grpc":
+	default:
+		return nil, errors.Errorf("unknown lnd transport %q", c.Transport)
+	}
+
 	if c.PoolCapacity < lndMinPoolCapacity {
 		c.PoolCapacity = lndMinPoolCapacity
 		logger.Info("pool_capacity too small, ignoring")
@@ -652,36 +1410,3 @@ func New(c *config.Network, logger logging.Logger) (*Backend, error) {
 
 	return backend, nil
 }
-
-// reTimeRange matches systemd.time-like short negative timeranges, e.g. "-200s".
-var reTimeRange = regexp.MustCompile(`^-\d{1,18}[s|m|h|d|w|M|y]$`)
-
-// secondsPer allows translating s(seconds), m(minutes), h(ours), d(ays),
-// w(eeks), M(onths) and y(ears) into corresponding seconds.
-var secondsPer = map[string]int64{
-	"s": 1,
-	"m": 60,
-	"h": 3600,
-	"d": 86400,
-	"w": 604800,
-	"M": 2630016,  // 30.44 days
-	"y": 31557600, // 365.25 days
-}
-
-// parseTime parses UNIX timestamps or short timeranges inspired by systemd
-// (when starting with "-"), e.g. "-1M" for one month (30.44 days) ago.
-func parseTime(s string, base time.Time) (uint64, error) {
-	if reTimeRange.MatchString(s) {
-		last := len(s) - 1
-
-		d, err := strconv.ParseInt(s[1:last], 10, 64)
-		if err != nil {
-			return uint64(0), err
-		}
-
-		mul := secondsPer[string(s[last])]
-		return uint64(base.Unix() - d*mul), nil
-	}
-
-	return strconv.ParseUint(s, 10, 64)
-}
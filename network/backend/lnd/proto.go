@@ -1,12 +1,15 @@
 package lnd
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/pkg/errors"
 
 	"github.com/edouardparis/lntop/network/models"
 )
@@ -19,6 +22,38 @@ func protoToWalletBalance(w *lnrpc.WalletBalanceResponse) *models.WalletBalance
 	}
 }
 
+func protoToUtxos(resp *lnrpc.ListUnspentResponse) []*models.Utxo {
+	if resp == nil {
+		return nil
+	}
+
+	utxos := make([]*models.Utxo, len(resp.Utxos))
+	for i, u := range resp.Utxos {
+		utxos[i] = &models.Utxo{
+			TxID:          u.GetOutpoint().GetTxidStr(),
+			OutputIndex:   u.GetOutpoint().GetOutputIndex(),
+			Amount:        u.AmountSat,
+			Address:       u.Address,
+			AddressType:   protoToAddressType(u.AddressType),
+			Confirmations: u.Confirmations,
+		}
+	}
+	return utxos
+}
+
+func protoToAddressType(t lnrpc.AddressType) int {
+	switch t {
+	case lnrpc.AddressType_WITNESS_PUBKEY_HASH, lnrpc.AddressType_UNUSED_WITNESS_PUBKEY_HASH:
+		return models.AddressP2WPKH
+	case lnrpc.AddressType_NESTED_PUBKEY_HASH, lnrpc.AddressType_UNUSED_NESTED_PUBKEY_HASH:
+		return models.AddressNP2WKH
+	case lnrpc.AddressType_TAPROOT_PUBKEY, lnrpc.AddressType_UNUSED_TAPROOT_PUBKEY:
+		return models.AddressP2TR
+	default:
+		return models.AddressUnknown
+	}
+}
+
 func protoToChannelsBalance(w *lnrpc.ChannelBalanceResponse) *models.ChannelsBalance {
 	return &models.ChannelsBalance{
 		PendingOpenBalance: w.GetPendingOpenBalance(),
@@ -35,6 +70,7 @@ func addInvoiceProtoToInvoice(req *lnrpc.Invoice, resp *lnrpc.AddInvoiceResponse
 		RHash:          resp.GetRHash(),
 		PaymentRequest: resp.GetPaymentRequest(),
 		Index:          resp.GetAddIndex(),
+		State:          models.InvoiceOpen,
 	}
 }
 
@@ -56,9 +92,33 @@ func lookupInvoiceProtoToInvoice(resp *lnrpc.Invoice) *models.Invoice {
 		Expiry:           resp.GetExpiry(),
 		CLTVExpiry:       resp.GetCltvExpiry(),
 		Private:          resp.GetPrivate(),
+		State:            invoiceStateProtoToState(resp.GetState()),
+	}
+}
+
+func invoiceStateProtoToState(s lnrpc.Invoice_InvoiceState) int {
+	switch s {
+	case lnrpc.Invoice_SETTLED:
+		return models.InvoiceSettled
+	case lnrpc.Invoice_CANCELED:
+		return models.InvoiceCanceled
+	case lnrpc.Invoice_ACCEPTED:
+		return models.InvoiceAccepted
+	default:
+		return models.InvoiceOpen
 	}
 }
 
+func listInvoicesProtoToInvoices(r *lnrpc.ListInvoiceResponse) []*models.Invoice {
+	resp := r.GetInvoices()
+	invoices := make([]*models.Invoice, len(resp))
+	for i := range resp {
+		invoices[i] = lookupInvoiceProtoToInvoice(resp[i])
+	}
+
+	return invoices
+}
+
 func listChannelsProtoToChannels(r *lnrpc.ListChannelsResponse) []*models.Channel {
 	resp := r.GetChannels()
 	channels := make([]*models.Channel, len(resp))
@@ -165,10 +225,23 @@ func closingChannelProtoToChannel(c *lnrpc.PendingChannelsResponse_ClosedChannel
 		LocalBalance:  c.Channel.LocalBalance,
 		RemoteBalance: c.Channel.RemoteBalance,
 		ChannelPoint:  c.Channel.ChannelPoint,
+		Pending:       &models.PendingChannel{ClosingTxID: c.ClosingTxid},
 	}
 }
 
 func forceClosingChannelProtoToChannel(c *lnrpc.PendingChannelsResponse_ForceClosedChannel) *models.Channel {
+	htlcs := make([]*models.PendingHTLC, len(c.PendingHtlcs))
+	for i, h := range c.PendingHtlcs {
+		htlcs[i] = &models.PendingHTLC{
+			Incoming:          h.Incoming,
+			Amount:            h.Amount,
+			Outpoint:          h.Outpoint,
+			MaturityHeight:    h.MaturityHeight,
+			BlocksTilMaturity: h.BlocksTilMaturity,
+			Stage:             h.Stage,
+		}
+	}
+
 	return &models.Channel{
 		Status:            models.ChannelForceClosing,
 		RemotePubKey:      c.Channel.RemoteNodePub,
@@ -177,18 +250,52 @@ func forceClosingChannelProtoToChannel(c *lnrpc.PendingChannelsResponse_ForceClo
 		RemoteBalance:     c.Channel.RemoteBalance,
 		ChannelPoint:      c.Channel.ChannelPoint,
 		BlocksTilMaturity: c.BlocksTilMaturity,
+		Pending: &models.PendingChannel{
+			ClosingTxID:      c.ClosingTxid,
+			LimboBalance:     c.LimboBalance,
+			RecoveredBalance: c.RecoveredBalance,
+			MaturityHeight:   c.MaturityHeight,
+			Anchor:           protoToAnchorState(c.Channel.CommitmentType, c.Anchor),
+			HTLCs:            htlcs,
+		},
+	}
+}
+
+// protoToAnchorState returns the state of the anchor output, lnd reports
+// the channels without anchor as in limbo.
+func protoToAnchorState(t lnrpc.CommitmentType, a lnrpc.PendingChannelsResponse_ForceClosedChannel_AnchorState) int {
+	if t != lnrpc.CommitmentType_ANCHORS && t != lnrpc.CommitmentType_SCRIPT_ENFORCED_LEASE {
+		return models.AnchorNone
+	}
+	switch a {
+	case lnrpc.PendingChannelsResponse_ForceClosedChannel_LIMBO:
+		return models.AnchorLimbo
+	case lnrpc.PendingChannelsResponse_ForceClosedChannel_RECOVERED:
+		return models.AnchorRecovered
+	case lnrpc.PendingChannelsResponse_ForceClosedChannel_LOST:
+		return models.AnchorLost
+	default:
+		return models.AnchorNone
 	}
 }
 
 func waitingCloseChannelProtoToChannel(c *lnrpc.PendingChannelsResponse_WaitingCloseChannel) *models.Channel {
-	return &models.Channel{
+	channel := &models.Channel{
 		Status:        models.ChannelWaitingClose,
 		RemotePubKey:  c.Channel.RemoteNodePub,
 		Capacity:      c.Channel.Capacity,
 		LocalBalance:  c.Channel.LocalBalance,
 		RemoteBalance: c.Channel.RemoteBalance,
 		ChannelPoint:  c.Channel.ChannelPoint,
+		Pending: &models.PendingChannel{
+			ClosingTxID:  c.ClosingTxid,
+			LimboBalance: c.LimboBalance,
+		},
+	}
+	if c.Commitments != nil {
+		channel.CommitFee = int64(c.Commitments.LocalCommitFeeSat)
 	}
+	return channel
 }
 
 func payreqProtoToPayReq(h *lnrpc.PayReq, payreq string) *models.PayReq {
@@ -231,6 +338,110 @@ func sendPaymentProtoToPayment(payreq *models.PayReq, resp *lnrpc.SendResponse)
 	return payment
 }
 
+func paymentProtoToPayment(p *lnrpc.Payment) *models.Payment {
+	payment := &models.Payment{
+		Index:        p.GetPaymentIndex(),
+		PaymentHash:  p.GetPaymentHash(),
+		Status:       paymentStatusProtoToStatus(p.GetStatus()),
+		AmountMsat:   p.GetValueMsat(),
+		FeeMsat:      p.GetFeeMsat(),
+		CreationDate: time.Unix(0, p.GetCreationTimeNs()),
+		HTLCs:        make([]*models.HTLCAttempt, len(p.GetHtlcs())),
+	}
+	payment.PaymentPreimage, _ = hex.DecodeString(p.GetPaymentPreimage())
+	if p.GetPaymentRequest() != "" {
+		payment.PayReq = &models.PayReq{
+			PaymentHash: p.GetPaymentHash(),
+			String:      p.GetPaymentRequest(),
+		}
+	}
+	if p.GetFailureReason() != lnrpc.PaymentFailureReason_FAILURE_REASON_NONE {
+		payment.FailureReason = protoEnumToString(
+			strings.TrimPrefix(p.GetFailureReason().String(), "FAILURE_REASON_"))
+	}
+
+	for i, h := range p.GetHtlcs() {
+		attempt := &models.HTLCAttempt{
+			Status:      htlcAttemptStatusProtoToStatus(h.GetStatus()),
+			Route:       routeProtoToRoute(h.GetRoute()),
+			AttemptTime: time.Unix(0, h.GetAttemptTimeNs()),
+		}
+		if h.GetResolveTimeNs() != 0 {
+			attempt.ResolveTime = time.Unix(0, h.GetResolveTimeNs())
+		}
+		if f := h.GetFailure(); f != nil {
+			attempt.Failure = fmt.Sprintf("%s at hop %d",
+				protoEnumToString(f.GetCode().String()), f.GetFailureSourceIndex())
+		}
+		payment.HTLCs[i] = attempt
+	}
+
+	return payment
+}
+
+func listPaymentsProtoToPayments(r *lnrpc.ListPaymentsResponse) []*models.Payment {
+	resp := r.GetPayments()
+	payments := make([]*models.Payment, len(resp))
+	for i := range resp {
+		payments[i] = paymentProtoToPayment(resp[i])
+	}
+
+	return payments
+}
+
+func paymentStatusProtoToStatus(s lnrpc.Payment_PaymentStatus) int {
+	switch s {
+	case lnrpc.Payment_SUCCEEDED:
+		return models.PaymentSucceeded
+	case lnrpc.Payment_FAILED:
+		return models.PaymentFailed
+	default:
+		return models.PaymentInFlight
+	}
+}
+
+func htlcAttemptStatusProtoToStatus(s lnrpc.HTLCAttempt_HTLCStatus) int {
+	switch s {
+	case lnrpc.HTLCAttempt_SUCCEEDED:
+		return models.HTLCAttemptSucceeded
+	case lnrpc.HTLCAttempt_FAILED:
+		return models.HTLCAttemptFailed
+	default:
+		return models.HTLCAttemptInFlight
+	}
+}
+
+func routeProtoToRoute(r *lnrpc.Route) *models.Route {
+	if r == nil {
+		return nil
+	}
+
+	route := &models.Route{
+		TimeLock: r.GetTotalTimeLock(),
+		Fee:      r.GetTotalFees(),
+		Amount:   r.GetTotalAmt(),
+		Hops:     make([]*models.Hop, len(r.GetHops())),
+	}
+	for i, h := range r.GetHops() {
+		route.Hops[i] = &models.Hop{
+			ChanID:       h.GetChanId(),
+			ChanCapacity: h.GetChanCapacity(),
+			Amount:       h.GetAmtToForward(),
+			Fee:          h.GetFee(),
+			Expiry:       h.GetExpiry(),
+			PubKey:       h.GetPubKey(),
+		}
+	}
+
+	return route
+}
+
+// protoEnumToString returns the name of an enum value in lower case words,
+// TEMPORARY_CHANNEL_FAILURE becomes temporary channel failure.
+func protoEnumToString(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "_", " "))
+}
+
 func infoProtoToInfo(resp *lnrpc.GetInfoResponse) *models.Info {
 	if resp == nil {
 		return nil
@@ -309,6 +520,102 @@ func protoToRoutingPolicy(resp *lnrpc.RoutingPolicy) *models.RoutingPolicy {
 	}
 }
 
+func protoToChannelUpdate(event *lnrpc.ChannelEventUpdate) *models.ChannelUpdate {
+	switch event.Type {
+	case lnrpc.ChannelEventUpdate_PENDING_OPEN_CHANNEL:
+		pending := event.GetPendingOpenChannel()
+		return &models.ChannelUpdate{
+			ChannelPoint: fmt.Sprintf("%s:%d", txidToString(pending.GetTxid()), pending.GetOutputIndex()),
+			Status:       models.ChannelOpening,
+		}
+	case lnrpc.ChannelEventUpdate_OPEN_CHANNEL:
+		status := models.ChannelInactive
+		if event.GetOpenChannel().GetActive() {
+			status = models.ChannelActive
+		}
+		return &models.ChannelUpdate{
+			ChannelPoint: event.GetOpenChannel().GetChannelPoint(),
+			Status:       status,
+		}
+	case lnrpc.ChannelEventUpdate_ACTIVE_CHANNEL:
+		return &models.ChannelUpdate{
+			ChannelPoint: chanpointToString(event.GetActiveChannel()),
+			Status:       models.ChannelActive,
+		}
+	case lnrpc.ChannelEventUpdate_INACTIVE_CHANNEL:
+		return &models.ChannelUpdate{
+			ChannelPoint: chanpointToString(event.GetInactiveChannel()),
+			Status:       models.ChannelInactive,
+		}
+	case lnrpc.ChannelEventUpdate_CLOSED_CHANNEL:
+		return &models.ChannelUpdate{
+			ChannelPoint: event.GetClosedChannel().GetChannelPoint(),
+			Status:       models.ChannelClosed,
+		}
+	case lnrpc.ChannelEventUpdate_FULLY_RESOLVED_CHANNEL:
+		return &models.ChannelUpdate{
+			ChannelPoint: chanpointToString(event.GetFullyResolvedChannel()),
+			Status:       models.ChannelClosed,
+		}
+	}
+	return &models.ChannelUpdate{}
+}
+
+func routingPolicyToProto(channelPoint *lnrpc.ChannelPoint, policy *models.RoutingPolicy) *lnrpc.PolicyUpdateRequest {
+	return &lnrpc.PolicyUpdateRequest{
+		Scope:                &lnrpc.PolicyUpdateRequest_ChanPoint{ChanPoint: channelPoint},
+		BaseFeeMsat:          policy.FeeBaseMsat,
+		FeeRatePpm:           uint32(policy.FeeRateMilliMsat),
+		TimeLockDelta:        policy.TimeLockDelta,
+		MinHtlcMsat:          uint64(policy.MinHtlc),
+		MinHtlcMsatSpecified: true,
+		MaxHtlcMsat:          policy.MaxHtlc,
+	}
+}
+
+// channelPointToProto parses a channel point formatted as txid:index.
+func channelPointToProto(channelPoint string) (*lnrpc.ChannelPoint, error) {
+	parts := strings.Split(channelPoint, ":")
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, errors.Errorf("invalid channel point %q", channelPoint)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, errors.Errorf("invalid channel point %q", channelPoint)
+	}
+	return &lnrpc.ChannelPoint{
+		FundingTxid: &lnrpc.ChannelPoint_FundingTxidStr{FundingTxidStr: parts[0]},
+		OutputIndex: uint32(index),
+	}, nil
+}
+
+// protoToOutpoint formats the outpoint as txid:index, the bytes of the txid
+// are in the order of the hash, reversed from the string.
+func protoToOutpoint(outpoint *lnrpc.OutPoint) string {
+	txid := outpoint.TxidStr
+	if txid == "" {
+		b := make([]byte, len(outpoint.TxidBytes))
+		for i := range outpoint.TxidBytes {
+			b[len(b)-1-i] = outpoint.TxidBytes[i]
+		}
+		txid = hex.EncodeToString(b)
+	}
+	return fmt.Sprintf("%s:%d", txid, outpoint.OutputIndex)
+}
+
+// outpointToProto parses an outpoint formatted as txid:index.
+func outpointToProto(outpoint string) (*lnrpc.OutPoint, error) {
+	parts := strings.Split(outpoint, ":")
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, errors.Errorf("invalid outpoint %q", outpoint)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, errors.Errorf("invalid outpoint %q", outpoint)
+	}
+	return &lnrpc.OutPoint{TxidStr: parts[0], OutputIndex: uint32(index)}, nil
+}
+
 func protoToTransactions(resp *lnrpc.TransactionDetails) []*models.Transaction {
 	if resp == nil {
 		return nil
@@ -322,7 +629,7 @@ func protoToTransactions(resp *lnrpc.TransactionDetails) []*models.Transaction {
 }
 
 func protoToTransaction(resp *lnrpc.Transaction) *models.Transaction {
-	return &models.Transaction{
+	tx := &models.Transaction{
 		TxHash:           resp.TxHash,
 		Amount:           resp.Amount,
 		NumConfirmations: resp.NumConfirmations,
@@ -331,7 +638,12 @@ func protoToTransaction(resp *lnrpc.Transaction) *models.Transaction {
 		Date:             time.Unix(int64(resp.TimeStamp), 0),
 		TotalFees:        resp.TotalFees,
 		DestAddresses:    resp.DestAddresses,
+		VSize:            models.RawTxVSize(resp.RawTxHex),
+	}
+	for _, previous := range resp.PreviousOutpoints {
+		tx.Inputs = append(tx.Inputs, previous.Outpoint)
 	}
+	return tx
 }
 
 func protoToRoutingEvent(resp *routerrpc.HtlcEvent) *models.RoutingEvent {
@@ -399,6 +711,47 @@ func protoToRoutingEvent(resp *routerrpc.HtlcEvent) *models.RoutingEvent {
 	}
 }
 
+func protoToClosedChannels(resp *lnrpc.ClosedChannelsResponse) []*models.ClosedChannel {
+	if resp == nil {
+		return nil
+	}
+
+	channels := make([]*models.ClosedChannel, len(resp.Channels))
+	for i, c := range resp.Channels {
+		channels[i] = &models.ClosedChannel{
+			ID:                c.ChanId,
+			ChannelPoint:      c.ChannelPoint,
+			RemotePubKey:      c.RemotePubkey,
+			Capacity:          c.Capacity,
+			CloseType:         protoToCloseType(c.CloseType),
+			ClosingTxID:       c.ClosingTxHash,
+			CloseHeight:       c.CloseHeight,
+			SettledBalance:    c.SettledBalance,
+			TimeLockedBalance: c.TimeLockedBalance,
+		}
+	}
+	return channels
+}
+
+func protoToCloseType(t lnrpc.ChannelCloseSummary_ClosureType) int {
+	switch t {
+	case lnrpc.ChannelCloseSummary_COOPERATIVE_CLOSE:
+		return models.CloseCooperative
+	case lnrpc.ChannelCloseSummary_LOCAL_FORCE_CLOSE:
+		return models.CloseLocalForce
+	case lnrpc.ChannelCloseSummary_REMOTE_FORCE_CLOSE:
+		return models.CloseRemoteForce
+	case lnrpc.ChannelCloseSummary_BREACH_CLOSE:
+		return models.CloseBreach
+	case lnrpc.ChannelCloseSummary_FUNDING_CANCELED:
+		return models.CloseFundingCanceled
+	case lnrpc.ChannelCloseSummary_ABANDONED:
+		return models.CloseAbandoned
+	default:
+		return models.CloseUnknown
+	}
+}
+
 func protoToForwardingHistory(resp *lnrpc.ForwardingHistoryResponse) []*models.ForwardingEvent {
 	if resp == nil {
 		return nil
@@ -425,3 +778,28 @@ func protoToForwardingEvent(resp *lnrpc.ForwardingEvent) *models.ForwardingEvent
 		EventTime:  time.Unix(0, int64(resp.TimestampNs)),
 	}
 }
+
+func listPeersProtoToPeers(resp *lnrpc.ListPeersResponse) []*models.Peer {
+	if resp == nil {
+		return nil
+	}
+
+	peers := make([]*models.Peer, len(resp.Peers))
+	for i, p := range resp.Peers {
+		peers[i] = &models.Peer{
+			PubKey:    p.PubKey,
+			Address:   p.Address,
+			Inbound:   p.Inbound,
+			PingTime:  time.Duration(p.PingTime) * time.Microsecond,
+			BytesSent: p.BytesSent,
+			BytesRecv: p.BytesRecv,
+			SatSent:   p.SatSent,
+			SatRecv:   p.SatRecv,
+			FlapCount: p.FlapCount,
+		}
+		if p.LastFlapNs > 0 {
+			peers[i].LastFlap = time.Unix(0, p.LastFlapNs)
+		}
+	}
+	return peers
+}
@@ -0,0 +1,517 @@
+package lnd
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/walletrpc"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/backend/dialer"
+)
+
+var (
+	restMarshaler   = protojson.MarshalOptions{UseProtoNames: true}
+	restUnmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// restClient calls the lnd REST gateway. It implements lightningClient,
+// routerClient and walletClient, the responses are decoded in the lnrpc messages so that the
+// conversions are the same as with the grpc transport.
+type restClient struct {
+	address  string
+	macaroon string
+	http     *http.Client
+}
+
+func newRestClient(c *config.Network) (*restClient, error) {
+	creds, err := loadCredentials(c)
+	if err != nil {
+		return nil, err
+	}
+
+	mac, err := loadMacaroon(c, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	macaroonBytes, err := mac.MarshalBinary()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	tlsConfig, err := newTLSConfig(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(creds.address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" {
+		u.Scheme = "https"
+	}
+
+	transport := &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: tlsConfig,
+	}
+	if c.Proxy != "" {
+		transport.Proxy = nil
+		transport.DialContext, err = dialer.New(c.Proxy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &restClient{
+		address:  strings.TrimSuffix(u.String(), "/"),
+		macaroon: hex.EncodeToString(macaroonBytes),
+		http:     &http.Client{Transport: transport},
+	}, nil
+}
+
+// restError is the error body returned by the gateway.
+type restError struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *restError) err() error {
+	return status.Error(codes.Code(e.Code), e.Message)
+}
+
+// do sends the request, for GET and DELETE requests the message fields are
+// passed in the query, otherwise the message is the json body.
+func (c *restClient) do(ctx context.Context, method, path string, in proto.Message) (*http.Response, error) {
+	u := c.address + path
+	var body io.Reader
+	if in != nil {
+		if method == http.MethodGet || method == http.MethodDelete {
+			if query := protoToQuery(in).Encode(); query != "" {
+				u += "?" + query
+			}
+		} else {
+			data, err := restMarshaler.Marshal(in)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			body = bytes.NewReader(data)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Grpc-Metadata-macaroon", c.macaroon)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, status.Error(codes.Canceled, ctx.Err().Error())
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		restErr := &restError{}
+		if json.Unmarshal(data, restErr) != nil || restErr.Message == "" {
+			restErr.Code = int32(codes.Unknown)
+			restErr.Message = fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+		}
+		return nil, restErr.err()
+	}
+
+	return resp, nil
+}
+
+// call sends the request and decodes the response in out.
+func (c *restClient) call(ctx context.Context, method, path string, in, out proto.Message) error {
+	resp, err := c.do(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(restUnmarshaler.Unmarshal(data, out))
+}
+
+// stream opens a streaming response of the gateway.
+func (c *restClient) stream(ctx context.Context, method, path string, in proto.Message) (*restStream, error) {
+	resp, err := c.do(ctx, method, path, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return &restStream{
+		ctx:     ctx,
+		body:    resp.Body,
+		decoder: json.NewDecoder(resp.Body),
+	}, nil
+}
+
+// protoToQuery encodes the populated scalar fields of the message as query
+// parameters.
+func protoToQuery(in proto.Message) url.Values {
+	query := url.Values{}
+	in.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() || fd.IsMap() {
+			return true
+		}
+		name := string(fd.Name())
+		switch fd.Kind() {
+		case protoreflect.MessageKind, protoreflect.GroupKind:
+		case protoreflect.BytesKind:
+			query.Set(name, base64.URLEncoding.EncodeToString(v.Bytes()))
+		case protoreflect.EnumKind:
+			query.Set(name, string(fd.Enum().Values().ByNumber(v.Enum()).Name()))
+		default:
+			query.Set(name, v.String())
+		}
+		return true
+	})
+	return query
+}
+
+// restStream reads the chunked responses of the gateway, each chunk is a json
+// object with either the result or the error of the stream.
+type restStream struct {
+	ctx     context.Context
+	body    io.ReadCloser
+	decoder *json.Decoder
+}
+
+func (s *restStream) Header() (metadata.MD, error) { return nil, nil }
+func (s *restStream) Trailer() metadata.MD         { return nil }
+func (s *restStream) CloseSend() error             { return nil }
+func (s *restStream) Context() context.Context     { return s.ctx }
+
+func (s *restStream) SendMsg(m interface{}) error {
+	return errors.New("lnd rest: stream is receive only")
+}
+
+func (s *restStream) RecvMsg(m interface{}) error {
+	chunk := struct {
+		Result json.RawMessage `json:"result"`
+		Error  *restError      `json:"error"`
+	}{}
+	err := s.decoder.Decode(&chunk)
+	if err != nil {
+		s.body.Close()
+		if s.ctx.Err() != nil {
+			return status.Error(codes.Canceled, s.ctx.Err().Error())
+		}
+		return errors.WithStack(err)
+	}
+
+	if chunk.Error != nil {
+		s.body.Close()
+		return chunk.Error.err()
+	}
+
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return errors.Errorf("lnd rest: %T is not a proto message", m)
+	}
+
+	return errors.WithStack(restUnmarshaler.Unmarshal(chunk.Result, msg))
+}
+
+type restInvoiceStream struct{ *restStream }
+
+func (s restInvoiceStream) Recv() (*lnrpc.Invoice, error) {
+	m := &lnrpc.Invoice{}
+	return m, s.RecvMsg(m)
+}
+
+type restTransactionStream struct{ *restStream }
+
+func (s restTransactionStream) Recv() (*lnrpc.Transaction, error) {
+	m := &lnrpc.Transaction{}
+	return m, s.RecvMsg(m)
+}
+
+type restChannelEventStream struct{ *restStream }
+
+func (s restChannelEventStream) Recv() (*lnrpc.ChannelEventUpdate, error) {
+	m := &lnrpc.ChannelEventUpdate{}
+	return m, s.RecvMsg(m)
+}
+
+type restGraphStream struct{ *restStream }
+
+func (s restGraphStream) Recv() (*lnrpc.GraphTopologyUpdate, error) {
+	m := &lnrpc.GraphTopologyUpdate{}
+	return m, s.RecvMsg(m)
+}
+
+type restCloseStatusStream struct{ *restStream }
+
+func (s restCloseStatusStream) Recv() (*lnrpc.CloseStatusUpdate, error) {
+	m := &lnrpc.CloseStatusUpdate{}
+	return m, s.RecvMsg(m)
+}
+
+type restPeerEventStream struct{ *restStream }
+
+func (s restPeerEventStream) Recv() (*lnrpc.PeerEvent, error) {
+	m := &lnrpc.PeerEvent{}
+	return m, s.RecvMsg(m)
+}
+
+type restHtlcEventStream struct{ *restStream }
+
+func (s restHtlcEventStream) Recv() (*routerrpc.HtlcEvent, error) {
+	m := &routerrpc.HtlcEvent{}
+	return m, s.RecvMsg(m)
+}
+
+func (c *restClient) GetInfo(ctx context.Context, in *lnrpc.GetInfoRequest, _ ...grpc.CallOption) (*lnrpc.GetInfoResponse, error) {
+	out := &lnrpc.GetInfoResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/getinfo", in, out)
+}
+
+func (c *restClient) WalletBalance(ctx context.Context, in *lnrpc.WalletBalanceRequest, _ ...grpc.CallOption) (*lnrpc.WalletBalanceResponse, error) {
+	out := &lnrpc.WalletBalanceResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/balance/blockchain", in, out)
+}
+
+func (c *restClient) NewAddress(ctx context.Context, in *lnrpc.NewAddressRequest, _ ...grpc.CallOption) (*lnrpc.NewAddressResponse, error) {
+	out := &lnrpc.NewAddressResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/newaddress", in, out)
+}
+
+func (c *restClient) ListUnspent(ctx context.Context, in *lnrpc.ListUnspentRequest, _ ...grpc.CallOption) (*lnrpc.ListUnspentResponse, error) {
+	out := &lnrpc.ListUnspentResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/utxos", in, out)
+}
+
+func (c *restClient) SendCoins(ctx context.Context, in *lnrpc.SendCoinsRequest, _ ...grpc.CallOption) (*lnrpc.SendCoinsResponse, error) {
+	out := &lnrpc.SendCoinsResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/transactions", in, out)
+}
+
+func (c *restClient) ChannelBalance(ctx context.Context, in *lnrpc.ChannelBalanceRequest, _ ...grpc.CallOption) (*lnrpc.ChannelBalanceResponse, error) {
+	out := &lnrpc.ChannelBalanceResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/balance/channels", in, out)
+}
+
+func (c *restClient) GetTransactions(ctx context.Context, in *lnrpc.GetTransactionsRequest, _ ...grpc.CallOption) (*lnrpc.TransactionDetails, error) {
+	out := &lnrpc.TransactionDetails{}
+	return out, c.call(ctx, http.MethodGet, "/v1/transactions", in, out)
+}
+
+func (c *restClient) ListChannels(ctx context.Context, in *lnrpc.ListChannelsRequest, _ ...grpc.CallOption) (*lnrpc.ListChannelsResponse, error) {
+	out := &lnrpc.ListChannelsResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/channels", in, out)
+}
+
+func (c *restClient) PendingChannels(ctx context.Context, in *lnrpc.PendingChannelsRequest, _ ...grpc.CallOption) (*lnrpc.PendingChannelsResponse, error) {
+	out := &lnrpc.PendingChannelsResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/channels/pending", in, out)
+}
+
+func (c *restClient) GetChanInfo(ctx context.Context, in *lnrpc.ChanInfoRequest, _ ...grpc.CallOption) (*lnrpc.ChannelEdge, error) {
+	out := &lnrpc.ChannelEdge{}
+	return out, c.call(ctx, http.MethodGet, fmt.Sprintf("/v1/graph/edge/%d", in.ChanId), nil, out)
+}
+
+func (c *restClient) UpdateChannelPolicy(ctx context.Context, in *lnrpc.PolicyUpdateRequest, _ ...grpc.CallOption) (*lnrpc.PolicyUpdateResponse, error) {
+	out := &lnrpc.PolicyUpdateResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/chanpolicy", in, out)
+}
+
+func (c *restClient) ListPeers(ctx context.Context, in *lnrpc.ListPeersRequest, _ ...grpc.CallOption) (*lnrpc.ListPeersResponse, error) {
+	out := &lnrpc.ListPeersResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/peers", in, out)
+}
+
+func (c *restClient) ConnectPeer(ctx context.Context, in *lnrpc.ConnectPeerRequest, _ ...grpc.CallOption) (*lnrpc.ConnectPeerResponse, error) {
+	out := &lnrpc.ConnectPeerResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/peers", in, out)
+}
+
+func (c *restClient) DisconnectPeer(ctx context.Context, in *lnrpc.DisconnectPeerRequest, _ ...grpc.CallOption) (*lnrpc.DisconnectPeerResponse, error) {
+	out := &lnrpc.DisconnectPeerResponse{}
+	return out, c.call(ctx, http.MethodDelete, "/v1/peers/"+in.PubKey, nil, out)
+}
+
+func (c *restClient) OpenChannelSync(ctx context.Context, in *lnrpc.OpenChannelRequest, _ ...grpc.CallOption) (*lnrpc.ChannelPoint, error) {
+	out := &lnrpc.ChannelPoint{}
+	return out, c.call(ctx, http.MethodPost, "/v1/channels", in, out)
+}
+
+func (c *restClient) CloseChannel(ctx context.Context, in *lnrpc.CloseChannelRequest, _ ...grpc.CallOption) (lnrpc.Lightning_CloseChannelClient, error) {
+	path := fmt.Sprintf("/v1/channels/%s/%d",
+		in.ChannelPoint.GetFundingTxidStr(), in.ChannelPoint.GetOutputIndex())
+	stream, err := c.stream(ctx, http.MethodDelete, path, in)
+	if err != nil {
+		return nil, err
+	}
+	return restCloseStatusStream{stream}, nil
+}
+
+func (c *restClient) GetNodeInfo(ctx context.Context, in *lnrpc.NodeInfoRequest, _ ...grpc.CallOption) (*lnrpc.NodeInfo, error) {
+	out := &lnrpc.NodeInfo{}
+	query := &lnrpc.NodeInfoRequest{IncludeChannels: in.IncludeChannels}
+	return out, c.call(ctx, http.MethodGet, "/v1/graph/node/"+in.PubKey, query, out)
+}
+
+func (c *restClient) ClosedChannels(ctx context.Context, in *lnrpc.ClosedChannelsRequest, _ ...grpc.CallOption) (*lnrpc.ClosedChannelsResponse, error) {
+	out := &lnrpc.ClosedChannelsResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/channels/closed", in, out)
+}
+
+func (c *restClient) ForwardingHistory(ctx context.Context, in *lnrpc.ForwardingHistoryRequest, _ ...grpc.CallOption) (*lnrpc.ForwardingHistoryResponse, error) {
+	out := &lnrpc.ForwardingHistoryResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/switch", in, out)
+}
+
+func (c *restClient) AddInvoice(ctx context.Context, in *lnrpc.Invoice, _ ...grpc.CallOption) (*lnrpc.AddInvoiceResponse, error) {
+	out := &lnrpc.AddInvoiceResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/invoices", in, out)
+}
+
+func (c *restClient) LookupInvoice(ctx context.Context, in *lnrpc.PaymentHash, _ ...grpc.CallOption) (*lnrpc.Invoice, error) {
+	out := &lnrpc.Invoice{}
+	return out, c.call(ctx, http.MethodGet, "/v1/invoice/"+in.RHashStr, nil, out)
+}
+
+func (c *restClient) ListInvoices(ctx context.Context, in *lnrpc.ListInvoiceRequest, _ ...grpc.CallOption) (*lnrpc.ListInvoiceResponse, error) {
+	out := &lnrpc.ListInvoiceResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/invoices", in, out)
+}
+
+func (c *restClient) ListPayments(ctx context.Context, in *lnrpc.ListPaymentsRequest, _ ...grpc.CallOption) (*lnrpc.ListPaymentsResponse, error) {
+	out := &lnrpc.ListPaymentsResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v1/payments", in, out)
+}
+
+func (c *restClient) DecodePayReq(ctx context.Context, in *lnrpc.PayReqString, _ ...grpc.CallOption) (*lnrpc.PayReq, error) {
+	out := &lnrpc.PayReq{}
+	return out, c.call(ctx, http.MethodGet, "/v1/payreq/"+in.PayReq, nil, out)
+}
+
+func (c *restClient) SendPaymentSync(ctx context.Context, in *lnrpc.SendRequest, _ ...grpc.CallOption) (*lnrpc.SendResponse, error) {
+	out := &lnrpc.SendResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v1/channels/transactions", in, out)
+}
+
+func (c *restClient) QueryRoutes(ctx context.Context, in *lnrpc.QueryRoutesRequest, _ ...grpc.CallOption) (*lnrpc.QueryRoutesResponse, error) {
+	out := &lnrpc.QueryRoutesResponse{}
+	query := &lnrpc.QueryRoutesRequest{FinalCltvDelta: in.FinalCltvDelta}
+	path := fmt.Sprintf("/v1/graph/routes/%s/%d", in.PubKey, in.Amt)
+	return out, c.call(ctx, http.MethodGet, path, query, out)
+}
+
+func (c *restClient) SubscribeInvoices(ctx context.Context, in *lnrpc.InvoiceSubscription, _ ...grpc.CallOption) (lnrpc.Lightning_SubscribeInvoicesClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v1/invoices/subscribe", in)
+	if err != nil {
+		return nil, err
+	}
+	return restInvoiceStream{stream}, nil
+}
+
+func (c *restClient) SubscribeTransactions(ctx context.Context, in *lnrpc.GetTransactionsRequest, _ ...grpc.CallOption) (lnrpc.Lightning_SubscribeTransactionsClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v1/transactions/subscribe", in)
+	if err != nil {
+		return nil, err
+	}
+	return restTransactionStream{stream}, nil
+}
+
+func (c *restClient) SubscribeChannelEvents(ctx context.Context, in *lnrpc.ChannelEventSubscription, _ ...grpc.CallOption) (lnrpc.Lightning_SubscribeChannelEventsClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v1/channels/subscribe", in)
+	if err != nil {
+		return nil, err
+	}
+	return restChannelEventStream{stream}, nil
+}
+
+func (c *restClient) SubscribeChannelGraph(ctx context.Context, in *lnrpc.GraphTopologySubscription, _ ...grpc.CallOption) (lnrpc.Lightning_SubscribeChannelGraphClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v1/graph/subscribe", in)
+	if err != nil {
+		return nil, err
+	}
+	return restGraphStream{stream}, nil
+}
+
+func (c *restClient) SubscribePeerEvents(ctx context.Context, in *lnrpc.PeerEventSubscription, _ ...grpc.CallOption) (lnrpc.Lightning_SubscribePeerEventsClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v1/peers/subscribe", in)
+	if err != nil {
+		return nil, err
+	}
+	return restPeerEventStream{stream}, nil
+}
+
+func (c *restClient) SubscribeHtlcEvents(ctx context.Context, in *routerrpc.SubscribeHtlcEventsRequest, _ ...grpc.CallOption) (routerrpc.Router_SubscribeHtlcEventsClient, error) {
+	stream, err := c.stream(ctx, http.MethodGet, "/v2/router/htlcevents", in)
+	if err != nil {
+		return nil, err
+	}
+	return restHtlcEventStream{stream}, nil
+}
+
+func (c *restClient) UpdateChanStatus(ctx context.Context, in *routerrpc.UpdateChanStatusRequest, _ ...grpc.CallOption) (*routerrpc.UpdateChanStatusResponse, error) {
+	out := &routerrpc.UpdateChanStatusResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v2/router/updatechanstatus", in, out)
+}
+
+func (c *restClient) EstimateFee(ctx context.Context, in *walletrpc.EstimateFeeRequest, _ ...grpc.CallOption) (*walletrpc.EstimateFeeResponse, error) {
+	out := &walletrpc.EstimateFeeResponse{}
+	return out, c.call(ctx, http.MethodGet, fmt.Sprintf("/v2/wallet/estimatefee/%d", in.ConfTarget), nil, out)
+}
+
+func (c *restClient) LeaseOutput(ctx context.Context, in *walletrpc.LeaseOutputRequest, _ ...grpc.CallOption) (*walletrpc.LeaseOutputResponse, error) {
+	out := &walletrpc.LeaseOutputResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v2/wallet/utxos/lease", in, out)
+}
+
+func (c *restClient) ReleaseOutput(ctx context.Context, in *walletrpc.ReleaseOutputRequest, _ ...grpc.CallOption) (*walletrpc.ReleaseOutputResponse, error) {
+	out := &walletrpc.ReleaseOutputResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v2/wallet/utxos/release", in, out)
+}
+
+func (c *restClient) PendingSweeps(ctx context.Context, in *walletrpc.PendingSweepsRequest, _ ...grpc.CallOption) (*walletrpc.PendingSweepsResponse, error) {
+	out := &walletrpc.PendingSweepsResponse{}
+	return out, c.call(ctx, http.MethodGet, "/v2/wallet/sweeps/pending", nil, out)
+}
+
+func (c *restClient) BumpFee(ctx context.Context, in *walletrpc.BumpFeeRequest, _ ...grpc.CallOption) (*walletrpc.BumpFeeResponse, error) {
+	out := &walletrpc.BumpFeeResponse{}
+	return out, c.call(ctx, http.MethodPost, "/v2/wallet/bumpfee", in, out)
+}
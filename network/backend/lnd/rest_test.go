@@ -0,0 +1,212 @@
+package lnd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lightningnetwork/lnd/lnrpc"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/edouardparis/lntop/config"
+)
+
+func TestProtoToQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		in   proto.Message
+		want string
+	}{
+		{
+			name: "empty message",
+			in:   &lnrpc.GetInfoRequest{},
+			want: "",
+		},
+		{
+			name: "scalars",
+			in:   &lnrpc.ListInvoiceRequest{IndexOffset: 5, NumMaxInvoices: 100, Reversed: true},
+			want: "index_offset=5&num_max_invoices=100&reversed=true",
+		},
+		{
+			name: "unset scalars are omitted",
+			in:   &lnrpc.ListInvoiceRequest{NumMaxInvoices: 100},
+			want: "num_max_invoices=100",
+		},
+		{
+			name: "url safe base64 bytes",
+			in:   &lnrpc.PaymentHash{RHash: []byte{0xff, 0xfe}},
+			want: "r_hash=__4%3D",
+		},
+		{
+			name: "enum name",
+			in:   &lnrpc.NewAddressRequest{Type: lnrpc.AddressType_NESTED_PUBKEY_HASH},
+			want: "type=NESTED_PUBKEY_HASH",
+		},
+		{
+			name: "messages and lists are omitted",
+			in: &lnrpc.QueryRoutesRequest{
+				PubKey:       "02ab",
+				Amt:          1000,
+				FeeLimit:     &lnrpc.FeeLimit{Limit: &lnrpc.FeeLimit_Fixed{Fixed: 10}},
+				IgnoredNodes: [][]byte{{0x01}},
+			},
+			want: "amt=1000&pub_key=02ab",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := protoToQuery(tt.in).Encode()
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestRestClient(t *testing.T, handler http.HandlerFunc) *restClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &restClient{address: srv.URL, macaroon: "0201", http: srv.Client()}
+}
+
+func TestRestCall(t *testing.T) {
+	c := newTestRestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Grpc-Metadata-macaroon") != "0201" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":2,"message":"verification failed"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/invoices":
+			fmt.Fprintf(w, `{"invoices":[{"memo":"%s","value":"10"}],"unknown":1}`, r.URL.RawQuery)
+		case "/v1/getinfo":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "starting")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"code":12,"message":"not implemented"}`)
+		}
+	})
+
+	resp, err := c.ListInvoices(context.Background(), &lnrpc.ListInvoiceRequest{NumMaxInvoices: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Invoices) != 1 || resp.Invoices[0].Memo != "num_max_invoices=2" || resp.Invoices[0].Value != 10 {
+		t.Errorf("got %v, want the invoice with the query as memo", resp.Invoices)
+	}
+
+	_, err = c.ListPeers(context.Background(), &lnrpc.ListPeersRequest{})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("got %v, want the code of the error body", err)
+	}
+
+	_, err = c.GetInfo(context.Background(), &lnrpc.GetInfoRequest{})
+	if status.Code(err) != codes.Unknown || status.Convert(err).Message() != "503 Service Unavailable: starting" {
+		t.Errorf("got %v, want the status and the body", err)
+	}
+
+	c.macaroon = "00"
+	_, err = c.ListInvoices(context.Background(), &lnrpc.ListInvoiceRequest{})
+	if status.Code(err) != codes.Unknown || status.Convert(err).Message() != "verification failed" {
+		t.Errorf("got %v, want the verification error", err)
+	}
+}
+
+func TestRestStream(t *testing.T) {
+	c := newTestRestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// the chunks are flushed separately and may be split anywhere.
+		chunks := []string{
+			`{"result":{"memo":"first","add_index":"1"}}` + "\n",
+			`{"result":{"memo":"sec`,
+			`ond","add_index":"2","unknown":true}}{"result":{"memo":"third"}}`,
+		}
+		if r.URL.Query().Get("add_index") == "3" {
+			chunks = append(chunks, `{"error":{"code":14,"message":"shutting down"}}`)
+		}
+		for _, chunk := range chunks {
+			fmt.Fprint(w, chunk)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	for _, tt := range []struct {
+		name     string
+		addIndex uint64
+		code     codes.Code
+	}{
+		{name: "end of the stream"},
+		{name: "error of the stream", addIndex: 3, code: codes.Unavailable},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := c.SubscribeInvoices(context.Background(), &lnrpc.InvoiceSubscription{AddIndex: tt.addIndex})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, memo := range []string{"first", "second", "third"} {
+				invoice, err := stream.Recv()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if invoice.Memo != memo {
+					t.Errorf("got invoice %q, want %q", invoice.Memo, memo)
+				}
+			}
+			_, err = stream.Recv()
+			if tt.code == codes.OK {
+				if errors.Cause(err) != io.EOF {
+					t.Errorf("got %v, want the end of the stream", err)
+				}
+				return
+			}
+			if status.Code(err) != tt.code {
+				t.Errorf("got %v, want the code %s", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestRestStreamCanceled(t *testing.T) {
+	c := newTestRestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.SubscribeInvoices(ctx, &lnrpc.InvoiceSubscription{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	_, err = stream.Recv()
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("got %v, want canceled", err)
+	}
+}
+
+func TestRestPing(t *testing.T) {
+	online := false
+	c := newTestRestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if !online {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, `{"alias":"alice"}`)
+	})
+	b := Backend{cfg: &config.Network{Name: "alice"}, rest: c}
+
+	if err := b.Ping(); err == nil {
+		t.Error("got no error from an unreachable node")
+	}
+	online = true
+	if err := b.Ping(); err != nil {
+		t.Error(err)
+	}
+}
@@ -3,11 +3,17 @@ package mock
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/gofrs/uuid"
+	"github.com/lightningnetwork/lnd/zpay32"
 	"github.com/pkg/errors"
 
 	"github.com/edouardparis/lntop/config"
@@ -15,23 +21,82 @@ import (
 	"github.com/edouardparis/lntop/network/options"
 )
 
+const (
+	// mockFeeBaseMsat and mockFeeRate are the routing policy of the nodes
+	// forwarding the payments sent by the mock.
+	mockFeeBaseMsat = 1000
+	mockFeeRate     = 100
+	// mockPaymentDuration is the time a payment sent by the mock is in
+	// flight.
+	mockPaymentDuration = 2 * time.Second
+	// mockSatPerVByte is the fee rate of a confirmation in the next block,
+	// it decreases with the confirmation target.
+	mockSatPerVByte = 50
+	// mockConfTarget is the confirmation target of the transactions sent
+	// without fee rate.
+	mockConfTarget = 6
+)
+
 type Backend struct {
 	invoices map[string]models.Invoice
 	count    uint64
 	cfg      *config.Network
 	sync.RWMutex
+
+	// state of the mocked node, loaded from the scenario and updated by
+	// its events.
+	start        time.Time
+	info         models.Info
+	utxos        []*models.Utxo
+	nodes        map[string]*models.Node
+	peers        map[string]*models.Peer
+	flaps        map[string]int32
+	channels     []*models.Channel
+	closed       []*models.ClosedChannel
+	transactions []*models.Transaction
+	forwards     []*models.ForwardingEvent
+	payments     []*models.Payment
+	htlcID       uint64
+	addresses    int
+	outputs      int
+
+	listeners   map[chan *notification]struct{}
+	listenersMu sync.Mutex
+
+	// stop is closed to end the play of the scenario.
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 func (b *Backend) Ping() error {
 	return nil
 }
 
-func (b *Backend) Info(ctx context.Context) (*models.Info, error) {
-	return nil, nil
+// Close stops the play of the scenario.
+func (b *Backend) Close() error {
+	b.stopOnce.Do(func() { close(b.stop) })
+	return nil
 }
 
-func (l *Backend) SendPayment(ctx context.Context, payreq *models.PayReq) (*models.Payment, error) {
-	return nil, nil
+func (b *Backend) Info(ctx context.Context) (*models.Info, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	info := b.info
+	info.NumPeers = uint32(len(b.peers))
+	for _, c := range b.channels {
+		switch c.Status {
+		case models.ChannelActive:
+			info.NumActiveChannels++
+		case models.ChannelInactive:
+			info.NumInactiveChannels++
+		case models.ChannelClosed:
+		default:
+			info.NumPendingChannels++
+		}
+	}
+
+	return &info, nil
 }
 
 func (b *Backend) NodeName() string {
@@ -39,60 +104,845 @@ func (b *Backend) NodeName() string {
 }
 
 func (b *Backend) SubscribeInvoice(ctx context.Context, ChannelInvoice chan *models.Invoice) error {
-	return nil
+	return b.subscribe(ctx, func(n *notification) {
+		if n.invoice != nil {
+			ChannelInvoice <- n.invoice
+		}
+	})
 }
 
-func (b *Backend) SubscribeChannels(context.Context, chan *models.ChannelUpdate) error {
-	return nil
+func (b *Backend) SubscribeChannels(ctx context.Context, events chan *models.ChannelUpdate) error {
+	return b.subscribe(ctx, func(n *notification) {
+		if n.channel != nil {
+			events <- n.channel
+		}
+	})
 }
 
 func (b *Backend) SubscribeTransactions(ctx context.Context, channel chan *models.Transaction) error {
-	return nil
+	return b.subscribe(ctx, func(n *notification) {
+		if n.transaction != nil {
+			channel <- n.transaction
+		}
+	})
 }
 
 func (b *Backend) SubscribeRoutingEvents(ctx context.Context, channel chan *models.RoutingEvent) error {
-	return nil
+	return b.subscribe(ctx, func(n *notification) {
+		if n.routing != nil {
+			channel <- n.routing
+		}
+	})
+}
+
+func (b *Backend) SubscribePeerEvents(ctx context.Context, events chan *models.PeerEvent) error {
+	return b.subscribe(ctx, func(n *notification) {
+		if n.peer != nil {
+			events <- n.peer
+		}
+	})
+}
+
+func (b *Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	return b.subscribe(ctx, func(n *notification) {
+		if n.payment != nil {
+			channel <- n.payment
+		}
+	})
 }
 
 func (b *Backend) SubscribeGraphEvents(ctx context.Context, channel chan *models.ChannelEdgeUpdate) error {
-	return nil
+	return b.subscribe(ctx, func(n *notification) {
+		if n.graph != nil {
+			channel <- n.graph
+		}
+	})
 }
 
 func (b *Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
-	return &models.Node{}, nil
+	b.RLock()
+	defer b.RUnlock()
+
+	node := &models.Node{PubKey: pubkey}
+	if n, ok := b.nodes[pubkey]; ok {
+		*node = *n
+	}
+
+	for _, c := range b.channels {
+		if c.RemotePubKey != pubkey || c.Status == models.ChannelClosed {
+			continue
+		}
+		node.NumChannels++
+		node.TotalCapacity += c.Capacity
+		if includeChannels {
+			channel := *c
+			node.Channels = append(node.Channels, &channel)
+		}
+	}
+
+	if forcedAlias, ok := b.cfg.Aliases[node.PubKey]; ok {
+		node.ForcedAlias = forcedAlias
+	}
+	return node, nil
 }
 
 func (b *Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, error) {
-	return &models.WalletBalance{}, nil
+	b.RLock()
+	defer b.RUnlock()
+
+	balance := &models.WalletBalance{}
+	for _, utxo := range b.utxos {
+		if utxo.Confirmations > 0 {
+			balance.ConfirmedBalance += utxo.Amount
+		} else {
+			balance.UnconfirmedBalance += utxo.Amount
+		}
+	}
+	balance.TotalBalance = balance.ConfirmedBalance + balance.UnconfirmedBalance
+	return balance, nil
+}
+
+// NewAddress returns a pay to witness pubkey hash address of a key derived
+// from the number of addresses returned.
+func (b *Backend) NewAddress(ctx context.Context) (string, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.newAddress(models.AddressP2WPKH), nil
+}
+
+// newAddress returns an address of the given type, the lock must be held.
+func (b *Backend) newAddress(addressType int) string {
+	b.addresses++
+	params := &chaincfg.MainNetParams
+	if b.info.Testnet {
+		params = &chaincfg.TestNet3Params
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("address %d", b.addresses)))
+
+	var address btcutil.Address
+	var err error
+	switch addressType {
+	case models.AddressP2TR:
+		address, err = btcutil.NewAddressTaproot(hash[:], params)
+	case models.AddressNP2WKH:
+		address, err = btcutil.NewAddressScriptHashFromHash(btcutil.Hash160(hash[:]), params)
+	default:
+		address, err = btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(hash[:]), params)
+	}
+	if err != nil {
+		// the hashes have the length expected by the addresses.
+		panic(err)
+	}
+	return address.EncodeAddress()
+}
+
+func (b *Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	utxos := make([]*models.Utxo, len(b.utxos))
+	for i := range b.utxos {
+		utxo := *b.utxos[i]
+		utxos[i] = &utxo
+	}
+	return utxos, nil
+}
+
+func (b *Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	if targetConf < 1 {
+		return 0, errors.New("invalid confirmation target")
+	}
+	return 1 + mockSatPerVByte/uint64(targetConf), nil
+}
+
+func (b *Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	satPerVByte := req.SatPerVByte
+	if satPerVByte == 0 {
+		target := req.TargetConf
+		if target == 0 {
+			target = mockConfTarget
+		}
+		rate, err := b.EstimateFee(ctx, target)
+		if err != nil {
+			return "", err
+		}
+		satPerVByte = rate
+	}
+
+	b.Lock()
+	amount := req.Amount
+	if req.SendAll {
+		amount = 0
+	}
+	tx, err := b.spend(amount, satPerVByte, req.Outpoints)
+	if err != nil {
+		b.Unlock()
+		return "", err
+	}
+	tx.DestAddresses = []string{req.Address}
+	b.transactions = append(b.transactions, tx)
+	b.Unlock()
+
+	sentTx := *tx
+	b.notify(&notification{transaction: &sentTx})
+	return tx.TxHash, nil
+}
+
+// BumpFee spends the output to a new address of the wallet for a CPFP or,
+// for a RBF, replaces the transaction by one taking the additional fee from
+// its change.
+func (b *Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	b.Lock()
+	tx := b.transaction(req.TxID)
+	if tx == nil || tx.NumConfirmations > 0 {
+		b.Unlock()
+		return errors.Errorf("unknown unconfirmed transaction %s", req.TxID)
+	}
+
+	bumped := tx
+	switch req.Method {
+	case models.BumpFeeCPFP:
+		utxo := b.utxo(req.Outpoint)
+		if utxo == nil || utxo.TxID != req.TxID {
+			b.Unlock()
+			return errors.Errorf("output %s is not an unspent output of the transaction", req.Outpoint)
+		}
+		child, err := b.spend(0, req.SatPerVByte, []string{req.Outpoint})
+		if err != nil {
+			b.Unlock()
+			return err
+		}
+		// the child sends the output back to the wallet, only its fee is
+		// spent.
+		b.utxos = append(b.utxos, &models.Utxo{
+			TxID:        child.TxHash,
+			Amount:      -child.Amount - child.TotalFees,
+			Address:     b.newAddress(models.AddressP2WPKH),
+			AddressType: models.AddressP2WPKH,
+		})
+		child.Amount = -child.TotalFees
+		b.transactions = append(b.transactions, child)
+		bumped = child
+	case models.BumpFeeRBF:
+		err := b.replace(tx, req.SatPerVByte)
+		if err != nil {
+			b.Unlock()
+			return err
+		}
+	default:
+		b.Unlock()
+		return errors.Errorf("unknown method %d", req.Method)
+	}
+	b.Unlock()
+
+	bumpedTx := *bumped
+	b.notify(&notification{transaction: &bumpedTx})
+	return nil
+}
+
+// replace replaces the transaction by one paying the fee rate, the
+// additional fee is taken from its change. The lock must be held.
+func (b *Backend) replace(tx *models.Transaction, satPerVByte uint64) error {
+	if tx.TotalFees == 0 || tx.VSize == 0 {
+		return errors.New("the transaction is not sent by the wallet")
+	}
+	for _, c := range b.channels {
+		if strings.HasPrefix(c.ChannelPoint, tx.TxHash+":") {
+			return errors.Errorf("the transaction funds the channel %s, it can only be bumped with a CPFP", c.ChannelPoint)
+		}
+	}
+
+	fee := int64(satPerVByte) * tx.VSize
+	if fee <= tx.TotalFees {
+		return errors.New("the fee rate is not above the rate of the transaction")
+	}
+	change := b.utxo(fmt.Sprintf("%s:1", tx.TxHash))
+	if change == nil || change.Amount <= fee-tx.TotalFees {
+		return errors.New("the change of the transaction cannot pay the fee")
+	}
+
+	hash := sha256.Sum256([]byte("replace " + tx.TxHash))
+	txid := hex.EncodeToString(hash[:])
+	change.TxID = txid
+	change.Amount -= fee - tx.TotalFees
+	tx.Amount -= fee - tx.TotalFees
+	tx.TotalFees = fee
+	tx.TxHash = txid
+	return nil
+}
+
+// receive adds the output to the wallet, its transaction id is generated as
+// well as its address when it is empty. The lock must be held.
+func (b *Backend) receive(utxo *models.Utxo) {
+	if utxo.AddressType == models.AddressUnknown {
+		utxo.AddressType = models.AddressP2WPKH
+	}
+	if utxo.Address == "" {
+		utxo.Address = b.newAddress(utxo.AddressType)
+	}
+	b.outputs++
+	hash := sha256.Sum256([]byte(fmt.Sprintf("output %d", b.outputs)))
+	utxo.TxID = hex.EncodeToString(hash[:])
+	b.utxos = append(b.utxos, utxo)
+}
+
+// spend removes from the wallet the outputs paying the amount and the fee,
+// all of them are sent when the amount is 0. The largest confirmed outputs
+// are spent first unless the outpoints are given, the change goes back to
+// the wallet. It returns the transaction, whose amount is the amount sent
+// and the fee, the lock must be held.
+func (b *Backend) spend(amount int64, satPerVByte uint64, outpoints []string) (*models.Transaction, error) {
+	candidates := []*models.Utxo{}
+	if len(outpoints) > 0 {
+		for _, outpoint := range outpoints {
+			utxo := b.utxo(outpoint)
+			if utxo == nil {
+				return nil, errors.Errorf("output %s is not an unspent output of the wallet", outpoint)
+			}
+			candidates = append(candidates, utxo)
+		}
+	} else {
+		for _, utxo := range b.utxos {
+			if utxo.Confirmations > 0 {
+				candidates = append(candidates, utxo)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Amount > candidates[j].Amount
+		})
+	}
+
+	inputs := []*models.Utxo{}
+	total, vsize := int64(0), int64(0)
+	for _, utxo := range candidates {
+		inputs = append(inputs, utxo)
+		total += utxo.Amount
+		if amount == 0 {
+			continue
+		}
+		vsize = models.EstimateVSize(inputs, 2)
+		if total >= amount+vsize*int64(satPerVByte) {
+			break
+		}
+	}
+	if amount == 0 {
+		vsize = models.EstimateVSize(inputs, 1)
+		amount = total - vsize*int64(satPerVByte)
+	}
+	fee := vsize * int64(satPerVByte)
+	if len(inputs) == 0 || amount <= 0 || total < amount+fee {
+		return nil, errors.New("insufficient funds")
+	}
+
+	spent := make(map[*models.Utxo]bool, len(inputs))
+	tx := &models.Transaction{
+		Amount:    -(amount + fee),
+		Date:      time.Now(),
+		TotalFees: fee,
+		VSize:     vsize,
+	}
+	for _, input := range inputs {
+		spent[input] = true
+		tx.Inputs = append(tx.Inputs, input.Outpoint())
+	}
+	utxos := b.utxos[:0]
+	for _, utxo := range b.utxos {
+		if !spent[utxo] {
+			utxos = append(utxos, utxo)
+		}
+	}
+	b.utxos = utxos
+
+	hash := sha256.Sum256([]byte(fmt.Sprintf("spend %s", inputs[0].Outpoint())))
+	tx.TxHash = hex.EncodeToString(hash[:])
+	if change := total - amount - fee; change > 0 {
+		b.utxos = append(b.utxos, &models.Utxo{
+			TxID:        tx.TxHash,
+			OutputIndex: 1,
+			Amount:      change,
+			Address:     b.newAddress(models.AddressP2WPKH),
+			AddressType: models.AddressP2WPKH,
+		})
+	}
+	return tx, nil
+}
+
+func (b *Backend) utxo(outpoint string) *models.Utxo {
+	for _, utxo := range b.utxos {
+		if utxo.Outpoint() == outpoint {
+			return utxo
+		}
+	}
+	return nil
+}
+
+func (b *Backend) transaction(txid string) *models.Transaction {
+	for _, tx := range b.transactions {
+		if tx.TxHash == txid {
+			return tx
+		}
+	}
+	return nil
 }
 
 func (b *Backend) GetTransactions(ctx context.Context) ([]*models.Transaction, error) {
-	return []*models.Transaction{}, nil
+	b.RLock()
+	defer b.RUnlock()
+
+	transactions := make([]*models.Transaction, len(b.transactions))
+	for i := range b.transactions {
+		tx := *b.transactions[i]
+		transactions[i] = &tx
+	}
+	return transactions, nil
 }
 
 func (b *Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
-	return &models.ChannelsBalance{}, nil
+	b.RLock()
+	defer b.RUnlock()
+
+	balance := &models.ChannelsBalance{}
+	for _, c := range b.channels {
+		switch c.Status {
+		case models.ChannelActive, models.ChannelInactive:
+			balance.Balance += c.LocalBalance
+		case models.ChannelOpening:
+			balance.PendingOpenBalance += c.LocalBalance
+		}
+	}
+	return balance, nil
 }
 
 func (b *Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*models.Channel, error) {
-	return []*models.Channel{}, nil
+	b.RLock()
+	defer b.RUnlock()
+
+	opts := options.NewChannelOptions(opt...)
+	channels := []*models.Channel{}
+	for _, c := range b.channels {
+		if c.Status == models.ChannelClosed {
+			continue
+		}
+		if c.Status != models.ChannelActive && c.Status != models.ChannelInactive {
+			if opts.Pending {
+				channels = append(channels, b.pendingChannel(c))
+			}
+			continue
+		}
+		if opts.Active && c.Status != models.ChannelActive ||
+			opts.Inactive && c.Status != models.ChannelInactive ||
+			opts.Public && c.Private ||
+			opts.Private && !c.Private {
+			continue
+		}
+		channel := *c
+		channels = append(channels, &channel)
+	}
+	return channels, nil
+}
+
+// pendingChannel returns a copy of a pending channel with the number of
+// blocks until the maturity of its outputs at the current height.
+func (b *Backend) pendingChannel(c *models.Channel) *models.Channel {
+	channel := *c
+	if c.Pending == nil {
+		return &channel
+	}
+	pending := *c.Pending
+	channel.Pending = &pending
+	if pending.MaturityHeight > 0 {
+		channel.BlocksTilMaturity = int32(pending.MaturityHeight) - int32(b.info.BlockHeight)
+	}
+	pending.HTLCs = make([]*models.PendingHTLC, len(c.Pending.HTLCs))
+	for i := range c.Pending.HTLCs {
+		htlc := *c.Pending.HTLCs[i]
+		htlc.BlocksTilMaturity = int32(htlc.MaturityHeight) - int32(b.info.BlockHeight)
+		pending.HTLCs[i] = &htlc
+	}
+	return &channel
+}
+
+func (b *Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	routed := make(map[uint64]int64)
+	for _, f := range b.forwards {
+		routed[f.ChanIdIn] += int64(f.AmtIn)
+		routed[f.ChanIdOut] += int64(f.AmtOut)
+	}
+
+	channels := make([]*models.ClosedChannel, len(b.closed))
+	for i := range b.closed {
+		c := *b.closed[i]
+		c.TotalRouted = routed[c.ID]
+		channels[i] = &c
+	}
+	return channels, nil
 }
 
 func (b *Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
+	b.RLock()
+	defer b.RUnlock()
+
+	c := b.channel(&scenarioChannel{ChannelPoint: channel.ChannelPoint})
+	if c == nil {
+		return nil
+	}
+	channel.LastUpdate = c.LastUpdate
+	channel.LocalPolicy = c.LocalPolicy
+	channel.RemotePolicy = c.RemotePolicy
 	return nil
 }
 
+func (b *Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	b.Lock()
+	c := b.channel(&scenarioChannel{ChannelPoint: channel.ChannelPoint})
+	if c == nil {
+		b.Unlock()
+		return errors.Errorf("unknown channel %s", channel.ChannelPoint)
+	}
+	now := time.Now()
+	updated := *policy
+	c.LocalPolicy = &updated
+	c.LastUpdate = &now
+	b.Unlock()
+
+	b.notify(&notification{graph: &models.ChannelEdgeUpdate{ChanPoints: []string{channel.ChannelPoint}}})
+	return nil
+}
+
+func (b *Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	peers := []*models.Peer{}
+	for _, p := range b.peers {
+		peer := *p
+		for _, c := range b.channels {
+			if c.RemotePubKey == p.PubKey {
+				peer.SatSent += c.TotalAmountSent
+				peer.SatRecv += c.TotalAmountReceived
+			}
+		}
+		peers = append(peers, &peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].PubKey < peers[j].PubKey })
+	return peers, nil
+}
+
+func (b *Backend) ConnectPeer(ctx context.Context, node string) error {
+	parts := strings.SplitN(node, "@", 2)
+	if len(parts) != 2 {
+		return errors.Errorf("invalid node %q, expected pubkey@host", node)
+	}
+
+	b.Lock()
+	if _, ok := b.peers[parts[0]]; ok {
+		b.Unlock()
+		return errors.Errorf("already connected to peer: %s", parts[0])
+	}
+	b.peers[parts[0]] = &models.Peer{PubKey: parts[0], Address: parts[1]}
+	b.Unlock()
+
+	b.notify(&notification{peer: &models.PeerEvent{PubKey: parts[0], Online: true}})
+	return nil
+}
+
+func (b *Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	b.Lock()
+	if _, ok := b.peers[pubkey]; !ok {
+		b.Unlock()
+		return errors.Errorf("peer %s is not connected", pubkey)
+	}
+	delete(b.peers, pubkey)
+	b.Unlock()
+
+	b.notify(&notification{peer: &models.PeerEvent{PubKey: pubkey}})
+	return nil
+}
+
+func (b *Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	if req.PushAmount > req.Amount {
+		return "", errors.New("push amount greater than the amount")
+	}
+
+	satPerVByte := req.SatPerVByte
+	if satPerVByte == 0 {
+		satPerVByte, _ = b.EstimateFee(ctx, mockConfTarget)
+	}
+
+	b.Lock()
+	tx, err := b.spend(req.Amount, satPerVByte, nil)
+	if err != nil {
+		b.Unlock()
+		return "", err
+	}
+	b.transactions = append(b.transactions, tx)
+	channel := &models.Channel{
+		Status:        models.ChannelOpening,
+		RemotePubKey:  req.PubKey(),
+		ChannelPoint:  fmt.Sprintf("%s:0", tx.TxHash),
+		Capacity:      req.Amount,
+		LocalBalance:  req.Amount - req.PushAmount,
+		RemoteBalance: req.PushAmount,
+		Private:       req.Private,
+		Node:          b.nodes[req.PubKey()],
+	}
+	b.channels = append(b.channels, channel)
+	b.Unlock()
+
+	fundingTx := *tx
+	b.notify(
+		&notification{channel: &models.ChannelUpdate{
+			ChannelPoint: channel.ChannelPoint,
+			Status:       channel.Status,
+		}},
+		&notification{transaction: &fundingTx},
+	)
+	return tx.TxHash, nil
+}
+
+func (b *Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	b.Lock()
+	c := b.channel(&scenarioChannel{ChannelPoint: channel.ChannelPoint})
+	if c == nil || c.Status == models.ChannelClosed {
+		b.Unlock()
+		return "", errors.Errorf("unknown channel %s", channel.ChannelPoint)
+	}
+	hash := sha256.Sum256([]byte("closing " + c.ChannelPoint))
+	c.Status = models.ChannelWaitingClose
+	c.Pending = &models.PendingChannel{ClosingTxID: fmt.Sprintf("%x", hash)}
+	if req.Force {
+		// the local balance and the htlcs are time locked by the csv delay
+		// of the channel, 144 blocks when it is unknown.
+		c.Status = models.ChannelForceClosing
+		delay := c.CSVDelay
+		if delay == 0 {
+			delay = 144
+		}
+		c.Pending.LimboBalance = c.LocalBalance
+		c.Pending.MaturityHeight = b.info.BlockHeight + delay
+		c.Pending.Anchor = models.AnchorLimbo
+		for i, htlc := range c.PendingHTLC {
+			expiry := htlc.ExpirationHeight
+			if expiry < b.info.BlockHeight {
+				expiry = b.info.BlockHeight
+			}
+			c.Pending.HTLCs = append(c.Pending.HTLCs, &models.PendingHTLC{
+				Incoming:       htlc.Incoming,
+				Amount:         htlc.Amount,
+				Outpoint:       fmt.Sprintf("%x:%d", hash, i+2),
+				MaturityHeight: expiry + delay,
+				Stage:          1,
+			})
+			c.Pending.LimboBalance += htlc.Amount
+		}
+	}
+	now := time.Now()
+	c.LastUpdate = &now
+	if c.LocalBalance > 0 {
+		b.receive(&models.Utxo{Amount: c.LocalBalance})
+	}
+	b.Unlock()
+
+	b.notify(&notification{channel: &models.ChannelUpdate{
+		ChannelPoint: channel.ChannelPoint,
+		Status:       c.Status,
+	}})
+	return c.Pending.ClosingTxID, nil
+}
+
 func (b *Backend) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
-	return &models.PayReq{}, nil
+	b.RLock()
+	params := &chaincfg.MainNetParams
+	if b.info.Testnet {
+		params = &chaincfg.TestNet3Params
+	}
+	b.RUnlock()
+
+	invoice, err := zpay32.Decode(strings.TrimSpace(payreq), params)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	req := &models.PayReq{
+		Destination: hex.EncodeToString(invoice.Destination.SerializeCompressed()),
+		Timestamp:   invoice.Timestamp.Unix(),
+		Expiry:      int64(invoice.Expiry().Seconds()),
+		CltvExpiry:  int64(invoice.MinFinalCLTVExpiry()),
+		String:      payreq,
+	}
+	if invoice.PaymentHash != nil {
+		req.PaymentHash = hex.EncodeToString(invoice.PaymentHash[:])
+	}
+	if invoice.MilliSat != nil {
+		req.Amount = int64(invoice.MilliSat.ToSatoshis())
+	}
+	if invoice.Description != nil {
+		req.Description = *invoice.Description
+	}
+	if invoice.DescriptionHash != nil {
+		req.DescriptionHash = hex.EncodeToString(invoice.DescriptionHash[:])
+	}
+	return req, nil
+}
+
+func (b *Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	_, fee, err := b.route(payreq)
+	return fee, err
+}
+
+// SendPayment pays through the channel returned by route, the payment is
+// in flight for mockPaymentDuration before it succeeds.
+func (b *Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	b.Lock()
+	channel, fee, err := b.route(payreq)
+	if err == nil && feeLimit > 0 && fee > feeLimit*1000 {
+		err = errors.New("no route")
+	}
+	now := time.Now()
+	payment := &models.Payment{
+		Index:        uint64(len(b.payments)) + 1,
+		PaymentHash:  payreq.PaymentHash,
+		Status:       models.PaymentInFlight,
+		AmountMsat:   payreq.Amount * 1000,
+		CreationDate: now,
+	}
+	if err != nil {
+		payment.Status = models.PaymentFailed
+		payment.FailureReason = err.Error()
+		b.payments = append(b.payments, payment)
+		b.Unlock()
+
+		b.notify(&notification{payment: payment})
+		return &models.Payment{PayReq: payreq, PaymentError: err.Error()}, nil
+	}
+
+	hops := []scenarioHop{{Channel: scid(channel.ID), PubKey: channel.RemotePubKey}}
+	if channel.RemotePubKey != payreq.Destination {
+		hops = append(hops, scenarioHop{PubKey: payreq.Destination})
+	}
+	route := scenarioToRoute(hops, payment.AmountMsat, fee)
+	payment.HTLCs = []*models.HTLCAttempt{{
+		Status:      models.HTLCAttemptInFlight,
+		Route:       route,
+		AttemptTime: now,
+	}}
+	b.payments = append(b.payments, payment)
+	b.Unlock()
+
+	b.notify(&notification{payment: payment})
+
+	select {
+	case <-ctx.Done():
+		return nil, errors.WithStack(ctx.Err())
+	case <-time.After(mockPaymentDuration):
+	}
+
+	b.Lock()
+	now = time.Now()
+	settled := *payment
+	settled.Status = models.PaymentSucceeded
+	settled.FeeMsat = fee
+	settled.HTLCs = []*models.HTLCAttempt{{
+		Status:      models.HTLCAttemptSucceeded,
+		Route:       route,
+		AttemptTime: payment.CreationDate,
+		ResolveTime: now,
+	}}
+	b.payments[settled.Index-1] = &settled
+	amount := (settled.AmountMsat + fee) / 1000
+	channel.LocalBalance -= amount
+	channel.RemoteBalance += amount
+	channel.TotalAmountSent += amount
+	channel.UpdatesCount++
+	b.Unlock()
+
+	b.notify(
+		&notification{payment: &settled},
+		&notification{channel: &models.ChannelUpdate{}},
+	)
+	preimage := sha256.Sum256([]byte("preimage " + payreq.PaymentHash))
+	return &models.Payment{
+		PayReq:          payreq,
+		PaymentPreimage: preimage[:],
+		Route:           route,
+	}, nil
+}
+
+// route returns the active channel with the greatest local balance able to
+// pay the payment request and the fee of the payment. A channel with the
+// destination is preferred, the other destinations are reached through the
+// peer of the channel, which charges the mockFeeBaseMsat and mockFeeRate
+// policy.
+func (b *Backend) route(payreq *models.PayReq) (*models.Channel, int64, error) {
+	if payreq.Amount <= 0 {
+		return nil, 0, errors.New("invalid amount")
+	}
+	fee := mockFeeBaseMsat + payreq.Amount*1000*mockFeeRate/1000000
+
+	var route *models.Channel
+	for _, c := range b.channels {
+		if c.Status != models.ChannelActive {
+			continue
+		}
+		if c.RemotePubKey == payreq.Destination && c.LocalBalance >= payreq.Amount {
+			return c, 0, nil
+		}
+		if c.LocalBalance*1000 >= payreq.Amount*1000+fee &&
+			(route == nil || c.LocalBalance > route.LocalBalance) {
+			route = c
+		}
+	}
+	if route == nil {
+		return nil, 0, errors.New("no route")
+	}
+	return route, fee, nil
 }
 
 func (b *Backend) GetForwardingHistory(ctx context.Context, startTime string, maxNumEvents uint32) ([]*models.ForwardingEvent, error) {
-	return []*models.ForwardingEvent{}, nil
+	start, err := options.ParseTime(startTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	b.RLock()
+	defer b.RUnlock()
+
+	events := []*models.ForwardingEvent{}
+	for _, f := range b.forwards {
+		if maxNumEvents > 0 && uint32(len(events)) >= maxNumEvents {
+			break
+		}
+		if f.EventTime.Unix() < int64(start) {
+			continue
+		}
+		event := *f
+		if node, ok := b.nodes[b.remotePubKey(event.ChanIdIn)]; ok {
+			event.PeerAliasIn = node.Alias
+		}
+		if node, ok := b.nodes[b.remotePubKey(event.ChanIdOut)]; ok {
+			event.PeerAliasOut = node.Alias
+		}
+		events = append(events, &event)
+	}
+	return events, nil
+}
+
+func (b *Backend) remotePubKey(id uint64) string {
+	if c := b.channelByID(id); c != nil {
+		return c.RemotePubKey
+	}
+	return ""
 }
 
 func (b *Backend) CreateInvoice(ctx context.Context, amt int64, desc string) (*models.Invoice, error) {
 	b.Lock()
-	defer b.Unlock()
 	b.count++
 
 	key := uuid.Must(uuid.NewV4()).String()
@@ -108,15 +958,22 @@ func (b *Backend) CreateInvoice(ctx context.Context, amt int64, desc string) (*m
 		Description:    desc,
 		CreationDate:   time.Now().Unix(),
 		Expiry:         3600,
+		State:          models.InvoiceOpen,
 		PaymentRequest: "lnbc28600u1pw9n7g7pp5enjn8exsyymyl6mlxmcvy7fdcwuh04z96swfmtasznppglgdyvsqdqqcqzysc8rve6vdwuvketcn7yp8gu3ltvq29vj588erp3at9z2msqj0yhhjdwsf7qtfy5lwf8favm6u3wr5qklvprlhrz89pknpdfxnc55wy6sqnrxjh7",
 	}
 
 	b.invoices[string(invoice.RHash)] = *invoice
+	b.Unlock()
 
+	created := *invoice
+	b.notify(&notification{invoice: &created})
 	return invoice, nil
 }
 
 func (b *Backend) GetInvoice(ctx context.Context, hash string) (*models.Invoice, error) {
+	b.RLock()
+	defer b.RUnlock()
+
 	invoice, ok := b.invoices[hash]
 	if !ok {
 		return nil, errors.New("unable to locate invoice")
@@ -125,9 +982,144 @@ func (b *Backend) GetInvoice(ctx context.Context, hash string) (*models.Invoice,
 	return &invoice, nil
 }
 
-func New(c *config.Network) *Backend {
-	return &Backend{
-		invoices: make(map[string]models.Invoice),
-		cfg:      c,
+func (b *Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	invoices := []*models.Invoice{}
+	for _, i := range b.invoices {
+		if i.Index > offset {
+			invoice := i
+			invoices = append(invoices, &invoice)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].Index < invoices[j].Index
+	})
+	if uint64(len(invoices)) > max {
+		invoices = invoices[:max]
+	}
+
+	return invoices, nil
+}
+
+func (b *Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	payments := []*models.Payment{}
+	for _, p := range b.payments {
+		if p.Index > offset && uint64(len(payments)) < max {
+			payment := *p
+			payments = append(payments, &payment)
+		}
+	}
+
+	return payments, nil
+}
+
+// load sets the state of the backend from the scenario.
+func (b *Backend) load(s *Scenario) {
+	b.info = models.Info{
+		PubKey:      s.Node.PubKey,
+		Alias:       s.Node.Alias,
+		BlockHeight: s.Node.BlockHeight,
+		Synced:      true,
+		Version:     s.Node.Version,
+		Chains:      []string{"bitcoin"},
+		Testnet:     s.Node.Testnet,
+	}
+	if b.info.Alias == "" {
+		b.info.Alias = b.cfg.Name
+	}
+	if b.info.Version == "" {
+		b.info.Version = "0.0.0-mock"
+	}
+
+	for _, u := range s.Wallet.Utxos {
+		b.receive(&models.Utxo{
+			Amount:        u.Amount,
+			Address:       u.Address,
+			AddressType:   int(u.Type),
+			Confirmations: u.Confirmations,
+		})
+	}
+	if len(s.Wallet.Utxos) == 0 {
+		if s.Wallet.Confirmed > 0 {
+			b.receive(&models.Utxo{Amount: s.Wallet.Confirmed, Confirmations: 6})
+		}
+		if s.Wallet.Unconfirmed > 0 {
+			b.receive(&models.Utxo{Amount: s.Wallet.Unconfirmed})
+		}
 	}
+
+	for _, p := range s.Peers {
+		node := &models.Node{
+			PubKey:     p.PubKey,
+			Alias:      p.Alias,
+			LastUpdate: b.start,
+		}
+		for _, addr := range p.Addresses {
+			node.Addresses = append(node.Addresses, &models.NodeAddress{Network: "tcp", Addr: addr})
+		}
+		b.nodes[p.PubKey] = node
+		b.peers[p.PubKey] = scenarioToPeer(&p)
+	}
+
+	for i := range s.Channels {
+		b.channels = append(b.channels, scenarioToChannel(&s.Channels[i], b.start))
+	}
+
+	for i := range s.ClosedChannels {
+		b.closed = append(b.closed, scenarioToClosedChannel(&s.ClosedChannels[i]))
+	}
+
+	for i := range s.Transactions {
+		b.transactions = append(b.transactions,
+			scenarioToTransaction(&s.Transactions[i], b.start, b.info.BlockHeight))
+	}
+
+	for i := range s.Forwards {
+		b.forwards = append(b.forwards, scenarioToForwardingEvent(&s.Forwards[i], b.start))
+	}
+
+	for i := range s.Payments {
+		b.payments = append(b.payments,
+			scenarioToPayment(&s.Payments[i], uint64(len(b.payments))+1, b.start))
+	}
+
+	for i := range s.Invoices {
+		b.count++
+		invoice := scenarioToInvoice(&s.Invoices[i], b.count, b.start)
+		b.invoices[string(invoice.RHash)] = *invoice
+	}
+}
+
+// New creates the mock backend, when the network has a scenario file its
+// state is loaded and its events are played back.
+func New(c *config.Network) (*Backend, error) {
+	backend := &Backend{
+		invoices:  make(map[string]models.Invoice),
+		cfg:       c,
+		start:     time.Now(),
+		nodes:     make(map[string]*models.Node),
+		peers:     make(map[string]*models.Peer),
+		flaps:     make(map[string]int32),
+		listeners: make(map[chan *notification]struct{}),
+		stop:      make(chan struct{}),
+	}
+
+	scenario := &Scenario{}
+	if c.Scenario != "" {
+		var err error
+		scenario, err = LoadScenario(c.Scenario)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	backend.load(scenario)
+	go backend.play(scenario.Events, scenario.Loop)
+
+	return backend, nil
 }
@@ -0,0 +1,662 @@
+package mock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// Scenario describes the state of the mocked node and the events played
+// back once the backend is started. Amounts are in satoshis unless the field
+// name says otherwise.
+type Scenario struct {
+	Node           scenarioNode            `toml:"node" json:"node"`
+	Wallet         scenarioWallet          `toml:"wallet" json:"wallet"`
+	Peers          []scenarioPeer          `toml:"peers" json:"peers"`
+	Channels       []scenarioChannel       `toml:"channels" json:"channels"`
+	ClosedChannels []scenarioClosedChannel `toml:"closed_channels" json:"closed_channels"`
+	Transactions   []scenarioTransaction   `toml:"transactions" json:"transactions"`
+	Forwards       []scenarioForward       `toml:"forwards" json:"forwards"`
+	Invoices       []scenarioInvoice       `toml:"invoices" json:"invoices"`
+	Payments       []scenarioPayment       `toml:"payments" json:"payments"`
+	Events         []scenarioEvent         `toml:"events" json:"events"`
+	// Loop restarts the events once the last one is played.
+	Loop bool `toml:"loop" json:"loop"`
+}
+
+type scenarioNode struct {
+	PubKey      string `toml:"pubkey" json:"pubkey"`
+	Alias       string `toml:"alias" json:"alias"`
+	Version     string `toml:"version" json:"version"`
+	BlockHeight uint32 `toml:"block_height" json:"block_height"`
+	Testnet     bool   `toml:"testnet" json:"testnet"`
+}
+
+// scenarioWallet is the on-chain wallet, it has the listed outputs or, when
+// there are none, an output of each of the confirmed and unconfirmed
+// balances.
+type scenarioWallet struct {
+	Confirmed   int64          `toml:"confirmed" json:"confirmed"`
+	Unconfirmed int64          `toml:"unconfirmed" json:"unconfirmed"`
+	Utxos       []scenarioUtxo `toml:"utxos" json:"utxos"`
+}
+
+// scenarioUtxo is an output of the wallet, its address is generated when it
+// is not given.
+type scenarioUtxo struct {
+	Amount        int64       `toml:"amount" json:"amount"`
+	Confirmations int64       `toml:"confirmations" json:"confirmations"`
+	Type          addressType `toml:"type" json:"type"`
+	Address       string      `toml:"address" json:"address"`
+}
+
+type scenarioPeer struct {
+	PubKey    string   `toml:"pubkey" json:"pubkey"`
+	Alias     string   `toml:"alias" json:"alias"`
+	Addresses []string `toml:"addresses" json:"addresses"`
+}
+
+type scenarioPolicy struct {
+	TimeLockDelta uint32 `toml:"time_lock_delta" json:"time_lock_delta"`
+	MinHtlcMsat   int64  `toml:"min_htlc_msat" json:"min_htlc_msat"`
+	MaxHtlcMsat   uint64 `toml:"max_htlc_msat" json:"max_htlc_msat"`
+	FeeBaseMsat   int64  `toml:"fee_base_msat" json:"fee_base_msat"`
+	FeeRate       int64  `toml:"fee_rate_ppm" json:"fee_rate_ppm"`
+	Disabled      bool   `toml:"disabled" json:"disabled"`
+}
+
+type scenarioChannel struct {
+	ID            scid            `toml:"id" json:"id"`
+	ChannelPoint  string          `toml:"channel_point" json:"channel_point"`
+	RemotePubKey  string          `toml:"remote_pubkey" json:"remote_pubkey"`
+	Status        channelStatus   `toml:"status" json:"status"`
+	Capacity      int64           `toml:"capacity" json:"capacity"`
+	LocalBalance  int64           `toml:"local_balance" json:"local_balance"`
+	CommitFee     int64           `toml:"commit_fee" json:"commit_fee"`
+	TotalSent     int64           `toml:"total_sent" json:"total_sent"`
+	TotalReceived int64           `toml:"total_received" json:"total_received"`
+	CSVDelay      uint32          `toml:"csv_delay" json:"csv_delay"`
+	Private       bool            `toml:"private" json:"private"`
+	LocalPolicy   *scenarioPolicy `toml:"local_policy" json:"local_policy"`
+	RemotePolicy  *scenarioPolicy `toml:"remote_policy" json:"remote_policy"`
+
+	// closing details of the pending channels.
+	ClosingTxID      string                `toml:"closing_txid" json:"closing_txid"`
+	LimboBalance     int64                 `toml:"limbo_balance" json:"limbo_balance"`
+	RecoveredBalance int64                 `toml:"recovered_balance" json:"recovered_balance"`
+	MaturityHeight   uint32                `toml:"maturity_height" json:"maturity_height"`
+	Anchor           anchorState           `toml:"anchor" json:"anchor"`
+	HTLCs            []scenarioPendingHTLC `toml:"htlcs" json:"htlcs"`
+}
+
+type scenarioPendingHTLC struct {
+	Incoming       bool   `toml:"incoming" json:"incoming"`
+	Amount         int64  `toml:"amount" json:"amount"`
+	Outpoint       string `toml:"outpoint" json:"outpoint"`
+	MaturityHeight uint32 `toml:"maturity_height" json:"maturity_height"`
+	Stage          uint32 `toml:"stage" json:"stage"`
+}
+
+type scenarioClosedChannel struct {
+	ID                scid      `toml:"id" json:"id"`
+	ChannelPoint      string    `toml:"channel_point" json:"channel_point"`
+	RemotePubKey      string    `toml:"remote_pubkey" json:"remote_pubkey"`
+	Capacity          int64     `toml:"capacity" json:"capacity"`
+	CloseType         closeType `toml:"close_type" json:"close_type"`
+	ClosingTxID       string    `toml:"closing_txid" json:"closing_txid"`
+	CloseHeight       uint32    `toml:"close_height" json:"close_height"`
+	SettledBalance    int64     `toml:"settled_balance" json:"settled_balance"`
+	TimeLockedBalance int64     `toml:"time_locked_balance" json:"time_locked_balance"`
+}
+
+type scenarioTransaction struct {
+	TxHash        string   `toml:"tx_hash" json:"tx_hash"`
+	Amount        int64    `toml:"amount" json:"amount"`
+	Fee           int64    `toml:"fee" json:"fee"`
+	Confirmations int32    `toml:"confirmations" json:"confirmations"`
+	Time          offset   `toml:"time" json:"time"`
+	Addresses     []string `toml:"addresses" json:"addresses"`
+}
+
+type scenarioForward struct {
+	Time       offset `toml:"time" json:"time"`
+	ChanIn     scid   `toml:"chan_in" json:"chan_in"`
+	ChanOut    scid   `toml:"chan_out" json:"chan_out"`
+	AmountMsat uint64 `toml:"amount_msat" json:"amount_msat"`
+	FeeMsat    uint64 `toml:"fee_msat" json:"fee_msat"`
+}
+
+type scenarioInvoice struct {
+	Amount      int64        `toml:"amount" json:"amount"`
+	AmountPaid  int64        `toml:"amount_paid" json:"amount_paid"`
+	Description string       `toml:"description" json:"description"`
+	State       invoiceState `toml:"state" json:"state"`
+	Time        offset       `toml:"time" json:"time"`
+	SettleTime  offset       `toml:"settle_time" json:"settle_time"`
+	Expiry      int64        `toml:"expiry" json:"expiry"`
+}
+
+// scenarioPayment is an outgoing payment, its attempts are made over the
+// given routes and the fee is shared by the intermediate hops. Payments with
+// the same hash are the same payment, a payment event can then update it.
+type scenarioPayment struct {
+	Hash     string            `toml:"hash" json:"hash"`
+	Amount   int64             `toml:"amount" json:"amount"`
+	FeeMsat  int64             `toml:"fee_msat" json:"fee_msat"`
+	Status   paymentStatus     `toml:"status" json:"status"`
+	Time     offset            `toml:"time" json:"time"`
+	Duration offset            `toml:"duration" json:"duration"`
+	Failure  string            `toml:"failure" json:"failure"`
+	Attempts []scenarioAttempt `toml:"attempts" json:"attempts"`
+}
+
+type scenarioAttempt struct {
+	Status  paymentStatus `toml:"status" json:"status"`
+	Failure string        `toml:"failure" json:"failure"`
+	Route   []scenarioHop `toml:"route" json:"route"`
+}
+
+type scenarioHop struct {
+	Channel scid   `toml:"channel" json:"channel"`
+	PubKey  string `toml:"pubkey" json:"pubkey"`
+}
+
+// scenarioEvent is an event played back at the given offset from the start
+// of the backend. The fields used depend on the type of the event:
+//
+//	block           a new block is mined, transactions get a confirmation.
+//	htlc            an htlc is forwarded, sent or received.
+//	channel_open    the channel is added to the channels.
+//	channel_status  the status of the channel is changed.
+//	channel_close   the channel is closed.
+//	peer_online     the peer connects.
+//	peer_offline    the peer disconnects.
+//	invoice         an invoice is settled on the channel.
+//	payment         a payment is sent or updated.
+//	transaction     an on-chain transaction is received by the wallet.
+type scenarioEvent struct {
+	At   offset `toml:"at" json:"at"`
+	Type string `toml:"type" json:"type"`
+
+	// htlc
+	Direction  string `toml:"direction" json:"direction"`
+	Status     string `toml:"status" json:"status"`
+	ChanIn     scid   `toml:"chan_in" json:"chan_in"`
+	ChanOut    scid   `toml:"chan_out" json:"chan_out"`
+	HtlcIn     uint64 `toml:"htlc_in" json:"htlc_in"`
+	HtlcOut    uint64 `toml:"htlc_out" json:"htlc_out"`
+	AmountMsat uint64 `toml:"amount_msat" json:"amount_msat"`
+	FeeMsat    uint64 `toml:"fee_msat" json:"fee_msat"`
+	Detail     string `toml:"detail" json:"detail"`
+
+	// channel_open, channel_status, channel_close
+	Channel *scenarioChannel `toml:"channel" json:"channel"`
+
+	// invoice
+	Amount      int64  `toml:"amount" json:"amount"`
+	Description string `toml:"description" json:"description"`
+
+	// transaction
+	Transaction *scenarioTransaction `toml:"transaction" json:"transaction"`
+
+	// payment
+	Payment *scenarioPayment `toml:"payment" json:"payment"`
+
+	// peer_online, peer_offline
+	PubKey string `toml:"pubkey" json:"pubkey"`
+}
+
+func (e *scenarioEvent) validate() error {
+	switch e.Type {
+	case "block", "htlc", "invoice":
+	case "channel_open", "channel_status", "channel_close":
+		if e.Channel == nil {
+			return errors.Errorf("%s event without channel", e.Type)
+		}
+	case "transaction":
+		if e.Transaction == nil {
+			return errors.New("transaction event without transaction")
+		}
+	case "payment":
+		if e.Payment == nil {
+			return errors.New("payment event without payment")
+		}
+	case "peer_online", "peer_offline":
+		if e.PubKey == "" {
+			return errors.Errorf("%s event without pubkey", e.Type)
+		}
+	default:
+		return errors.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// offset is a duration relative to the start of the backend, written as a
+// Go duration ("90s", "-2h").
+type offset time.Duration
+
+func (o *offset) UnmarshalText(text []byte) error {
+	d, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	*o = offset(d)
+	return nil
+}
+
+func (o offset) time(start time.Time) time.Time {
+	return start.Add(time.Duration(o))
+}
+
+// scid is a short channel id written as "BLOCKxTXxOUTPUT".
+type scid uint64
+
+func (s *scid) UnmarshalText(text []byte) error {
+	*s = scid(backend.ParseShortChannelID(string(text)))
+	return nil
+}
+
+// channelStatus is the status of a channel written as "active", "inactive",
+// "opening", "closing", "force_closing", "waiting_close" or "closed".
+type channelStatus int
+
+func (s *channelStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "active":
+		*s = models.ChannelActive
+	case "inactive":
+		*s = models.ChannelInactive
+	case "opening":
+		*s = models.ChannelOpening
+	case "closing":
+		*s = models.ChannelClosing
+	case "force_closing":
+		*s = models.ChannelForceClosing
+	case "waiting_close":
+		*s = models.ChannelWaitingClose
+	case "closed":
+		*s = models.ChannelClosed
+	default:
+		return errors.Errorf("unknown channel status %q", text)
+	}
+	return nil
+}
+
+// closeType is the type of the close of a channel written as "cooperative",
+// "local_force", "remote_force", "breach", "funding_canceled" or
+// "abandoned".
+type closeType int
+
+func (t *closeType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "cooperative":
+		*t = models.CloseCooperative
+	case "local_force":
+		*t = models.CloseLocalForce
+	case "remote_force":
+		*t = models.CloseRemoteForce
+	case "breach":
+		*t = models.CloseBreach
+	case "funding_canceled":
+		*t = models.CloseFundingCanceled
+	case "abandoned":
+		*t = models.CloseAbandoned
+	default:
+		return errors.Errorf("unknown close type %q", text)
+	}
+	return nil
+}
+
+// anchorState is the state of the anchor output of a force closed channel
+// written as "limbo", "recovered" or "lost".
+type anchorState int
+
+func (s *anchorState) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "limbo":
+		*s = models.AnchorLimbo
+	case "recovered":
+		*s = models.AnchorRecovered
+	case "lost":
+		*s = models.AnchorLost
+	default:
+		return errors.Errorf("unknown anchor state %q", text)
+	}
+	return nil
+}
+
+// invoiceState is the state of an invoice written as "open", "settled",
+// "canceled" or "accepted".
+type invoiceState int
+
+func (s *invoiceState) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "open":
+		*s = models.InvoiceOpen
+	case "settled":
+		*s = models.InvoiceSettled
+	case "canceled":
+		*s = models.InvoiceCanceled
+	case "accepted":
+		*s = models.InvoiceAccepted
+	default:
+		return errors.Errorf("unknown invoice state %q", text)
+	}
+	return nil
+}
+
+// addressType is the type of the address of an output written as "p2wpkh",
+// "np2wkh" or "p2tr".
+type addressType int
+
+func (t *addressType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "p2wpkh":
+		*t = models.AddressP2WPKH
+	case "np2wkh":
+		*t = models.AddressNP2WKH
+	case "p2tr":
+		*t = models.AddressP2TR
+	default:
+		return errors.Errorf("unknown address type %q", text)
+	}
+	return nil
+}
+
+// paymentStatus is the status of a payment or of an attempt written as
+// "in_flight", "succeeded" or "failed". The statuses of the payments and of
+// the attempts have the same values.
+type paymentStatus int
+
+func (s *paymentStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "in_flight":
+		*s = models.PaymentInFlight
+	case "succeeded":
+		*s = models.PaymentSucceeded
+	case "failed":
+		*s = models.PaymentFailed
+	default:
+		return errors.Errorf("unknown payment status %q", text)
+	}
+	return nil
+}
+
+// LoadScenario reads a scenario file, the format is chosen from the file
+// extension: .json for JSON and TOML otherwise.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	scenario := &Scenario{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(data, scenario)
+	} else {
+		err = toml.Unmarshal(data, scenario)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "scenario %s", path)
+	}
+
+	for i := range scenario.Events {
+		err = scenario.Events[i].validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "scenario %s: event %d", path, i)
+		}
+	}
+
+	sort.SliceStable(scenario.Events, func(i, j int) bool {
+		return scenario.Events[i].At < scenario.Events[j].At
+	})
+
+	return scenario, nil
+}
+
+func policyToRoutingPolicy(p *scenarioPolicy) *models.RoutingPolicy {
+	if p == nil {
+		return nil
+	}
+	return &models.RoutingPolicy{
+		TimeLockDelta:    p.TimeLockDelta,
+		MinHtlc:          p.MinHtlcMsat,
+		MaxHtlc:          p.MaxHtlcMsat,
+		FeeBaseMsat:      p.FeeBaseMsat,
+		FeeRateMilliMsat: p.FeeRate,
+		Disabled:         p.Disabled,
+	}
+}
+
+func scenarioToPeer(p *scenarioPeer) *models.Peer {
+	peer := &models.Peer{PubKey: p.PubKey}
+	if len(p.Addresses) > 0 {
+		peer.Address = p.Addresses[0]
+	}
+	return peer
+}
+
+func scenarioToChannel(c *scenarioChannel, start time.Time) *models.Channel {
+	status := int(c.Status)
+	if status == 0 {
+		status = models.ChannelActive
+	}
+	channel := &models.Channel{
+		ID:                  uint64(c.ID),
+		Status:              status,
+		RemotePubKey:        c.RemotePubKey,
+		ChannelPoint:        c.ChannelPoint,
+		Capacity:            c.Capacity,
+		LocalBalance:        c.LocalBalance,
+		RemoteBalance:       c.Capacity - c.LocalBalance - c.CommitFee,
+		CommitFee:           c.CommitFee,
+		TotalAmountSent:     c.TotalSent,
+		TotalAmountReceived: c.TotalReceived,
+		CSVDelay:            c.CSVDelay,
+		Private:             c.Private,
+		LastUpdate:          &start,
+		LocalPolicy:         policyToRoutingPolicy(c.LocalPolicy),
+		RemotePolicy:        policyToRoutingPolicy(c.RemotePolicy),
+	}
+	switch status {
+	case models.ChannelClosing, models.ChannelForceClosing, models.ChannelWaitingClose:
+		channel.Pending = &models.PendingChannel{
+			ClosingTxID:      c.ClosingTxID,
+			LimboBalance:     c.LimboBalance,
+			RecoveredBalance: c.RecoveredBalance,
+			MaturityHeight:   c.MaturityHeight,
+			Anchor:           int(c.Anchor),
+		}
+		for _, h := range c.HTLCs {
+			channel.Pending.HTLCs = append(channel.Pending.HTLCs, &models.PendingHTLC{
+				Incoming:       h.Incoming,
+				Amount:         h.Amount,
+				Outpoint:       h.Outpoint,
+				MaturityHeight: h.MaturityHeight,
+				Stage:          h.Stage,
+			})
+		}
+	}
+	return channel
+}
+
+func scenarioToClosedChannel(c *scenarioClosedChannel) *models.ClosedChannel {
+	return &models.ClosedChannel{
+		ID:                uint64(c.ID),
+		ChannelPoint:      c.ChannelPoint,
+		RemotePubKey:      c.RemotePubKey,
+		Capacity:          c.Capacity,
+		CloseType:         int(c.CloseType),
+		ClosingTxID:       c.ClosingTxID,
+		CloseHeight:       c.CloseHeight,
+		SettledBalance:    c.SettledBalance,
+		TimeLockedBalance: c.TimeLockedBalance,
+	}
+}
+
+func scenarioToTransaction(t *scenarioTransaction, start time.Time, height uint32) *models.Transaction {
+	tx := &models.Transaction{
+		TxHash:           t.TxHash,
+		Amount:           t.Amount,
+		NumConfirmations: t.Confirmations,
+		Date:             t.Time.time(start),
+		TotalFees:        t.Fee,
+		DestAddresses:    t.Addresses,
+	}
+	if t.Confirmations > 0 {
+		tx.BlockHeight = int32(height) - t.Confirmations + 1
+	}
+	return tx
+}
+
+func scenarioToForwardingEvent(f *scenarioForward, start time.Time) *models.ForwardingEvent {
+	return &models.ForwardingEvent{
+		ChanIdIn:   uint64(f.ChanIn),
+		ChanIdOut:  uint64(f.ChanOut),
+		AmtIn:      (f.AmountMsat + f.FeeMsat) / 1000,
+		AmtOut:     f.AmountMsat / 1000,
+		Fee:        f.FeeMsat / 1000,
+		FeeMsat:    f.FeeMsat,
+		AmtInMsat:  f.AmountMsat + f.FeeMsat,
+		AmtOutMsat: f.AmountMsat,
+		EventTime:  f.Time.time(start),
+	}
+}
+
+func scenarioToInvoice(i *scenarioInvoice, index uint64, start time.Time) *models.Invoice {
+	preimage := []byte(fmt.Sprintf("preimage %d", index))
+	hash := sha256.Sum256(preimage)
+	invoice := &models.Invoice{
+		Index:        index,
+		RPreImage:    preimage,
+		RHash:        hash[:],
+		Amount:       i.Amount,
+		Description:  i.Description,
+		CreationDate: i.Time.time(start).Unix(),
+		Expiry:       i.Expiry,
+		State:        int(i.State),
+	}
+	if invoice.Expiry == 0 {
+		invoice.Expiry = 3600
+	}
+	if invoice.State == 0 {
+		invoice.State = models.InvoiceOpen
+	}
+	if invoice.State == models.InvoiceSettled {
+		invoice.Settled = true
+		invoice.AmountPaid = i.AmountPaid
+		if invoice.AmountPaid == 0 {
+			invoice.AmountPaid = i.Amount
+		}
+		invoice.AmountPaidInMSat = invoice.AmountPaid * 1000
+		invoice.SettleDate = i.SettleTime.time(start).Unix()
+	}
+	return invoice
+}
+
+func scenarioToPayment(p *scenarioPayment, index uint64, start time.Time) *models.Payment {
+	hash := p.Hash
+	if hash == "" {
+		h := sha256.Sum256([]byte(fmt.Sprintf("payment %d", index)))
+		hash = hex.EncodeToString(h[:])
+	}
+	payment := &models.Payment{
+		Index:         index,
+		PaymentHash:   hash,
+		Status:        int(p.Status),
+		AmountMsat:    p.Amount * 1000,
+		FeeMsat:       p.FeeMsat,
+		CreationDate:  p.Time.time(start),
+		FailureReason: p.Failure,
+	}
+	if payment.Status == 0 {
+		payment.Status = models.PaymentSucceeded
+	}
+
+	resolve := payment.CreationDate.Add(time.Duration(p.Duration))
+	for i := range p.Attempts {
+		a := &p.Attempts[i]
+		attempt := &models.HTLCAttempt{
+			Status:      int(a.Status),
+			AttemptTime: payment.CreationDate,
+			Failure:     a.Failure,
+			Route:       scenarioToRoute(a.Route, payment.AmountMsat, payment.FeeMsat),
+		}
+		if attempt.Status == 0 {
+			attempt.Status = payment.Status
+		}
+		if attempt.Status != models.HTLCAttemptInFlight {
+			attempt.ResolveTime = resolve
+		}
+		payment.HTLCs = append(payment.HTLCs, attempt)
+	}
+	return payment
+}
+
+// scenarioToRoute shares the fee between the intermediate hops, like lnd the
+// amount of a hop is the amount it forwards, without its fee.
+func scenarioToRoute(hops []scenarioHop, amountMsat, feeMsat int64) *models.Route {
+	route := &models.Route{
+		Amount: (amountMsat + feeMsat) / 1000,
+		Fee:    feeMsat / 1000,
+	}
+	if len(hops) == 0 {
+		return route
+	}
+
+	var fee int64
+	if len(hops) > 1 {
+		fee = feeMsat / int64(len(hops)-1)
+	}
+	forward := amountMsat
+	route.Hops = make([]*models.Hop, len(hops))
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := &models.Hop{
+			ChanID: uint64(hops[i].Channel),
+			PubKey: hops[i].PubKey,
+			Expiry: uint32(40 * (len(hops) - i)),
+		}
+		hop.Amount = forward / 1000
+		if i < len(hops)-1 {
+			hop.Fee = fee / 1000
+			forward += fee
+		}
+		route.Hops[i] = hop
+	}
+	route.TimeLock = route.Hops[0].Expiry
+	return route
+}
+
+func routingDirection(direction string) int {
+	switch direction {
+	case "send":
+		return models.RoutingSend
+	case "receive":
+		return models.RoutingReceive
+	}
+	return models.RoutingForward
+}
+
+func routingStatus(status string) int {
+	switch status {
+	case "settled":
+		return models.RoutingStatusSettled
+	case "failed":
+		return models.RoutingStatusFailed
+	case "linkfail":
+		return models.RoutingStatusLinkFailed
+	}
+	return models.RoutingStatusActive
+}
@@ -0,0 +1,157 @@
+package mock
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+const testScenario = `
+loop = true
+
+[node]
+alias = "alice"
+block_height = 800000
+
+[wallet]
+confirmed = 100000
+
+[[channels]]
+id = "800000x1x0"
+channel_point = "aa:0"
+remote_pubkey = "02bbbb"
+status = "active"
+capacity = 1000000
+local_balance = 400000
+
+[[channels]]
+id = "800000x2x0"
+channel_point = "bb:0"
+remote_pubkey = "02cccc"
+status = "active"
+capacity = 1000000
+local_balance = 600000
+
+[[events]]
+at = "90s"
+type = "htlc"
+direction = "forward"
+status = "settled"
+chan_in = "800000x1x0"
+chan_out = "800000x2x0"
+amount_msat = 100000000
+fee_msat = 1000
+
+[[events]]
+at = "2m"
+type = "channel_close"
+[events.channel]
+id = "800000x2x0"
+`
+
+func writeScenario(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := ioutil.WriteFile(path, []byte(data), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadScenario(t *testing.T) {
+	toml := writeScenario(t, "scenario.toml", testScenario)
+	json := writeScenario(t, "scenario.json", `{
+		"loop": true,
+		"node": {"alias": "alice", "block_height": 800000},
+		"channels": [{"id": "800000x1x0", "channel_point": "aa:0", "status": "active", "capacity": 1000000}],
+		"events": [{"at": "90s", "type": "htlc", "chan_in": "800000x1x0"}]
+	}`)
+
+	for _, path := range []string{toml, json} {
+		t.Run(filepath.Ext(path), func(t *testing.T) {
+			s, err := LoadScenario(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !s.Loop || s.Node.Alias != "alice" || s.Node.BlockHeight != 800000 {
+				t.Errorf("unexpected scenario %+v", s)
+			}
+			c := s.Channels[0]
+			if c.ID != scid(800000<<40|1<<16) || c.Status != models.ChannelActive || c.Capacity != 1000000 {
+				t.Errorf("unexpected channel %+v", c)
+			}
+			e := s.Events[0]
+			if time.Duration(e.At) != 90*time.Second || e.Type != "htlc" || e.ChanIn != c.ID {
+				t.Errorf("unexpected event %+v", e)
+			}
+		})
+	}
+}
+
+func TestLoadScenarioErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		err  string
+	}{
+		{
+			name: "invalid event",
+			file: "scenario.toml",
+			data: "[[events]]\ntype = \"block\"\n[[events]]\ntype = \"payment\"\n",
+			err:  "event 1: payment event without payment",
+		},
+		{
+			name: "unknown status",
+			file: "scenario.toml",
+			data: "[[channels]]\nstatus = \"sleeping\"\n",
+			err:  `unknown channel status "sleeping"`,
+		},
+		{
+			name: "invalid offset",
+			file: "scenario.json",
+			data: `{"events": [{"at": "soon", "type": "block"}]}`,
+			err:  "invalid duration",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadScenario(writeScenario(t, tt.file, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestEventValidate(t *testing.T) {
+	tests := []struct {
+		event scenarioEvent
+		err   string
+	}{
+		{event: scenarioEvent{Type: "block"}},
+		{event: scenarioEvent{Type: "htlc"}},
+		{event: scenarioEvent{Type: "invoice"}},
+		{event: scenarioEvent{Type: "channel_open", Channel: &scenarioChannel{}}},
+		{event: scenarioEvent{Type: "channel_close"}, err: "channel_close event without channel"},
+		{event: scenarioEvent{Type: "transaction"}, err: "transaction event without transaction"},
+		{event: scenarioEvent{Type: "payment", Payment: &scenarioPayment{}}},
+		{event: scenarioEvent{Type: "peer_online"}, err: "peer_online event without pubkey"},
+		{event: scenarioEvent{Type: "peer_offline", PubKey: "02bbbb"}},
+		{event: scenarioEvent{Type: "reorg"}, err: `unknown event type "reorg"`},
+	}
+	for _, tt := range tests {
+		err := tt.event.validate()
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.event.Type, err)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("%s: got %v, want %q", tt.event.Type, err, tt.err)
+		}
+	}
+}
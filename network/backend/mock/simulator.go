@@ -0,0 +1,343 @@
+package mock
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// notification is sent to the subscribers when an event of the scenario is
+// played, only the fields concerned by the event are set.
+type notification struct {
+	invoice     *models.Invoice
+	transaction *models.Transaction
+	routing     *models.RoutingEvent
+	channel     *models.ChannelUpdate
+	graph       *models.ChannelEdgeUpdate
+	peer        *models.PeerEvent
+	payment     *models.Payment
+}
+
+// listen registers a new subscriber, the returned func unregisters it.
+func (b *Backend) listen() (chan *notification, func()) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	ch := make(chan *notification, 64)
+	b.listeners[ch] = struct{}{}
+	return ch, func() {
+		b.listenersMu.Lock()
+		defer b.listenersMu.Unlock()
+		delete(b.listeners, ch)
+	}
+}
+
+// notify sends the notifications to every subscriber, a notification is
+// dropped for a subscriber that is too slow.
+func (b *Backend) notify(notifications ...*notification) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	for ch := range b.listeners {
+		for _, n := range notifications {
+			select {
+			case ch <- n:
+			default:
+			}
+		}
+	}
+}
+
+// subscribe passes the notifications to fn until the context is canceled.
+func (b *Backend) subscribe(ctx context.Context, fn func(*notification)) error {
+	notifications, stop := b.listen()
+	defer stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-notifications:
+			fn(n)
+		}
+	}
+}
+
+// play applies the events of the scenario at their offset from start until
+// the backend is closed.
+func (b *Backend) play(events []scenarioEvent, loop bool) {
+	if len(events) == 0 {
+		return
+	}
+	start := b.start
+	for {
+		for i := range events {
+			wait := time.Until(events[i].At.time(start))
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-b.stop:
+					timer.Stop()
+					return
+				}
+			}
+			select {
+			case <-b.stop:
+				return
+			default:
+			}
+			b.notify(b.apply(&events[i])...)
+		}
+
+		// a loop of events all played at once would never end.
+		if !loop || events[len(events)-1].At <= 0 {
+			return
+		}
+		start = time.Now()
+	}
+}
+
+// apply updates the state of the backend with the event and returns the
+// notifications for the subscribers.
+func (b *Backend) apply(e *scenarioEvent) []*notification {
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	switch e.Type {
+	case "block":
+		b.info.BlockHeight++
+		for _, utxo := range b.utxos {
+			utxo.Confirmations++
+		}
+		notifications := []*notification{}
+		for _, tx := range b.transactions {
+			if tx.NumConfirmations == 0 {
+				tx.BlockHeight = int32(b.info.BlockHeight)
+				confirmed := *tx
+				confirmed.NumConfirmations = 1
+				notifications = append(notifications, &notification{transaction: &confirmed})
+			}
+			tx.NumConfirmations++
+		}
+		return notifications
+
+	case "htlc":
+		return b.applyHTLC(e, now)
+
+	case "channel_open":
+		channel := scenarioToChannel(e.Channel, now)
+		if existing := b.channel(e.Channel); existing != nil {
+			*existing = *channel
+		} else {
+			b.channels = append(b.channels, channel)
+		}
+		return []*notification{
+			{channel: &models.ChannelUpdate{ChannelPoint: channel.ChannelPoint, Status: channel.Status}},
+			{graph: &models.ChannelEdgeUpdate{ChanPoints: []string{channel.ChannelPoint}}},
+		}
+
+	case "channel_status", "channel_close":
+		channel := b.channel(e.Channel)
+		if channel == nil {
+			return nil
+		}
+		if e.Channel.Status != 0 {
+			channel.Status = int(e.Channel.Status)
+		}
+		if e.Type == "channel_close" {
+			// the channels closed by a force close request are force
+			// closed by the node.
+			closeType := models.CloseCooperative
+			if channel.Status == models.ChannelForceClosing {
+				closeType = models.CloseLocalForce
+			}
+			hash := sha256.Sum256([]byte("closing " + channel.ChannelPoint))
+			b.closed = append(b.closed, &models.ClosedChannel{
+				ID:             channel.ID,
+				ChannelPoint:   channel.ChannelPoint,
+				RemotePubKey:   channel.RemotePubKey,
+				Capacity:       channel.Capacity,
+				CloseType:      closeType,
+				ClosingTxID:    fmt.Sprintf("%x", hash),
+				CloseHeight:    b.info.BlockHeight,
+				SettledBalance: channel.LocalBalance,
+			})
+			channel.Status = models.ChannelClosed
+			if channel.LocalBalance > 0 {
+				b.receive(&models.Utxo{Amount: channel.LocalBalance})
+			}
+		}
+		channel.LastUpdate = &now
+		channel.UpdatesCount++
+		return []*notification{{channel: &models.ChannelUpdate{
+			ChannelPoint: channel.ChannelPoint,
+			Status:       channel.Status,
+		}}}
+
+	case "peer_online":
+		b.flaps[e.PubKey]++
+		peer, ok := b.peers[e.PubKey]
+		if !ok {
+			peer = &models.Peer{PubKey: e.PubKey}
+			if node, ok := b.nodes[e.PubKey]; ok && len(node.Addresses) > 0 {
+				peer.Address = node.Addresses[0].Addr
+			}
+			b.peers[e.PubKey] = peer
+		}
+		peer.FlapCount = b.flaps[e.PubKey]
+		peer.LastFlap = now
+		return []*notification{{peer: &models.PeerEvent{PubKey: e.PubKey, Online: true}}}
+
+	case "peer_offline":
+		if _, ok := b.peers[e.PubKey]; !ok {
+			return nil
+		}
+		b.flaps[e.PubKey]++
+		delete(b.peers, e.PubKey)
+		return []*notification{{peer: &models.PeerEvent{PubKey: e.PubKey}}}
+
+	case "invoice":
+		b.count++
+		preimage := []byte(fmt.Sprintf("preimage %d", b.count))
+		hash := sha256.Sum256(preimage)
+		invoice := &models.Invoice{
+			Index:            b.count,
+			RPreImage:        preimage,
+			RHash:            hash[:],
+			Amount:           e.Amount,
+			AmountPaid:       e.Amount,
+			AmountPaidInMSat: e.Amount * 1000,
+			Description:      e.Description,
+			Settled:          true,
+			State:            models.InvoiceSettled,
+			CreationDate:     now.Unix(),
+			SettleDate:       now.Unix(),
+			Expiry:           3600,
+		}
+		b.invoices[string(invoice.RHash)] = *invoice
+
+		notifications := []*notification{{invoice: invoice}}
+		if channel := b.channelByID(uint64(e.ChanIn)); channel != nil {
+			channel.LocalBalance += e.Amount
+			channel.RemoteBalance -= e.Amount
+			channel.TotalAmountReceived += e.Amount
+			channel.UpdatesCount++
+			notifications = append(notifications, &notification{channel: &models.ChannelUpdate{}})
+		}
+		return notifications
+
+	case "payment":
+		payment := scenarioToPayment(e.Payment, uint64(len(b.payments))+1, now)
+		for i := range b.payments {
+			if b.payments[i].PaymentHash == payment.PaymentHash {
+				payment.Index = b.payments[i].Index
+				payment.CreationDate = b.payments[i].CreationDate
+				b.payments[i] = payment
+				return []*notification{{payment: payment}}
+			}
+		}
+		b.payments = append(b.payments, payment)
+		return []*notification{{payment: payment}}
+
+	case "transaction":
+		tx := scenarioToTransaction(e.Transaction, now, b.info.BlockHeight)
+		b.transactions = append(b.transactions, tx)
+		if tx.Amount > 0 {
+			utxo := &models.Utxo{Amount: tx.Amount, Confirmations: int64(tx.NumConfirmations)}
+			if len(tx.DestAddresses) > 0 {
+				utxo.Address = tx.DestAddresses[0]
+			}
+			b.receive(utxo)
+		} else if tx.Amount < 0 {
+			// the amount sent includes the fee.
+			b.spend(-tx.Amount, 0, nil)
+		}
+		received := *tx
+		return []*notification{{transaction: &received}}
+	}
+	return nil
+}
+
+func (b *Backend) applyHTLC(e *scenarioEvent, now time.Time) []*notification {
+	htlcIn, htlcOut := e.HtlcIn, e.HtlcOut
+	if htlcIn == 0 && htlcOut == 0 {
+		b.htlcID++
+		htlcIn, htlcOut = b.htlcID, b.htlcID
+	}
+
+	event := &models.RoutingEvent{
+		IncomingChannelId: uint64(e.ChanIn),
+		OutgoingChannelId: uint64(e.ChanOut),
+		IncomingHtlcId:    htlcIn,
+		OutgoingHtlcId:    htlcOut,
+		LastUpdate:        now,
+		Direction:         routingDirection(e.Direction),
+		Status:            routingStatus(e.Status),
+		IncomingTimelock:  b.info.BlockHeight + 80,
+		OutgoingTimelock:  b.info.BlockHeight + 40,
+		AmountMsat:        e.AmountMsat,
+		FeeMsat:           e.FeeMsat,
+		FailureDetail:     e.Detail,
+	}
+	notifications := []*notification{{routing: event}}
+	if event.Status != models.RoutingStatusSettled {
+		return notifications
+	}
+
+	amountIn := int64(e.AmountMsat+e.FeeMsat) / 1000
+	amountOut := int64(e.AmountMsat) / 1000
+	if event.Direction == models.RoutingSend {
+		amountOut = amountIn
+	}
+	if event.Direction != models.RoutingSend {
+		if channel := b.channelByID(event.IncomingChannelId); channel != nil {
+			channel.LocalBalance += amountIn
+			channel.RemoteBalance -= amountIn
+			channel.TotalAmountReceived += amountIn
+			channel.UpdatesCount++
+		}
+	}
+	if event.Direction != models.RoutingReceive {
+		if channel := b.channelByID(event.OutgoingChannelId); channel != nil {
+			channel.LocalBalance -= amountOut
+			channel.RemoteBalance += amountOut
+			channel.TotalAmountSent += amountOut
+			channel.UpdatesCount++
+		}
+	}
+	if event.Direction == models.RoutingForward {
+		b.forwards = append(b.forwards, &models.ForwardingEvent{
+			ChanIdIn:   event.IncomingChannelId,
+			ChanIdOut:  event.OutgoingChannelId,
+			AmtIn:      uint64(amountIn),
+			AmtOut:     uint64(amountOut),
+			Fee:        e.FeeMsat / 1000,
+			FeeMsat:    e.FeeMsat,
+			AmtInMsat:  e.AmountMsat + e.FeeMsat,
+			AmtOutMsat: e.AmountMsat,
+			EventTime:  now,
+		})
+	}
+
+	return append(notifications, &notification{channel: &models.ChannelUpdate{}})
+}
+
+// channel returns the channel matching the id or the channel point of c.
+func (b *Backend) channel(c *scenarioChannel) *models.Channel {
+	for _, channel := range b.channels {
+		if (c.ID != 0 && channel.ID == uint64(c.ID)) ||
+			(c.ChannelPoint != "" && channel.ChannelPoint == c.ChannelPoint) {
+			return channel
+		}
+	}
+	return nil
+}
+
+func (b *Backend) channelByID(id uint64) *models.Channel {
+	if id == 0 {
+		return nil
+	}
+	return b.channel(&scenarioChannel{ID: scid(id)})
+}
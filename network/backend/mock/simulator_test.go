@@ -0,0 +1,152 @@
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// newTestBackend returns a backend of the scenario, its events are far
+// enough to be applied by the tests.
+func newTestBackend(t *testing.T, scenario string) *Backend {
+	t.Helper()
+	b, err := New(&config.Network{Name: "mock", Scenario: writeScenario(t, "scenario.toml", scenario)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestApplyEvents(t *testing.T) {
+	b := newTestBackend(t, testScenario)
+	s, err := LoadScenario(b.cfg.Scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	notifications := b.apply(&s.Events[0])
+	if len(notifications) != 2 || notifications[0].routing == nil || notifications[1].channel == nil {
+		t.Fatalf("unexpected notifications %+v", notifications)
+	}
+	if e := notifications[0].routing; e.Status != models.RoutingStatusSettled ||
+		e.Direction != models.RoutingForward || e.AmountMsat != 100000000 || e.FeeMsat != 1000 {
+		t.Errorf("unexpected routing event %+v", e)
+	}
+
+	// the incoming channel receives the amount and the fee, the outgoing
+	// one sends the amount.
+	channels, err := b.ListChannels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balances := map[string]int64{}
+	for _, c := range channels {
+		balances[c.ChannelPoint] = c.LocalBalance
+		if c.LocalBalance+c.RemoteBalance != c.Capacity {
+			t.Errorf("channel %s: balances %d and %d do not add up to the capacity",
+				c.ChannelPoint, c.LocalBalance, c.RemoteBalance)
+		}
+	}
+	if balances["aa:0"] != 500001 || balances["bb:0"] != 500000 {
+		t.Errorf("unexpected balances %v", balances)
+	}
+	forwards, err := b.GetForwardingHistory(ctx, "0", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forwards) != 1 || forwards[0].AmtIn != 100001 || forwards[0].AmtOut != 100000 || forwards[0].FeeMsat != 1000 {
+		t.Errorf("unexpected forwards %+v", forwards)
+	}
+
+	utxos, err := b.ListUnspent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifications = b.apply(&s.Events[1])
+	if len(notifications) != 1 || notifications[0].channel.Status != models.ChannelClosed {
+		t.Fatalf("unexpected notifications %+v", notifications)
+	}
+
+	closed, err := b.ClosedChannels(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 1 || closed[0].ChannelPoint != "bb:0" ||
+		closed[0].CloseType != models.CloseCooperative || closed[0].SettledBalance != 500000 {
+		t.Errorf("unexpected closed channels %+v", closed)
+	}
+	after, err := b.ListUnspent(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(utxos)+1 || after[len(after)-1].Amount != 500000 {
+		t.Errorf("got %d outputs, want the balance of the closed channel added to %d", len(after), len(utxos))
+	}
+}
+
+func TestApplyFailedHTLC(t *testing.T) {
+	b := newTestBackend(t, testScenario)
+	b.apply(&scenarioEvent{
+		Type:       "htlc",
+		Direction:  "forward",
+		Status:     "failed",
+		ChanIn:     scid(800000<<40 | 1<<16),
+		ChanOut:    scid(800000<<40 | 2<<16),
+		AmountMsat: 100000000,
+	})
+
+	channels, err := b.ListChannels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range channels {
+		if c.UpdatesCount != 0 {
+			t.Errorf("channel %s updated by a failed htlc", c.ChannelPoint)
+		}
+	}
+}
+
+func TestPlayStopsOnClose(t *testing.T) {
+	b := newTestBackend(t, `
+loop = true
+
+[[events]]
+at = "10ms"
+type = "block"
+`)
+	info, err := b.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := info.BlockHeight
+
+	// the loop replays the block.
+	for info.BlockHeight < height+2 {
+		time.Sleep(10 * time.Millisecond)
+		info, err = b.Info(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b.Close()
+	time.Sleep(50 * time.Millisecond)
+	info, err = b.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	height = info.BlockHeight
+	time.Sleep(50 * time.Millisecond)
+	info, err = b.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.BlockHeight != height {
+		t.Errorf("got %d blocks after the close", info.BlockHeight-height)
+	}
+}
@@ -0,0 +1,352 @@
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+// Backend is a backend decorator recording the results of the calls and the
+// messages of the subscriptions of the backend it wraps.
+type Backend struct {
+	backend.Backend
+	recorder *Recorder
+	logger   logging.Logger
+}
+
+// New wraps the backend b, the records are written by the recorder.
+func New(b backend.Backend, recorder *Recorder, logger logging.Logger) *Backend {
+	return &Backend{
+		Backend:  b,
+		recorder: recorder,
+		logger:   logger,
+	}
+}
+
+// Close closes the wrapped backend, the recorder is closed by its owner.
+func (b *Backend) Close() error {
+	if c, ok := b.Backend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// record writes a record of the method, a failure to record is only logged
+// to not disturb the session.
+func (b *Backend) record(method string, args, data interface{}, callErr error) {
+	r := &Record{
+		Time:   time.Now(),
+		Node:   b.NodeName(),
+		Method: method,
+	}
+
+	var err error
+	if args != nil {
+		r.Args, err = json.Marshal(args)
+		if err != nil {
+			b.logger.Error("record args", logging.String("method", method), logging.Error(err))
+			return
+		}
+	}
+	if callErr != nil {
+		r.Error = callErr.Error()
+	} else if data != nil {
+		r.Data, err = json.Marshal(data)
+		if err != nil {
+			b.logger.Error("record data", logging.String("method", method), logging.Error(err))
+			return
+		}
+	}
+
+	err = b.recorder.Write(r)
+	if err != nil {
+		b.logger.Error("record", logging.String("method", method), logging.Error(err))
+	}
+}
+
+func (b *Backend) Info(ctx context.Context) (*models.Info, error) {
+	info, err := b.Backend.Info(ctx)
+	b.record("Info", nil, info, err)
+	return info, err
+}
+
+func (b *Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
+	node, err := b.Backend.GetNode(ctx, pubkey, includeChannels)
+	b.record("GetNode", []interface{}{pubkey, includeChannels}, node, err)
+	return node, err
+}
+
+func (b *Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, error) {
+	balance, err := b.Backend.GetWalletBalance(ctx)
+	b.record("GetWalletBalance", nil, balance, err)
+	return balance, err
+}
+
+func (b *Backend) NewAddress(ctx context.Context) (string, error) {
+	address, err := b.Backend.NewAddress(ctx)
+	b.record("NewAddress", nil, address, err)
+	return address, err
+}
+
+func (b *Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	utxos, err := b.Backend.ListUnspent(ctx)
+	b.record("ListUnspent", nil, utxos, err)
+	return utxos, err
+}
+
+func (b *Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	satPerVByte, err := b.Backend.EstimateFee(ctx, targetConf)
+	b.record("EstimateFee", targetConf, satPerVByte, err)
+	return satPerVByte, err
+}
+
+func (b *Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	txid, err := b.Backend.SendCoins(ctx, req)
+	b.record("SendCoins", req, txid, err)
+	return txid, err
+}
+
+func (b *Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	err := b.Backend.BumpFee(ctx, req)
+	b.record("BumpFee", req, nil, err)
+	return err
+}
+
+func (b *Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
+	balance, err := b.Backend.GetChannelsBalance(ctx)
+	b.record("GetChannelsBalance", nil, balance, err)
+	return balance, err
+}
+
+func (b *Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*models.Channel, error) {
+	channels, err := b.Backend.ListChannels(ctx, opt...)
+	b.record("ListChannels", options.NewChannelOptions(opt...), channels, err)
+	return channels, err
+}
+
+func (b *Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	channels, err := b.Backend.ClosedChannels(ctx)
+	b.record("ClosedChannels", nil, channels, err)
+	return channels, err
+}
+
+func (b *Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
+	err := b.Backend.GetChannelInfo(ctx, channel)
+	b.record("GetChannelInfo", channel.ChannelPoint, channel, err)
+	return err
+}
+
+func (b *Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	err := b.Backend.UpdateChannelPolicy(ctx, channel, policy)
+	b.record("UpdateChannelPolicy", []interface{}{channel.ChannelPoint, policy}, nil, err)
+	return err
+}
+
+func (b *Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	peers, err := b.Backend.ListPeers(ctx)
+	b.record("ListPeers", nil, peers, err)
+	return peers, err
+}
+
+func (b *Backend) ConnectPeer(ctx context.Context, node string) error {
+	err := b.Backend.ConnectPeer(ctx, node)
+	b.record("ConnectPeer", node, nil, err)
+	return err
+}
+
+func (b *Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	err := b.Backend.DisconnectPeer(ctx, pubkey)
+	b.record("DisconnectPeer", pubkey, nil, err)
+	return err
+}
+
+func (b *Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	txid, err := b.Backend.OpenChannel(ctx, req)
+	b.record("OpenChannel", req, txid, err)
+	return txid, err
+}
+
+func (b *Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	txid, err := b.Backend.CloseChannel(ctx, channel, req)
+	b.record("CloseChannel", []interface{}{channel.ChannelPoint, req}, txid, err)
+	return txid, err
+}
+
+func (b *Backend) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
+	invoice, err := b.Backend.CreateInvoice(ctx, amount, desc)
+	b.record("CreateInvoice", []interface{}{amount, desc}, invoice, err)
+	return invoice, err
+}
+
+func (b *Backend) GetInvoice(ctx context.Context, hash string) (*models.Invoice, error) {
+	invoice, err := b.Backend.GetInvoice(ctx, hash)
+	b.record("GetInvoice", hash, invoice, err)
+	return invoice, err
+}
+
+func (b *Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	invoices, err := b.Backend.ListInvoices(ctx, offset, max)
+	b.record("ListInvoices", []interface{}{offset, max}, invoices, err)
+	return invoices, err
+}
+
+func (b *Backend) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
+	p, err := b.Backend.DecodePayReq(ctx, payreq)
+	b.record("DecodePayReq", payreq, p, err)
+	return p, err
+}
+
+func (b *Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	payments, err := b.Backend.ListPayments(ctx, offset, max)
+	b.record("ListPayments", []interface{}{offset, max}, payments, err)
+	return payments, err
+}
+
+func (b *Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	fee, err := b.Backend.EstimateRouteFee(ctx, payreq)
+	b.record("EstimateRouteFee", payreq, fee, err)
+	return fee, err
+}
+
+func (b *Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	payment, err := b.Backend.SendPayment(ctx, payreq, feeLimit)
+	b.record("SendPayment", []interface{}{payreq, feeLimit}, payment, err)
+	return payment, err
+}
+
+func (b *Backend) GetTransactions(ctx context.Context) ([]*models.Transaction, error) {
+	transactions, err := b.Backend.GetTransactions(ctx)
+	b.record("GetTransactions", nil, transactions, err)
+	return transactions, err
+}
+
+func (b *Backend) GetForwardingHistory(ctx context.Context, startTime string, maxNumEvents uint32) ([]*models.ForwardingEvent, error) {
+	events, err := b.Backend.GetForwardingHistory(ctx, startTime, maxNumEvents)
+	b.record("GetForwardingHistory", []interface{}{startTime, maxNumEvents}, events, err)
+	return events, err
+}
+
+func (b *Backend) SubscribeInvoice(ctx context.Context, channel chan *models.Invoice) error {
+	invoices := make(chan *models.Invoice)
+	done := make(chan struct{})
+	go func() {
+		for invoice := range invoices {
+			b.record("SubscribeInvoice", nil, invoice, nil)
+			channel <- invoice
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribeInvoice(ctx, invoices)
+	close(invoices)
+	<-done
+	return err
+}
+
+func (b *Backend) SubscribeChannels(ctx context.Context, channel chan *models.ChannelUpdate) error {
+	updates := make(chan *models.ChannelUpdate)
+	done := make(chan struct{})
+	go func() {
+		for update := range updates {
+			b.record("SubscribeChannels", nil, update, nil)
+			channel <- update
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribeChannels(ctx, updates)
+	close(updates)
+	<-done
+	return err
+}
+
+func (b *Backend) SubscribeTransactions(ctx context.Context, channel chan *models.Transaction) error {
+	transactions := make(chan *models.Transaction)
+	done := make(chan struct{})
+	go func() {
+		for tx := range transactions {
+			b.record("SubscribeTransactions", nil, tx, nil)
+			channel <- tx
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribeTransactions(ctx, transactions)
+	close(transactions)
+	<-done
+	return err
+}
+
+func (b *Backend) SubscribeRoutingEvents(ctx context.Context, channel chan *models.RoutingEvent) error {
+	events := make(chan *models.RoutingEvent)
+	done := make(chan struct{})
+	go func() {
+		for event := range events {
+			b.record("SubscribeRoutingEvents", nil, event, nil)
+			channel <- event
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribeRoutingEvents(ctx, events)
+	close(events)
+	<-done
+	return err
+}
+
+func (b *Backend) SubscribeGraphEvents(ctx context.Context, channel chan *models.ChannelEdgeUpdate) error {
+	updates := make(chan *models.ChannelEdgeUpdate)
+	done := make(chan struct{})
+	go func() {
+		for update := range updates {
+			b.record("SubscribeGraphEvents", nil, update, nil)
+			channel <- update
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribeGraphEvents(ctx, updates)
+	close(updates)
+	<-done
+	return err
+}
+
+func (b *Backend) SubscribePeerEvents(ctx context.Context, channel chan *models.PeerEvent) error {
+	events := make(chan *models.PeerEvent)
+	done := make(chan struct{})
+	go func() {
+		for event := range events {
+			b.record("SubscribePeerEvents", nil, event, nil)
+			channel <- event
+		}
+		close(done)
+	}()
+
+	err := b.Backend.SubscribePeerEvents(ctx, events)
+	close(events)
+	<-done
+	return err
+}
+
+func (b *Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	payments := make(chan *models.Payment)
+	done := make(chan struct{})
+	go func() {
+		for payment := range payments {
+			b.record("TrackPayments", nil, payment, nil)
+			channel <- payment
+		}
+		close(done)
+	}()
+
+	err := b.Backend.TrackPayments(ctx, payments)
+	close(payments)
+	<-done
+	return err
+}
@@ -0,0 +1,111 @@
+// Package record records the sessions of a backend to be played back later
+// by the replay backend.
+package record
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Record is a line of a recording: the result of a call or a message of a
+// subscription made by the backend of a node.
+type Record struct {
+	Time   time.Time       `json:"time"`
+	Node   string          `json:"node"`
+	Method string          `json:"method"`
+	Args   json.RawMessage `json:"args,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Recorder writes the records of one or several backends to a file, one
+// JSON object per line.
+type Recorder struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+// Create creates or truncates the recording file.
+func Create(path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Recorder{file: file}, nil
+}
+
+// Write appends a record to the file, the whole line is written at once so
+// that a recording cut short by a crash stays readable.
+func (r *Recorder) Write(record *Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.file.Write(line)
+	return errors.WithStack(err)
+}
+
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// Load reads all the records of a recording file ordered by time. A last
+// line left incomplete is ignored.
+func Load(path string) ([]*Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	records := []*Record{}
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		record := &Record{}
+		err = json.Unmarshal(line, record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "recording %s: line %d", path, n)
+		}
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// Nodes returns the names of the nodes of the records in the order of their
+// first record.
+func Nodes(records []*Record) []string {
+	nodes := []string{}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if !seen[r.Node] {
+			seen[r.Node] = true
+			nodes = append(nodes, r.Node)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,144 @@
+package replay
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the clock of a replay, it is shared by the nodes of a recording
+// so that they are played together. It runs speed times faster than the wall
+// clock until it is paused, a paused clock can be stepped from one message
+// to the next.
+type Clock struct {
+	mu sync.Mutex
+	// the clock was at origin at the wall clock time start.
+	origin time.Time
+	start  time.Time
+	speed  float64
+	paused bool
+	// times are the times of the messages of all the nodes, in order.
+	times []time.Time
+	// changed is closed when the clock is paused, resumed or stepped.
+	changed chan struct{}
+}
+
+var (
+	clocksMu sync.Mutex
+	// clocks are the clocks of the recordings by path.
+	clocks = make(map[string]*Clock)
+)
+
+// clockOf returns the clock of the recording, it is created by the first
+// node of the recording at its first record.
+func clockOf(path string, origin time.Time, speed float64) *Clock {
+	clocksMu.Lock()
+	defer clocksMu.Unlock()
+	c, ok := clocks[path]
+	if !ok {
+		c = &Clock{
+			origin:  origin,
+			start:   time.Now(),
+			speed:   speed,
+			changed: make(chan struct{}),
+		}
+		clocks[path] = c
+	}
+	return c
+}
+
+// add adds the times of the messages of a node.
+func (c *Clock) add(times []time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.times = append(c.times, times...)
+	sort.Slice(c.times, func(i, j int) bool { return c.times[i].Before(c.times[j]) })
+}
+
+// Now returns the time of the recording being played.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now()
+}
+
+func (c *Clock) now() time.Time {
+	if c.paused {
+		return c.origin
+	}
+	return c.origin.Add(time.Duration(float64(time.Since(c.start)) * c.speed))
+}
+
+// Pause pauses the clock or resumes it if it is paused, it returns true if
+// the clock is paused.
+func (c *Clock) Pause() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.start = time.Now()
+	} else {
+		c.origin = c.now()
+	}
+	c.paused = !c.paused
+	c.notify()
+	return c.paused
+}
+
+// Step pauses the clock at the time of the next message of the recording,
+// it returns false if there is none.
+func (c *Clock) Step() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	i := sort.Search(len(c.times), func(i int) bool {
+		return c.times[i].After(now)
+	})
+	c.paused = true
+	c.origin = now
+	if i < len(c.times) {
+		c.origin = c.times[i]
+	}
+	c.notify()
+	return i < len(c.times)
+}
+
+// notify wakes up the messages waiting for the clock, the caller must hold
+// the lock.
+func (c *Clock) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// wait waits until the clock reaches the time t or the context is canceled.
+func (c *Clock) wait(ctx context.Context, t time.Time) error {
+	for {
+		c.mu.Lock()
+		now := c.now()
+		if !now.Before(t) {
+			c.mu.Unlock()
+			return nil
+		}
+		changed := c.changed
+		// a paused clock only moves when it is resumed or stepped.
+		var timer *time.Timer
+		var due <-chan time.Time
+		if !c.paused {
+			timer = time.NewTimer(time.Duration(float64(t.Sub(now)) / c.speed))
+			due = timer.C
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,500 @@
+// Package replay plays back a session of a node recorded by the record
+// package.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend/record"
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+)
+
+// Backend answers the calls with the results recorded at the time of the
+// replay clock and sends the recorded messages to the subscriptions when the
+// clock reaches them. The clock starts at the first record of the recording
+// and runs speed times faster than the wall clock, it can be paused and
+// stepped.
+type Backend struct {
+	cfg    *config.Network
+	logger logging.Logger
+
+	// calls are the results of the calls by method.
+	calls map[string][]*record.Record
+	// messages are the messages of the subscriptions by method.
+	messages map[string][]*record.Record
+	// times are the times of all the messages, in order.
+	times []time.Time
+
+	clock *Clock
+}
+
+// Pause pauses the replay or resumes it if it is paused, it returns true if
+// the replay is paused. The nodes of the recording are paused together.
+func (b *Backend) Pause() bool {
+	return b.clock.Pause()
+}
+
+// Step pauses the replay at the next message of the recording, it returns
+// false if all the messages were played.
+func (b *Backend) Step() bool {
+	return b.clock.Step()
+}
+
+// callLag is the time after a message during which the calls recorded are
+// the ones the message caused, the refreshes of the node that follow it.
+const callLag = 5 * time.Second
+
+// horizon returns the time of the next message to play.
+func (b *Backend) horizon(now time.Time) time.Time {
+	i := sort.Search(len(b.times), func(i int) bool {
+		return b.times[i].After(now)
+	})
+	if i == len(b.times) {
+		return time.Time{}
+	}
+	return b.times[i]
+}
+
+// lookup decodes into v the last result of the call recorded before the
+// replay clock, or the first one if the call was only made later. The calls
+// recorded within callLag after the clock are included unless the next
+// message comes first, they answer the refreshes caused by the last message
+// played.
+func (b *Backend) lookup(method string, args interface{}, v interface{}) error {
+	var key []byte
+	if args != nil {
+		var err error
+		key, err = json.Marshal(args)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	now := b.clock.Now()
+	bound := now.Add(callLag)
+	if horizon := b.horizon(now); !horizon.IsZero() && horizon.Before(bound) {
+		bound = horizon
+	}
+	var found *record.Record
+	for _, r := range b.calls[method] {
+		if !bytes.Equal(r.Args, key) {
+			continue
+		}
+		if found != nil && !r.Time.Before(bound) {
+			break
+		}
+		found = r
+	}
+	if found == nil {
+		return errors.Errorf("%s%s was not recorded", method, key)
+	}
+
+	if found.Error != "" {
+		return errors.New(found.Error)
+	}
+	if len(found.Data) == 0 {
+		return nil
+	}
+	return errors.WithStack(json.Unmarshal(found.Data, v))
+}
+
+// subscribe calls fn with each message of the subscription when it is due,
+// until the context is canceled or the messages run out. The messages already
+// due are sent at once.
+func (b *Backend) subscribe(ctx context.Context, method string, fn func(json.RawMessage) error) error {
+	for _, r := range b.messages[method] {
+		err := b.clock.wait(ctx, r.Time)
+		if err != nil {
+			return nil
+		}
+
+		err = fn(r.Data)
+		if err != nil {
+			b.logger.Error("replay message", logging.String("method", method), logging.Error(err))
+		}
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+func (b *Backend) Ping() error {
+	return nil
+}
+
+func (b *Backend) NodeName() string {
+	return b.cfg.Name
+}
+
+func (b *Backend) Info(ctx context.Context) (*models.Info, error) {
+	info := &models.Info{}
+	err := b.lookup("Info", nil, info)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (b *Backend) GetNode(ctx context.Context, pubkey string, includeChannels bool) (*models.Node, error) {
+	node := &models.Node{}
+	err := b.lookup("GetNode", []interface{}{pubkey, includeChannels}, node)
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (b *Backend) GetWalletBalance(ctx context.Context) (*models.WalletBalance, error) {
+	balance := &models.WalletBalance{}
+	err := b.lookup("GetWalletBalance", nil, balance)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (b *Backend) NewAddress(ctx context.Context) (string, error) {
+	var address string
+	err := b.lookup("NewAddress", nil, &address)
+	if err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+func (b *Backend) ListUnspent(ctx context.Context) ([]*models.Utxo, error) {
+	utxos := []*models.Utxo{}
+	err := b.lookup("ListUnspent", nil, &utxos)
+	if err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+func (b *Backend) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	var satPerVByte uint64
+	err := b.lookup("EstimateFee", targetConf, &satPerVByte)
+	if err != nil {
+		return 0, err
+	}
+	return satPerVByte, nil
+}
+
+func (b *Backend) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	var txid string
+	err := b.lookup("SendCoins", req, &txid)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+func (b *Backend) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	return b.lookup("BumpFee", req, nil)
+}
+
+func (b *Backend) GetChannelsBalance(ctx context.Context) (*models.ChannelsBalance, error) {
+	balance := &models.ChannelsBalance{}
+	err := b.lookup("GetChannelsBalance", nil, balance)
+	if err != nil {
+		return nil, err
+	}
+	return balance, nil
+}
+
+func (b *Backend) ListChannels(ctx context.Context, opt ...options.Channel) ([]*models.Channel, error) {
+	channels := []*models.Channel{}
+	err := b.lookup("ListChannels", options.NewChannelOptions(opt...), &channels)
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (b *Backend) ClosedChannels(ctx context.Context) ([]*models.ClosedChannel, error) {
+	channels := []*models.ClosedChannel{}
+	err := b.lookup("ClosedChannels", nil, &channels)
+	if err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+func (b *Backend) GetChannelInfo(ctx context.Context, channel *models.Channel) error {
+	recorded := &models.Channel{}
+	err := b.lookup("GetChannelInfo", channel.ChannelPoint, recorded)
+	if err != nil {
+		return err
+	}
+	channel.LastUpdate = recorded.LastUpdate
+	channel.LocalPolicy = recorded.LocalPolicy
+	channel.RemotePolicy = recorded.RemotePolicy
+	return nil
+}
+
+func (b *Backend) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	return b.lookup("UpdateChannelPolicy", []interface{}{channel.ChannelPoint, policy}, nil)
+}
+
+func (b *Backend) ListPeers(ctx context.Context) ([]*models.Peer, error) {
+	peers := []*models.Peer{}
+	err := b.lookup("ListPeers", nil, &peers)
+	if err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+func (b *Backend) ConnectPeer(ctx context.Context, node string) error {
+	return b.lookup("ConnectPeer", node, nil)
+}
+
+func (b *Backend) DisconnectPeer(ctx context.Context, pubkey string) error {
+	return b.lookup("DisconnectPeer", pubkey, nil)
+}
+
+func (b *Backend) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	var txid string
+	err := b.lookup("OpenChannel", req, &txid)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+func (b *Backend) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	var txid string
+	err := b.lookup("CloseChannel", []interface{}{channel.ChannelPoint, req}, &txid)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+func (b *Backend) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	err := b.lookup("CreateInvoice", []interface{}{amount, desc}, invoice)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (b *Backend) GetInvoice(ctx context.Context, hash string) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	err := b.lookup("GetInvoice", hash, invoice)
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func (b *Backend) ListInvoices(ctx context.Context, offset, max uint64) ([]*models.Invoice, error) {
+	invoices := []*models.Invoice{}
+	err := b.lookup("ListInvoices", []interface{}{offset, max}, &invoices)
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (b *Backend) ListPayments(ctx context.Context, offset, max uint64) ([]*models.Payment, error) {
+	payments := []*models.Payment{}
+	err := b.lookup("ListPayments", []interface{}{offset, max}, &payments)
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (b *Backend) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
+	p := &models.PayReq{}
+	err := b.lookup("DecodePayReq", payreq, p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (b *Backend) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	var fee int64
+	err := b.lookup("EstimateRouteFee", payreq, &fee)
+	if err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
+
+func (b *Backend) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	payment := &models.Payment{}
+	err := b.lookup("SendPayment", []interface{}{payreq, feeLimit}, payment)
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+func (b *Backend) GetTransactions(ctx context.Context) ([]*models.Transaction, error) {
+	transactions := []*models.Transaction{}
+	err := b.lookup("GetTransactions", nil, &transactions)
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+func (b *Backend) GetForwardingHistory(ctx context.Context, startTime string, maxNumEvents uint32) ([]*models.ForwardingEvent, error) {
+	events := []*models.ForwardingEvent{}
+	err := b.lookup("GetForwardingHistory", []interface{}{startTime, maxNumEvents}, &events)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (b *Backend) SubscribeInvoice(ctx context.Context, channel chan *models.Invoice) error {
+	return b.subscribe(ctx, "SubscribeInvoice", func(data json.RawMessage) error {
+		invoice := &models.Invoice{}
+		err := json.Unmarshal(data, invoice)
+		if err != nil {
+			return err
+		}
+		channel <- invoice
+		return nil
+	})
+}
+
+func (b *Backend) SubscribeChannels(ctx context.Context, channel chan *models.ChannelUpdate) error {
+	return b.subscribe(ctx, "SubscribeChannels", func(data json.RawMessage) error {
+		update := &models.ChannelUpdate{}
+		err := json.Unmarshal(data, update)
+		if err != nil {
+			return err
+		}
+		channel <- update
+		return nil
+	})
+}
+
+func (b *Backend) SubscribeTransactions(ctx context.Context, channel chan *models.Transaction) error {
+	return b.subscribe(ctx, "SubscribeTransactions", func(data json.RawMessage) error {
+		tx := &models.Transaction{}
+		err := json.Unmarshal(data, tx)
+		if err != nil {
+			return err
+		}
+		channel <- tx
+		return nil
+	})
+}
+
+func (b *Backend) SubscribeRoutingEvents(ctx context.Context, channel chan *models.RoutingEvent) error {
+	return b.subscribe(ctx, "SubscribeRoutingEvents", func(data json.RawMessage) error {
+		event := &models.RoutingEvent{}
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			return err
+		}
+		channel <- event
+		return nil
+	})
+}
+
+func (b *Backend) SubscribeGraphEvents(ctx context.Context, channel chan *models.ChannelEdgeUpdate) error {
+	return b.subscribe(ctx, "SubscribeGraphEvents", func(data json.RawMessage) error {
+		update := &models.ChannelEdgeUpdate{}
+		err := json.Unmarshal(data, update)
+		if err != nil {
+			return err
+		}
+		channel <- update
+		return nil
+	})
+}
+
+func (b *Backend) SubscribePeerEvents(ctx context.Context, channel chan *models.PeerEvent) error {
+	return b.subscribe(ctx, "SubscribePeerEvents", func(data json.RawMessage) error {
+		event := &models.PeerEvent{}
+		err := json.Unmarshal(data, event)
+		if err != nil {
+			return err
+		}
+		channel <- event
+		return nil
+	})
+}
+
+func (b *Backend) TrackPayments(ctx context.Context, channel chan *models.Payment) error {
+	return b.subscribe(ctx, "TrackPayments", func(data json.RawMessage) error {
+		payment := &models.Payment{}
+		err := json.Unmarshal(data, payment)
+		if err != nil {
+			return err
+		}
+		channel <- payment
+		return nil
+	})
+}
+
+// New loads the records of the node from the recording file given as the
+// address of the network.
+func New(c *config.Network, logger logging.Logger) (*Backend, error) {
+	records, err := record.Load(c.Address)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.Errorf("recording %s is empty", c.Address)
+	}
+
+	speed := c.ReplaySpeed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	backend := &Backend{
+		cfg:      c,
+		logger:   logger,
+		calls:    make(map[string][]*record.Record),
+		messages: make(map[string][]*record.Record),
+		// all the nodes of the recording share the same clock.
+		clock: clockOf(c.Address, records[0].Time, speed),
+	}
+
+	for _, r := range records {
+		if r.Node != c.Name {
+			continue
+		}
+		if isSubscription(r.Method) {
+			backend.messages[r.Method] = append(backend.messages[r.Method], r)
+			backend.times = append(backend.times, r.Time)
+		} else {
+			backend.calls[r.Method] = append(backend.calls[r.Method], r)
+		}
+	}
+	if len(backend.calls) == 0 && len(backend.messages) == 0 {
+		return nil, errors.Errorf("recording %s has no records of node %q", c.Address, c.Name)
+	}
+	backend.clock.add(backend.times)
+
+	return backend, nil
+}
+
+func isSubscription(method string) bool {
+	switch method {
+	case "SubscribeInvoice", "SubscribeChannels", "SubscribeTransactions",
+		"SubscribeRoutingEvents", "SubscribeGraphEvents", "SubscribePeerEvents",
+		"TrackPayments":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/config"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/backend/record"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// fakeNode answers Info and sends its routing events to the subscription,
+// the other methods are not implemented.
+type fakeNode struct {
+	backend.Backend
+	info   *models.Info
+	events []*models.RoutingEvent
+}
+
+func (n *fakeNode) NodeName() string {
+	return "alice"
+}
+
+func (n *fakeNode) Info(context.Context) (*models.Info, error) {
+	return n.info, nil
+}
+
+func (n *fakeNode) SubscribeRoutingEvents(ctx context.Context, channel chan *models.RoutingEvent) error {
+	for _, e := range n.events {
+		select {
+		case channel <- e:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func newReplay(t *testing.T, path string) *Backend {
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(&config.Network{Name: "alice", Type: "replay", Address: path, ReplaySpeed: 1}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := record.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := &fakeNode{
+		info:   &models.Info{PubKey: "02aaaa", Alias: "alice", BlockHeight: 800000},
+		events: []*models.RoutingEvent{{IncomingChannelId: 1, OutgoingChannelId: 2, AmountMsat: 1000}},
+	}
+	recorded := record.New(node, recorder, logger)
+
+	_, err = recorded.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *models.RoutingEvent)
+	done := make(chan error)
+	go func() { done <- recorded.SubscribeRoutingEvents(ctx, events) }()
+	<-events
+	cancel()
+	<-done
+	err = recorder.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := newReplay(t, path)
+	info, err := b.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.PubKey != "02aaaa" || info.Alias != "alice" || info.BlockHeight != 800000 {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go b.SubscribeRoutingEvents(ctx, events)
+	select {
+	case e := <-events:
+		if e.IncomingChannelId != 1 || e.OutgoingChannelId != 2 || e.AmountMsat != 1000 {
+			t.Errorf("unexpected routing event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no routing event replayed")
+	}
+}
+
+func TestLookupClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := record.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2023, 11, 14, 12, 0, 0, 0, time.UTC)
+	info := func(alias string) json.RawMessage {
+		data, _ := json.Marshal(&models.Info{Alias: alias})
+		return data
+	}
+	records := []*record.Record{
+		{Time: start, Method: "Info", Data: info("first")},
+		{Time: start.Add(time.Minute), Method: "SubscribeRoutingEvents", Data: json.RawMessage(`{}`)},
+		// the refresh caused by the message.
+		{Time: start.Add(time.Minute + time.Second), Method: "Info", Data: info("caused")},
+		// a poll recorded long after the message, before the next one.
+		{Time: start.Add(time.Hour), Method: "Info", Data: info("later")},
+		{Time: start.Add(2 * time.Hour), Method: "SubscribeRoutingEvents", Data: json.RawMessage(`{}`)},
+	}
+	for _, r := range records {
+		r.Node = "alice"
+		err := recorder.Write(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	recorder.Close()
+
+	b := newReplay(t, path)
+	if !b.Pause() {
+		t.Fatal("replay not paused")
+	}
+
+	tests := []string{"first", "caused", "later"}
+	for i, want := range tests {
+		if i > 0 && !b.Step() {
+			t.Fatalf("step %d: no next message", i)
+		}
+		got := &models.Info{}
+		err := b.lookup("Info", nil, got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Alias != want {
+			t.Errorf("step %d: got info %q at %s, want %q", i, got.Alias, b.clock.Now(), want)
+		}
+	}
+
+	err = b.lookup("GetWalletBalance", nil, &models.WalletBalance{})
+	if err == nil {
+		t.Error("a call never recorded was found")
+	}
+}
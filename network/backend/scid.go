@@ -0,0 +1,34 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseShortChannelID converts a BxTxO formatted short channel id into its
+// uint64 form as used by lnd, it returns 0 if the string is malformed.
+func ParseShortChannelID(scid string) uint64 {
+	parts := strings.Split(scid, "x")
+	if len(parts) != 3 {
+		return 0
+	}
+	block, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	tx, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	out, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return block<<40 | tx<<16 | out
+}
+
+// FormatShortChannelID converts a uint64 short channel id into its BxTxO form.
+func FormatShortChannelID(id uint64) string {
+	return fmt.Sprintf("%dx%dx%d", id>>40, (id>>16)&0xFFFFFF, id&0xFFFF)
+}
@@ -54,6 +54,7 @@ type Channel struct {
 	LocalPolicy         *RoutingPolicy
 	RemotePolicy        *RoutingPolicy
 	BlocksTilMaturity   int32
+	Pending             *PendingChannel
 }
 
 func (m Channel) MarshalLogObject(enc logging.ObjectEncoder) error {
@@ -75,14 +76,28 @@ func (m Channel) MarshalLogObject(enc logging.ObjectEncoder) error {
 	return nil
 }
 
+// FundingTxID returns the transaction id of the channel point.
+func (m Channel) FundingTxID() string {
+	if i := strings.LastIndex(m.ChannelPoint, ":"); i > 0 {
+		return m.ChannelPoint[:i]
+	}
+	return m.ChannelPoint
+}
+
 func (m Channel) ShortAlias() (alias string, forced bool) {
-	if m.Node != nil && m.Node.ForcedAlias != "" {
-		alias = m.Node.ForcedAlias
+	return shortAlias(m.Node, m.RemotePubKey)
+}
+
+// shortAlias returns the alias of the node truncated to 25 columns, the
+// forced alias first and the public key when the node has no alias.
+func shortAlias(node *Node, pubkey string) (alias string, forced bool) {
+	if node != nil && node.ForcedAlias != "" {
+		alias = node.ForcedAlias
 		forced = true
-	} else if m.Node == nil || m.Node.Alias == "" {
-		alias = m.RemotePubKey[:25]
+	} else if node == nil || node.Alias == "" {
+		alias = pubkey[:25]
 	} else {
-		alias = strings.ReplaceAll(m.Node.Alias, "\ufe0f", "")
+		alias = strings.ReplaceAll(node.Alias, "\ufe0f", "")
 	}
 	if runewidth.StringWidth(alias) > 25 {
 		alias = runewidth.Truncate(alias, 25, "")
@@ -90,7 +105,107 @@ func (m Channel) ShortAlias() (alias string, forced bool) {
 	return
 }
 
+const (
+	CloseUnknown = iota
+	CloseCooperative
+	CloseLocalForce
+	CloseRemoteForce
+	CloseBreach
+	CloseFundingCanceled
+	CloseAbandoned
+)
+
+// ClosedChannel is a channel closed on-chain or abandoned.
+type ClosedChannel struct {
+	ID           uint64
+	ChannelPoint string
+	RemotePubKey string
+	Capacity     int64
+	// CloseType: one of the Close constants.
+	CloseType   int
+	ClosingTxID string
+	CloseHeight uint32
+	// SettledBalance: amount in satoshis returned to the wallet.
+	SettledBalance int64
+	// TimeLockedBalance: amount in satoshis waiting for the expiry of a
+	// time lock before it is returned to the wallet.
+	TimeLockedBalance int64
+	// TotalRouted: amount in satoshis forwarded in and out of the channel.
+	TotalRouted int64
+	Node        *Node
+}
+
+// Lifetime returns the number of blocks between the funding and the closing
+// of the channel, 0 when one of them is unknown.
+func (m ClosedChannel) Lifetime() uint32 {
+	open := uint32(m.ID >> 40)
+	if open == 0 || m.CloseHeight < open {
+		return 0
+	}
+	return m.CloseHeight - open
+}
+
+func (m ClosedChannel) ShortAlias() (alias string, forced bool) {
+	return shortAlias(m.Node, m.RemotePubKey)
+}
+
+const (
+	AnchorNone = iota
+	AnchorLimbo
+	AnchorRecovered
+	AnchorLost
+)
+
+// PendingChannel holds the closing details of a pending channel, the
+// balances and maturity are only known of the force closed channels.
+type PendingChannel struct {
+	ClosingTxID      string
+	LimboBalance     int64
+	RecoveredBalance int64
+	MaturityHeight   uint32
+	Anchor           int
+	HTLCs            []*PendingHTLC
+}
+
+// ChannelUpdate is a change of the state of a channel, the status is not
+// set when the backend only knows that the channels changed.
 type ChannelUpdate struct {
+	ChannelPoint string
+	Status       int
+}
+
+// OpenChannelRequest is the request of a channel opening with a node given
+// as pubkey@host, the host is optional when the node is already a peer.
+type OpenChannelRequest struct {
+	Node        string
+	Amount      int64
+	PushAmount  int64
+	SatPerVByte uint64
+	Private     bool
+	MinHtlcMsat int64
+}
+
+// PubKey returns the public key of the node to open the channel with.
+func (r OpenChannelRequest) PubKey() string {
+	return strings.SplitN(r.Node, "@", 2)[0]
+}
+
+// Host returns the address of the node, empty if it is not given.
+func (r OpenChannelRequest) Host() string {
+	parts := strings.SplitN(r.Node, "@", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// CloseChannelRequest is the request of a channel closing, the funds go to
+// the wallet of the node when the delivery address is empty and the fee
+// rate is estimated by the node when it is zero.
+type CloseChannelRequest struct {
+	Force           bool
+	SatPerVByte     uint64
+	DeliveryAddress string
 }
 
 type ChannelEdgeUpdate struct {
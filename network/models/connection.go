@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+const (
+	ConnectionConnected = iota
+	ConnectionReconnecting
+	ConnectionDown
+)
+
+// Connection is the state of the subscriptions to a node. Since is the time
+// of the last change of status.
+type Connection struct {
+	Status int
+	Since  time.Time
+}
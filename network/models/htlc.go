@@ -6,3 +6,15 @@ type HTLC struct {
 	Hashlock         []byte
 	ExpirationHeight uint32
 }
+
+// PendingHTLC is an output of a force closed channel locked until its
+// maturity height, the stage is 1 while the htlc is on the commitment
+// transaction and 2 once it is swept by a second level transaction.
+type PendingHTLC struct {
+	Incoming          bool
+	Amount            int64
+	Outpoint          string
+	MaturityHeight    uint32
+	BlocksTilMaturity int32
+	Stage             uint32
+}
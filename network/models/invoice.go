@@ -6,6 +6,13 @@ import (
 	"github.com/edouardparis/lntop/logging"
 )
 
+const (
+	InvoiceOpen = iota + 1
+	InvoiceSettled
+	InvoiceCanceled
+	InvoiceAccepted
+)
+
 type Invoice struct {
 	// Index: index of this invoice.
 	// Each newly created invoice will increment
@@ -37,6 +44,8 @@ type Invoice struct {
 	CLTVExpiry uint64
 	// Private: Whether this invoice should include routing hints for private channels.
 	Private bool
+	// State: one of InvoiceOpen, InvoiceSettled, InvoiceCanceled or InvoiceAccepted.
+	State int
 }
 
 func (m Invoice) GetRHash() string {
@@ -54,6 +63,7 @@ func (m Invoice) MarshalLogObject(enc logging.ObjectEncoder) error {
 	enc.AddString("payment_request", m.PaymentRequest)
 	enc.AddBool("settled", m.Settled)
 	enc.AddInt64("expiry", m.Expiry)
+	enc.AddInt("state", m.State)
 
 	return nil
 }
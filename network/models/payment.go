@@ -1,16 +1,103 @@
 package models
 
-import "github.com/edouardparis/lntop/logging"
+import (
+	"time"
+
+	"github.com/edouardparis/lntop/logging"
+)
+
+const (
+	PaymentInFlight = iota + 1
+	PaymentSucceeded
+	PaymentFailed
+)
+
+const (
+	HTLCAttemptInFlight = iota + 1
+	HTLCAttemptSucceeded
+	HTLCAttemptFailed
+)
 
 type Payment struct {
 	PaymentError    string
 	PaymentPreimage []byte
 	PayReq          *PayReq
 	Route           *Route
+
+	// The fields below are the ones of the payments listed or tracked.
+	//
+	// Index: index of the payment, monotonically increasing.
+	Index       uint64
+	PaymentHash string
+	// Status: one of PaymentInFlight, PaymentSucceeded or PaymentFailed.
+	Status     int
+	AmountMsat int64
+	FeeMsat    int64
+	// CreationDate: time at which the payment was initiated.
+	CreationDate time.Time
+	// FailureReason: reason of the failure of the payment.
+	FailureReason string
+	// HTLCs: the attempts made to pay, with their route.
+	HTLCs []*HTLCAttempt
+}
+
+// Amount returns the amount received by the destination in satoshis.
+func (p Payment) Amount() int64 {
+	return p.AmountMsat / 1000
+}
+
+// Fee returns the fee paid in satoshis.
+func (p Payment) Fee() int64 {
+	return p.FeeMsat / 1000
+}
+
+// FeePPM returns the fee paid in parts per million of the amount.
+func (p Payment) FeePPM() int64 {
+	if p.AmountMsat == 0 {
+		return 0
+	}
+	return p.FeeMsat * 1000000 / p.AmountMsat
+}
+
+// Duration returns the time between the creation of the payment and the
+// resolution of its last attempt, it is zero while the payment is in flight.
+func (p Payment) Duration() time.Duration {
+	if p.Status == PaymentInFlight || p.CreationDate.IsZero() {
+		return 0
+	}
+	var resolved time.Time
+	for _, h := range p.HTLCs {
+		if h.ResolveTime.After(resolved) {
+			resolved = h.ResolveTime
+		}
+	}
+	if resolved.Before(p.CreationDate) {
+		return 0
+	}
+	return resolved.Sub(p.CreationDate)
 }
 
 func (p Payment) MarshalLogObject(enc logging.ObjectEncoder) error {
 	enc.AddString("payment_error", p.PaymentError)
+	if p.PaymentHash != "" {
+		enc.AddString("payment_hash", p.PaymentHash)
+		enc.AddInt("status", p.Status)
+		enc.AddInt64("amount_msat", p.AmountMsat)
+		enc.AddInt64("fee_msat", p.FeeMsat)
+		enc.AddInt("htlcs", len(p.HTLCs))
+	}
 
 	return nil
 }
+
+// HTLCAttempt is an attempt to pay a payment over a route.
+type HTLCAttempt struct {
+	// Status: one of HTLCAttemptInFlight, HTLCAttemptSucceeded or
+	// HTLCAttemptFailed.
+	Status      int
+	Route       *Route
+	AttemptTime time.Time
+	ResolveTime time.Time
+	// Failure: reason of the failure of the attempt.
+	Failure string
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Peer is a node connected to the node. The counters a backend does not
+// report are left to zero, NumChannels is the number of channels shared with
+// the peer.
+type Peer struct {
+	PubKey      string
+	Address     string
+	Inbound     bool
+	PingTime    time.Duration
+	BytesSent   uint64
+	BytesRecv   uint64
+	SatSent     int64
+	SatRecv     int64
+	FlapCount   int32
+	LastFlap    time.Time
+	NumChannels uint32
+	Node        *Node
+}
+
+func (m Peer) ShortAlias() (alias string, forced bool) {
+	return shortAlias(m.Node, m.PubKey)
+}
+
+// PeerEvent is the connection or the disconnection of a peer.
+type PeerEvent struct {
+	PubKey string
+	Online bool
+}
@@ -0,0 +1,59 @@
+package models
+
+// ChannelProfit is the profit and loss of a channel over a period, the
+// amounts are in millisatoshis except for the on-chain costs in satoshis.
+type ChannelProfit struct {
+	ID           uint64
+	ChannelPoint string
+	RemotePubKey string
+	Capacity     int64
+	// Closed is true if the channel is closed.
+	Closed bool
+	Node   *Node
+
+	// FeesOutMsat: fees earned by the forwards leaving the channel.
+	FeesOutMsat uint64
+	// FeesInMsat: fees earned by the forwards entering the channel, they
+	// are also counted in the FeesOutMsat of the outgoing channels.
+	FeesInMsat uint64
+	// VolumeOutMsat and VolumeInMsat: amounts forwarded out and in.
+	VolumeOutMsat uint64
+	VolumeInMsat  uint64
+	Forwards      int
+
+	// RebalanceMsat: fees paid by the circular payments bringing liquidity
+	// back to the channel.
+	RebalanceMsat int64
+
+	// OpenCost and CloseCost: on-chain fees of the funding and closing
+	// transactions paid by the wallet of the node. The fees of a closing
+	// transaction paid from the channel funds, without inputs of the
+	// wallet, are not known by the wallet and are not counted.
+	OpenCost  int64
+	CloseCost int64
+
+	// CapitalDays: local balance in satoshis times the days it was
+	// deployed in the channel.
+	CapitalDays float64
+}
+
+// NetMsat returns the fees earned out of the channel minus the rebalancing
+// and on-chain costs.
+func (p ChannelProfit) NetMsat() int64 {
+	return int64(p.FeesOutMsat) - p.RebalanceMsat - (p.OpenCost+p.CloseCost)*1000
+}
+
+// YieldPPM returns the annualized fees earned out of the channel minus the
+// rebalancing costs in parts per million of the capital deployed, 0 when no
+// capital was deployed.
+func (p ChannelProfit) YieldPPM() int64 {
+	if p.CapitalDays <= 0 {
+		return 0
+	}
+	income := float64(int64(p.FeesOutMsat)-p.RebalanceMsat) / 1000
+	return int64(income * 365 * 1e6 / p.CapitalDays)
+}
+
+func (p ChannelProfit) ShortAlias() (alias string, forced bool) {
+	return shortAlias(p.Node, p.RemotePubKey)
+}
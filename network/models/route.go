@@ -41,4 +41,11 @@ type Hop struct {
 	Amount       int64
 	Fee          int64
 	Expiry       uint32
+	// PubKey: public key of the node at the end of the hop.
+	PubKey string
+	Node   *Node
+}
+
+func (h Hop) ShortAlias() (alias string, forced bool) {
+	return shortAlias(h.Node, h.PubKey)
 }
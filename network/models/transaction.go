@@ -1,6 +1,12 @@
 package models
 
-import "time"
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
 
 type Transaction struct {
 	// / The transaction hash
@@ -19,4 +25,34 @@ type Transaction struct {
 	TotalFees int64
 	// / Addresses that received funds for this transaction
 	DestAddresses []string
+	// VSize: virtual size of the transaction in bytes, 0 when it is unknown.
+	VSize int64
+	// Inputs: outpoints spent by the transaction as txid:index.
+	Inputs []string
+}
+
+// FeeRate returns the fee rate of the transaction in sat/vbyte, 0 when its
+// size or its fee is unknown.
+func (t Transaction) FeeRate() float64 {
+	if t.VSize == 0 {
+		return 0
+	}
+	return float64(t.TotalFees) / float64(t.VSize)
+}
+
+// RawTxVSize returns the virtual size in bytes of the transaction serialized
+// in hex, 0 when it cannot be decoded.
+func RawTxVSize(raw string) int64 {
+	b, err := hex.DecodeString(raw)
+	if err != nil || len(b) == 0 {
+		return 0
+	}
+	tx := &wire.MsgTx{}
+	err = tx.Deserialize(bytes.NewReader(b))
+	if err != nil {
+		return 0
+	}
+	// the witness data is counted once in the weight, the rest four times.
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return int64(weight+3) / 4
 }
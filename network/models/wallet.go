@@ -1,6 +1,10 @@
 package models
 
-import "github.com/edouardparis/lntop/logging"
+import (
+	"fmt"
+
+	"github.com/edouardparis/lntop/logging"
+)
 
 type WalletBalance struct {
 	TotalBalance       int64
@@ -15,3 +19,93 @@ func (m WalletBalance) MarshalLogObject(enc logging.ObjectEncoder) error {
 
 	return nil
 }
+
+const (
+	AddressUnknown = iota
+	AddressP2WPKH
+	AddressNP2WKH
+	AddressP2TR
+)
+
+// Utxo is an unspent output of the on-chain wallet.
+type Utxo struct {
+	TxID        string
+	OutputIndex uint32
+	// Amount: amount of the output in satoshis.
+	Amount  int64
+	Address string
+	// AddressType: one of AddressP2WPKH, AddressNP2WKH, AddressP2TR or
+	// AddressUnknown.
+	AddressType   int
+	Confirmations int64
+}
+
+// Outpoint returns the outpoint of the output as txid:index.
+func (u Utxo) Outpoint() string {
+	return fmt.Sprintf("%s:%d", u.TxID, u.OutputIndex)
+}
+
+// InputVSize returns the virtual size in bytes of the input spending the
+// output, unknown types are counted as legacy inputs.
+func (u Utxo) InputVSize() int64 {
+	switch u.AddressType {
+	case AddressP2WPKH:
+		return 68
+	case AddressNP2WKH:
+		return 91
+	case AddressP2TR:
+		return 58
+	default:
+		return 148
+	}
+}
+
+// EstimateVSize returns the virtual size in bytes of a transaction spending
+// the inputs, the outputs are counted as taproot outputs which are the
+// largest of the segwit ones.
+func EstimateVSize(inputs []*Utxo, outputs int) int64 {
+	vsize := int64(11 + 43*outputs)
+	for _, input := range inputs {
+		vsize += input.InputVSize()
+	}
+	return vsize
+}
+
+// SendCoinsRequest is an on-chain payment.
+type SendCoinsRequest struct {
+	Address string
+	// Amount: amount sent in satoshis, it is ignored when SendAll is set.
+	Amount int64
+	// SendAll: the outputs are swept to the address, without change.
+	SendAll bool
+	// SatPerVByte: fee rate of the transaction, it is estimated for the
+	// confirmation target when it is zero.
+	SatPerVByte uint64
+	TargetConf  int32
+	// Outpoints: outputs spent by the transaction as txid:index, the wallet
+	// selects them when it is empty.
+	Outpoints []string
+}
+
+const (
+	// BumpFeeCPFP: the output of the transaction is spent by a child
+	// paying for both.
+	BumpFeeCPFP = iota
+	// BumpFeeRBF: the transaction is replaced by one spending the same
+	// inputs with a greater fee.
+	BumpFeeRBF
+)
+
+// BumpFeeRequest bumps the fee of an unconfirmed transaction.
+type BumpFeeRequest struct {
+	TxID string
+	// Method: one of BumpFeeCPFP or BumpFeeRBF.
+	Method int
+	// Outpoint: output of the transaction spent by the child of a CPFP.
+	Outpoint string
+	// Inputs: outpoints spent by the transaction replaced by a RBF.
+	Inputs []string
+	// SatPerVByte: fee rate of the new transaction, the child of a CPFP or
+	// the replacement.
+	SatPerVByte uint64
+}
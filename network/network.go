@@ -1,11 +1,16 @@
 package network
 
 import (
+	"io"
+
 	"github.com/edouardparis/lntop/config"
 	"github.com/edouardparis/lntop/logging"
 	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/backend/cln"
+	"github.com/edouardparis/lntop/network/backend/eclair"
 	"github.com/edouardparis/lntop/network/backend/lnd"
 	"github.com/edouardparis/lntop/network/backend/mock"
+	"github.com/edouardparis/lntop/network/backend/replay"
 )
 
 type Network struct {
@@ -17,19 +22,30 @@ func New(c *config.Network, logger logging.Logger) (*Network, error) {
 		err error
 		b   backend.Backend
 	)
-	if c.Type == "mock" {
-		b = mock.New(c)
-	} else {
+	switch c.Type {
+	case "mock":
+		b, err = mock.New(c)
+	case "cln":
+		b, err = cln.New(c, logger.With(logging.String("network", "cln")))
+	case "eclair":
+		b, err = eclair.New(c, logger.With(logging.String("network", "eclair")))
+	case "replay":
+		b, err = replay.New(c, logger.With(logging.String("network", "replay")))
+	default:
 		b, err = lnd.New(c, logger.With(logging.String("network", "lnd")))
-		if err != nil {
-			return nil, err
-		}
 	}
-
-	err = b.Ping()
 	if err != nil {
 		return nil, err
 	}
 
 	return &Network{b}, nil
 }
+
+// Close stops the background work of the backend, like the play of the
+// mock scenario.
+func (n *Network) Close() error {
+	if c, ok := n.Backend.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -15,6 +15,12 @@ type PubSub struct {
 	logger  logging.Logger
 	network *network.Network
 	wg      *sync.WaitGroup
+
+	// mu guards failing, the subscriptions being resubscribed, and the
+	// connection state they result in.
+	mu         sync.Mutex
+	failing    map[string]bool
+	connection models.Connection
 }
 
 func New(logger logging.Logger, network *network.Network) *PubSub {
@@ -23,6 +29,7 @@ func New(logger logging.Logger, network *network.Network) *PubSub {
 		network: network,
 		wg:      &sync.WaitGroup{},
 		stop:    make(chan bool),
+		failing: make(map[string]bool),
 	}
 }
 
@@ -34,20 +41,22 @@ func (p *PubSub) invoices(ctx context.Context, sub chan *events.Event) {
 	go func() {
 		for invoice := range invoices {
 			p.logger.Debug("receive invoice", logging.Object("invoice", invoice))
-			if invoice.Settled {
-				sub <- events.New(events.InvoiceSettled)
-			} else {
-				sub <- events.New(events.InvoiceCreated)
+			switch invoice.State {
+			case models.InvoiceSettled:
+				sub <- events.NewWithData(events.InvoiceSettled, invoice)
+			case models.InvoiceOpen:
+				sub <- events.NewWithData(events.InvoiceCreated, invoice)
+			default:
+				sub <- events.NewWithData(events.InvoiceUpdated, invoice)
 			}
 		}
 		p.wg.Done()
 	}()
 
 	go func() {
-		err := p.network.SubscribeInvoice(ctx, invoices)
-		if err != nil {
-			p.logger.Error("SubscribeInvoice returned an error", logging.Error(err))
-		}
+		p.supervise(ctx, sub, "SubscribeInvoice", func(ctx context.Context) error {
+			return p.network.SubscribeInvoice(ctx, invoices)
+		})
 		p.wg.Done()
 	}()
 
@@ -73,10 +82,9 @@ func (p *PubSub) transactions(ctx context.Context, sub chan *events.Event) {
 	}()
 
 	go func() {
-		err := p.network.SubscribeTransactions(ctx, transactions)
-		if err != nil {
-			p.logger.Error("SubscribeTransactions returned an error", logging.Error(err))
-		}
+		p.supervise(ctx, sub, "SubscribeTransactions", func(ctx context.Context) error {
+			return p.network.SubscribeTransactions(ctx, transactions)
+		})
 		p.wg.Done()
 	}()
 
@@ -102,10 +110,9 @@ func (p *PubSub) routingUpdates(ctx context.Context, sub chan *events.Event) {
 	}()
 
 	go func() {
-		err := p.network.SubscribeRoutingEvents(ctx, routingUpdates)
-		if err != nil {
-			p.logger.Error("SubscribeRoutingEvents returned an error", logging.Error(err))
-		}
+		p.supervise(ctx, sub, "SubscribeRoutingEvents", func(ctx context.Context) error {
+			return p.network.SubscribeRoutingEvents(ctx, routingUpdates)
+		})
 		p.wg.Done()
 	}()
 
@@ -131,10 +138,9 @@ func (p *PubSub) graphUpdates(ctx context.Context, sub chan *events.Event) {
 	}()
 
 	go func() {
-		err := p.network.SubscribeGraphEvents(ctx, graphUpdates)
-		if err != nil {
-			p.logger.Error("SubscribeGraphEvents returned an error", logging.Error(err))
-		}
+		p.supervise(ctx, sub, "SubscribeGraphEvents", func(ctx context.Context) error {
+			return p.network.SubscribeGraphEvents(ctx, graphUpdates)
+		})
 		p.wg.Done()
 	}()
 
@@ -146,24 +152,93 @@ func (p *PubSub) graphUpdates(ctx context.Context, sub chan *events.Event) {
 	}()
 }
 
+func (p *PubSub) peers(ctx context.Context, sub chan *events.Event) {
+	p.wg.Add(3)
+	peerEvents := make(chan *models.PeerEvent)
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		for pe := range peerEvents {
+			p.logger.Debug("receive peer event",
+				logging.String("pubkey", pe.PubKey),
+				logging.Bool("online", pe.Online))
+			sub <- events.NewWithData(events.PeerUpdated, pe)
+		}
+		p.wg.Done()
+	}()
+
+	go func() {
+		p.supervise(ctx, sub, "SubscribePeerEvents", func(ctx context.Context) error {
+			return p.network.SubscribePeerEvents(ctx, peerEvents)
+		})
+		p.wg.Done()
+	}()
+
+	go func() {
+		<-p.stop
+		cancel()
+		close(peerEvents)
+		p.wg.Done()
+	}()
+}
+
+func (p *PubSub) payments(ctx context.Context, sub chan *events.Event) {
+	p.wg.Add(3)
+	payments := make(chan *models.Payment)
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		for payment := range payments {
+			p.logger.Debug("receive payment", logging.Object("payment", payment))
+			sub <- events.NewWithData(events.PaymentUpdated, payment)
+		}
+		p.wg.Done()
+	}()
+
+	go func() {
+		p.supervise(ctx, sub, "TrackPayments", func(ctx context.Context) error {
+			return p.network.TrackPayments(ctx, payments)
+		})
+		p.wg.Done()
+	}()
+
+	go func() {
+		<-p.stop
+		cancel()
+		close(payments)
+		p.wg.Done()
+	}()
+}
+
 func (p *PubSub) channels(ctx context.Context, sub chan *events.Event) {
 	p.wg.Add(3)
 	channels := make(chan *models.ChannelUpdate)
 	ctx, cancel := context.WithCancel(ctx)
 
 	go func() {
-		for range channels {
-			p.logger.Debug("channels updated")
-			sub <- events.New(events.ChannelActive)
+		for update := range channels {
+			p.logger.Debug("channels updated",
+				logging.String("channel_point", update.ChannelPoint),
+				logging.Int("status", update.Status))
+			switch update.Status {
+			case models.ChannelOpening, models.ChannelClosing,
+				models.ChannelForceClosing, models.ChannelWaitingClose:
+				sub <- events.NewWithData(events.ChannelPending, update)
+			case models.ChannelInactive:
+				sub <- events.NewWithData(events.ChannelInactive, update)
+			case models.ChannelClosed:
+				sub <- events.NewWithData(events.ChannelClosed, update)
+			default:
+				sub <- events.NewWithData(events.ChannelActive, update)
+			}
 		}
 		p.wg.Done()
 	}()
 
 	go func() {
-		err := p.network.SubscribeChannels(ctx, channels)
-		if err != nil {
-			p.logger.Error("SubscribeChannels returned an error", logging.Error(err))
-		}
+		p.supervise(ctx, sub, "SubscribeChannels", func(ctx context.Context) error {
+			return p.network.SubscribeChannels(ctx, channels)
+		})
 		p.wg.Done()
 	}()
 
@@ -181,14 +256,29 @@ func (p *PubSub) Stop() {
 	p.logger.Debug("Received signal, gracefully stopping")
 }
 
-func (p *PubSub) Run(ctx context.Context, sub chan *events.Event) {
+func (p *PubSub) Run(ctx context.Context, out chan *events.Event) {
 	p.logger.Debug("Starting...")
 
+	// events are tagged with the node name before being forwarded, so that
+	// several pubsubs can share the same channel.
+	sub := make(chan *events.Event)
+	done := make(chan struct{})
+	go func() {
+		for event := range sub {
+			event.Node = p.network.NodeName()
+			out <- event
+		}
+		close(done)
+	}()
+
+	p.reconnect(ctx, sub)
 	p.invoices(ctx, sub)
 	p.transactions(ctx, sub)
 	p.routingUpdates(ctx, sub)
 	p.channels(ctx, sub)
 	p.graphUpdates(ctx, sub)
+	p.peers(ctx, sub)
+	p.payments(ctx, sub)
 	p.ticker(ctx, sub,
 		withTickerInfo(),
 		withTickerChannelsBalance(),
@@ -198,4 +288,6 @@ func (p *PubSub) Run(ctx context.Context, sub chan *events.Event) {
 
 	<-p.stop
 	p.wg.Wait()
+	close(sub)
+	<-done
 }
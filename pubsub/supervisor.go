@@ -0,0 +1,218 @@
+package pubsub
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// the durations are variables for the tests.
+var (
+	backoffMin = time.Second
+	backoffMax = time.Minute
+	// stableAfter is how long a subscription must run to be recovered
+	// and to reset its backoff.
+	stableAfter = 10 * time.Second
+	// downAfter is how long the node is reconnecting before it is down.
+	downAfter = 30 * time.Second
+)
+
+// startName is the name under which a node down at start is failing.
+const startName = "start"
+
+// backoff returns the delay before the attempt, exponential with jitter: a
+// random duration between the half and the whole of the exponential delay.
+func backoff(attempt int) time.Duration {
+	d := backoffMax
+	if attempt < 16 {
+		d = backoffMin << uint(attempt)
+		if d > backoffMax {
+			d = backoffMax
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)))
+}
+
+// supervise runs the subscription until the context is canceled. When it
+// fails, the node is polled with an exponential backoff until it answers and
+// the subscription is made again. The subscription is only recovered and
+// its backoff reset once it has run for stableAfter, a subscription failing
+// right after the node answers keeps reconnecting.
+func (p *PubSub) supervise(ctx context.Context, sub chan *events.Event, name string, subscribe func(context.Context) error) {
+	attempt := 0
+	failed := false
+	for {
+		done := make(chan error, 1)
+		go func() { done <- subscribe(ctx) }()
+
+		var err error
+		timer := time.NewTimer(stableAfter)
+		select {
+		case err = <-done:
+			timer.Stop()
+		case <-timer.C:
+			attempt = 0
+			if failed {
+				p.recovered(sub, name)
+				failed = false
+			}
+			err = <-done
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			p.logger.Error(name+" returned an error", logging.Error(err))
+		} else {
+			p.logger.Error(name + " stopped")
+		}
+		if !failed {
+			p.failed(sub, name)
+			failed = true
+		}
+
+		for {
+			timer := time.NewTimer(backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			attempt++
+
+			_, err := p.network.Info(ctx)
+			if err == nil {
+				break
+			}
+			p.logger.Debug("node unreachable",
+				logging.String("subscription", name),
+				logging.Int("attempt", attempt),
+				logging.Error(err))
+			p.unreachable(sub)
+		}
+
+		p.logger.Info("resubscribing", logging.String("subscription", name))
+	}
+}
+
+// Down marks the node as down at start, it is polled with an exponential
+// backoff until it answers.
+func (p *PubSub) Down() {
+	p.mu.Lock()
+	p.failing[startName] = true
+	p.connection = models.Connection{Status: models.ConnectionDown, Since: time.Now()}
+	p.mu.Unlock()
+}
+
+// reconnect polls the node down at start until it answers or the pubsub is
+// stopped.
+func (p *PubSub) reconnect(ctx context.Context, sub chan *events.Event) {
+	p.mu.Lock()
+	down := p.failing[startName]
+	p.mu.Unlock()
+	if !down {
+		return
+	}
+
+	p.wg.Add(2)
+	ctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer p.wg.Done()
+		for attempt := 0; ; attempt++ {
+			timer := time.NewTimer(backoff(attempt))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			_, err := p.network.Info(ctx)
+			if err == nil {
+				p.logger.Info("node reachable")
+				p.recovered(sub, startName)
+				return
+			}
+			p.logger.Debug("node unreachable",
+				logging.Int("attempt", attempt),
+				logging.Error(err))
+		}
+	}()
+
+	go func() {
+		<-p.stop
+		cancel()
+		p.wg.Done()
+	}()
+}
+
+// failed marks the subscription as failed, the node is reconnecting from
+// the first failure unless it is already down.
+func (p *PubSub) failed(sub chan *events.Event, name string) {
+	p.mu.Lock()
+	p.failing[name] = true
+	changed := false
+	if p.connection.Status != models.ConnectionDown {
+		changed = p.setStatus(models.ConnectionReconnecting)
+	}
+	connection := p.connection
+	p.mu.Unlock()
+
+	if changed {
+		sub <- events.NewWithData(events.ConnectionUpdated, &connection)
+	}
+}
+
+// unreachable marks the node as down once it has been reconnecting for too
+// long.
+func (p *PubSub) unreachable(sub chan *events.Event) {
+	p.mu.Lock()
+	changed := false
+	if p.connection.Status == models.ConnectionReconnecting &&
+		time.Since(p.connection.Since) > downAfter {
+		changed = p.setStatus(models.ConnectionDown)
+	}
+	connection := p.connection
+	p.mu.Unlock()
+
+	if changed {
+		sub <- events.NewWithData(events.ConnectionUpdated, &connection)
+	}
+}
+
+// recovered marks the subscription as running again, the node is connected
+// once all its subscriptions are.
+func (p *PubSub) recovered(sub chan *events.Event, name string) {
+	p.mu.Lock()
+	delete(p.failing, name)
+	changed := false
+	if len(p.failing) == 0 {
+		changed = p.setStatus(models.ConnectionConnected)
+	}
+	connection := p.connection
+	p.mu.Unlock()
+
+	if changed {
+		sub <- events.NewWithData(events.ConnectionUpdated, &connection)
+	}
+}
+
+// setStatus changes the status of the connection and returns true if it
+// changed, the caller must hold the lock.
+func (p *PubSub) setStatus(status int) bool {
+	if p.connection.Status == status {
+		return false
+	}
+	// a node going down has been unreachable since it started reconnecting.
+	if status != models.ConnectionDown {
+		p.connection.Since = time.Now()
+	}
+	p.connection.Status = status
+	return true
+}
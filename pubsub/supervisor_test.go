@@ -0,0 +1,197 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/events"
+	"github.com/edouardparis/lntop/logging"
+	"github.com/edouardparis/lntop/network"
+	"github.com/edouardparis/lntop/network/backend"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// fakeNode always answers Info, the other methods are not implemented.
+type fakeNode struct {
+	backend.Backend
+}
+
+func (fakeNode) Info(context.Context) (*models.Info, error) {
+	return &models.Info{}, nil
+}
+
+func newTestPubSub(t *testing.T) *PubSub {
+	t.Helper()
+	logger, err := logging.NewNopLogger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(logger, &network.Network{Backend: fakeNode{}})
+}
+
+// setDurations shortens the durations of the supervisor for the test.
+func setDurations(t *testing.T, min, max, stable time.Duration) {
+	t.Helper()
+	saved := []time.Duration{backoffMin, backoffMax, stableAfter}
+	backoffMin, backoffMax, stableAfter = min, max, stable
+	t.Cleanup(func() {
+		backoffMin, backoffMax, stableAfter = saved[0], saved[1], saved[2]
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 20; attempt++ {
+		max := backoffMax
+		if attempt < 6 {
+			max = backoffMin << uint(attempt)
+		}
+		for i := 0; i < 100; i++ {
+			d := backoff(attempt)
+			if d < max/2 || d >= max {
+				t.Fatalf("attempt %d: got %s, want between %s and %s", attempt, d, max/2, max)
+			}
+		}
+	}
+}
+
+// flakySubscription fails at once the given number of times, then runs
+// until the context is done. The times of the calls are kept.
+type flakySubscription struct {
+	mu    sync.Mutex
+	fails int
+	calls []time.Time
+}
+
+func (s *flakySubscription) subscribe(ctx context.Context) error {
+	s.mu.Lock()
+	s.calls = append(s.calls, time.Now())
+	fail := len(s.calls) <= s.fails
+	s.mu.Unlock()
+	if fail {
+		return errors.New("subscription failed")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+func TestSuperviseConnectionUpdates(t *testing.T) {
+	setDurations(t, time.Millisecond, 4*time.Millisecond, 100*time.Millisecond)
+	p := newTestPubSub(t)
+	s := &flakySubscription{fails: 3}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := make(chan *events.Event)
+	done := make(chan struct{})
+	go func() {
+		p.supervise(ctx, sub, "Subscribe", s.subscribe)
+		close(done)
+	}()
+
+	// the failures right after the node answers do not flip the status.
+	statuses := []int{}
+	timeout := time.After(time.Second)
+	for len(statuses) < 2 {
+		select {
+		case event := <-sub:
+			if event.Type != events.ConnectionUpdated {
+				t.Fatalf("unexpected event %s", event.Type)
+			}
+			statuses = append(statuses, event.Data.(*models.Connection).Status)
+		case <-timeout:
+			t.Fatalf("got statuses %v, want reconnecting then connected", statuses)
+		}
+	}
+	if statuses[0] != models.ConnectionReconnecting || statuses[1] != models.ConnectionConnected {
+		t.Errorf("got statuses %v, want reconnecting then connected", statuses)
+	}
+
+	s.mu.Lock()
+	calls := len(s.calls)
+	s.mu.Unlock()
+	if calls != 4 {
+		t.Errorf("got %d subscriptions, want 4", calls)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor not stopped on cancel")
+	}
+	select {
+	case event := <-sub:
+		t.Errorf("unexpected event after the cancel %+v", event)
+	default:
+	}
+}
+
+func TestSuperviseBackoffReset(t *testing.T) {
+	setDurations(t, 20*time.Millisecond, time.Second, 100*time.Millisecond)
+	p := newTestPubSub(t)
+
+	// the subscription fails 3 times at once, then runs for longer than
+	// stableAfter before failing again.
+	var mu sync.Mutex
+	calls := []time.Time{}
+	failed := time.Time{}
+	subscribe := func(ctx context.Context) error {
+		mu.Lock()
+		calls = append(calls, time.Now())
+		n := len(calls)
+		mu.Unlock()
+		switch {
+		case n <= 3:
+			return errors.New("subscription failed")
+		case n == 4:
+			time.Sleep(2 * stableAfter)
+			mu.Lock()
+			failed = time.Now()
+			mu.Unlock()
+			return errors.New("subscription failed")
+		}
+		<-ctx.Done()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := make(chan *events.Event, 10)
+	done := make(chan struct{})
+	go func() {
+		p.supervise(ctx, sub, "Subscribe", subscribe)
+		close(done)
+	}()
+	// the supervisor stops before the durations are restored.
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(calls)
+		mu.Unlock()
+		if n == 5 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d subscriptions, want 5", n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// without the reset, the fourth attempt would wait between 80ms and
+	// 160ms.
+	if wait := calls[4].Sub(failed); wait >= 60*time.Millisecond {
+		t.Errorf("waited %s after a stable subscription, want the first backoff", wait)
+	}
+	// the quick failures are backed off.
+	if wait := calls[3].Sub(calls[2]); wait < 40*time.Millisecond {
+		t.Errorf("waited %s after the third failure, want an increased backoff", wait)
+	}
+}
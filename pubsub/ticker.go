@@ -32,6 +32,7 @@ func (p *PubSub) ticker(ctx context.Context, sub chan *events.Event, fn ...ticke
 }
 
 // withTickerInfo checks if general information did not changed changed in the ticker interval.
+// The changes of the channels and of the peers are streamed by their subscriptions.
 func withTickerInfo() tickerFunc {
 	var old *models.Info
 	return func(ctx context.Context, logger logging.Logger, net *network.Network, sub chan *events.Event) {
@@ -43,22 +44,6 @@ func withTickerInfo() tickerFunc {
 			if old.BlockHeight != info.BlockHeight {
 				sub <- events.New(events.BlockReceived)
 			}
-
-			if old.NumPeers != info.NumPeers {
-				sub <- events.New(events.PeerUpdated)
-			}
-
-			if old.NumPendingChannels < info.NumPendingChannels {
-				sub <- events.New(events.ChannelPending)
-			}
-
-			if old.NumActiveChannels < info.NumActiveChannels {
-				sub <- events.New(events.ChannelActive)
-			}
-
-			if old.NumInactiveChannels < info.NumInactiveChannels {
-				sub <- events.New(events.ChannelInactive)
-			}
 		}
 		old = info
 	}
@@ -0,0 +1,340 @@
+// Package report computes the reports of a node from its channels, its
+// forwarding history, its payments and its on-chain transactions.
+package report
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edouardparis/lntop/history"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+const (
+	// blockInterval is the average time between two blocks, the openings
+	// and closings of the channels are dated from their block heights.
+	blockInterval = 10 * time.Minute
+
+	// forwardsPageSize is the number of forwarding events fetched at once.
+	forwardsPageSize = 1000
+
+	// paymentsPageSize is the number of payments fetched at once.
+	paymentsPageSize = 1000
+)
+
+// ProfitData is the data of a node the profit report is computed from.
+type ProfitData struct {
+	// Since and Now are the start and the end of the period of the report.
+	Since time.Time
+	Now   time.Time
+	// PubKey and BlockHeight are the ones of the node.
+	PubKey      string
+	BlockHeight uint32
+
+	Channels       []*models.Channel
+	ClosedChannels []*models.ClosedChannel
+	Forwards       []*models.ForwardingEvent
+	Payments       []*models.Payment
+	Transactions   []*models.Transaction
+	// Snapshots are the balances of the channels recorded by the history,
+	// the capital deployed is estimated from the current balances without
+	// them.
+	Snapshots []*history.Snapshot
+}
+
+// Profit returns the profit and loss of the channels open during the
+// period, from the most profitable. The forwards, the rebalancing payments
+// and the on-chain transactions outside of the period are ignored.
+func Profit(d *ProfitData) []*models.ChannelProfit {
+	byID := make(map[uint64]*models.ChannelProfit)
+	byPoint := make(map[string]*models.ChannelProfit)
+	// periods are the start and the end of each channel in the period.
+	periods := make(map[string][2]time.Time)
+	// balances are the local balances of the channels without snapshots.
+	balances := make(map[string]int64)
+	add := func(p *models.ChannelProfit, start, end time.Time, balance int64) {
+		if end.Before(d.Since) {
+			return
+		}
+		if start.Before(d.Since) {
+			start = d.Since
+		}
+		byPoint[p.ChannelPoint] = p
+		if p.ID != 0 {
+			byID[p.ID] = p
+		}
+		periods[p.ChannelPoint] = [2]time.Time{start, end}
+		balances[p.ChannelPoint] = balance
+	}
+
+	for _, c := range d.Channels {
+		if c.Status == models.ChannelClosed {
+			continue
+		}
+		add(&models.ChannelProfit{
+			ID:           c.ID,
+			ChannelPoint: c.ChannelPoint,
+			RemotePubKey: c.RemotePubKey,
+			Capacity:     c.Capacity,
+			Node:         c.Node,
+		}, d.blockTime(uint32(c.ID>>40)), d.Now, c.LocalBalance)
+	}
+	for _, c := range d.ClosedChannels {
+		if _, ok := byPoint[c.ChannelPoint]; ok {
+			continue
+		}
+		add(&models.ChannelProfit{
+			ID:           c.ID,
+			ChannelPoint: c.ChannelPoint,
+			RemotePubKey: c.RemotePubKey,
+			Capacity:     c.Capacity,
+			Closed:       true,
+			Node:         c.Node,
+		}, d.blockTime(uint32(c.ID>>40)), d.blockTime(c.CloseHeight), c.SettledBalance)
+	}
+
+	d.addForwards(byID)
+	d.addRebalances(byID)
+	d.addOnChainCosts(byPoint)
+	d.addCapitalDays(byPoint, periods, balances)
+
+	result := make([]*models.ChannelProfit, 0, len(byPoint))
+	for _, p := range byPoint {
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NetMsat() != result[j].NetMsat() {
+			return result[i].NetMsat() > result[j].NetMsat()
+		}
+		return result[i].ChannelPoint < result[j].ChannelPoint
+	})
+	return result
+}
+
+// blockTime returns the estimated time of the block at the height, it is
+// now for unknown heights.
+func (d *ProfitData) blockTime(height uint32) time.Time {
+	if height == 0 || height > d.BlockHeight {
+		return d.Now
+	}
+	return d.Now.Add(-time.Duration(d.BlockHeight-height) * blockInterval)
+}
+
+func (d *ProfitData) inPeriod(t time.Time) bool {
+	return !t.Before(d.Since) && !t.After(d.Now)
+}
+
+func (d *ProfitData) addForwards(byID map[uint64]*models.ChannelProfit) {
+	for _, e := range d.Forwards {
+		if !d.inPeriod(e.EventTime) {
+			continue
+		}
+		fee := e.FeeMsat
+		if fee == 0 {
+			fee = e.Fee * 1000
+		}
+		if p, ok := byID[e.ChanIdOut]; ok {
+			amount := e.AmtOutMsat
+			if amount == 0 {
+				amount = e.AmtOut * 1000
+			}
+			p.FeesOutMsat += fee
+			p.VolumeOutMsat += amount
+			p.Forwards++
+		}
+		if p, ok := byID[e.ChanIdIn]; ok {
+			amount := e.AmtInMsat
+			if amount == 0 {
+				amount = e.AmtIn * 1000
+			}
+			p.FeesInMsat += fee
+			p.VolumeInMsat += amount
+			p.Forwards++
+		}
+	}
+}
+
+// addRebalances adds the fees of the payments of the node to itself to the
+// channel receiving them.
+func (d *ProfitData) addRebalances(byID map[uint64]*models.ChannelProfit) {
+	for _, payment := range d.Payments {
+		if payment.Status != models.PaymentSucceeded || !d.inPeriod(payment.CreationDate) {
+			continue
+		}
+		succeeded := []*models.Route{}
+		for _, htlc := range payment.HTLCs {
+			if htlc.Status == models.HTLCAttemptSucceeded && htlc.Route != nil && len(htlc.Route.Hops) > 0 {
+				succeeded = append(succeeded, htlc.Route)
+			}
+		}
+		for _, route := range succeeded {
+			last := route.Hops[len(route.Hops)-1]
+			if last.PubKey != d.PubKey {
+				continue
+			}
+			p, ok := byID[last.ChanID]
+			if !ok {
+				continue
+			}
+			if len(succeeded) == 1 {
+				p.RebalanceMsat += payment.FeeMsat
+				continue
+			}
+			p.RebalanceMsat += route.Fee * 1000
+		}
+	}
+}
+
+// addOnChainCosts adds the fees of the funding and closing transactions,
+// the fee of a funding transaction opening several channels is shared. The
+// fees are the ones of the wallet transactions, a closing transaction spends
+// only the funding output and its fees, paid by the funder from the channel
+// balance, are 0 in the wallet.
+func (d *ProfitData) addOnChainCosts(byPoint map[string]*models.ChannelProfit) {
+	transactions := make(map[string]*models.Transaction, len(d.Transactions))
+	for _, tx := range d.Transactions {
+		transactions[tx.TxHash] = tx
+	}
+
+	funded := make(map[string][]*models.ChannelProfit)
+	for _, p := range byPoint {
+		txid := strings.Split(p.ChannelPoint, ":")[0]
+		funded[txid] = append(funded[txid], p)
+	}
+	for txid, channels := range funded {
+		tx, ok := transactions[txid]
+		if !ok || !d.inPeriod(tx.Date) {
+			continue
+		}
+		for _, p := range channels {
+			p.OpenCost = tx.TotalFees / int64(len(channels))
+		}
+	}
+
+	for _, c := range d.ClosedChannels {
+		p, ok := byPoint[c.ChannelPoint]
+		if !ok {
+			continue
+		}
+		tx, ok := transactions[c.ClosingTxID]
+		if !ok || !d.inPeriod(tx.Date) {
+			continue
+		}
+		p.CloseCost = tx.TotalFees
+	}
+}
+
+// addCapitalDays adds the local balance of the channels times the days it
+// was deployed. The balance of a snapshot is kept until the next one, the
+// first one is also the balance since the start of the channel.
+func (d *ProfitData) addCapitalDays(byPoint map[string]*models.ChannelProfit, periods map[string][2]time.Time, balances map[string]int64) {
+	type sample struct {
+		time    time.Time
+		balance int64
+	}
+	samples := make(map[string][]sample)
+	for _, snapshot := range d.Snapshots {
+		if !d.inPeriod(snapshot.Time) {
+			continue
+		}
+		for _, c := range snapshot.Channels {
+			samples[c.ChannelPoint] = append(samples[c.ChannelPoint], sample{snapshot.Time, c.LocalBalance})
+		}
+	}
+
+	for point, p := range byPoint {
+		start, end := periods[point][0], periods[point][1]
+		list := samples[point]
+		if len(list) == 0 {
+			list = []sample{{start, balances[point]}}
+		}
+		for i := range list {
+			from, to := list[i].time, end
+			if i == 0 {
+				from = start
+			}
+			if i+1 < len(list) && list[i+1].time.Before(to) {
+				to = list[i+1].time
+			}
+			if from.Before(start) {
+				from = start
+			}
+			if !to.After(from) {
+				continue
+			}
+			p.CapitalDays += float64(list[i].balance) * to.Sub(from).Hours() / 24
+		}
+	}
+}
+
+// forwardingHistory is a node giving its forwarding history.
+type forwardingHistory interface {
+	GetForwardingHistory(context.Context, string, uint32) ([]*models.ForwardingEvent, error)
+}
+
+// ForwardingHistory returns the forwarding events of the node since the
+// time, they are fetched by pages from the oldest.
+func ForwardingHistory(ctx context.Context, n forwardingHistory, since time.Time) ([]*models.ForwardingEvent, error) {
+	type key struct {
+		time    int64
+		in, out uint64
+	}
+	seen := make(map[key]bool)
+	result := []*models.ForwardingEvent{}
+	start := since.Unix()
+	if start < 0 {
+		start = 0
+	}
+	for {
+		forwards, err := n.GetForwardingHistory(ctx, strconv.FormatInt(start, 10), forwardsPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		// the events of the second of the last event are fetched again.
+		for _, e := range forwards {
+			k := key{e.EventTime.UnixNano(), e.ChanIdIn, e.ChanIdOut}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			result = append(result, e)
+		}
+
+		if len(forwards) < forwardsPageSize ||
+			forwards[len(forwards)-1].EventTime.Unix() <= start {
+			return result, nil
+		}
+		start = forwards[len(forwards)-1].EventTime.Unix()
+	}
+}
+
+// paymentsList is a node listing its payments.
+type paymentsList interface {
+	ListPayments(context.Context, uint64, uint64) ([]*models.Payment, error)
+}
+
+// Payments returns all the payments of the node, they are fetched by pages
+// from the oldest.
+func Payments(ctx context.Context, n paymentsList) ([]*models.Payment, error) {
+	result := []*models.Payment{}
+	var offset uint64
+	for {
+		payments, err := n.ListPayments(ctx, offset, paymentsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, payments...)
+		for _, p := range payments {
+			if p.Index > offset {
+				offset = p.Index
+			}
+		}
+		if len(payments) < paymentsPageSize {
+			return result, nil
+		}
+	}
+}
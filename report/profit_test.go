@@ -0,0 +1,431 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/edouardparis/lntop/history"
+	"github.com/edouardparis/lntop/network/models"
+)
+
+const (
+	testPubKey = "self"
+	testHeight = 700000
+	// blocksPerDay is the number of blocks of a day at the block interval.
+	blocksPerDay = 144
+)
+
+var testNow = time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// daysAgo returns the time and the height of the block days before now.
+func daysAgo(days int) (time.Time, uint32) {
+	return testNow.AddDate(0, 0, -days), uint32(testHeight - days*blocksPerDay)
+}
+
+// scid returns the id of the channel funded at the height.
+func scid(height uint32, index uint64) uint64 {
+	return uint64(height)<<40 | index<<16
+}
+
+func testData() *ProfitData {
+	since, _ := daysAgo(30)
+	return &ProfitData{
+		Since:       since,
+		Now:         testNow,
+		PubKey:      testPubKey,
+		BlockHeight: testHeight,
+	}
+}
+
+func openChannel(point string, days int, index uint64, balance int64) *models.Channel {
+	_, height := daysAgo(days)
+	return &models.Channel{
+		ID:           scid(height, index),
+		ChannelPoint: point,
+		Status:       models.ChannelActive,
+		Capacity:     1000000,
+		LocalBalance: balance,
+	}
+}
+
+func closedChannel(point string, opened, closed int, index uint64, closingTx string) *models.ClosedChannel {
+	_, open := daysAgo(opened)
+	_, close := daysAgo(closed)
+	return &models.ClosedChannel{
+		ID:             scid(open, index),
+		ChannelPoint:   point,
+		Capacity:       1000000,
+		ClosingTxID:    closingTx,
+		CloseHeight:    close,
+		SettledBalance: 200000,
+	}
+}
+
+func byPoint(result []*models.ChannelProfit) map[string]*models.ChannelProfit {
+	m := make(map[string]*models.ChannelProfit, len(result))
+	for _, p := range result {
+		m[p.ChannelPoint] = p
+	}
+	return m
+}
+
+func TestProfitPeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []*models.Channel
+		closed   []*models.ClosedChannel
+		// days are the expected days of capital deployed of each channel.
+		days map[string]float64
+	}{
+		{
+			name:     "opened before the start",
+			channels: []*models.Channel{openChannel("a:0", 60, 1, 100000)},
+			days:     map[string]float64{"a:0": 30},
+		},
+		{
+			name:     "opened during the period",
+			channels: []*models.Channel{openChannel("a:0", 10, 1, 100000)},
+			days:     map[string]float64{"a:0": 10},
+		},
+		{
+			name:   "closed during the period",
+			closed: []*models.ClosedChannel{closedChannel("c:0", 50, 5, 1, "")},
+			days:   map[string]float64{"c:0": 25},
+		},
+		{
+			name:   "closed before the start",
+			closed: []*models.ClosedChannel{closedChannel("c:0", 50, 40, 1, "")},
+			days:   map[string]float64{},
+		},
+		{
+			name: "closed channel listed with the open ones",
+			channels: []*models.Channel{{
+				ChannelPoint: "c:0",
+				Status:       models.ChannelClosed,
+			}},
+			closed: []*models.ClosedChannel{closedChannel("c:0", 50, 5, 1, "")},
+			days:   map[string]float64{"c:0": 25},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testData()
+			d.Channels = tt.channels
+			d.ClosedChannels = tt.closed
+			result := byPoint(Profit(d))
+			if len(result) != len(tt.days) {
+				t.Fatalf("got %d channels, want %d", len(result), len(tt.days))
+			}
+			for point, days := range tt.days {
+				p, ok := result[point]
+				if !ok {
+					t.Fatalf("missing channel %s", point)
+				}
+				balance := float64(100000)
+				if p.Closed {
+					balance = 200000
+				}
+				if math.Abs(p.CapitalDays-balance*days) > 1 {
+					t.Errorf("%s: got %f capital days, want %f", point, p.CapitalDays, balance*days)
+				}
+			}
+		})
+	}
+}
+
+func TestProfitSnapshots(t *testing.T) {
+	d := testData()
+	channel := openChannel("a:0", 60, 1, 100000)
+	d.Channels = []*models.Channel{channel}
+	at := func(days int) time.Time {
+		t, _ := daysAgo(days)
+		return t
+	}
+	balance := func(days int, amount int64) *history.Snapshot {
+		return &history.Snapshot{
+			Time: at(days),
+			Channels: []*history.ChannelBalance{{
+				ID:           channel.ID,
+				ChannelPoint: channel.ChannelPoint,
+				LocalBalance: amount,
+			}},
+		}
+	}
+	d.Snapshots = []*history.Snapshot{
+		balance(40, 900000),
+		balance(20, 300000),
+		balance(10, 600000),
+	}
+
+	result := Profit(d)
+	if len(result) != 1 {
+		t.Fatalf("got %d channels, want 1", len(result))
+	}
+	// the first snapshot of the period is kept since the start.
+	want := float64(300000)*20 + float64(600000)*10
+	if math.Abs(result[0].CapitalDays-want) > 1 {
+		t.Errorf("got %f capital days, want %f", result[0].CapitalDays, want)
+	}
+}
+
+func TestProfitForwards(t *testing.T) {
+	a := openChannel("a:0", 60, 1, 100000)
+	b := openChannel("b:0", 60, 2, 100000)
+	recent, _ := daysAgo(1)
+	old, _ := daysAgo(31)
+
+	tests := []struct {
+		name     string
+		forwards []*models.ForwardingEvent
+		out, in  uint64
+		volume   uint64
+		count    int
+	}{
+		{
+			name: "msat",
+			forwards: []*models.ForwardingEvent{{
+				ChanIdIn: b.ID, ChanIdOut: a.ID,
+				AmtInMsat: 1001500, AmtOutMsat: 1000000, FeeMsat: 1500,
+				EventTime: recent,
+			}},
+			out: 1500, in: 1500, volume: 1000000, count: 1,
+		},
+		{
+			name: "sat",
+			forwards: []*models.ForwardingEvent{{
+				ChanIdIn: b.ID, ChanIdOut: a.ID,
+				AmtIn: 1002, AmtOut: 1000, Fee: 2,
+				EventTime: recent,
+			}},
+			out: 2000, in: 2000, volume: 1000000, count: 1,
+		},
+		{
+			name: "before the start",
+			forwards: []*models.ForwardingEvent{{
+				ChanIdIn: b.ID, ChanIdOut: a.ID,
+				AmtInMsat: 1001500, AmtOutMsat: 1000000, FeeMsat: 1500,
+				EventTime: old,
+			}},
+		},
+		{
+			name: "unknown channel in",
+			forwards: []*models.ForwardingEvent{{
+				ChanIdIn: 42, ChanIdOut: a.ID,
+				AmtInMsat: 1001500, AmtOutMsat: 1000000, FeeMsat: 1500,
+				EventTime: recent,
+			}},
+			out: 1500, volume: 1000000, count: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testData()
+			d.Channels = []*models.Channel{a, b}
+			d.Forwards = tt.forwards
+			result := byPoint(Profit(d))
+			pa, pb := result["a:0"], result["b:0"]
+			if pa.FeesOutMsat != tt.out || pa.VolumeOutMsat != tt.volume || pa.Forwards != tt.count {
+				t.Errorf("out: got fees %d, volume %d, forwards %d", pa.FeesOutMsat, pa.VolumeOutMsat, pa.Forwards)
+			}
+			if pb.FeesInMsat != tt.in {
+				t.Errorf("in: got fees %d, want %d", pb.FeesInMsat, tt.in)
+			}
+			if pa.FeesInMsat != 0 || pb.FeesOutMsat != 0 {
+				t.Errorf("fees counted in the wrong direction")
+			}
+		})
+	}
+}
+
+func TestProfitRebalances(t *testing.T) {
+	a := openChannel("a:0", 60, 1, 100000)
+	b := openChannel("b:0", 60, 2, 100000)
+	recent, _ := daysAgo(1)
+	old, _ := daysAgo(31)
+	route := func(chanID uint64, pubkey string, fee int64) *models.Route {
+		return &models.Route{
+			Fee: fee,
+			Hops: []*models.Hop{
+				{ChanID: 42, PubKey: "peer"},
+				{ChanID: chanID, PubKey: pubkey},
+			},
+		}
+	}
+	htlc := func(status int, r *models.Route) *models.HTLCAttempt {
+		return &models.HTLCAttempt{Status: status, Route: r}
+	}
+
+	tests := []struct {
+		name    string
+		payment *models.Payment
+		// want are the rebalancing costs of a and b.
+		want [2]int64
+	}{
+		{
+			name: "single route",
+			payment: &models.Payment{
+				Status: models.PaymentSucceeded, FeeMsat: 1234, CreationDate: recent,
+				HTLCs: []*models.HTLCAttempt{
+					htlc(models.HTLCAttemptFailed, route(b.ID, testPubKey, 9)),
+					htlc(models.HTLCAttemptSucceeded, route(a.ID, testPubKey, 1)),
+				},
+			},
+			want: [2]int64{1234, 0},
+		},
+		{
+			name: "several routes",
+			payment: &models.Payment{
+				Status: models.PaymentSucceeded, FeeMsat: 5000, CreationDate: recent,
+				HTLCs: []*models.HTLCAttempt{
+					htlc(models.HTLCAttemptSucceeded, route(a.ID, testPubKey, 2)),
+					htlc(models.HTLCAttemptSucceeded, route(b.ID, testPubKey, 3)),
+				},
+			},
+			want: [2]int64{2000, 3000},
+		},
+		{
+			name: "payment to another node",
+			payment: &models.Payment{
+				Status: models.PaymentSucceeded, FeeMsat: 1234, CreationDate: recent,
+				HTLCs: []*models.HTLCAttempt{
+					htlc(models.HTLCAttemptSucceeded, route(a.ID, "other", 1)),
+				},
+			},
+		},
+		{
+			name: "failed payment",
+			payment: &models.Payment{
+				Status: models.PaymentFailed, FeeMsat: 1234, CreationDate: recent,
+				HTLCs: []*models.HTLCAttempt{
+					htlc(models.HTLCAttemptFailed, route(a.ID, testPubKey, 1)),
+				},
+			},
+		},
+		{
+			name: "before the start",
+			payment: &models.Payment{
+				Status: models.PaymentSucceeded, FeeMsat: 1234, CreationDate: old,
+				HTLCs: []*models.HTLCAttempt{
+					htlc(models.HTLCAttemptSucceeded, route(a.ID, testPubKey, 1)),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testData()
+			d.Channels = []*models.Channel{a, b}
+			d.Payments = []*models.Payment{tt.payment}
+			result := byPoint(Profit(d))
+			got := [2]int64{result["a:0"].RebalanceMsat, result["b:0"].RebalanceMsat}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfitOnChainCosts(t *testing.T) {
+	recent, _ := daysAgo(5)
+	old, _ := daysAgo(50)
+	tx := func(hash string, date time.Time, fees int64) *models.Transaction {
+		return &models.Transaction{TxHash: hash, Date: date, TotalFees: fees}
+	}
+
+	tests := []struct {
+		name         string
+		channels     []*models.Channel
+		closed       []*models.ClosedChannel
+		transactions []*models.Transaction
+		// open and close are the expected costs by channel point.
+		open, close map[string]int64
+	}{
+		{
+			name: "shared funding",
+			channels: []*models.Channel{
+				openChannel("f:0", 5, 1, 100000),
+				openChannel("f:1", 5, 1, 100000),
+			},
+			transactions: []*models.Transaction{tx("f", recent, 1001)},
+			open:         map[string]int64{"f:0": 500, "f:1": 500},
+		},
+		{
+			name:         "funding before the start",
+			channels:     []*models.Channel{openChannel("f:0", 50, 1, 100000)},
+			transactions: []*models.Transaction{tx("f", old, 1000)},
+			open:         map[string]int64{"f:0": 0},
+		},
+		{
+			name:         "closing paid by the wallet",
+			closed:       []*models.ClosedChannel{closedChannel("f:0", 50, 5, 1, "c")},
+			transactions: []*models.Transaction{tx("f", old, 1000), tx("c", recent, 300)},
+			open:         map[string]int64{"f:0": 0},
+			close:        map[string]int64{"f:0": 300},
+		},
+		{
+			// a closing transaction without inputs of the wallet has no
+			// fees in the wallet.
+			name:         "closing not paid by the wallet",
+			closed:       []*models.ClosedChannel{closedChannel("f:0", 50, 5, 1, "c")},
+			transactions: []*models.Transaction{tx("c", recent, 0)},
+			close:        map[string]int64{"f:0": 0},
+		},
+		{
+			name:   "closing unknown",
+			closed: []*models.ClosedChannel{closedChannel("f:0", 50, 5, 1, "c")},
+			close:  map[string]int64{"f:0": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := testData()
+			d.Channels = tt.channels
+			d.ClosedChannels = tt.closed
+			d.Transactions = tt.transactions
+			result := byPoint(Profit(d))
+			for point, want := range tt.open {
+				if got := result[point].OpenCost; got != want {
+					t.Errorf("%s: got open cost %d, want %d", point, got, want)
+				}
+			}
+			for point, want := range tt.close {
+				if got := result[point].CloseCost; got != want {
+					t.Errorf("%s: got close cost %d, want %d", point, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestProfitOrder(t *testing.T) {
+	a := openChannel("a:0", 60, 1, 100000)
+	b := openChannel("b:0", 60, 2, 100000)
+	c := openChannel("c:0", 60, 3, 100000)
+	recent, _ := daysAgo(1)
+	d := testData()
+	d.Channels = []*models.Channel{a, b, c}
+	d.Forwards = []*models.ForwardingEvent{
+		{ChanIdIn: a.ID, ChanIdOut: b.ID, FeeMsat: 1000, EventTime: recent},
+	}
+	d.Transactions = []*models.Transaction{{TxHash: "c", Date: recent, TotalFees: 10}}
+
+	result := Profit(d)
+	points := []string{}
+	for _, p := range result {
+		points = append(points, p.ChannelPoint)
+	}
+	want := []string{"b:0", "a:0", "c:0"}
+	if len(points) != len(want) {
+		t.Fatalf("got %v, want %v", points, want)
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Fatalf("got %v, want %v", points, want)
+		}
+	}
+}
@@ -2,13 +2,18 @@ package ui
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/awesome-gocui/gocui"
+	"github.com/pkg/errors"
 
 	"github.com/edouardparis/lntop/app"
 	"github.com/edouardparis/lntop/events"
 	"github.com/edouardparis/lntop/logging"
+	netmodels "github.com/edouardparis/lntop/network/models"
 	"github.com/edouardparis/lntop/ui/cursor"
 	"github.com/edouardparis/lntop/ui/models"
 	"github.com/edouardparis/lntop/ui/views"
@@ -18,6 +23,21 @@ type controller struct {
 	logger logging.Logger
 	models *models.Models
 	views  *views.Views
+	// nodes holds the models of every monitored node and nodeViews the
+	// views rendering them, models and views are the ones of the current
+	// node.
+	nodes     *models.Nodes
+	nodeViews []*views.Views
+	// replay is the clock of the nodes replaying a recording, it is nil
+	// when no node is replayed.
+	replay replayer
+}
+
+// replayer is a node playing back a recording, the replay can be paused and
+// stepped from one recorded message to the next.
+type replayer interface {
+	Pause() bool
+	Step() bool
 }
 
 func (c *controller) layout(g *gocui.Gui) error {
@@ -28,7 +48,11 @@ func (c *controller) layout(g *gocui.Gui) error {
 func (c *controller) cursorDown(g *gocui.Gui, v *gocui.View) error {
 	view := c.views.Get(v)
 	if view != nil {
-		return cursor.Down(view)
+		err := cursor.Down(view)
+		if err != nil {
+			return err
+		}
+		c.loadMore(g, v)
 	}
 	return nil
 }
@@ -68,7 +92,11 @@ func (c *controller) cursorHome(g *gocui.Gui, v *gocui.View) error {
 func (c *controller) cursorEnd(g *gocui.Gui, v *gocui.View) error {
 	view := c.views.Get(v)
 	if view != nil {
-		return cursor.End(view)
+		err := cursor.End(view)
+		if err != nil {
+			return err
+		}
+		c.loadMore(g, v)
 	}
 	return nil
 }
@@ -76,7 +104,11 @@ func (c *controller) cursorEnd(g *gocui.Gui, v *gocui.View) error {
 func (c *controller) cursorPageDown(g *gocui.Gui, v *gocui.View) error {
 	view := c.views.Get(v)
 	if view != nil {
-		return cursor.PageDown(view)
+		err := cursor.PageDown(view)
+		if err != nil {
+			return err
+		}
+		c.loadMore(g, v)
 	}
 	return nil
 }
@@ -89,28 +121,76 @@ func (c *controller) cursorPageUp(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
-func (c *controller) SetModels(ctx context.Context) error {
-	err := c.models.RefreshInfo(ctx)
-	if err != nil {
-		return err
+// loadMore loads the next page of the invoices or the payments in the
+// background once the cursor is on the last one loaded.
+func (c *controller) loadMore(g *gocui.Gui, v *gocui.View) {
+	var load func(context.Context) error
+	name := v.Name()
+	switch name {
+	case views.INVOICES:
+		if c.models.Invoices.Complete() ||
+			c.views.Invoices.Index() < c.models.Invoices.Len()-1 {
+			return
+		}
+		load = c.models.LoadInvoices
+	case views.PAYMENTS:
+		if c.models.Payments.Complete() ||
+			c.views.Payments.Index() < c.models.Payments.Len()-1 {
+			return
+		}
+		load = c.models.LoadPayments
+	default:
+		return
 	}
 
-	err = c.models.RefreshWalletBalance(ctx)
-	if err != nil {
-		return err
-	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		err := load(ctx)
+		if err != nil {
+			c.logger.Error("failed to load the next page",
+				logging.String("view", name),
+				logging.Error(err))
+		}
+		g.Update(func(*gocui.Gui) error { return nil })
+	}()
+}
 
-	err = c.models.RefreshChannelsBalance(ctx)
-	if err != nil {
-		return err
+// SetModels refreshes the models of the nodes, the nodes down at start are
+// refreshed once they reconnect.
+func (c *controller) SetModels(ctx context.Context) {
+	for _, m := range c.nodes.List() {
+		if m.Connection.Connection != nil &&
+			m.Connection.Status == netmodels.ConnectionDown {
+			continue
+		}
+		c.setModels(ctx, m)
 	}
+}
 
-	err = c.models.RefreshTransactions(ctx)
-	if err != nil {
-		return err
+// setModels refreshes all the models of the node, a model failing to refresh
+// is logged and the others are still refreshed.
+func (c *controller) setModels(ctx context.Context, m *models.Models) {
+	refresh := []func(context.Context) error{
+		m.RefreshInfo,
+		m.RefreshWalletBalance,
+		m.RefreshChannelsBalance,
+		m.RefreshTransactions,
+		m.RefreshInvoices,
+		m.RefreshPayments,
+		m.RefreshChannels,
+		m.RefreshPeers,
+		m.RefreshUtxos,
+		m.RefreshClosedChannels,
+	}
+	for i := range refresh {
+		err := refresh[i](ctx)
+		if err != nil {
+			c.logger.Error("failed to refresh models",
+				logging.String("node", m.Name()),
+				logging.Error(err))
+		}
 	}
-
-	return c.models.RefreshChannels(ctx)
 }
 
 func (c *controller) Listen(ctx context.Context, g *gocui.Gui, sub chan *events.Event) {
@@ -126,61 +206,108 @@ func (c *controller) Listen(ctx context.Context, g *gocui.Gui, sub chan *events.
 	}
 
 	for event := range sub {
-		c.logger.Debug("event received", logging.String("type", event.Type))
+		c.logger.Debug("event received",
+			logging.String("type", event.Type),
+			logging.String("node", event.Node))
+		m := c.nodes.GetByName(event.Node)
+		if m == nil {
+			m = c.nodes.Current()
+		}
 		switch event.Type {
 		case events.TransactionCreated:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshWalletBalance,
-				c.models.RefreshTransactions,
+				m.RefreshInfo,
+				m.RefreshWalletBalance,
+				m.RefreshTransactions,
+				m.RefreshUtxos,
 			)
 		case events.BlockReceived:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshTransactions,
+				m.RefreshInfo,
+				m.RefreshTransactions,
+				m.RefreshUtxos,
 			)
 		case events.WalletBalanceUpdated:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshWalletBalance,
-				c.models.RefreshTransactions,
+				m.RefreshInfo,
+				m.RefreshWalletBalance,
+				m.RefreshTransactions,
+				m.RefreshUtxos,
 			)
 		case events.ChannelBalanceUpdated:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshChannelsBalance,
-				c.models.RefreshChannels,
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshChannels,
 			)
 		case events.ChannelPending:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshChannelsBalance,
-				c.models.RefreshChannels,
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshWalletBalance,
+				m.RefreshChannels,
+				m.RefreshUtxos,
 			)
 		case events.ChannelActive:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshChannelsBalance,
-				c.models.RefreshChannels,
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshChannels,
 			)
 		case events.ChannelInactive:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshChannelsBalance,
-				c.models.RefreshChannels,
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshChannels,
 			)
+		case events.ChannelClosed:
+			refresh(
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshWalletBalance,
+				m.RefreshChannels,
+				m.RefreshUtxos,
+				m.RefreshClosedChannels,
+			)
+		case events.InvoiceCreated, events.InvoiceUpdated:
+			refresh(m.RefreshInvoice(event.Data))
 		case events.InvoiceSettled:
 			refresh(
-				c.models.RefreshInfo,
-				c.models.RefreshChannelsBalance,
-				c.models.RefreshChannels,
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshChannels,
+				m.RefreshInvoice(event.Data),
+			)
+		case events.PaymentUpdated:
+			refresh(
+				m.RefreshInfo,
+				m.RefreshChannelsBalance,
+				m.RefreshChannels,
+				m.RefreshPayment(event.Data),
 			)
 		case events.PeerUpdated:
-			refresh(c.models.RefreshInfo)
+			refresh(m.RefreshInfo, m.RefreshPeers)
 		case events.RoutingEventUpdated:
-			refresh(c.models.RefreshRouting(event.Data))
+			refresh(m.RefreshRouting(event.Data))
 		case events.GraphUpdated:
-			refresh(c.models.RefreshPolicies(event.Data))
+			refresh(m.RefreshPolicies(event.Data))
+		case events.ConnectionUpdated:
+			connection, ok := event.Data.(*netmodels.Connection)
+			if !ok {
+				break
+			}
+			if m.SetConnection(connection) {
+				// events may have been missed while reconnecting.
+				refresh(
+					func(ctx context.Context) error {
+						c.setModels(ctx, m)
+						return nil
+					},
+					m.RefreshForwardingHistory,
+				)
+				break
+			}
+			refresh()
 		}
 	}
 }
@@ -227,8 +354,22 @@ func (c *controller) Order(order models.Order) func(*gocui.Gui, *gocui.View) err
 			c.views.Channels.Sort("", order)
 		case views.TRANSACTIONS:
 			c.views.Transactions.Sort("", order)
+		case views.INVOICES:
+			c.views.Invoices.Sort("", order)
+		case views.PAYMENTS:
+			c.views.Payments.Sort("", order)
 		case views.FWDINGHIST:
 			c.views.FwdingHist.Sort("", order)
+		case views.PEERS:
+			c.views.Peers.Sort("", order)
+		case views.WALLET:
+			c.views.Wallet.Sort("", order)
+		case views.PENDING:
+			c.views.Pending.Sort("", order)
+		case views.CLOSED:
+			c.views.Closed.Sort("", order)
+		case views.PROFIT:
+			c.views.Profit.Sort("", order)
 		}
 		return nil
 	}
@@ -284,6 +425,28 @@ func (c *controller) OnEnter(g *gocui.Gui, v *gocui.View) error {
 			if err != nil {
 				return err
 			}
+		case views.INVOICES:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Invoices
+			err = c.views.Invoices.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.PAYMENTS:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Payments
+			err = c.views.Payments.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
 		case views.ROUTING:
 			err := c.views.Main.Delete(g)
 			if err != nil {
@@ -308,6 +471,83 @@ func (c *controller) OnEnter(g *gocui.Gui, v *gocui.View) error {
 			if err != nil {
 				return err
 			}
+		case views.PEERS:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Peers
+			err = c.views.Peers.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.WALLET:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Wallet
+			err = c.views.Wallet.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.PENDING:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Pending
+			err = c.views.Pending.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.CLOSED:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Closed
+			err = c.views.Closed.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.PROFIT:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+			// the forwarding history can take a while to be synchronized,
+			// the view is drawn again once the profit is computed.
+			m := c.models
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
+				defer cancel()
+				err := m.RefreshProfit(ctx)
+				if err != nil {
+					c.logger.Error("refresh profit", logging.Error(err))
+				}
+				g.Update(func(*gocui.Gui) error { return nil })
+			}()
+			c.views.Main = c.views.Profit
+			err = c.views.Profit.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
+		case views.NODES:
+			err := c.views.Main.Delete(g)
+			if err != nil {
+				return err
+			}
+
+			c.views.Main = c.views.Nodes
+			err = c.views.Nodes.Set(g, 11, 6, maxX-1, maxY)
+			if err != nil {
+				return err
+			}
 		}
 
 	case views.TRANSACTIONS:
@@ -319,10 +559,116 @@ func (c *controller) OnEnter(g *gocui.Gui, v *gocui.View) error {
 	case views.TRANSACTION:
 		c.views.Main = c.views.Transactions
 		return ToggleView(g, view, c.views.Transactions)
+
+	case views.INVOICES:
+		index := c.views.Invoices.Index()
+		c.models.Invoices.SetCurrent(index)
+		if c.models.Invoices.Current() == nil {
+			return nil
+		}
+		c.views.Main = c.views.Invoice
+		return ToggleView(g, view, c.views.Invoice)
+
+	case views.INVOICE:
+		c.views.Main = c.views.Invoices
+		return ToggleView(g, view, c.views.Invoices)
+
+	case views.PAYMENTS:
+		index := c.views.Payments.Index()
+		c.models.Payments.SetCurrent(index)
+		if c.models.Payments.Current() == nil {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+		defer cancel()
+		c.models.RefreshCurrentPayment(ctx)
+		c.views.Main = c.views.Payment
+		return ToggleView(g, view, c.views.Payment)
+
+	case views.PAYMENT:
+		c.views.Main = c.views.Payments
+		return ToggleView(g, view, c.views.Payments)
+
+	case views.PENDING:
+		index := c.views.Pending.Index()
+		c.models.PendingChannels.SetCurrent(index)
+		if c.models.PendingChannels.Current() == nil {
+			return nil
+		}
+		c.views.Main = c.views.PendingChan
+		return ToggleView(g, view, c.views.PendingChan)
+
+	case views.PENDING_CHANNEL:
+		c.views.Main = c.views.Pending
+		return ToggleView(g, view, c.views.Pending)
+
+	case views.NODES:
+		return c.switchNode(g, c.views.Nodes.Index())
+	}
+	return nil
+}
+
+// NextNode displays the next monitored node.
+func (c *controller) NextNode(g *gocui.Gui, v *gocui.View) error {
+	return c.switchNode(g, (c.nodes.CurrentIndex()+1)%c.nodes.Len())
+}
+
+// PauseReplay pauses the replay or resumes it.
+func (c *controller) PauseReplay(g *gocui.Gui, v *gocui.View) error {
+	if c.replay == nil {
+		return nil
 	}
+	c.logger.Info("replay", logging.Bool("paused", c.replay.Pause()))
 	return nil
 }
 
+// StepReplay plays the next recorded message of the replay, it is paused
+// until it is resumed.
+func (c *controller) StepReplay(g *gocui.Gui, v *gocui.View) error {
+	if c.replay == nil {
+		return nil
+	}
+	if !c.replay.Step() {
+		c.logger.Info("replay: no more messages")
+	}
+	return nil
+}
+
+// switchNode replaces the displayed views by the views of the node at the
+// given index.
+func (c *controller) switchNode(g *gocui.Gui, index int) error {
+	if index == c.nodes.CurrentIndex() || c.nodes.Get(index) == nil {
+		return nil
+	}
+
+	current := g.CurrentView()
+	if current != nil && current.Name() == c.views.Menu.Name() {
+		err := c.views.Menu.Delete(g)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := c.views.Main.Delete(g)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+
+	c.nodes.SetCurrent(index)
+	c.models = c.nodes.Current()
+	c.views = c.nodeViews[index]
+	c.logger.Debug("switch node", logging.String("node", c.models.Name()))
+
+	maxX, maxY := g.Size()
+	err = c.views.Main.Set(g, 0, 6, maxX-1, maxY)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.SetCurrentView(c.views.Main.Name())
+	return err
+}
+
 func (c *controller) NodeInfo(g *gocui.Gui, v *gocui.View) error {
 	if v.Name() != views.CHANNEL {
 		return nil
@@ -333,6 +679,958 @@ func (c *controller) NodeInfo(g *gocui.Gui, v *gocui.View) error {
 	return nil
 }
 
+// EditPolicy opens the dialog editing the local policy of the displayed
+// channel.
+func (c *controller) EditPolicy(g *gocui.Gui, v *gocui.View) error {
+	if v.Name() != views.CHANNEL {
+		return nil
+	}
+	channel := c.models.Channels.Current()
+	if channel == nil || channel.ID == 0 {
+		return nil
+	}
+
+	policy := netmodels.RoutingPolicy{}
+	if channel.LocalPolicy != nil {
+		policy = *channel.LocalPolicy
+	}
+	status := "enabled"
+	if policy.Disabled {
+		status = "disabled"
+	}
+
+	fields := []*views.DialogField{
+		{Label: "Base fee (msat)", Value: strconv.FormatInt(policy.FeeBaseMsat, 10)},
+		{Label: "Fee rate (ppm)", Value: strconv.FormatInt(policy.FeeRateMilliMsat, 10)},
+		{Label: "Time lock delta", Value: strconv.FormatUint(uint64(policy.TimeLockDelta), 10)},
+		{Label: "Min htlc (msat)", Value: strconv.FormatInt(policy.MinHtlc, 10)},
+		{Label: "Max htlc (msat)", Value: strconv.FormatUint(policy.MaxHtlc, 10)},
+		{Label: "Status", Value: status, Options: []string{"enabled", "disabled"}},
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Channel policy", fields, func(values []string) error {
+		updated, err := parsePolicy(values)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		err = m.UpdateChannelPolicy(ctx, channel, updated)
+		if err != nil {
+			c.logger.Error("update channel policy", logging.Error(err))
+		}
+		return err
+	})
+	return nil
+}
+
+// parsePolicy parses the values of the policy dialog.
+func parsePolicy(values []string) (*netmodels.RoutingPolicy, error) {
+	policy := &netmodels.RoutingPolicy{Disabled: values[5] == "disabled"}
+
+	var err error
+	policy.FeeBaseMsat, err = strconv.ParseInt(values[0], 10, 64)
+	if err != nil || policy.FeeBaseMsat < 0 {
+		return nil, errors.New("invalid base fee")
+	}
+	policy.FeeRateMilliMsat, err = strconv.ParseInt(values[1], 10, 32)
+	if err != nil || policy.FeeRateMilliMsat < 0 {
+		return nil, errors.New("invalid fee rate")
+	}
+	delta, err := strconv.ParseUint(values[2], 10, 16)
+	if err != nil {
+		return nil, errors.New("invalid time lock delta")
+	}
+	policy.TimeLockDelta = uint32(delta)
+	policy.MinHtlc, err = strconv.ParseInt(values[3], 10, 64)
+	if err != nil || policy.MinHtlc < 0 {
+		return nil, errors.New("invalid min htlc")
+	}
+	policy.MaxHtlc, err = strconv.ParseUint(values[4], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid max htlc")
+	}
+	return policy, nil
+}
+
+func (c *controller) OpenChannel(g *gocui.Gui, v *gocui.View) error {
+	if v.Name() != views.CHANNELS {
+		return nil
+	}
+
+	fields := []*views.DialogField{
+		{Label: "Node (pubkey@host)"},
+		{Label: "Amount (sat)"},
+		{Label: "Push amount (sat)", Value: "0"},
+		{Label: "Fee rate (sat/vB)"},
+		{Label: "Private", Value: "no", Options: []string{"no", "yes"}},
+		{Label: "Min htlc (msat)"},
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Open channel", fields, func(values []string) error {
+		req, err := parseOpenChannel(values)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		txid, err := m.OpenChannel(ctx, req)
+		if err != nil {
+			c.logger.Error("open channel", logging.Error(err))
+			return err
+		}
+		c.logger.Info("channel opening", logging.String("txid", txid))
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		req, err := parseOpenChannel(values)
+		if err != nil {
+			return nil, err
+		}
+		feeRate := "estimated by the node"
+		if req.SatPerVByte > 0 {
+			feeRate = fmt.Sprintf("%d sat/vB", req.SatPerVByte)
+		}
+		announce := "public"
+		if req.Private {
+			announce = "private"
+		}
+		minHtlc := "default of the node"
+		if req.MinHtlcMsat > 0 {
+			minHtlc = fmt.Sprintf("%d msat", req.MinHtlcMsat)
+		}
+		return []string{
+			fmt.Sprintf("Node: %s", req.Node),
+			fmt.Sprintf("Amount: %d sat", req.Amount),
+			fmt.Sprintf("Push amount: %d sat", req.PushAmount),
+			fmt.Sprintf("Fee rate: %s", feeRate),
+			fmt.Sprintf("Channel: %s", announce),
+			fmt.Sprintf("Min htlc: %s", minHtlc),
+		}, nil
+	})
+	return nil
+}
+
+// parseOpenChannel parses the values of the open channel dialog.
+func parseOpenChannel(values []string) (*netmodels.OpenChannelRequest, error) {
+	req := &netmodels.OpenChannelRequest{
+		Node:    strings.TrimSpace(values[0]),
+		Private: values[4] == "yes",
+	}
+	if len(req.PubKey()) != 66 {
+		return nil, errors.New("invalid node")
+	}
+
+	var err error
+	req.Amount, err = strconv.ParseInt(values[1], 10, 64)
+	if err != nil || req.Amount <= 0 {
+		return nil, errors.New("invalid amount")
+	}
+	req.PushAmount, err = parseOptionalInt(values[2])
+	if err != nil || req.PushAmount < 0 || req.PushAmount > req.Amount {
+		return nil, errors.New("invalid push amount")
+	}
+	feeRate, err := parseOptionalInt(values[3])
+	if err != nil || feeRate < 0 {
+		return nil, errors.New("invalid fee rate")
+	}
+	req.SatPerVByte = uint64(feeRate)
+	req.MinHtlcMsat, err = parseOptionalInt(values[5])
+	if err != nil || req.MinHtlcMsat < 0 {
+		return nil, errors.New("invalid min htlc")
+	}
+	return req, nil
+}
+
+func (c *controller) CloseChannel(g *gocui.Gui, v *gocui.View) error {
+	if v.Name() != views.CHANNELS {
+		return nil
+	}
+	channel := c.models.Channels.Get(c.views.Channels.Index())
+	if channel == nil ||
+		channel.Status != netmodels.ChannelActive && channel.Status != netmodels.ChannelInactive {
+		return nil
+	}
+
+	fields := []*views.DialogField{
+		{Label: "Type", Value: "cooperative", Options: []string{"cooperative", "force"}},
+		{Label: "Fee rate (sat/vB)"},
+		{Label: "Address"},
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Close channel", fields, func(values []string) error {
+		req, err := parseCloseChannel(values)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		txid, err := m.CloseChannel(ctx, channel, req)
+		if err != nil {
+			c.logger.Error("close channel", logging.Error(err))
+			return err
+		}
+		c.logger.Info("channel closing", logging.String("txid", txid))
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		req, err := parseCloseChannel(values)
+		if err != nil {
+			return nil, err
+		}
+		alias, _ := channel.ShortAlias()
+		lines := []string{fmt.Sprintf("Channel: %s %s", alias, channel.ChannelPoint)}
+		if req.Force {
+			return append(lines,
+				"Type: force close",
+				fmt.Sprintf("Fee rate: commitment fee rate of %d sat/kw", channel.FeePerKiloWeight),
+				fmt.Sprintf("Funds: %d sat to the wallet after %d blocks",
+					channel.LocalBalance, channel.CSVDelay),
+			), nil
+		}
+		feeRate := "estimated by the node"
+		if req.SatPerVByte > 0 {
+			feeRate = fmt.Sprintf("%d sat/vB", req.SatPerVByte)
+		}
+		destination := "the wallet"
+		if req.DeliveryAddress != "" {
+			destination = req.DeliveryAddress
+		}
+		return append(lines,
+			"Type: cooperative close",
+			fmt.Sprintf("Fee rate: %s", feeRate),
+			fmt.Sprintf("Funds: %d sat to %s", channel.LocalBalance, destination),
+		), nil
+	})
+	return nil
+}
+
+// parseCloseChannel parses the values of the close channel dialog.
+func parseCloseChannel(values []string) (*netmodels.CloseChannelRequest, error) {
+	req := &netmodels.CloseChannelRequest{
+		Force:           values[0] == "force",
+		DeliveryAddress: strings.TrimSpace(values[2]),
+	}
+	feeRate, err := parseOptionalInt(values[1])
+	if err != nil || feeRate < 0 {
+		return nil, errors.New("invalid fee rate")
+	}
+	req.SatPerVByte = uint64(feeRate)
+
+	// the commitment transaction is already signed, its fee rate and
+	// outputs cannot be chosen.
+	if req.Force && req.SatPerVByte > 0 {
+		return nil, errors.New("the fee rate of a force close cannot be set")
+	}
+	if req.Force && req.DeliveryAddress != "" {
+		return nil, errors.New("the funds of a force close go to the wallet")
+	}
+	return req, nil
+}
+
+// parseOptionalInt parses the value of a field that can be left empty.
+func parseOptionalInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// ConnectPeer opens the dialog connecting to a new peer.
+func (c *controller) ConnectPeer(g *gocui.Gui, v *gocui.View) error {
+	fields := []*views.DialogField{
+		{Label: "Node (pubkey@host)"},
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Connect peer", fields, func(values []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		err := m.ConnectPeer(ctx, strings.TrimSpace(values[0]))
+		if err != nil {
+			c.logger.Error("connect peer", logging.Error(err))
+		}
+		return err
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		node := strings.TrimSpace(values[0])
+		parts := strings.SplitN(node, "@", 2)
+		if len(parts) != 2 || len(parts[0]) != 66 || parts[1] == "" {
+			return nil, errors.New("invalid node, expected pubkey@host")
+		}
+		return []string{
+			fmt.Sprintf("Node: %s", parts[0]),
+			fmt.Sprintf("Address: %s", parts[1]),
+		}, nil
+	})
+	return nil
+}
+
+// DisconnectPeer asks for the confirmation of the disconnection of the
+// selected peer.
+func (c *controller) DisconnectPeer(g *gocui.Gui, v *gocui.View) error {
+	peer := c.models.Peers.Get(c.views.Peers.Index())
+	if peer == nil {
+		return nil
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Disconnect peer", nil, func(values []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		err := m.DisconnectPeer(ctx, peer.PubKey)
+		if err != nil {
+			c.logger.Error("disconnect peer", logging.Error(err))
+		}
+		return err
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		alias, _ := peer.ShortAlias()
+		lines := []string{
+			fmt.Sprintf("Peer: %s", alias),
+			fmt.Sprintf("Node: %s", peer.PubKey),
+		}
+		if peer.NumChannels > 0 {
+			lines = append(lines, fmt.Sprintf(
+				"Channels: %d, inactive until the peer reconnects", peer.NumChannels))
+		}
+		return lines, nil
+	})
+	c.views.Dialog.Confirm()
+	return nil
+}
+
+// Pay opens the dialog paying a payment request, the dialog follows the
+// payment once it is sent.
+func (c *controller) Pay(g *gocui.Gui, v *gocui.View) error {
+	fields := []*views.DialogField{
+		{Label: "Payment request"},
+		{Label: "Fee limit (sat)"},
+	}
+
+	// payreq is decoded by the summary before the payment is applied.
+	var payreq *netmodels.PayReq
+	m := c.models
+	c.views.Dialog.Open("Pay", fields, func(values []string) error {
+		feeLimit, err := parseOptionalInt(values[1])
+		if err != nil || feeLimit < 0 {
+			return errors.New("invalid fee limit")
+		}
+
+		req := payreq
+		var (
+			sent    *netmodels.Payment
+			sendErr error
+		)
+		g.UpdateAsync(func(*gocui.Gui) error {
+			c.views.Dialog.Report(func() []string {
+				return views.PaymentReport(req, m.Payments.Find(req.PaymentHash), sent, sendErr)
+			})
+			return nil
+		})
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+			defer cancel()
+			payment, err := m.SendPayment(ctx, req, feeLimit)
+			if err != nil {
+				c.logger.Error("send payment", logging.Error(err))
+			}
+			g.Update(func(*gocui.Gui) error {
+				sent, sendErr = payment, err
+				return nil
+			})
+		}()
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		feeLimit, err := parseOptionalInt(values[1])
+		if err != nil || feeLimit < 0 {
+			return nil, errors.New("invalid fee limit")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		payreq, err = m.DecodePayReq(ctx, strings.TrimSpace(values[0]))
+		if err != nil {
+			c.logger.Debug("decode payreq", logging.Error(err))
+			return nil, errors.New("invalid payment request")
+		}
+		if payreq.Amount <= 0 {
+			return nil, errors.New("payment requests without amount are not supported")
+		}
+		expiry := time.Unix(payreq.Timestamp+payreq.Expiry, 0)
+		if expiry.Before(time.Now()) {
+			return nil, errors.New("expired payment request")
+		}
+
+		alias := "unknown"
+		node, err := m.GetNode(ctx, payreq.Destination)
+		if err == nil && node.Alias != "" {
+			alias = node.Alias
+		}
+		fee := "unknown, no route found"
+		estimate, err := m.EstimateRouteFee(ctx, payreq)
+		if err == nil {
+			fee = fmt.Sprintf("%d msat", estimate)
+			if feeLimit > 0 && estimate > feeLimit*1000 {
+				fee += ", above the fee limit"
+			}
+		}
+		limit := "default of the node"
+		if feeLimit > 0 {
+			limit = fmt.Sprintf("%d sat", feeLimit)
+		}
+		return []string{
+			fmt.Sprintf("Destination: %s", alias),
+			fmt.Sprintf("Node: %s", payreq.Destination),
+			fmt.Sprintf("Amount: %d sat", payreq.Amount),
+			fmt.Sprintf("Description: %s", payreq.Description),
+			fmt.Sprintf("Expiry: %s", expiry.Format("15:04:05 Jan _2")),
+			fmt.Sprintf("Estimated fee: %s", fee),
+			fmt.Sprintf("Fee limit: %s", limit),
+		}, nil
+	})
+	return nil
+}
+
+// Receive opens the dialog creating an invoice or a new on-chain address,
+// its QR code is displayed once it is created.
+func (c *controller) Receive(g *gocui.Gui, v *gocui.View) error {
+	fields := []*views.DialogField{
+		{Label: "Type", Value: "invoice", Options: []string{"invoice", "address"}},
+		{Label: "Amount (sat)"},
+		{Label: "Description"},
+	}
+
+	m := c.models
+	c.views.Dialog.Open("Receive", fields, func(values []string) error {
+		amount, err := parseOptionalInt(values[1])
+		if err != nil || amount < 0 {
+			return errors.New("invalid amount")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+
+		if values[0] == "address" {
+			address, err := m.NewAddress(ctx)
+			if err != nil {
+				c.logger.Error("new address", logging.Error(err))
+				return err
+			}
+			g.UpdateAsync(func(*gocui.Gui) error {
+				c.showAddress(address, amount)
+				return nil
+			})
+			return nil
+		}
+
+		invoice, err := m.CreateInvoice(ctx, amount, strings.TrimSpace(values[2]))
+		if err != nil {
+			c.logger.Error("create invoice", logging.Error(err))
+			return err
+		}
+		g.UpdateAsync(func(*gocui.Gui) error {
+			c.showInvoice(invoice)
+			return nil
+		})
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		amount, err := parseOptionalInt(values[1])
+		if err != nil || amount < 0 {
+			return nil, errors.New("invalid amount")
+		}
+		requested := "any, chosen by the payer"
+		if amount > 0 {
+			requested = fmt.Sprintf("%d sat", amount)
+		}
+		if values[0] == "address" {
+			return []string{
+				"Type: new on-chain address",
+				fmt.Sprintf("Amount: %s", requested),
+			}, nil
+		}
+		return []string{
+			"Type: invoice",
+			fmt.Sprintf("Amount: %s", requested),
+			fmt.Sprintf("Description: %s", strings.TrimSpace(values[2])),
+		}, nil
+	})
+	return nil
+}
+
+// sendTargets are the confirmation targets, in blocks, of the fee estimates
+// of the send dialog.
+var sendTargets = []string{"2", "6", "12", "144"}
+
+// estimateFees estimates the fee rates for the sendTargets in the background,
+// set is called on the gui thread with the rates by target, the targets whose
+// rate cannot be estimated are missing. The summary of a dialog waiting for a
+// confirmation is displayed again with the rates.
+func (c *controller) estimateFees(g *gocui.Gui, m *models.Models, set func(map[int64]uint64)) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		rates := make(map[int64]uint64, len(sendTargets))
+		for _, t := range sendTargets {
+			blocks, _ := strconv.ParseInt(t, 10, 32)
+			rate, err := m.EstimateFee(ctx, int32(blocks))
+			if err != nil {
+				c.logger.Debug("estimate fee", logging.Error(err))
+				continue
+			}
+			rates[blocks] = rate
+		}
+		g.Update(func(*gocui.Gui) error {
+			set(rates)
+			if c.views.Dialog.Confirming() {
+				c.views.Dialog.Confirm()
+			}
+			return nil
+		})
+	}()
+}
+
+// SelectUtxo selects the output under the cursor to be spent by the next
+// transaction or unselects it.
+func (c *controller) SelectUtxo(g *gocui.Gui, v *gocui.View) error {
+	c.models.Utxos.Toggle(c.views.Wallet.Index())
+	return nil
+}
+
+// SendCoins opens the dialog sending an on-chain payment, it spends the
+// selected outputs or the ones chosen by the node when none is selected.
+func (c *controller) SendCoins(g *gocui.Gui, v *gocui.View) error {
+	fields := []*views.DialogField{
+		{Label: "Address"},
+		{Label: "Amount (sat)"},
+		{Label: "Target (blocks)", Value: "6", Options: sendTargets},
+		{Label: "Fee rate (sat/vB)"},
+	}
+
+	// req is built by the summary before the payment is applied, the fee
+	// rates are estimated once the dialog is opened.
+	var (
+		req   *netmodels.SendCoinsRequest
+		rates map[int64]uint64
+	)
+	m := c.models
+	c.estimateFees(g, m, func(estimated map[int64]uint64) { rates = estimated })
+	c.views.Dialog.Open("Send", fields, func(values []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		txid, err := m.SendCoins(ctx, req)
+		if err != nil {
+			c.logger.Error("send coins", logging.Error(err))
+			return err
+		}
+		lines := []string{
+			fmt.Sprintf("Address: %s", req.Address),
+			fmt.Sprintf("Transaction: %s", txid),
+		}
+		g.UpdateAsync(func(*gocui.Gui) error {
+			c.views.Dialog.Report(func() []string { return lines })
+			return nil
+		})
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		address := strings.TrimSpace(values[0])
+		if address == "" {
+			return nil, errors.New("missing address")
+		}
+		amount, err := parseOptionalInt(values[1])
+		if err != nil || amount < 0 {
+			return nil, errors.New("invalid amount")
+		}
+		target, err := strconv.ParseInt(values[2], 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid confirmation target")
+		}
+		feeRate, err := parseOptionalInt(values[3])
+		if err != nil || feeRate < 0 {
+			return nil, errors.New("invalid fee rate")
+		}
+
+		selected := m.Utxos.Selected()
+		inputs := m.Utxos.Inputs(amount)
+		total := int64(0)
+		for _, input := range inputs {
+			total += input.Amount
+		}
+		if len(inputs) == 0 || total < amount {
+			return nil, errors.New("insufficient funds")
+		}
+
+		// the transaction has a change output unless it sends all the
+		// inputs.
+		outputs := 2
+		sent := fmt.Sprintf("%d sat", amount)
+		if amount == 0 {
+			outputs = 1
+			sent = "all the inputs, without change"
+		}
+		vsize := netmodels.EstimateVSize(inputs, outputs)
+
+		origin := "estimated selection of the node"
+		if len(selected) > 0 {
+			origin = "selected"
+		}
+		lines := []string{
+			fmt.Sprintf("Address: %s", address),
+			fmt.Sprintf("Amount: %s", sent),
+			fmt.Sprintf("Inputs: %d outputs, %d sat (%s)", len(inputs), total, origin),
+			fmt.Sprintf("Estimated size: %d vB", vsize),
+			"Fee estimates:",
+		}
+
+		satPerVByte := uint64(feeRate)
+		for _, t := range sendTargets {
+			blocks, _ := strconv.ParseInt(t, 10, 32)
+			marker := " "
+			if blocks == target {
+				marker = ">"
+			}
+			rate, ok := rates[blocks]
+			if !ok {
+				lines = append(lines, fmt.Sprintf("%s %3d blocks: %s", marker, blocks, estimateState(rates)))
+				continue
+			}
+			if blocks == target && feeRate == 0 {
+				satPerVByte = rate
+			}
+			lines = append(lines, fmt.Sprintf("%s %3d blocks: %3d sat/vB, %d sat",
+				marker, blocks, rate, int64(rate)*vsize))
+		}
+
+		fee := int64(satPerVByte) * vsize
+		switch {
+		case feeRate > 0:
+			lines = append(lines, fmt.Sprintf("Fee: %d sat/vB, %d sat", satPerVByte, fee))
+		case satPerVByte > 0:
+			lines = append(lines, fmt.Sprintf("Fee: %d sat/vB, %d sat for %d blocks", satPerVByte, fee, target))
+		default:
+			lines = append(lines, fmt.Sprintf("Fee: estimated by the node for %d blocks", target))
+		}
+		if len(selected) > 0 && total < amount+fee {
+			return nil, errors.New("insufficient funds in the selected outputs")
+		}
+		if amount == 0 && total <= fee {
+			return nil, errors.New("the fee is above the amount of the inputs")
+		}
+
+		req = &netmodels.SendCoinsRequest{
+			Address:     address,
+			Amount:      amount,
+			SendAll:     amount == 0,
+			SatPerVByte: satPerVByte,
+			TargetConf:  int32(target),
+		}
+		for _, utxo := range selected {
+			req.Outpoints = append(req.Outpoints, utxo.Outpoint())
+		}
+		return lines, nil
+	})
+	return nil
+}
+
+// estimateState describes a fee rate missing from the rates, it is not
+// estimated yet or cannot be estimated.
+func estimateState(rates map[int64]uint64) string {
+	if rates == nil {
+		return "estimating..."
+	}
+	return "unknown"
+}
+
+// BumpFee opens the dialog bumping the fee of the displayed transaction with
+// a CPFP spending one of its outputs or with a RBF.
+func (c *controller) BumpFee(g *gocui.Gui, v *gocui.View) error {
+	tx := c.models.Transactions.Current()
+	if tx == nil {
+		return nil
+	}
+
+	m := c.models
+	outputs := m.Utxos.Outputs(tx.TxHash)
+	outpoints := make([]string, len(outputs))
+	for i := range outputs {
+		outpoints[i] = outputs[i].Outpoint()
+	}
+	output := &views.DialogField{Label: "Output (CPFP)", Options: outpoints}
+	if len(outpoints) > 0 {
+		output.Value = outpoints[0]
+	}
+	fields := []*views.DialogField{
+		{Label: "Method", Value: "cpfp", Options: []string{"cpfp", "rbf"}},
+		output,
+		{Label: "Target (blocks)", Value: "2", Options: sendTargets},
+		{Label: "Fee rate (sat/vB)"},
+	}
+
+	// req and its target rate are built by the summary before the fee is
+	// bumped, the fee rates are estimated once the dialog is opened.
+	var (
+		req   *netmodels.BumpFeeRequest
+		rate  uint64
+		rates map[int64]uint64
+	)
+	c.estimateFees(g, m, func(estimated map[int64]uint64) { rates = estimated })
+	c.views.Dialog.Open("Bump fee", fields, func(values []string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+		err := m.BumpFee(ctx, req)
+		if err != nil {
+			c.logger.Error("bump fee", logging.Error(err))
+			return err
+		}
+		lines := []string{
+			fmt.Sprintf("Transaction: %s", req.TxID),
+			fmt.Sprintf("Fee bumped to %d sat/vB", rate),
+		}
+		g.UpdateAsync(func(*gocui.Gui) error {
+			c.views.Dialog.Report(func() []string { return lines })
+			return nil
+		})
+		return nil
+	})
+	c.views.Dialog.SetSummary(func(values []string) ([]string, error) {
+		if tx.NumConfirmations > 0 {
+			return nil, errors.New("the transaction is confirmed")
+		}
+		target, err := strconv.ParseInt(values[2], 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid confirmation target")
+		}
+		feeRate, err := parseOptionalInt(values[3])
+		if err != nil || feeRate < 0 {
+			return nil, errors.New("invalid fee rate")
+		}
+
+		// the fee is only known for the transactions sent by the wallet.
+		size := "unknown size"
+		if tx.VSize > 0 {
+			size = fmt.Sprintf("%d vB", tx.VSize)
+		}
+		current := "unknown fee"
+		if tx.TotalFees > 0 {
+			current = fmt.Sprintf("%d sat", tx.TotalFees)
+			if tx.VSize > 0 {
+				current = fmt.Sprintf("%d sat, %.1f sat/vB", tx.TotalFees, tx.FeeRate())
+			}
+		}
+		lines := []string{
+			fmt.Sprintf("Transaction: %s", tx.TxHash),
+			fmt.Sprintf("Current: %s, %s", size, current),
+			"Fee estimates:",
+		}
+
+		satPerVByte := uint64(feeRate)
+		for _, t := range sendTargets {
+			blocks, _ := strconv.ParseInt(t, 10, 32)
+			marker := " "
+			if blocks == target {
+				marker = ">"
+			}
+			estimate, ok := rates[blocks]
+			if !ok {
+				lines = append(lines, fmt.Sprintf("%s %3d blocks: %s", marker, blocks, estimateState(rates)))
+				continue
+			}
+			if blocks == target && feeRate == 0 {
+				satPerVByte = estimate
+			}
+			lines = append(lines, fmt.Sprintf("%s %3d blocks: %3d sat/vB", marker, blocks, estimate))
+		}
+		if satPerVByte == 0 && rates == nil {
+			return nil, errors.New("the fee rates are being estimated")
+		}
+		if satPerVByte == 0 {
+			return nil, errors.New("missing fee rate")
+		}
+		if tx.FeeRate() > 0 && float64(satPerVByte) <= tx.FeeRate() {
+			return nil, errors.Errorf("the fee rate is not above the current rate of %.1f sat/vB", tx.FeeRate())
+		}
+
+		rate = satPerVByte
+		req = &netmodels.BumpFeeRequest{
+			TxID:        tx.TxHash,
+			SatPerVByte: satPerVByte,
+		}
+		if values[0] == "rbf" {
+			if tx.VSize == 0 || len(tx.Inputs) == 0 {
+				return nil, errors.New("the inputs of the transaction are unknown, it cannot be replaced")
+			}
+			fee := int64(satPerVByte) * tx.VSize
+			req.Method = netmodels.BumpFeeRBF
+			req.Inputs = tx.Inputs
+			lines = append(lines,
+				fmt.Sprintf("RBF: replacement at %d sat/vB, %d sat", satPerVByte, fee),
+				fmt.Sprintf("Additional fee: %d sat", fee-tx.TotalFees),
+			)
+			return lines, nil
+		}
+
+		var utxo *netmodels.Utxo
+		for i := range outputs {
+			if outputs[i].Outpoint() == values[1] {
+				utxo = outputs[i]
+			}
+		}
+		if utxo == nil {
+			return nil, errors.New("the transaction has no unspent output of the wallet")
+		}
+
+		// the child pays the fee missing from the transaction for the
+		// target rate of both, it pays the rate alone when the size of the
+		// transaction is unknown.
+		childVSize := netmodels.EstimateVSize([]*netmodels.Utxo{utxo}, 1)
+		packageVSize, packageFee := childVSize, int64(0)
+		if tx.VSize > 0 {
+			packageVSize, packageFee = tx.VSize+childVSize, tx.TotalFees
+		}
+		childFee := int64(satPerVByte)*packageVSize - packageFee
+		childRate := (childFee + childVSize - 1) / childVSize
+		childFee = childRate * childVSize
+		if childFee >= utxo.Amount {
+			return nil, errors.Errorf("the output of %d sat cannot pay the fee of the child", utxo.Amount)
+		}
+		req.Method = netmodels.BumpFeeCPFP
+		req.Outpoint = utxo.Outpoint()
+		req.SatPerVByte = uint64(childRate)
+		lines = append(lines,
+			fmt.Sprintf("CPFP: child spending %d sat, %d vB", utxo.Amount, childVSize),
+			fmt.Sprintf("Child fee: %d sat/vB, %d sat", childRate, childFee),
+			fmt.Sprintf("Package: %.1f sat/vB", float64(packageFee+childFee)/float64(packageVSize)),
+		)
+		return lines, nil
+	})
+	return nil
+}
+
+// ShowQRCode displays the QR code of the payment request of the selected
+// invoice.
+func (c *controller) ShowQRCode(g *gocui.Gui, v *gocui.View) error {
+	invoice := c.models.Invoices.Current()
+	if v.Name() == views.INVOICES {
+		invoice = c.models.Invoices.Get(c.views.Invoices.Index())
+	}
+	if invoice == nil || invoice.PaymentRequest == "" {
+		return nil
+	}
+	c.showInvoice(invoice)
+	return nil
+}
+
+// showInvoice displays the QR code of the payment request of the invoice, the
+// state of the invoice below the code is updated once it is paid.
+func (c *controller) showInvoice(invoice *netmodels.Invoice) {
+	m := c.models
+	// the upper case payment request is encoded with the alphanumeric mode
+	// of the QR codes, which is denser.
+	c.views.QRCode.Open("Invoice", strings.ToUpper(invoice.PaymentRequest), func() []string {
+		if updated := m.Invoices.Find(invoice.RHash); updated != nil {
+			invoice = updated
+		}
+		return views.InvoiceReport(invoice)
+	})
+}
+
+// showAddress displays the QR code of the BIP21 URI of the address.
+func (c *controller) showAddress(address string, amount int64) {
+	lines := []string{fmt.Sprintf("Address: %s", address)}
+	if amount > 0 {
+		lines = append(lines, fmt.Sprintf("Amount: %d sat", amount))
+	}
+	c.views.QRCode.Open("Address", addressURI(address, amount), func() []string {
+		return lines
+	})
+}
+
+// addressURI returns the bip21 uri of the address. The bech32 addresses
+// are uppercased for a denser QR code, the base58 ones are case sensitive.
+func addressURI(address string, amount int64) string {
+	uri := "bitcoin:" + address
+	lower := strings.ToLower(address)
+	for _, hrp := range []string{"bc1", "tb1", "bcrt1"} {
+		if strings.HasPrefix(lower, hrp) {
+			uri = strings.ToUpper(uri)
+			break
+		}
+	}
+	if amount > 0 {
+		uri += "?amount=" + strconv.FormatFloat(float64(amount)/1e8, 'f', -1, 64)
+	}
+	return uri
+}
+
+// CloseQRCode hides the QR code.
+func (c *controller) CloseQRCode(g *gocui.Gui, v *gocui.View) error {
+	err := c.views.QRCode.Close(g)
+	if err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView(c.views.Main.Name())
+	return err
+}
+
+// DialogEnter confirms the changes of the dialog, then applies them. A
+// dialog reporting what it applied is closed.
+func (c *controller) DialogEnter(g *gocui.Gui, v *gocui.View) error {
+	if c.views.Dialog.Pending() {
+		return nil
+	}
+	if c.views.Dialog.Reporting() {
+		return c.closeDialog(g)
+	}
+	if !c.views.Dialog.Confirming() {
+		c.views.Dialog.Confirm()
+		return nil
+	}
+	c.views.Dialog.Apply(g, func(g *gocui.Gui) error {
+		if c.views.Dialog.Reporting() {
+			return nil
+		}
+		return c.closeDialog(g)
+	})
+	return nil
+}
+
+// DialogEscape goes back from the confirmation or closes the dialog, a
+// pending dialog stays opened until it is applied.
+func (c *controller) DialogEscape(g *gocui.Gui, v *gocui.View) error {
+	if c.views.Dialog.Pending() {
+		return nil
+	}
+	if c.views.Dialog.Confirming() && c.views.Dialog.Editable() {
+		c.views.Dialog.Cancel()
+		return nil
+	}
+	return c.closeDialog(g)
+}
+
+func (c *controller) DialogNext(g *gocui.Gui, v *gocui.View) error {
+	c.views.Dialog.Next()
+	return nil
+}
+
+func (c *controller) DialogPrevious(g *gocui.Gui, v *gocui.View) error {
+	c.views.Dialog.Previous()
+	return nil
+}
+
+func (c *controller) closeDialog(g *gocui.Gui) error {
+	err := c.views.Dialog.Close(g)
+	if err != nil {
+		return err
+	}
+	_, err = g.SetCurrentView(c.views.Main.Name())
+	return err
+}
+
 func ToggleView(g *gocui.Gui, v1, v2 views.View) error {
 	maxX, maxY := g.Size()
 	err := v1.Delete(g)
@@ -350,10 +1648,25 @@ func ToggleView(g *gocui.Gui, v1, v2 views.View) error {
 }
 
 func newController(app *app.App) *controller {
-	m := models.New(app)
+	nodes := models.NewNodes(app)
+	nodeViews := make([]*views.Views, nodes.Len())
+	for i, m := range nodes.List() {
+		nodeViews[i] = views.New(app.Config.Views, m, nodes)
+	}
+	// the nodes of a recording share the clock of the replay.
+	var replay replayer
+	for i := range app.Networks {
+		if r, ok := app.Networks[i].Backend.(replayer); ok {
+			replay = r
+			break
+		}
+	}
 	return &controller{
-		logger: app.Logger.With(logging.String("logger", "controller")),
-		models: m,
-		views:  views.New(app.Config.Views, m),
+		logger:    app.Logger.With(logging.String("logger", "controller")),
+		models:    nodes.Current(),
+		views:     nodeViews[nodes.CurrentIndex()],
+		nodes:     nodes,
+		nodeViews: nodeViews,
+		replay:    replay,
 	}
 }
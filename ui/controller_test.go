@@ -0,0 +1,48 @@
+package ui
+
+import "testing"
+
+func TestAddressURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		amount  int64
+		want    string
+	}{
+		{
+			name:    "bech32",
+			address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+			want:    "BITCOIN:BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ",
+		},
+		{
+			name:    "bech32 with an amount",
+			address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+			amount:  150000,
+			want:    "BITCOIN:TB1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KXPJZSX?amount=0.0015",
+		},
+		{
+			name:    "regtest bech32",
+			address: "bcrt1qs758ursh4q9z627kt3pp5yysm78ddny6txaqgw",
+			want:    "BITCOIN:BCRT1QS758URSH4Q9Z627KT3PP5YYSM78DDNY6TXAQGW",
+		},
+		{
+			name:    "base58",
+			address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+			want:    "bitcoin:3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+		},
+		{
+			name:    "base58 with an amount",
+			address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2",
+			amount:  100000000,
+			want:    "bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2?amount=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := addressURI(tt.address, tt.amount)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/awesome-gocui/gocui"
 	"github.com/edouardparis/lntop/ui/models"
+	"github.com/edouardparis/lntop/ui/views"
 )
 
 func quit(g *gocui.Gui, v *gocui.View) error {
@@ -125,5 +126,117 @@ func setKeyBinding(c *controller, g *gocui.Gui) error {
 		return err
 	}
 
+	err = g.SetKeybinding("", 'n', gocui.ModNone, c.NextNode)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding("", 'e', gocui.ModNone, c.EditPolicy)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding("", 'o', gocui.ModNone, c.OpenChannel)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding("", 'x', gocui.ModNone, c.CloseChannel)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding("", 'p', gocui.ModNone, c.Pay)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding("", 'r', gocui.ModNone, c.Receive)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.INVOICES, 's', gocui.ModNone, c.ShowQRCode)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.INVOICE, 's', gocui.ModNone, c.ShowQRCode)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.QRCODE, gocui.KeyEsc, gocui.ModNone, c.CloseQRCode)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.QRCODE, gocui.KeyEnter, gocui.ModNone, c.CloseQRCode)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.PEERS, 'o', gocui.ModNone, c.ConnectPeer)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.PEERS, 'x', gocui.ModNone, c.DisconnectPeer)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.TRANSACTION, 'b', gocui.ModNone, c.BumpFee)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.WALLET, gocui.KeySpace, gocui.ModNone, c.SelectUtxo)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.WALLET, 's', gocui.ModNone, c.SendCoins)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.DIALOG, gocui.KeyEnter, gocui.ModNone, c.DialogEnter)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.DIALOG, gocui.KeyEsc, gocui.ModNone, c.DialogEscape)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.DIALOG, gocui.KeyTab, gocui.ModNone, c.DialogNext)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.DIALOG, gocui.KeyArrowDown, gocui.ModNone, c.DialogNext)
+	if err != nil {
+		return err
+	}
+
+	err = g.SetKeybinding(views.DIALOG, gocui.KeyArrowUp, gocui.ModNone, c.DialogPrevious)
+	if err != nil {
+		return err
+	}
+
+	if c.replay != nil {
+		err = g.SetKeybinding("", 'P', gocui.ModNone, c.PauseReplay)
+		if err != nil {
+			return err
+		}
+
+		err = g.SetKeybinding("", '.', gocui.ModNone, c.StepReplay)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edouardparis/lntop/history"
+)
+
+// MaxBalancePeriod is the longest period of the balance history.
+const MaxBalancePeriod = 30 * 24 * time.Hour
+
+// BalanceSample is the local balance of a channel at a time.
+type BalanceSample struct {
+	Time         time.Time
+	LocalBalance int64
+	Capacity     int64
+}
+
+// Ratio returns the local balance per capacity.
+func (s BalanceSample) Ratio() float64 {
+	if s.Capacity == 0 {
+		return 0
+	}
+	return float64(s.LocalBalance) / float64(s.Capacity)
+}
+
+// BalanceHistory is the balance of the channels over the last
+// MaxBalancePeriod, it is taken from the snapshots of the history.
+type BalanceHistory struct {
+	last    time.Time
+	samples map[string][]BalanceSample
+	mu      sync.RWMutex
+}
+
+func NewBalanceHistory() *BalanceHistory {
+	return &BalanceHistory{samples: make(map[string][]BalanceSample)}
+}
+
+// Last returns the time of the last added snapshot.
+func (b *BalanceHistory) Last() time.Time {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.last
+}
+
+// Samples returns the samples of the channel since the time, from the
+// oldest.
+func (b *BalanceHistory) Samples(chanPoint string, since time.Time) []BalanceSample {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	samples := b.samples[chanPoint]
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].Time.Before(since)
+	})
+	return samples[i:]
+}
+
+// Add adds the snapshots newer than the last added one, the samples older
+// than MaxBalancePeriod are dropped.
+func (b *BalanceHistory) Add(snapshots []*history.Snapshot) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, snapshot := range snapshots {
+		if !snapshot.Time.After(b.last) {
+			continue
+		}
+		for _, c := range snapshot.Channels {
+			b.samples[c.ChannelPoint] = append(b.samples[c.ChannelPoint], BalanceSample{
+				Time:         snapshot.Time,
+				LocalBalance: c.LocalBalance,
+				Capacity:     c.Capacity,
+			})
+		}
+		b.last = snapshot.Time
+	}
+
+	min := time.Now().Add(-MaxBalancePeriod)
+	for chanPoint, samples := range b.samples {
+		i := 0
+		for i < len(samples) && samples[i].Time.Before(min) {
+			i++
+		}
+		if i == len(samples) {
+			delete(b.samples, chanPoint)
+			continue
+		}
+		if i > 0 {
+			b.samples[chanPoint] = append([]BalanceSample{}, samples[i:]...)
+		}
+	}
+}
+
+// RefreshBalanceHistory adds the snapshots of the history recorded since the
+// last refresh.
+func (m *Models) RefreshBalanceHistory(ctx context.Context) error {
+	if m.history == nil {
+		return nil
+	}
+	since := time.Now().Add(-MaxBalancePeriod)
+	if last := m.BalanceHistory.Last(); last.After(since) {
+		since = last.Add(time.Nanosecond)
+	}
+	snapshots, err := m.history.Snapshots(m.Name(), since)
+	if err != nil {
+		return err
+	}
+	m.BalanceHistory.Add(snapshots)
+	return nil
+}
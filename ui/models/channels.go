@@ -106,6 +106,7 @@ func (c *Channels) Update(newChannel *models.Channel) {
 	oldChannel.PendingHTLC = newChannel.PendingHTLC
 	oldChannel.Age = newChannel.Age
 	oldChannel.BlocksTilMaturity = newChannel.BlocksTilMaturity
+	oldChannel.Pending = newChannel.Pending
 
 	if newChannel.LastUpdate != nil {
 		oldChannel.LastUpdate = newChannel.LastUpdate
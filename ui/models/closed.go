@@ -0,0 +1,90 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+type ClosedChannelsSort func(*models.ClosedChannel, *models.ClosedChannel) bool
+
+// ClosedChannels are the channels closed by the node, they are retrieved
+// from the node and are kept across restarts.
+type ClosedChannels struct {
+	list []*models.ClosedChannel
+	sort ClosedChannelsSort
+	// nodes caches the nodes of the channels, they are only retrieved once.
+	nodes map[string]*models.Node
+	mu    sync.RWMutex
+}
+
+func NewClosedChannels() *ClosedChannels {
+	return &ClosedChannels{nodes: make(map[string]*models.Node)}
+}
+
+func (c *ClosedChannels) List() []*models.ClosedChannel {
+	return c.list
+}
+
+func (c *ClosedChannels) Len() int {
+	return len(c.list)
+}
+
+func (c *ClosedChannels) Swap(i, j int) {
+	c.list[i], c.list[j] = c.list[j], c.list[i]
+}
+
+func (c *ClosedChannels) Less(i, j int) bool {
+	return c.sort(c.list[i], c.list[j])
+}
+
+func (c *ClosedChannels) Sort(s ClosedChannelsSort) {
+	if s == nil {
+		return
+	}
+	c.sort = s
+	sort.Sort(c)
+}
+
+func (c *ClosedChannels) Get(index int) *models.ClosedChannel {
+	if index < 0 || index > len(c.list)-1 {
+		return nil
+	}
+
+	return c.list[index]
+}
+
+func (c *ClosedChannels) Update(channels []*models.ClosedChannel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list = channels
+	if c.sort != nil {
+		sort.Sort(c)
+	}
+}
+
+func (m *Models) RefreshClosedChannels(ctx context.Context) error {
+	channels, err := m.network.ClosedChannels(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		node, ok := m.ClosedChannels.nodes[channel.RemotePubKey]
+		if !ok {
+			node, err = m.network.GetNode(ctx, channel.RemotePubKey, false)
+			if err != nil {
+				// the peers of the closed channels may have left the
+				// graph.
+				node = &models.Node{PubKey: channel.RemotePubKey}
+			}
+			m.ClosedChannels.nodes[channel.RemotePubKey] = node
+		}
+		channel.Node = node
+	}
+
+	m.ClosedChannels.Update(channels)
+	return nil
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// invoicesPageSize is the number of invoices retrieved by call when the
+// invoices are listed, a page is loaded each time the last invoice loaded is
+// displayed.
+const invoicesPageSize = 100
+
+type InvoicesSort func(*models.Invoice, *models.Invoice) bool
+
+type Invoices struct {
+	current *models.Invoice
+	list    []*models.Invoice
+	sort    InvoicesSort
+	// index is the invoices by payment hash.
+	index map[string]*models.Invoice
+	// offset is the index of the last invoice of the pages loaded.
+	offset   uint64
+	complete bool
+	loading  bool
+	mu       sync.RWMutex
+}
+
+func NewInvoices() *Invoices {
+	return &Invoices{index: make(map[string]*models.Invoice)}
+}
+
+func (i *Invoices) Current() *models.Invoice {
+	return i.current
+}
+
+func (i *Invoices) SetCurrent(index int) {
+	i.current = i.Get(index)
+}
+
+func (i *Invoices) List() []*models.Invoice {
+	return i.list
+}
+
+func (i *Invoices) Len() int {
+	return len(i.list)
+}
+
+func (i *Invoices) Swap(a, b int) {
+	i.list[a], i.list[b] = i.list[b], i.list[a]
+}
+
+func (i *Invoices) Less(a, b int) bool {
+	return i.sort(i.list[a], i.list[b])
+}
+
+func (i *Invoices) Sort(s InvoicesSort) {
+	if s == nil {
+		return
+	}
+	i.sort = s
+	sort.Sort(i)
+}
+
+func (i *Invoices) Get(index int) *models.Invoice {
+	if index < 0 || index > len(i.list)-1 {
+		return nil
+	}
+
+	return i.list[index]
+}
+
+// Find returns the invoice with the payment hash.
+func (i *Invoices) Find(hash []byte) *models.Invoice {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.index[string(hash)]
+}
+
+// Complete returns true when all the pages of invoices are loaded.
+func (i *Invoices) Complete() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	return i.complete
+}
+
+// Update adds the invoices, an invoice with the payment hash of a known
+// invoice replaces it in place.
+func (i *Invoices) Update(invoices ...*models.Invoice) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, invoice := range invoices {
+		if invoice == nil {
+			continue
+		}
+		known, ok := i.index[string(invoice.RHash)]
+		if !ok {
+			i.index[string(invoice.RHash)] = invoice
+			i.list = append(i.list, invoice)
+			continue
+		}
+		if invoice.Index == 0 {
+			invoice.Index = known.Index
+		}
+		*known = *invoice
+	}
+
+	if i.sort != nil {
+		sort.Sort(i)
+	}
+}
+
+// RefreshInvoices loads the invoices again from the first page.
+func (m *Models) RefreshInvoices(ctx context.Context) error {
+	m.Invoices.mu.Lock()
+	m.Invoices.offset = 0
+	m.Invoices.complete = false
+	m.Invoices.mu.Unlock()
+	return m.LoadInvoices(ctx)
+}
+
+// LoadInvoices loads the next page of invoices, it does nothing if all of
+// them are loaded or if a page is being loaded.
+func (m *Models) LoadInvoices(ctx context.Context) error {
+	i := m.Invoices
+	i.mu.Lock()
+	if i.complete || i.loading {
+		i.mu.Unlock()
+		return nil
+	}
+	i.loading = true
+	offset := i.offset
+	i.mu.Unlock()
+
+	invoices, err := m.network.ListInvoices(ctx, offset, invoicesPageSize)
+
+	i.mu.Lock()
+	i.loading = false
+	if err == nil {
+		for _, invoice := range invoices {
+			if invoice.Index > i.offset {
+				i.offset = invoice.Index
+			}
+		}
+		i.complete = len(invoices) < invoicesPageSize
+	}
+	i.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	i.Update(invoices...)
+	return nil
+}
+
+// NewAddress returns a new address of the on-chain wallet.
+func (m *Models) NewAddress(ctx context.Context) (string, error) {
+	return m.network.NewAddress(ctx)
+}
+
+// CreateInvoice creates an invoice of the amount in satoshis, the invoice is
+// added to the invoices.
+func (m *Models) CreateInvoice(ctx context.Context, amount int64, desc string) (*models.Invoice, error) {
+	invoice, err := m.network.CreateInvoice(ctx, amount, desc)
+	if err != nil {
+		return nil, err
+	}
+	m.Invoices.Update(invoice)
+	return invoice, nil
+}
+
+func (m *Models) RefreshInvoice(update interface{}) func(context.Context) error {
+	return (func(ctx context.Context) error {
+		invoice, ok := update.(*models.Invoice)
+		if !ok {
+			m.logger.Error("refreshInvoice: invalid event data")
+			return nil
+		}
+		m.Invoices.Update(invoice)
+		return nil
+	})
+}
@@ -3,8 +3,10 @@ package models
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/edouardparis/lntop/app"
+	"github.com/edouardparis/lntop/history"
 	"github.com/edouardparis/lntop/logging"
 	"github.com/edouardparis/lntop/network"
 	"github.com/edouardparis/lntop/network/models"
@@ -14,19 +16,32 @@ import (
 type Models struct {
 	logger          logging.Logger
 	network         *network.Network
+	history         *history.Store
 	Info            *Info
 	Channels        *Channels
 	WalletBalance   *WalletBalance
 	ChannelsBalance *ChannelsBalance
 	Transactions    *Transactions
+	Invoices        *Invoices
+	Payments        *Payments
+	Peers           *Peers
+	Utxos           *Utxos
+	ClosedChannels  *ClosedChannels
+	PendingChannels *PendingChannels
+	BalanceHistory  *BalanceHistory
+	Profit          *Profit
 	RoutingLog      *RoutingLog
 	FwdingHist      *FwdingHist
+	Connection      *Connection
 }
 
-func New(app *app.App) *Models {
+func New(app *app.App, network *network.Network) *Models {
 	fwdingHist := FwdingHist{}
-	startTime := app.Config.Views.FwdingHist.Options.GetOption("START_TIME", "start_time")
-	maxNumEvents := app.Config.Views.FwdingHist.Options.GetOption("MAX_NUM_EVENTS", "max_num_events")
+	startTime, maxNumEvents := "", ""
+	if app.Config.Views.FwdingHist != nil {
+		startTime = app.Config.Views.FwdingHist.Options.GetOption("START_TIME", "start_time")
+		maxNumEvents = app.Config.Views.FwdingHist.Options.GetOption("MAX_NUM_EVENTS", "max_num_events")
+	}
 
 	if startTime != "" {
 		fwdingHist.StartTime = startTime
@@ -41,23 +56,82 @@ func New(app *app.App) *Models {
 		}
 	}
 
-	return &Models{
+	profit := NewProfit()
+	if app.Config.Views.Profit != nil {
+		if s := app.Config.Views.Profit.Options.GetOption("START_TIME", "start_time"); s != "" {
+			profit.StartTime = s
+		}
+	}
+
+	m := &Models{
 		logger:          app.Logger.With(logging.String("logger", "models")),
-		network:         app.Network,
-		Info:            &Info{},
+		network:         network,
+		history:         app.History,
+		Info:            &Info{&models.Info{}},
 		Channels:        NewChannels(),
-		WalletBalance:   &WalletBalance{},
-		ChannelsBalance: &ChannelsBalance{},
+		WalletBalance:   &WalletBalance{&models.WalletBalance{}},
+		ChannelsBalance: &ChannelsBalance{&models.ChannelsBalance{}},
 		Transactions:    &Transactions{},
+		Invoices:        NewInvoices(),
+		Payments:        NewPayments(),
+		Peers:           NewPeers(),
+		Utxos:           NewUtxos(),
+		ClosedChannels:  NewClosedChannels(),
+		PendingChannels: NewPendingChannels(),
+		BalanceHistory:  NewBalanceHistory(),
+		Profit:          profit,
 		RoutingLog:      &RoutingLog{},
 		FwdingHist:      &fwdingHist,
+		Connection:      &Connection{},
 	}
+	// the models of a node down at start stay empty until it reconnects.
+	if app.Unreachable[network.NodeName()] {
+		m.Connection.Connection = &models.Connection{
+			Status: models.ConnectionDown,
+			Since:  time.Now(),
+		}
+	}
+	m.loadRoutingLog()
+	return m
+}
+
+// loadRoutingLog fills the routing log with the last routing events of the
+// history.
+func (m *Models) loadRoutingLog() {
+	if m.history == nil {
+		return
+	}
+	events, err := m.history.RoutingEvents(m.Name(), time.Time{}, MaxRoutingEvents)
+	if err != nil {
+		m.logger.Error("load routing log", logging.Error(err))
+		return
+	}
+	m.RoutingLog.Log = events
+}
+
+// Name returns the name of the node the models are refreshed from.
+func (m *Models) Name() string {
+	return m.network.NodeName()
 }
 
 type Info struct {
 	*models.Info
 }
 
+type Connection struct {
+	*models.Connection
+}
+
+// SetConnection updates the state of the connection to the node, it returns
+// true when the node is connected again.
+func (m *Models) SetConnection(connection *models.Connection) bool {
+	reconnected := m.Connection.Connection != nil &&
+		m.Connection.Status != models.ConnectionConnected &&
+		connection.Status == models.ConnectionConnected
+	*m.Connection = Connection{connection}
+	return reconnected
+}
+
 func (m *Models) RefreshInfo(ctx context.Context) error {
 	info, err := m.network.Info(ctx)
 	if err != nil {
@@ -68,6 +142,10 @@ func (m *Models) RefreshInfo(ctx context.Context) error {
 }
 
 func (m *Models) RefreshForwardingHistory(ctx context.Context) error {
+	if m.history != nil {
+		return m.refreshForwardingHistoryFromStore(ctx)
+	}
+
 	forwardingEvents, err := m.network.GetForwardingHistory(ctx, m.FwdingHist.StartTime, m.FwdingHist.MaxNumEvents)
 	if err != nil {
 		return err
@@ -78,6 +156,31 @@ func (m *Models) RefreshForwardingHistory(ctx context.Context) error {
 	return nil
 }
 
+// refreshForwardingHistoryFromStore synchronizes the history with the new
+// forwarding events of the node and reads the last events since the start
+// time from it.
+func (m *Models) refreshForwardingHistoryFromStore(ctx context.Context) error {
+	err := m.history.SyncForwards(ctx, m.network)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	start, err := options.ParseTime(m.FwdingHist.StartTime, time.Now())
+	if err == nil {
+		since = time.Unix(int64(start), 0)
+	}
+
+	forwardingEvents, err := m.history.ForwardingEvents(m.Name(), since, int(m.FwdingHist.MaxNumEvents))
+	if err != nil {
+		return err
+	}
+
+	m.FwdingHist.Update(forwardingEvents)
+
+	return nil
+}
+
 func (m *Models) RefreshChannels(ctx context.Context) error {
 	channels, err := m.network.ListChannels(ctx, options.WithChannelPending)
 	if err != nil {
@@ -113,12 +216,17 @@ func (m *Models) RefreshChannels(ctx context.Context) error {
 
 		m.Channels.Update(channels[i])
 	}
+	pending := []*models.Channel{}
 	for _, c := range m.Channels.List() {
 		if _, ok := index[c.ChannelPoint]; !ok {
 			c.Status = models.ChannelClosed
 		}
+		if IsPending(c) {
+			pending = append(pending, c)
+		}
 	}
-	return nil
+	m.PendingChannels.Update(pending)
+	return m.RefreshBalanceHistory(ctx)
 }
 
 type WalletBalance struct {
@@ -194,6 +302,36 @@ func (m *Models) RefreshPolicies(update interface{}) func(context.Context) error
 	}
 }
 
+// UpdateChannelPolicy sets the local policy of the channel, the channel is
+// refreshed with the policy announced by the node.
+func (m *Models) UpdateChannelPolicy(ctx context.Context, channel *models.Channel, policy *models.RoutingPolicy) error {
+	err := m.network.UpdateChannelPolicy(ctx, channel, policy)
+	if err != nil {
+		return err
+	}
+	return m.network.GetChannelInfo(ctx, channel)
+}
+
+// OpenChannel opens a channel and returns the funding transaction id, the
+// channels are refreshed with the pending channel.
+func (m *Models) OpenChannel(ctx context.Context, req *models.OpenChannelRequest) (string, error) {
+	txid, err := m.network.OpenChannel(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return txid, m.RefreshChannels(ctx)
+}
+
+// CloseChannel closes the channel and returns the closing transaction id if
+// it is already known, the channels are refreshed with the closing channel.
+func (m *Models) CloseChannel(ctx context.Context, channel *models.Channel, req *models.CloseChannelRequest) (string, error) {
+	txid, err := m.network.CloseChannel(ctx, channel, req)
+	if err != nil {
+		return "", err
+	}
+	return txid, m.RefreshChannels(ctx)
+}
+
 func (m *Models) RefreshCurrentNode(ctx context.Context) (err error) {
 	cur := m.Channels.Current()
 	if cur != nil {
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"github.com/edouardparis/lntop/app"
+)
+
+// Nodes is the list of the models of each monitored node.
+type Nodes struct {
+	list    []*Models
+	current int
+}
+
+func NewNodes(app *app.App) *Nodes {
+	nodes := &Nodes{list: make([]*Models, len(app.Networks))}
+	for i := range app.Networks {
+		nodes.list[i] = New(app, app.Networks[i])
+	}
+	return nodes
+}
+
+func (n *Nodes) List() []*Models {
+	return n.list
+}
+
+func (n *Nodes) Len() int {
+	return len(n.list)
+}
+
+func (n *Nodes) Get(index int) *Models {
+	if index < 0 || index > len(n.list)-1 {
+		return nil
+	}
+	return n.list[index]
+}
+
+// GetByName returns the models of the node with the given name.
+func (n *Nodes) GetByName(name string) *Models {
+	for i := range n.list {
+		if n.list[i].Name() == name {
+			return n.list[i]
+		}
+	}
+	return nil
+}
+
+func (n *Nodes) Current() *Models {
+	return n.list[n.current]
+}
+
+func (n *Nodes) CurrentIndex() int {
+	return n.current
+}
+
+func (n *Nodes) SetCurrent(index int) {
+	if index < 0 || index > len(n.list)-1 {
+		return
+	}
+	n.current = index
+}
+
+// Total sums the balances and the channels counts of all the nodes.
+func (n *Nodes) Total() *NodesTotal {
+	total := &NodesTotal{}
+	for _, m := range n.list {
+		if m.ChannelsBalance.ChannelsBalance != nil {
+			total.ChannelsBalance += m.ChannelsBalance.Balance
+			total.PendingOpenBalance += m.ChannelsBalance.PendingOpenBalance
+		}
+		if m.WalletBalance.WalletBalance != nil {
+			total.WalletBalance += m.WalletBalance.TotalBalance
+			total.UnconfirmedBalance += m.WalletBalance.UnconfirmedBalance
+		}
+		if m.Info.Info != nil {
+			total.NumActiveChannels += m.Info.NumActiveChannels
+			total.NumPendingChannels += m.Info.NumPendingChannels
+			total.NumInactiveChannels += m.Info.NumInactiveChannels
+		}
+	}
+	return total
+}
+
+type NodesTotal struct {
+	ChannelsBalance     int64
+	PendingOpenBalance  int64
+	WalletBalance       int64
+	UnconfirmedBalance  int64
+	NumActiveChannels   uint32
+	NumPendingChannels  uint32
+	NumInactiveChannels uint32
+}
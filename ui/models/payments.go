@@ -0,0 +1,229 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+// paymentsPageSize is the number of payments retrieved by call when the
+// payments are listed, a page is loaded each time the last payment loaded is
+// displayed.
+const paymentsPageSize = 100
+
+type PaymentsSort func(*models.Payment, *models.Payment) bool
+
+type Payments struct {
+	current *models.Payment
+	list    []*models.Payment
+	sort    PaymentsSort
+	// index is the payments by payment hash.
+	index map[string]*models.Payment
+	// offset is the index of the last payment of the pages loaded.
+	offset   uint64
+	complete bool
+	loading  bool
+	// nodes caches the nodes of the hops, they are only retrieved once.
+	nodes map[string]*models.Node
+	mu    sync.RWMutex
+}
+
+func NewPayments() *Payments {
+	return &Payments{
+		index: make(map[string]*models.Payment),
+		nodes: make(map[string]*models.Node),
+	}
+}
+
+func (p *Payments) Current() *models.Payment {
+	return p.current
+}
+
+func (p *Payments) SetCurrent(index int) {
+	p.current = p.Get(index)
+}
+
+func (p *Payments) List() []*models.Payment {
+	return p.list
+}
+
+func (p *Payments) Len() int {
+	return len(p.list)
+}
+
+func (p *Payments) Swap(i, j int) {
+	p.list[i], p.list[j] = p.list[j], p.list[i]
+}
+
+func (p *Payments) Less(i, j int) bool {
+	return p.sort(p.list[i], p.list[j])
+}
+
+func (p *Payments) Sort(s PaymentsSort) {
+	if s == nil {
+		return
+	}
+	p.sort = s
+	sort.Sort(p)
+}
+
+func (p *Payments) Get(index int) *models.Payment {
+	if index < 0 || index > len(p.list)-1 {
+		return nil
+	}
+
+	return p.list[index]
+}
+
+// Find returns the payment with the payment hash.
+func (p *Payments) Find(hash string) *models.Payment {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.index[hash]
+}
+
+// Complete returns true when all the pages of payments are loaded.
+func (p *Payments) Complete() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.complete
+}
+
+// Update adds the payments, a payment with the hash of a known payment
+// replaces it in place.
+func (p *Payments) Update(payments ...*models.Payment) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, payment := range payments {
+		if payment == nil {
+			continue
+		}
+		known, ok := p.index[payment.PaymentHash]
+		if !ok {
+			p.index[payment.PaymentHash] = payment
+			p.list = append(p.list, payment)
+			continue
+		}
+		if payment.Index == 0 {
+			payment.Index = known.Index
+		}
+		*known = *payment
+	}
+
+	if p.sort != nil {
+		sort.Sort(p)
+	}
+}
+
+// RefreshPayments loads the payments again from the first page.
+func (m *Models) RefreshPayments(ctx context.Context) error {
+	m.Payments.mu.Lock()
+	m.Payments.offset = 0
+	m.Payments.complete = false
+	m.Payments.mu.Unlock()
+	return m.LoadPayments(ctx)
+}
+
+// LoadPayments loads the next page of payments, it does nothing if all of
+// them are loaded or if a page is being loaded.
+func (m *Models) LoadPayments(ctx context.Context) error {
+	p := m.Payments
+	p.mu.Lock()
+	if p.complete || p.loading {
+		p.mu.Unlock()
+		return nil
+	}
+	p.loading = true
+	offset := p.offset
+	p.mu.Unlock()
+
+	payments, err := m.network.ListPayments(ctx, offset, paymentsPageSize)
+
+	p.mu.Lock()
+	p.loading = false
+	if err == nil {
+		for _, payment := range payments {
+			if payment.Index > p.offset {
+				p.offset = payment.Index
+			}
+		}
+		p.complete = len(payments) < paymentsPageSize
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p.Update(payments...)
+	return nil
+}
+
+func (m *Models) RefreshPayment(update interface{}) func(context.Context) error {
+	return (func(ctx context.Context) error {
+		payment, ok := update.(*models.Payment)
+		if !ok {
+			m.logger.Error("refreshPayment: invalid event data")
+			return nil
+		}
+		m.Payments.Update(payment)
+		return nil
+	})
+}
+
+func (m *Models) DecodePayReq(ctx context.Context, payreq string) (*models.PayReq, error) {
+	return m.network.DecodePayReq(ctx, payreq)
+}
+
+// EstimateRouteFee returns the fee in millisatoshis of a route paying the
+// payment request.
+func (m *Models) EstimateRouteFee(ctx context.Context, payreq *models.PayReq) (int64, error) {
+	return m.network.EstimateRouteFee(ctx, payreq)
+}
+
+// SendPayment pays the payment request, the progress of the payment is
+// reported by the payment events.
+func (m *Models) SendPayment(ctx context.Context, payreq *models.PayReq, feeLimit int64) (*models.Payment, error) {
+	return m.network.SendPayment(ctx, payreq, feeLimit)
+}
+
+// GetNode returns the node of the network with the public key.
+func (m *Models) GetNode(ctx context.Context, pubkey string) (*models.Node, error) {
+	return m.network.GetNode(ctx, pubkey, false)
+}
+
+// RefreshCurrentPayment retrieves the nodes of the hops of the current
+// payment to display their aliases.
+func (m *Models) RefreshCurrentPayment(ctx context.Context) error {
+	cur := m.Payments.Current()
+	if cur == nil {
+		return nil
+	}
+
+	for _, htlc := range cur.HTLCs {
+		if htlc.Route == nil {
+			continue
+		}
+		for _, hop := range htlc.Route.Hops {
+			if hop.PubKey == "" {
+				continue
+			}
+			node, ok := m.Payments.nodes[hop.PubKey]
+			if !ok {
+				var err error
+				node, err = m.network.GetNode(ctx, hop.PubKey, false)
+				if err != nil {
+					return err
+				}
+				m.Payments.nodes[hop.PubKey] = node
+			}
+			hop.Node = node
+		}
+	}
+
+	return nil
+}
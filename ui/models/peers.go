@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+type PeersSort func(*models.Peer, *models.Peer) bool
+
+type Peers struct {
+	list []*models.Peer
+	sort PeersSort
+	// nodes caches the nodes of the peers, they are only retrieved once.
+	nodes map[string]*models.Node
+	mu    sync.RWMutex
+}
+
+func NewPeers() *Peers {
+	return &Peers{nodes: make(map[string]*models.Node)}
+}
+
+func (p *Peers) List() []*models.Peer {
+	return p.list
+}
+
+func (p *Peers) Len() int {
+	return len(p.list)
+}
+
+func (p *Peers) Swap(i, j int) {
+	p.list[i], p.list[j] = p.list[j], p.list[i]
+}
+
+func (p *Peers) Less(i, j int) bool {
+	return p.sort(p.list[i], p.list[j])
+}
+
+func (p *Peers) Sort(s PeersSort) {
+	if s == nil {
+		return
+	}
+	p.sort = s
+	sort.Sort(p)
+}
+
+func (p *Peers) Get(index int) *models.Peer {
+	if index < 0 || index > len(p.list)-1 {
+		return nil
+	}
+
+	return p.list[index]
+}
+
+func (p *Peers) Update(peers []*models.Peer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.list = peers
+	if p.sort != nil {
+		sort.Sort(p)
+	}
+}
+
+func (m *Models) RefreshPeers(ctx context.Context) error {
+	peers, err := m.network.ListPeers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		node, ok := m.Peers.nodes[peer.PubKey]
+		if !ok {
+			node, err = m.network.GetNode(ctx, peer.PubKey, false)
+			if err != nil {
+				// the node of a peer is not always in the graph.
+				node = &models.Node{PubKey: peer.PubKey}
+			}
+			m.Peers.nodes[peer.PubKey] = node
+		}
+		peer.Node = node
+
+		for _, c := range m.Channels.List() {
+			if c.RemotePubKey == peer.PubKey && c.Status != models.ChannelClosed {
+				peer.NumChannels++
+			}
+		}
+	}
+
+	m.Peers.Update(peers)
+	return nil
+}
+
+// ConnectPeer connects to a node given as pubkey@host, the peers are
+// refreshed with the new peer.
+func (m *Models) ConnectPeer(ctx context.Context, node string) error {
+	err := m.network.ConnectPeer(ctx, node)
+	if err != nil {
+		return err
+	}
+	return m.RefreshPeers(ctx)
+}
+
+// DisconnectPeer disconnects the peer, the peers are refreshed without it.
+func (m *Models) DisconnectPeer(ctx context.Context, pubkey string) error {
+	err := m.network.DisconnectPeer(ctx, pubkey)
+	if err != nil {
+		return err
+	}
+	return m.RefreshPeers(ctx)
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+type PendingChannelsSort func(*models.Channel, *models.Channel) bool
+
+// PendingChannels are the channels being opened or closed, they are taken
+// from the channels at each refresh.
+type PendingChannels struct {
+	current *models.Channel
+	list    []*models.Channel
+	sort    PendingChannelsSort
+	mu      sync.RWMutex
+}
+
+func NewPendingChannels() *PendingChannels {
+	return &PendingChannels{list: []*models.Channel{}}
+}
+
+func (p *PendingChannels) Current() *models.Channel {
+	return p.current
+}
+
+func (p *PendingChannels) SetCurrent(index int) {
+	p.current = p.Get(index)
+}
+
+func (p *PendingChannels) List() []*models.Channel {
+	return p.list
+}
+
+func (p *PendingChannels) Len() int {
+	return len(p.list)
+}
+
+func (p *PendingChannels) Swap(i, j int) {
+	p.list[i], p.list[j] = p.list[j], p.list[i]
+}
+
+func (p *PendingChannels) Less(i, j int) bool {
+	return p.sort(p.list[i], p.list[j])
+}
+
+func (p *PendingChannels) Sort(s PendingChannelsSort) {
+	if s == nil {
+		return
+	}
+	p.sort = s
+	sort.Sort(p)
+}
+
+func (p *PendingChannels) Get(index int) *models.Channel {
+	if index < 0 || index > len(p.list)-1 {
+		return nil
+	}
+
+	return p.list[index]
+}
+
+func (p *PendingChannels) Update(channels []*models.Channel) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.list = channels
+	if p.sort != nil {
+		sort.Sort(p)
+	}
+}
+
+// IsPending returns true if the channel is being opened or closed.
+func IsPending(c *models.Channel) bool {
+	switch c.Status {
+	case models.ChannelOpening,
+		models.ChannelClosing,
+		models.ChannelForceClosing,
+		models.ChannelWaitingClose:
+		return true
+	}
+	return false
+}
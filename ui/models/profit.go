@@ -0,0 +1,132 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/network/options"
+	"github.com/edouardparis/lntop/report"
+)
+
+type ProfitSort func(*models.ChannelProfit, *models.ChannelProfit) bool
+
+// Profit is the profit and loss of the channels since the start time.
+type Profit struct {
+	StartTime  string
+	list       []*models.ChannelProfit
+	sort       ProfitSort
+	refreshing bool
+	mu         sync.RWMutex
+}
+
+func NewProfit() *Profit {
+	return &Profit{StartTime: "-30d"}
+}
+
+func (p *Profit) List() []*models.ChannelProfit {
+	return p.list
+}
+
+func (p *Profit) Len() int {
+	return len(p.list)
+}
+
+func (p *Profit) Swap(i, j int) {
+	p.list[i], p.list[j] = p.list[j], p.list[i]
+}
+
+func (p *Profit) Less(i, j int) bool {
+	return p.sort(p.list[i], p.list[j])
+}
+
+func (p *Profit) Sort(s ProfitSort) {
+	if s == nil {
+		return
+	}
+	p.sort = s
+	sort.Sort(p)
+}
+
+func (p *Profit) Get(index int) *models.ChannelProfit {
+	if index < 0 || index > len(p.list)-1 {
+		return nil
+	}
+
+	return p.list[index]
+}
+
+func (p *Profit) Update(list []*models.ChannelProfit) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.list = list
+	if p.sort != nil {
+		sort.Sort(p)
+	}
+}
+
+// RefreshProfit computes the profit of the channels from the forwarding
+// history, the payments of the node and the channels and transactions of the
+// models. The payments are listed from the node since the model only has the
+// pages displayed. It does nothing while the profit is being computed.
+func (m *Models) RefreshProfit(ctx context.Context) error {
+	m.Profit.mu.Lock()
+	if m.Profit.refreshing {
+		m.Profit.mu.Unlock()
+		return nil
+	}
+	m.Profit.refreshing = true
+	m.Profit.mu.Unlock()
+	defer func() {
+		m.Profit.mu.Lock()
+		m.Profit.refreshing = false
+		m.Profit.mu.Unlock()
+	}()
+
+	now := time.Now()
+	start, err := options.ParseTime(m.Profit.StartTime, now)
+	if err != nil {
+		return err
+	}
+	since := time.Unix(int64(start), 0)
+
+	data := &report.ProfitData{
+		Since:          since,
+		Now:            now,
+		PubKey:         m.Info.PubKey,
+		BlockHeight:    m.Info.BlockHeight,
+		Channels:       m.Channels.List(),
+		ClosedChannels: m.ClosedChannels.List(),
+		Transactions:   m.Transactions.List(),
+	}
+
+	data.Payments, err = report.Payments(ctx, m.network)
+	if err != nil {
+		return err
+	}
+
+	if m.history != nil {
+		err = m.history.SyncForwards(ctx, m.network)
+		if err != nil {
+			return err
+		}
+		data.Forwards, err = m.history.ForwardingEvents(m.Name(), since, 0)
+		if err != nil {
+			return err
+		}
+		data.Snapshots, err = m.history.Snapshots(m.Name(), since)
+		if err != nil {
+			return err
+		}
+	} else {
+		data.Forwards, err = report.ForwardingHistory(ctx, m.network, since)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.Profit.Update(report.Profit(data))
+	return nil
+}
@@ -57,6 +57,15 @@ func (t *Transactions) Get(index int) *models.Transaction {
 	return t.list[index]
 }
 
+func (t *Transactions) GetByTxHash(hash string) *models.Transaction {
+	for i := range t.list {
+		if t.list[i].TxHash == hash {
+			return t.list[i]
+		}
+	}
+	return nil
+}
+
 func (t *Transactions) Contains(tx *models.Transaction) bool {
 	if tx == nil {
 		return false
@@ -105,6 +114,25 @@ func (t *Transactions) Update(tx *models.Transaction) {
 	}
 }
 
+// Retain removes the unconfirmed transactions missing from the list, they
+// have been replaced or evicted from the mempool.
+func (t *Transactions) Retain(transactions []*models.Transaction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	known := make(map[string]bool, len(transactions))
+	for i := range transactions {
+		known[transactions[i].TxHash] = true
+	}
+	list := t.list[:0]
+	for i := range t.list {
+		if t.list[i].NumConfirmations > 0 || known[t.list[i].TxHash] {
+			list = append(list, t.list[i])
+		}
+	}
+	t.list = list
+}
+
 func (m *Models) RefreshTransactions(ctx context.Context) error {
 	transactions, err := m.network.GetTransactions(ctx)
 	if err != nil {
@@ -114,6 +142,22 @@ func (m *Models) RefreshTransactions(ctx context.Context) error {
 	for i := range transactions {
 		m.Transactions.Update(transactions[i])
 	}
+	m.Transactions.Retain(transactions)
 
 	return nil
 }
+
+// BumpFee bumps the fee of the transaction and refreshes the transactions
+// and the outputs it changes.
+func (m *Models) BumpFee(ctx context.Context, req *models.BumpFeeRequest) error {
+	err := m.network.BumpFee(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	err = m.RefreshTransactions(ctx)
+	if err != nil {
+		return err
+	}
+	return m.RefreshUtxos(ctx)
+}
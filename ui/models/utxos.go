@@ -0,0 +1,185 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/edouardparis/lntop/network/models"
+)
+
+type UtxosSort func(*models.Utxo, *models.Utxo) bool
+
+// Utxos are the unspent outputs of the on-chain wallet, some of them can be
+// selected to be spent by the next transaction.
+type Utxos struct {
+	list []*models.Utxo
+	sort UtxosSort
+	// selected holds the outpoints of the selected outputs.
+	selected map[string]bool
+	mu       sync.RWMutex
+}
+
+func NewUtxos() *Utxos {
+	return &Utxos{selected: make(map[string]bool)}
+}
+
+func (u *Utxos) List() []*models.Utxo {
+	return u.list
+}
+
+func (u *Utxos) Len() int {
+	return len(u.list)
+}
+
+func (u *Utxos) Swap(i, j int) {
+	u.list[i], u.list[j] = u.list[j], u.list[i]
+}
+
+func (u *Utxos) Less(i, j int) bool {
+	return u.sort(u.list[i], u.list[j])
+}
+
+func (u *Utxos) Sort(s UtxosSort) {
+	if s == nil {
+		return
+	}
+	u.sort = s
+	sort.Sort(u)
+}
+
+func (u *Utxos) Get(index int) *models.Utxo {
+	if index < 0 || index > len(u.list)-1 {
+		return nil
+	}
+
+	return u.list[index]
+}
+
+// Toggle selects the output at the index or unselects it.
+func (u *Utxos) Toggle(index int) {
+	utxo := u.Get(index)
+	if utxo == nil {
+		return
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.selected[utxo.Outpoint()] {
+		delete(u.selected, utxo.Outpoint())
+		return
+	}
+	u.selected[utxo.Outpoint()] = true
+}
+
+func (u *Utxos) IsSelected(utxo *models.Utxo) bool {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.selected[utxo.Outpoint()]
+}
+
+// Selected returns the selected outputs in the order of the list.
+func (u *Utxos) Selected() []*models.Utxo {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	selected := []*models.Utxo{}
+	for _, utxo := range u.list {
+		if u.selected[utxo.Outpoint()] {
+			selected = append(selected, utxo)
+		}
+	}
+	return selected
+}
+
+// Outputs returns the unspent outputs of the transaction.
+func (u *Utxos) Outputs(txid string) []*models.Utxo {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	outputs := []*models.Utxo{}
+	for _, utxo := range u.list {
+		if utxo.TxID == txid {
+			outputs = append(outputs, utxo)
+		}
+	}
+	return outputs
+}
+
+// Inputs returns the outputs spent by a transaction of the amount: the
+// selected ones or, when none is selected, the largest confirmed outputs
+// covering the amount, which estimates the selection of the node. All the
+// confirmed outputs are spent when the amount is 0.
+func (u *Utxos) Inputs(amount int64) []*models.Utxo {
+	selected := u.Selected()
+	if len(selected) > 0 {
+		return selected
+	}
+
+	u.mu.RLock()
+	confirmed := []*models.Utxo{}
+	for _, utxo := range u.list {
+		if utxo.Confirmations > 0 {
+			confirmed = append(confirmed, utxo)
+		}
+	}
+	u.mu.RUnlock()
+	if amount == 0 {
+		return confirmed
+	}
+
+	sort.Slice(confirmed, func(i, j int) bool {
+		return confirmed[i].Amount > confirmed[j].Amount
+	})
+	total := int64(0)
+	for i, utxo := range confirmed {
+		total += utxo.Amount
+		if total >= amount {
+			return confirmed[:i+1]
+		}
+	}
+	return confirmed
+}
+
+// Update replaces the outputs, the spent ones are unselected.
+func (u *Utxos) Update(utxos []*models.Utxo) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.list = utxos
+	unspent := make(map[string]bool, len(utxos))
+	for _, utxo := range utxos {
+		unspent[utxo.Outpoint()] = true
+	}
+	for outpoint := range u.selected {
+		if !unspent[outpoint] {
+			delete(u.selected, outpoint)
+		}
+	}
+	if u.sort != nil {
+		sort.Sort(u)
+	}
+}
+
+func (m *Models) RefreshUtxos(ctx context.Context) error {
+	utxos, err := m.network.ListUnspent(ctx)
+	if err != nil {
+		return err
+	}
+	m.Utxos.Update(utxos)
+	return nil
+}
+
+// EstimateFee returns the fee rate in sat/vbyte of a transaction confirmed
+// within the given number of blocks.
+func (m *Models) EstimateFee(ctx context.Context, targetConf int32) (uint64, error) {
+	return m.network.EstimateFee(ctx, targetConf)
+}
+
+// SendCoins sends the on-chain payment, the outputs are refreshed without
+// the spent ones.
+func (m *Models) SendCoins(ctx context.Context, req *models.SendCoinsRequest) (string, error) {
+	txid, err := m.network.SendCoins(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return txid, m.RefreshUtxos(ctx)
+}
@@ -19,10 +19,7 @@ func Run(ctx context.Context, app *app.App, sub chan *events.Event) error {
 
 	g.Cursor = false
 	ctrl := newController(app)
-	err = ctrl.SetModels(ctx)
-	if err != nil {
-		return err
-	}
+	ctrl.SetModels(ctx)
 
 	g.SetManagerFunc(ctrl.layout)
 
@@ -2,6 +2,7 @@ package views
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
 	"golang.org/x/text/language"
@@ -21,6 +22,7 @@ const (
 type Channel struct {
 	view     *gocui.View
 	channels *models.Channels
+	balances *models.BalanceHistory
 }
 
 func (c Channel) Name() string {
@@ -96,10 +98,11 @@ func (c *Channel) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 	footer.FgColor = gocui.ColorBlack
 	footer.Rewind()
 	blackBg := color.Black(color.Background)
-	fmt.Fprintf(footer, "%s%s %s%s %s%s %s%s\n",
+	fmt.Fprintf(footer, "%s%s %s%s %s%s %s%s %s%s\n",
 		blackBg("F2"), "Menu",
 		blackBg("Enter"), "Channels",
 		blackBg("C"), "Get disabled",
+		blackBg("E"), "Edit policy",
 		blackBg("F10"), "Quit",
 	)
 	return nil
@@ -203,6 +206,8 @@ func (c *Channel) display() {
 		cyan("      Channel Point:"), channel.ChannelPoint)
 	fmt.Fprintln(v, "")
 
+	c.displayBalanceHistory(channel)
+
 	fmt.Fprintln(v, green(" [ Node ]"))
 	fmt.Fprintf(v, "%s %s\n",
 		cyan("         PubKey:"), channel.RemotePubKey)
@@ -258,6 +263,45 @@ func (c *Channel) display() {
 
 }
 
-func NewChannel(channels *models.Channels) *Channel {
-	return &Channel{channels: channels}
+// balanceChartWidth is the number of periods of the balance history chart.
+const balanceChartWidth = 48
+
+// displayBalanceHistory displays the local balance of the channel over the
+// last 24h, 7d and 30d if the history recorded it.
+func (c *Channel) displayBalanceHistory(channel *netmodels.Channel) {
+	samples := c.balances.Samples(channel.ChannelPoint, time.Now().Add(-models.MaxBalancePeriod))
+	if len(samples) == 0 {
+		return
+	}
+
+	v := c.view
+	green := color.Green()
+	cyan := color.Cyan()
+	fmt.Fprintln(v, green(" [ Local Balance History ]"))
+	periods := []struct {
+		name   string
+		period time.Duration
+	}{
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"30d", models.MaxBalancePeriod},
+	}
+	for _, p := range periods {
+		buckets := sparkline(samples, p.period, balanceChartWidth)
+		fmt.Fprintf(v, "%s %s %s\n",
+			cyan(fmt.Sprintf("%20s", p.name+":")),
+			formatSparkline(buckets),
+			formatTrend(trend(buckets)))
+	}
+	fmt.Fprintln(v, "")
+}
+
+// formatTrend returns the change of the local balance in percent of the
+// capacity.
+func formatTrend(t float64) string {
+	return fmt.Sprintf("%+4.0f%%", t*100)
+}
+
+func NewChannel(channels *models.Channels, balances *models.BalanceHistory) *Channel {
+	return &Channel{channels: channels, balances: balances}
 }
@@ -3,6 +3,7 @@ package views
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"github.com/awesome-gocui/gocui"
 	"golang.org/x/text/language"
@@ -20,22 +21,6 @@ const (
 	CHANNELS_FOOTER  = "channels_footer"
 )
 
-var DefaultChannelsColumns = []string{
-	"STATUS",
-	"ALIAS",
-	"GAUGE",
-	"LOCAL",
-	"CAP",
-	"SENT",
-	"RECEIVED",
-	"HTLC",
-	"UNSETTLED",
-	"CFEE",
-	"LAST UPDATE",
-	"PRIVATE",
-	"ID",
-}
-
 type Channels struct {
 	cfg *config.View
 
@@ -259,9 +244,11 @@ func (c *Channels) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 	footer.FgColor = gocui.ColorBlack
 	footer.Rewind()
 	blackBg := color.Black(color.Background)
-	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s",
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s %s%s",
 		blackBg("F2"), "Menu",
 		blackBg("Enter"), "Channel",
+		blackBg("O"), "Open",
+		blackBg("X"), "Close",
 		blackBg("F10"), "Quit",
 	))
 	return nil
@@ -320,7 +307,7 @@ func (c *Channels) display(g *gocui.Gui) {
 	}
 }
 
-func NewChannels(cfg *config.View, chans *models.Channels) *Channels {
+func NewChannels(cfg *config.View, chans *models.Channels, balances *models.BalanceHistory) *Channels {
 	channels := &Channels{
 		cfg:      cfg,
 		channels: chans,
@@ -328,7 +315,7 @@ func NewChannels(cfg *config.View, chans *models.Channels) *Channels {
 
 	printer := message.NewPrinter(language.English)
 
-	columns := DefaultChannelsColumns
+	columns := config.DefaultChannelsColumns
 	if cfg != nil && len(cfg.Columns) != 0 {
 		columns = cfg.Columns
 	}
@@ -397,6 +384,40 @@ func NewChannels(cfg *config.View, chans *models.Channels) *Channels {
 						white(fmt.Sprintf("] %2d%%", c.LocalBalance*100/c.Capacity)))
 				},
 			}
+		case "SPARK":
+			period := 24 * time.Hour
+			if cfg != nil {
+				period = balancePeriod(cfg.Options.GetOption("SPARK", "period"), period)
+			}
+			spark := func(c *netmodels.Channel) []float64 {
+				samples := balances.Samples(c.ChannelPoint, time.Now().Add(-period))
+				return sparkline(samples, period, 20)
+			}
+			channels.columns[i] = channelsColumn{
+				width: 20,
+				name:  fmt.Sprintf("%-20s", columns[i]),
+				// the trends are computed once when the column is sorted,
+				// the channels added after are computed when compared.
+				sort: func(order models.Order) models.ChannelsSort {
+					trends := make(map[string]float64, chans.Len())
+					for _, c := range chans.List() {
+						trends[c.ChannelPoint] = trend(spark(c))
+					}
+					trendOf := func(c *netmodels.Channel) float64 {
+						t, ok := trends[c.ChannelPoint]
+						if !ok {
+							t = trend(spark(c))
+						}
+						return t
+					}
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Float64Sort(trendOf(c1), trendOf(c2), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return formatSparkline(spark(c), opts...)
+				},
+			}
 		case "LOCAL":
 			channels.columns[i] = channelsColumn{
 				width: 12,
@@ -0,0 +1,506 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	CLOSED         = "closed"
+	CLOSED_COLUMNS = "closed_columns"
+	CLOSED_FOOTER  = "closed_footer"
+)
+
+type Closed struct {
+	cfg *config.View
+
+	columns           []closedColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	channels          *models.ClosedChannels
+
+	ox, oy int
+	cx, cy int
+}
+
+type closedColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.ClosedChannelsSort
+	display func(*netmodels.ClosedChannel, ...color.Option) string
+}
+
+func (c Closed) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Closed) Name() string {
+	return CLOSED
+}
+
+func (c *Closed) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Closed) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Closed) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Closed) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Closed) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Closed) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Closed) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.channels.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Closed) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.channels.Len()
+	return
+}
+
+func (c *Closed) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.channels.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Closed) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(CLOSED_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(CLOSED)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(CLOSED_FOOTER)
+}
+
+func (c *Closed) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(CLOSED_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(CLOSED, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(CLOSED_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c *Closed) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	c.view.Clear()
+	for _, item := range c.channels.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+	if c.oy+c.cy > c.channels.Len()-1 && c.cy > 0 {
+		c.cy = c.channels.Len() - 1 - c.oy
+		if c.cy < 0 {
+			c.cy = 0
+		}
+	}
+	c.view.SetOrigin(c.ox, c.oy)
+	c.view.SetCursor(c.cx, c.cy)
+}
+
+// closeTypeString returns the short name of the type of a closing.
+func closeTypeString(closeType int) string {
+	switch closeType {
+	case netmodels.CloseCooperative:
+		return "coop"
+	case netmodels.CloseLocalForce:
+		return "local force"
+	case netmodels.CloseRemoteForce:
+		return "remote force"
+	case netmodels.CloseBreach:
+		return "breach"
+	case netmodels.CloseFundingCanceled:
+		return "canceled"
+	case netmodels.CloseAbandoned:
+		return "abandoned"
+	default:
+		return "unknown"
+	}
+}
+
+func NewClosed(cfg *config.View, channels *models.ClosedChannels) *Closed {
+	view := &Closed{
+		cfg:      cfg,
+		channels: channels,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultClosedColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	view.columns = make([]closedColumn, len(columns))
+
+	for i := range columns {
+		switch columns[i] {
+		case "ALIAS":
+			view.columns[i] = closedColumn{
+				width: 25,
+				name:  fmt.Sprintf("%-25s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						a1, _ := c1.ShortAlias()
+						a2, _ := c2.ShortAlias()
+						return models.StringSort(a1, a2, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					aliasColor := color.White(opts...)
+					alias, forced := c.ShortAlias()
+					if forced {
+						aliasColor = color.Cyan(opts...)
+					}
+					return aliasColor(fmt.Sprintf("%-25s", alias))
+				},
+			}
+		case "TYPE":
+			view.columns[i] = closedColumn{
+				width: 12,
+				name:  fmt.Sprintf("%-12s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.IntSort(c1.CloseType, c2.CloseType, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					result := fmt.Sprintf("%-12s", closeTypeString(c.CloseType))
+					switch c.CloseType {
+					case netmodels.CloseLocalForce, netmodels.CloseRemoteForce, netmodels.CloseBreach:
+						return color.Red(opts...)(result)
+					}
+					return color.White(opts...)(result)
+				},
+			}
+		case "CAP":
+			view.columns[i] = closedColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.Int64Sort(c1.Capacity, c2.Capacity, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%12d", c.Capacity))
+				},
+			}
+		case "SETTLED":
+			view.columns[i] = closedColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.Int64Sort(c1.SettledBalance, c2.SettledBalance, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.Cyan(opts...)(printer.Sprintf("%12d", c.SettledBalance))
+				},
+			}
+		case "TIME_LOCKED":
+			view.columns[i] = closedColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.Int64Sort(c1.TimeLockedBalance, c2.TimeLockedBalance, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					if c.TimeLockedBalance > 0 {
+						return color.Yellow(opts...)(printer.Sprintf("%12d", c.TimeLockedBalance))
+					}
+					return color.White(opts...)(printer.Sprintf("%12d", c.TimeLockedBalance))
+				},
+			}
+		case "ROUTED":
+			view.columns[i] = closedColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.Int64Sort(c1.TotalRouted, c2.TotalRouted, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%12d", c.TotalRouted))
+				},
+			}
+		case "LIFETIME":
+			view.columns[i] = closedColumn{
+				width: 10,
+				name:  fmt.Sprintf("%10s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.UInt32Sort(c1.Lifetime(), c2.Lifetime(), order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					lifetime := c.Lifetime()
+					if lifetime == 0 {
+						return fmt.Sprintf("%10s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%10s", FormatAge(lifetime)))
+				},
+			}
+		case "HEIGHT":
+			view.columns[i] = closedColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.UInt32Sort(c1.CloseHeight, c2.CloseHeight, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					if c.CloseHeight == 0 {
+						return fmt.Sprintf("%8s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%8d", c.CloseHeight))
+				},
+			}
+		case "CLOSING_TX":
+			view.columns[i] = closedColumn{
+				width: 64,
+				name:  fmt.Sprintf("%-64s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.StringSort(c1.ClosingTxID, c2.ClosingTxID, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-64s", c.ClosingTxID))
+				},
+			}
+		case "SCID":
+			view.columns[i] = closedColumn{
+				width: 14,
+				name:  fmt.Sprintf("%-14s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.UInt64Sort(c1.ID, c2.ID, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					if c.ID == 0 {
+						return fmt.Sprintf("%-14s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%-14s", ToScid(c.ID)))
+				},
+			}
+		case "CHANNEL_POINT":
+			view.columns[i] = closedColumn{
+				width: 68,
+				name:  fmt.Sprintf("%-68s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.StringSort(c1.ChannelPoint, c2.ChannelPoint, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-68s", c.ChannelPoint))
+				},
+			}
+		case "PUBKEY":
+			view.columns[i] = closedColumn{
+				width: 66,
+				name:  fmt.Sprintf("%-66s", columns[i]),
+				sort: func(order models.Order) models.ClosedChannelsSort {
+					return func(c1, c2 *netmodels.ClosedChannel) bool {
+						return models.StringSort(c1.RemotePubKey, c2.RemotePubKey, order)
+					}
+				},
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-66s", c.RemotePubKey))
+				},
+			}
+		default:
+			view.columns[i] = closedColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(c *netmodels.ClosedChannel, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}
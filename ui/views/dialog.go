@@ -0,0 +1,339 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/pkg/errors"
+
+	"github.com/edouardparis/lntop/ui/color"
+)
+
+const (
+	DIALOG = "dialog"
+)
+
+// DialogField is a field of a dialog, its value is typed or, when the field
+// has options, chosen among them with space.
+type DialogField struct {
+	Label   string
+	Value   string
+	Options []string
+	initial string
+}
+
+// Dialog is a form displayed over the main view. The changed values, or the
+// summary of the values when the dialog has one, are displayed for
+// confirmation before the dialog is applied. The dialog is applied in the
+// background, it can stay opened once applied to report the progress or the
+// result of what it applied.
+type Dialog struct {
+	view    *gocui.View
+	title   string
+	fields  []*DialogField
+	current int
+	confirm bool
+	pending bool
+	err     error
+	apply   func([]string) error
+	summary func([]string) ([]string, error)
+	report  func() []string
+	lines   []string
+}
+
+func (d Dialog) Name() string {
+	return DIALOG
+}
+
+// Opened returns true when the dialog is displayed.
+func (d Dialog) Opened() bool {
+	return d.apply != nil
+}
+
+// Open displays the dialog with the fields, apply is called with the values
+// of the fields once the changes are confirmed. apply is called outside of
+// the gui thread, it must change the views with gocui.Gui.UpdateAsync so
+// that they are changed before the dialog is closed.
+func (d *Dialog) Open(title string, fields []*DialogField, apply func([]string) error) {
+	for i := range fields {
+		fields[i].initial = fields[i].Value
+	}
+	*d = Dialog{title: title, fields: fields, apply: apply}
+}
+
+// SetSummary replaces the changes displayed for confirmation by the lines
+// returned by summary, the values are confirmed even if none changed.
+func (d *Dialog) SetSummary(summary func([]string) ([]string, error)) {
+	d.summary = summary
+}
+
+// Report replaces the fields by the lines returned by report, it is called
+// each time the dialog is displayed.
+func (d *Dialog) Report(report func() []string) {
+	d.report = report
+}
+
+// Reporting returns true when the dialog displays the lines of its report.
+func (d Dialog) Reporting() bool {
+	return d.report != nil
+}
+
+// Close hides the dialog.
+func (d *Dialog) Close(g *gocui.Gui) error {
+	d.apply = nil
+	err := g.DeleteView(DIALOG)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	return nil
+}
+
+// Pending returns true while the dialog is applied.
+func (d Dialog) Pending() bool {
+	return d.pending
+}
+
+// Next selects the next field.
+func (d *Dialog) Next() {
+	if !d.confirm && !d.pending && d.report == nil && d.current < len(d.fields)-1 {
+		d.current++
+	}
+}
+
+// Previous selects the previous field.
+func (d *Dialog) Previous() {
+	if !d.confirm && !d.pending && d.report == nil && d.current > 0 {
+		d.current--
+	}
+}
+
+// Editable returns true when the dialog has fields, a dialog without fields
+// only asks for a confirmation.
+func (d Dialog) Editable() bool {
+	return len(d.fields) > 0
+}
+
+// Confirming returns true when the changes are waiting for a confirmation.
+func (d Dialog) Confirming() bool {
+	return d.confirm
+}
+
+// Confirm displays the changes for confirmation.
+func (d *Dialog) Confirm() {
+	d.err = nil
+	if d.summary != nil {
+		d.lines, d.err = d.summary(d.values())
+		d.confirm = d.err == nil
+		return
+	}
+	d.lines = d.changes()
+	d.confirm = len(d.lines) > 0
+	if !d.confirm {
+		d.err = errors.New("nothing changed")
+	}
+}
+
+// Cancel goes back from the confirmation to the edition of the fields.
+func (d *Dialog) Cancel() {
+	d.confirm = false
+}
+
+// Apply calls the apply func of the dialog with the values in a goroutine,
+// the dialog is pending until it returns. done is then called on the gui
+// thread if it succeeded, the dialog goes back to the edition of the fields
+// with the error if it failed.
+func (d *Dialog) Apply(g *gocui.Gui, done func(*gocui.Gui) error) {
+	d.confirm = false
+	d.pending = true
+	d.err = nil
+	apply, values := d.apply, d.values()
+	go func() {
+		err := apply(values)
+		g.UpdateAsync(func(g *gocui.Gui) error {
+			d.pending = false
+			d.err = err
+			if err != nil {
+				return nil
+			}
+			return done(g)
+		})
+	}()
+}
+
+func (d *Dialog) values() []string {
+	values := make([]string, len(d.fields))
+	for i := range d.fields {
+		values[i] = d.fields[i].Value
+	}
+	return values
+}
+
+// Edit implements gocui.Editor for the selected field.
+func (d *Dialog) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	if d.confirm || d.pending || d.report != nil || len(d.fields) == 0 {
+		return
+	}
+	field := d.fields[d.current]
+
+	if len(field.Options) > 0 {
+		if key == gocui.KeySpace || ch == ' ' {
+			next := 0
+			for i := range field.Options {
+				if field.Options[i] == field.Value {
+					next = (i + 1) % len(field.Options)
+				}
+			}
+			field.Value = field.Options[next]
+		}
+		return
+	}
+
+	switch {
+	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
+		if len(field.Value) > 0 {
+			runes := []rune(field.Value)
+			field.Value = string(runes[:len(runes)-1])
+		}
+	case key == gocui.KeySpace:
+		field.Value += " "
+	case ch != 0 && mod == gocui.ModNone:
+		field.Value += string(ch)
+	}
+}
+
+func (d *Dialog) changes() []string {
+	changes := []string{}
+	for _, f := range d.fields {
+		if f.Value != f.initial {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s",
+				f.Label, f.initial, color.Yellow(color.Bold)(f.Value)))
+		}
+	}
+	return changes
+}
+
+func (d *Dialog) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	if d.report != nil {
+		d.lines = d.report()
+	}
+
+	// the dialog grows with the values, a node uri is longer than the
+	// default width.
+	width := 60
+	labels := 0
+	for _, f := range d.fields {
+		if len(f.Label) > labels {
+			labels = len(f.Label)
+		}
+	}
+	for _, f := range d.fields {
+		if d.report == nil && labels+len(f.Value)+5 > width {
+			width = labels + len(f.Value) + 5
+		}
+	}
+	for _, line := range d.lines {
+		if (d.confirm || d.report != nil) && len(line)+3 > width {
+			width = len(line) + 3
+		}
+	}
+	if x1-x0 < width {
+		width = x1 - x0
+	}
+	height := len(d.fields) + 4
+	if (d.confirm || d.report != nil) && len(d.lines)+4 > height {
+		height = len(d.lines) + 4
+	}
+	x := x0 + (x1-x0-width)/2
+	y := y0 + (y1-y0-height)/2
+
+	v, err := g.SetView(DIALOG, x, y, x+width, y+height, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	v.Frame = true
+	v.Title = d.title
+	v.Editable = true
+	v.Editor = d
+	d.view = v
+
+	_, err = g.SetViewOnTop(DIALOG)
+	if err != nil {
+		return err
+	}
+
+	d.display()
+	return nil
+}
+
+func (d *Dialog) display() {
+	v := d.view
+	v.Clear()
+	cyan := color.Cyan()
+	green := color.Green()
+	blackBg := color.Black(color.Background)
+
+	if d.report != nil {
+		for _, line := range d.lines {
+			fmt.Fprintf(v, " %s\n", line)
+		}
+		fmt.Fprintln(v)
+		fmt.Fprintf(v, " %s%s\n", blackBg("Esc"), "Close")
+		return
+	}
+
+	if d.confirm {
+		title := " [ Changes ]"
+		if d.summary != nil {
+			title = " [ Summary ]"
+		}
+		fmt.Fprintln(v, green(title))
+		for _, line := range d.lines {
+			fmt.Fprintf(v, " %s\n", line)
+		}
+		back := "Back"
+		if !d.Editable() {
+			back = "Cancel"
+		}
+		fmt.Fprintln(v)
+		fmt.Fprintf(v, " %s%s %s%s\n",
+			blackBg("Enter"), "Apply",
+			blackBg("Esc"), back,
+		)
+		return
+	}
+
+	width := 0
+	for _, f := range d.fields {
+		if len(f.Label) > width {
+			width = len(f.Label)
+		}
+	}
+	for i, f := range d.fields {
+		value := f.Value
+		if i == d.current {
+			value = color.Cyan(color.Background)(value + " ")
+		}
+		fmt.Fprintf(v, " %s %s\n", cyan(fmt.Sprintf("%*s:", width, f.Label)), value)
+	}
+	fmt.Fprintln(v)
+	switch {
+	case d.pending:
+		fmt.Fprintf(v, " %s\n", color.Yellow()("applying..."))
+	case d.err != nil:
+		fmt.Fprintf(v, " %s\n", color.Red()(d.err.Error()))
+	default:
+		fmt.Fprintln(v)
+	}
+	fmt.Fprintf(v, " %s%s %s%s %s%s %s%s\n",
+		blackBg("Tab"), "Next",
+		blackBg("Space"), "Toggle",
+		blackBg("Enter"), "Confirm",
+		blackBg("Esc"), "Cancel",
+	)
+}
+
+func NewDialog() *Dialog {
+	return &Dialog{}
+}
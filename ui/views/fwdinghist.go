@@ -20,17 +20,6 @@ const (
 	FWDINGHIST_FOOTER  = "fwdinghist_footer"
 )
 
-var DefaultFwdinghistColumns = []string{
-	"ALIAS_IN",
-	"ALIAS_OUT",
-	"AMT_IN",
-	"AMT_OUT",
-	"FEE",
-	"TIMESTAMP_NS",
-	"CHAN_ID_IN",
-	"CHAN_ID_OUT",
-}
-
 type FwdingHist struct {
 	cfg *config.View
 
@@ -285,7 +274,7 @@ func NewFwdingHist(cfg *config.View, hist *models.FwdingHist) *FwdingHist {
 
 	printer := message.NewPrinter(language.English)
 
-	columns := DefaultFwdinghistColumns
+	columns := config.DefaultFwdinghistColumns
 	if cfg != nil && len(cfg.Columns) != 0 {
 		columns = cfg.Columns
 	}
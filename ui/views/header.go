@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/awesome-gocui/gocui"
+	netmodels "github.com/edouardparis/lntop/network/models"
 	"github.com/edouardparis/lntop/ui/color"
 	"github.com/edouardparis/lntop/ui/models"
 )
@@ -16,7 +17,11 @@ const (
 var versionReg = regexp.MustCompile(`(\d+\.)?(\d+\.)?(\*|\d+)`)
 
 type Header struct {
-	Info *models.Info
+	Info       *models.Info
+	Connection *models.Connection
+	// Node is the name of the displayed node, it is only set when several
+	// nodes are monitored.
+	Node string
 }
 
 func (h *Header) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
@@ -49,19 +54,37 @@ func (h *Header) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 		sync = color.Green()("[synced]")
 	}
 
+	node := ""
+	if h.Node != "" {
+		node = fmt.Sprintf(" %s %s", color.Cyan()("node:"), h.Node)
+	}
+
+	connection := color.Green()("[connected]")
+	if h.Connection.Connection != nil {
+		switch h.Connection.Status {
+		case netmodels.ConnectionReconnecting:
+			connection = color.Yellow()("[reconnecting]")
+		case netmodels.ConnectionDown:
+			connection = color.Red()(fmt.Sprintf("[down since %s]",
+				h.Connection.Since.Format("15:04:05")))
+		}
+	}
+
 	v.Clear()
 	cyan := color.Cyan()
-	fmt.Fprintln(v, fmt.Sprintf("%s %s %s %s %s %s",
+	fmt.Fprintln(v, fmt.Sprintf("%s %s %s %s %s %s %s%s",
 		color.Cyan(color.Background)(h.Info.Alias),
 		cyan(fmt.Sprintf("%s-v%s", "lnd", version)),
 		fmt.Sprintf("%s %s", chain, network),
 		sync,
+		connection,
 		fmt.Sprintf("%s %d", cyan("height:"), h.Info.BlockHeight),
 		fmt.Sprintf("%s %d", cyan("peers:"), h.Info.NumPeers),
+		node,
 	))
 	return nil
 }
 
-func NewHeader(info *models.Info) *Header {
-	return &Header{Info: info}
+func NewHeader(info *models.Info, connection *models.Connection) *Header {
+	return &Header{Info: info, Connection: connection}
 }
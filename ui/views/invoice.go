@@ -0,0 +1,186 @@
+package views
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	INVOICE        = "invoice"
+	INVOICE_HEADER = "invoice_header"
+	INVOICE_FOOTER = "invoice_footer"
+)
+
+type Invoice struct {
+	view     *gocui.View
+	invoices *models.Invoices
+}
+
+func (c Invoice) Name() string {
+	return INVOICE
+}
+
+func (c Invoice) Empty() bool {
+	return c.invoices == nil
+}
+
+func (c *Invoice) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Invoice) Origin() (int, int) {
+	return c.view.Origin()
+}
+
+func (c Invoice) Cursor() (int, int) {
+	return c.view.Cursor()
+}
+
+func (c Invoice) Speed() (int, int, int, int) {
+	return 1, 1, 1, 1
+}
+
+func (c Invoice) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = len(c.view.BufferLines()) - 1
+	return
+}
+
+func (c *Invoice) SetCursor(x, y int) error {
+	return c.view.SetCursor(x, y)
+}
+
+func (c *Invoice) SetOrigin(x, y int) error {
+	return c.view.SetOrigin(x, y)
+}
+
+func (c *Invoice) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	header, err := g.SetView(INVOICE_HEADER, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	header.Frame = false
+	header.BgColor = gocui.ColorGreen
+	header.FgColor = gocui.ColorBlack | gocui.AttrBold
+	header.Rewind()
+	fmt.Fprintln(header, "Invoice")
+
+	v, err := g.SetView(INVOICE, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	v.Frame = false
+	c.view = v
+	c.display()
+
+	footer, err := g.SetView(INVOICE_FOOTER, x0-1, y1-2, x1, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Invoices",
+		blackBg("S"), "QR code",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c Invoice) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(INVOICE_HEADER)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(INVOICE)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(INVOICE_FOOTER)
+}
+
+func (c *Invoice) display() {
+	p := message.NewPrinter(language.English)
+	v := c.view
+	v.Rewind()
+	invoice := c.invoices.Current()
+	if invoice == nil {
+		return
+	}
+	green := color.Green()
+	cyan := color.Cyan()
+	fmt.Fprintln(v, green(" [ Invoice ]"))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-8s",
+		cyan("          State:"), invoiceState(invoice.State)))
+	fmt.Fprintln(v, p.Sprintf("%s %d",
+		cyan("         Amount:"), invoice.Amount))
+	fmt.Fprintln(v, p.Sprintf("%s %d (%d msat)",
+		cyan("           Paid:"), invoice.AmountPaid, invoice.AmountPaidInMSat))
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan("           Memo:"), invoice.Description))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-15s",
+		cyan("        Created:"), formatUnix(invoice.CreationDate)))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-15s",
+		cyan("        Settled:"), formatUnix(invoice.SettleDate)))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-10s",
+		cyan("         Expiry:"), time.Duration(invoice.Expiry)*time.Second))
+	fmt.Fprintln(v, p.Sprintf("%s %-6d",
+		cyan("     CLTVExpiry:"), invoice.CLTVExpiry))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-5t",
+		cyan("        Private:"), invoice.Private))
+	fmt.Fprintln(v, p.Sprintf("%s %-10d",
+		cyan("          Index:"), invoice.Index))
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, green(" [ Payment ]"))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-64s",
+		cyan("          RHash:"), invoice.GetRHash()))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-64s",
+		cyan("      RPreImage:"), hex.EncodeToString(invoice.RPreImage)))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-64s",
+		cyan("DescriptionHash:"), hex.EncodeToString(invoice.DescriptionHash)))
+	fmt.Fprintln(v, fmt.Sprintf("%s %-64s",
+		cyan("FallBackAddress:"), invoice.FallBackAddress))
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan(" PaymentRequest:"), invoice.PaymentRequest))
+}
+
+// InvoiceReport returns the lines displaying the invoice to the payer below
+// the QR code of its payment request.
+func InvoiceReport(invoice *netmodels.Invoice) []string {
+	amount := "any"
+	if invoice.Amount > 0 {
+		amount = fmt.Sprintf("%d sat", invoice.Amount)
+	}
+	return []string{
+		fmt.Sprintf("State: %s", invoiceState(invoice.State)),
+		fmt.Sprintf("Amount: %s", amount),
+		fmt.Sprintf("Description: %s", invoice.Description),
+		fmt.Sprintf("Expiry: %s", formatUnix(invoice.CreationDate+invoice.Expiry)),
+	}
+}
+
+func NewInvoice(invoices *models.Invoices) *Invoice {
+	return &Invoice{invoices: invoices}
+}
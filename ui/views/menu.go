@@ -16,12 +16,20 @@ const (
 var menu = []string{
 	"CHANNEL",
 	"TRANSAC",
+	"INVOICE",
+	"PAYMENT",
 	"ROUTING",
 	"FWDHIST",
+	"PEERS",
+	"WALLET",
+	"PENDING",
+	"CLOSED",
+	"PROFIT",
 }
 
 type Menu struct {
-	view *gocui.View
+	view  *gocui.View
+	items []string
 
 	cy, oy int
 }
@@ -45,15 +53,15 @@ func (h Menu) Cursor() (int, int) {
 
 func (h Menu) Speed() (int, int, int, int) {
 	down := 0
-	if h.cy+h.oy < len(menu)-1 {
+	if h.cy+h.oy < len(h.items)-1 {
 		down = 1
 	}
 	return 0, 0, down, 1
 }
 
 func (h Menu) Limits() (pageSize int, fullSize int) {
-	pageSize = len(menu)
-	fullSize = len(menu)
+	pageSize = len(h.items)
+	fullSize = len(h.items)
 	return
 }
 
@@ -78,16 +86,32 @@ func (h *Menu) SetOrigin(x, y int) error {
 
 func (h Menu) Current() string {
 	_, y := h.view.Cursor()
-	if y < len(menu) {
-		switch menu[y] {
+	if y < len(h.items) {
+		switch h.items[y] {
 		case "CHANNEL":
 			return CHANNELS
 		case "TRANSAC":
 			return TRANSACTIONS
+		case "INVOICE":
+			return INVOICES
+		case "PAYMENT":
+			return PAYMENTS
 		case "ROUTING":
 			return ROUTING
 		case "FWDHIST":
 			return FWDINGHIST
+		case "PEERS":
+			return PEERS
+		case "WALLET":
+			return WALLET
+		case "PENDING":
+			return PENDING
+		case "CLOSED":
+			return CLOSED
+		case "PROFIT":
+			return PROFIT
+		case "NODES":
+			return NODES
 		}
 	}
 	return ""
@@ -138,7 +162,7 @@ func (h Menu) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 
 	h.view.Rewind()
 	for i := range menu {
-		fmt.Fprintln(h.view, fmt.Sprintf(" %-9s", menu[i]))
+		fmt.Fprintln(h.view, fmt.Sprintf(" %-9s", h.items[i]))
 	}
 	_, err = g.SetCurrentView(MENU)
 	if err != nil {
@@ -176,4 +200,9 @@ func (h Menu) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 	return nil
 }
 
-func NewMenu() *Menu { return &Menu{} }
+func NewMenu() *Menu { return &Menu{items: menu} }
+
+// AddItem appends an entry to the menu.
+func (h *Menu) AddItem(item string) {
+	h.items = append(h.items[:len(h.items):len(h.items)], item)
+}
@@ -0,0 +1,230 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	NODES         = "nodes"
+	NODES_COLUMNS = "nodes_columns"
+	NODES_FOOTER  = "nodes_footer"
+)
+
+// Nodes lists the monitored nodes with their balances and the total of
+// all the nodes.
+type Nodes struct {
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	nodes             *models.Nodes
+
+	ox, oy int
+	cx, cy int
+}
+
+func (c Nodes) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Nodes) Name() string {
+	return NODES
+}
+
+func (c *Nodes) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Nodes) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Nodes) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Nodes) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err := c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Nodes) SetOrigin(ox, oy int) error {
+	err := c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Nodes) Speed() (int, int, int, int) {
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.nodes.Len()-1 {
+		down = 1
+	}
+	return 0, 0, down, up
+}
+
+func (c *Nodes) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.nodes.Len()
+	return
+}
+
+func (c Nodes) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(NODES_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(NODES)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(NODES_FOOTER)
+}
+
+func (c *Nodes) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(NODES_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(NODES, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(NODES_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Switch",
+		blackBg("n"), "Next node",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c *Nodes) display() {
+	c.columnHeadersView.Rewind()
+	fmt.Fprintln(c.columnHeadersView, fmt.Sprintf("%-15s %-25s %6s %7s %8s %15s %15s %15s",
+		"NAME", "ALIAS", "ACTIVE", "PENDING", "INACTIVE", "CHANNELS", "PENDING OPEN", "WALLET",
+	))
+
+	c.view.Rewind()
+	green := color.Green()
+	yellow := color.Yellow()
+	red := color.Red()
+	for i, m := range c.nodes.List() {
+		var buffer bytes.Buffer
+		name := fmt.Sprintf("%-15s", m.Name())
+		if i == c.nodes.CurrentIndex() {
+			name = color.Cyan(color.Bold)(name)
+		}
+		buffer.WriteString(name)
+		buffer.WriteString(" ")
+
+		if m.Info.Info != nil {
+			buffer.WriteString(fmt.Sprintf("%-25s %s %s %s ",
+				m.Info.Alias,
+				green(fmt.Sprintf("%6d", m.Info.NumActiveChannels)),
+				yellow(fmt.Sprintf("%7d", m.Info.NumPendingChannels)),
+				red(fmt.Sprintf("%8d", m.Info.NumInactiveChannels)),
+			))
+		} else {
+			buffer.WriteString(fmt.Sprintf("%-25s %6s %7s %8s ", "", "", "", ""))
+		}
+
+		channels, pending, wallet := int64(0), int64(0), int64(0)
+		if m.ChannelsBalance.ChannelsBalance != nil {
+			channels = m.ChannelsBalance.Balance
+			pending = m.ChannelsBalance.PendingOpenBalance
+		}
+		if m.WalletBalance.WalletBalance != nil {
+			wallet = m.WalletBalance.TotalBalance
+		}
+		buffer.WriteString(fmt.Sprintf("%15s %15s %15s",
+			formatAmount(channels), formatAmount(pending), formatAmount(wallet),
+		))
+		fmt.Fprintln(c.view, buffer.String())
+	}
+
+	total := c.nodes.Total()
+	bold := color.White(color.Bold)
+	fmt.Fprintln(c.view, "")
+	fmt.Fprintln(c.view, fmt.Sprintf("%s %-25s %s %s %s %s %s %s",
+		bold(fmt.Sprintf("%-15s", "TOTAL")), "",
+		color.Green(color.Bold)(fmt.Sprintf("%6d", total.NumActiveChannels)),
+		color.Yellow(color.Bold)(fmt.Sprintf("%7d", total.NumPendingChannels)),
+		color.Red(color.Bold)(fmt.Sprintf("%8d", total.NumInactiveChannels)),
+		bold(fmt.Sprintf("%15s", formatAmount(total.ChannelsBalance))),
+		bold(fmt.Sprintf("%15s", formatAmount(total.PendingOpenBalance))),
+		bold(fmt.Sprintf("%15s", formatAmount(total.WalletBalance))),
+	))
+}
+
+func NewNodes(nodes *models.Nodes) *Nodes {
+	return &Nodes{nodes: nodes}
+}
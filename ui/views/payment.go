@@ -0,0 +1,249 @@
+package views
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PAYMENT        = "payment"
+	PAYMENT_HEADER = "payment_header"
+	PAYMENT_FOOTER = "payment_footer"
+)
+
+type Payment struct {
+	view     *gocui.View
+	payments *models.Payments
+}
+
+func (c Payment) Name() string {
+	return PAYMENT
+}
+
+func (c Payment) Empty() bool {
+	return c.payments == nil
+}
+
+func (c *Payment) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Payment) Origin() (int, int) {
+	return c.view.Origin()
+}
+
+func (c Payment) Cursor() (int, int) {
+	return c.view.Cursor()
+}
+
+func (c Payment) Speed() (int, int, int, int) {
+	return 1, 1, 1, 1
+}
+
+func (c Payment) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = len(c.view.BufferLines()) - 1
+	return
+}
+
+func (c *Payment) SetCursor(x, y int) error {
+	return c.view.SetCursor(x, y)
+}
+
+func (c *Payment) SetOrigin(x, y int) error {
+	return c.view.SetOrigin(x, y)
+}
+
+func (c *Payment) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	header, err := g.SetView(PAYMENT_HEADER, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	header.Frame = false
+	header.BgColor = gocui.ColorGreen
+	header.FgColor = gocui.ColorBlack | gocui.AttrBold
+	header.Rewind()
+	fmt.Fprintln(header, "Payment")
+
+	v, err := g.SetView(PAYMENT, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	v.Frame = false
+	c.view = v
+	c.display()
+
+	footer, err := g.SetView(PAYMENT_FOOTER, x0-1, y1-2, x1, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Payments",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c Payment) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PAYMENT_HEADER)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PAYMENT)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PAYMENT_FOOTER)
+}
+
+func (c *Payment) display() {
+
+	p := message.NewPrinter(language.English)
+	v := c.view
+	// the number of lines depends on the attempts, the view is cleared and
+	// its position, reset by Clear, is restored.
+	ox, oy := v.Origin()
+	cx, cy := v.Cursor()
+	v.Clear()
+	defer func() {
+		v.SetOrigin(ox, oy)
+		v.SetCursor(cx, cy)
+	}()
+
+	payment := c.payments.Current()
+	if payment == nil {
+		return
+	}
+	green := color.Green()
+	cyan := color.Cyan()
+	fmt.Fprintln(v, green(" [ Payment ]"))
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan("        Status:"), paymentStatus(payment.Status)))
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan("          Date:"), payment.CreationDate.Format("15:04:05 Jan _2")))
+	fmt.Fprintln(v, p.Sprintf("%s %d (%d msat)",
+		cyan("        Amount:"), payment.Amount(), payment.AmountMsat))
+	fmt.Fprintln(v, p.Sprintf("%s %d (%d msat, %d ppm)",
+		cyan("           Fee:"), payment.Fee(), payment.FeeMsat, payment.FeePPM()))
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan("      Duration:"), payment.Duration().Round(time.Millisecond)))
+	if payment.FailureReason != "" {
+		fmt.Fprintln(v, fmt.Sprintf("%s %s",
+			cyan("       Failure:"), color.Red()(payment.FailureReason)))
+	}
+	fmt.Fprintln(v, fmt.Sprintf("%s %s",
+		cyan("   PaymentHash:"), payment.PaymentHash))
+	if len(payment.PaymentPreimage) > 0 {
+		fmt.Fprintln(v, fmt.Sprintf("%s %s",
+			cyan("      Preimage:"), hex.EncodeToString(payment.PaymentPreimage)))
+	}
+	if payment.PayReq != nil && payment.PayReq.String != "" {
+		fmt.Fprintln(v, fmt.Sprintf("%s %s",
+			cyan("PaymentRequest:"), payment.PayReq.String))
+	}
+
+	for i, htlc := range payment.HTLCs {
+		fmt.Fprintln(v, "")
+		status := color.Yellow()("in flight")
+		switch htlc.Status {
+		case netmodels.HTLCAttemptSucceeded:
+			status = color.Green()("succeeded")
+		case netmodels.HTLCAttemptFailed:
+			status = color.Red()("failed")
+		}
+		fmt.Fprintln(v, fmt.Sprintf("%s %s",
+			green(fmt.Sprintf(" [ Attempt %d ]", i+1)), status))
+		if htlc.Failure != "" {
+			fmt.Fprintln(v, fmt.Sprintf("%s %s",
+				cyan("       Failure:"), htlc.Failure))
+		}
+		if htlc.Route == nil {
+			continue
+		}
+		fmt.Fprintln(v, p.Sprintf("%s %d",
+			cyan("        Amount:"), htlc.Route.Amount))
+		fmt.Fprintln(v, p.Sprintf("%s %d",
+			cyan("           Fee:"), htlc.Route.Fee))
+		if htlc.Route.TimeLock != 0 {
+			fmt.Fprintln(v, p.Sprintf("%s %d",
+				cyan("      TimeLock:"), htlc.Route.TimeLock))
+		}
+		if len(htlc.Route.Hops) == 0 {
+			continue
+		}
+		fmt.Fprintln(v, cyan(fmt.Sprintf("  %3s %-14s %-25s %13s %9s %7s",
+			"HOP", "CHANNEL", "ALIAS", "AMOUNT", "FEE", "EXPIRY")))
+		for j, hop := range htlc.Route.Hops {
+			alias := ""
+			if hop.PubKey != "" {
+				alias, _ = hop.ShortAlias()
+			}
+			fmt.Fprintln(v, p.Sprintf("  %3d %-14s %-25s %13d %9d %7d",
+				j+1, ToScid(hop.ChanID), alias, hop.Amount, hop.Fee, hop.Expiry))
+		}
+	}
+}
+
+// PaymentReport returns the lines following a payment of the payment
+// request: tracked is the payment reported by the node while it is in
+// flight, sent and err the result of the payment once it is done.
+func PaymentReport(payreq *netmodels.PayReq, tracked, sent *netmodels.Payment, err error) []string {
+	lines := []string{
+		fmt.Sprintf("Amount: %d sat", payreq.Amount),
+		fmt.Sprintf("Hash: %s", payreq.PaymentHash),
+	}
+	switch {
+	case err != nil:
+		return append(lines, fmt.Sprintf("Error: %s", err))
+	case sent != nil && sent.PaymentError != "":
+		return append(lines, "Status: failed", fmt.Sprintf("Failure: %s", sent.PaymentError))
+	case sent != nil:
+		lines = append(lines, "Status: succeeded")
+		if sent.Route != nil {
+			lines = append(lines, fmt.Sprintf("Fee: %d sat", sent.Route.Fee))
+		}
+		return append(lines, fmt.Sprintf("Preimage: %s", hex.EncodeToString(sent.PaymentPreimage)))
+	case tracked == nil:
+		return append(lines, "Status: sending")
+	}
+
+	lines = append(lines,
+		fmt.Sprintf("Status: %s", paymentStatus(tracked.Status)),
+		fmt.Sprintf("Attempts: %d", len(tracked.HTLCs)),
+	)
+	for i := len(tracked.HTLCs) - 1; i >= 0; i-- {
+		if tracked.HTLCs[i].Failure != "" {
+			lines = append(lines, fmt.Sprintf("Last failure: %s", tracked.HTLCs[i].Failure))
+			break
+		}
+	}
+	return lines
+}
+
+func NewPayment(payments *models.Payments) *Payment {
+	return &Payment{payments: payments}
+}
@@ -0,0 +1,439 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PAYMENTS         = "payments"
+	PAYMENTS_COLUMNS = "payments_columns"
+	PAYMENTS_FOOTER  = "payments_footer"
+)
+
+type Payments struct {
+	cfg *config.View
+
+	columns           []paymentsColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	payments          *models.Payments
+
+	ox, oy int
+	cx, cy int
+}
+
+type paymentsColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.PaymentsSort
+	display func(*netmodels.Payment, ...color.Option) string
+}
+
+func (c Payments) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Payments) Name() string {
+	return PAYMENTS
+}
+
+func (c *Payments) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Payments) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Payments) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Payments) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Payments) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Payments) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Payments) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.payments.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Payments) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.payments.Len()
+	return
+}
+
+func (c *Payments) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.payments.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Payments) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PAYMENTS_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PAYMENTS)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PAYMENTS_FOOTER)
+}
+
+func (c *Payments) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(PAYMENTS_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(PAYMENTS, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(PAYMENTS_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Payment",
+		blackBg("P"), "Pay",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c *Payments) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	c.view.Rewind()
+	for _, item := range c.payments.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+}
+
+func paymentStatus(status int) string {
+	switch status {
+	case netmodels.PaymentInFlight:
+		return "in flight"
+	case netmodels.PaymentSucceeded:
+		return "succeeded"
+	case netmodels.PaymentFailed:
+		return "failed"
+	}
+	return ""
+}
+
+func NewPayments(cfg *config.View, payments *models.Payments) *Payments {
+	view := &Payments{
+		cfg:      cfg,
+		payments: payments,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultPaymentsColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	view.columns = make([]paymentsColumn, len(columns))
+
+	for i := range columns {
+		switch columns[i] {
+		case "DATE":
+			view.columns[i] = paymentsColumn{
+				width: 15,
+				name:  fmt.Sprintf("%-15s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.DateSort(&p1.CreationDate, &p2.CreationDate, order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.Cyan(opts...)(
+						fmt.Sprintf("%15s", p.CreationDate.Format("15:04:05 Jan _2")),
+					)
+				},
+			}
+		case "STATUS":
+			view.columns[i] = paymentsColumn{
+				width: 9,
+				name:  fmt.Sprintf("%-9s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.IntSort(p1.Status, p2.Status, order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					status := fmt.Sprintf("%-9s", paymentStatus(p.Status))
+					switch p.Status {
+					case netmodels.PaymentSucceeded:
+						return color.Green(opts...)(status)
+					case netmodels.PaymentFailed:
+						return color.Red(opts...)(status)
+					}
+					return color.Yellow(opts...)(status)
+				},
+			}
+		case "AMOUNT":
+			view.columns[i] = paymentsColumn{
+				width: 13,
+				name:  fmt.Sprintf("%13s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.Int64Sort(p1.AmountMsat, p2.AmountMsat, order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%13d", p.Amount()))
+				},
+			}
+		case "FEE":
+			view.columns[i] = paymentsColumn{
+				width: 9,
+				name:  fmt.Sprintf("%9s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.Int64Sort(p1.FeeMsat, p2.FeeMsat, order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%9d", p.Fee()))
+				},
+			}
+		case "FEE_PPM":
+			view.columns[i] = paymentsColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.Int64Sort(p1.FeePPM(), p2.FeePPM(), order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%8d", p.FeePPM()))
+				},
+			}
+		case "ATTEMPTS":
+			view.columns[i] = paymentsColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.IntSort(len(p1.HTLCs), len(p2.HTLCs), order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%8d", len(p.HTLCs)))
+				},
+			}
+		case "DURATION":
+			view.columns[i] = paymentsColumn{
+				width: 9,
+				name:  fmt.Sprintf("%9s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.Int64Sort(int64(p1.Duration()), int64(p2.Duration()), order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					d := p.Duration()
+					if d == 0 {
+						return fmt.Sprintf("%9s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%9s", d.Round(time.Millisecond)))
+				},
+			}
+		case "FAILURE":
+			view.columns[i] = paymentsColumn{
+				width: 30,
+				name:  fmt.Sprintf("%-30s", columns[i]),
+				sort: func(order models.Order) models.PaymentsSort {
+					return func(p1, p2 *netmodels.Payment) bool {
+						return models.StringSort(p1.FailureReason, p2.FailureReason, order)
+					}
+				},
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					failure := runewidth.Truncate(p.FailureReason, 30, "…")
+					return color.Red(opts...)(runewidth.FillRight(failure, 30))
+				},
+			}
+		case "HASH":
+			view.columns[i] = paymentsColumn{
+				width: 64,
+				name:  fmt.Sprintf("%-64s", columns[i]),
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-64s", p.PaymentHash))
+				},
+			}
+		default:
+			view.columns[i] = paymentsColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(p *netmodels.Payment, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}
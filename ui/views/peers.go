@@ -0,0 +1,513 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PEERS         = "peers"
+	PEERS_COLUMNS = "peers_columns"
+	PEERS_FOOTER  = "peers_footer"
+)
+
+type Peers struct {
+	cfg *config.View
+
+	columns           []peersColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	peers             *models.Peers
+
+	ox, oy int
+	cx, cy int
+}
+
+type peersColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.PeersSort
+	display func(*netmodels.Peer, ...color.Option) string
+}
+
+func (c Peers) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Peers) Name() string {
+	return PEERS
+}
+
+func (c *Peers) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Peers) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Peers) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Peers) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Peers) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Peers) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Peers) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.peers.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Peers) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.peers.Len()
+	return
+}
+
+func (c *Peers) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.peers.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Peers) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PEERS_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PEERS)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PEERS_FOOTER)
+}
+
+func (c *Peers) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(PEERS_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(PEERS, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(PEERS_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("O"), "Connect",
+		blackBg("X"), "Disconnect",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c *Peers) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	// the peers come and go, the view is cleared to remove the rows of the
+	// disconnected peers and the cursor, reset by Clear, is restored.
+	c.view.Clear()
+	for _, item := range c.peers.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+	if c.oy+c.cy > c.peers.Len()-1 && c.cy > 0 {
+		c.cy = c.peers.Len() - 1 - c.oy
+		if c.cy < 0 {
+			c.cy = 0
+		}
+	}
+	c.view.SetOrigin(c.ox, c.oy)
+	c.view.SetCursor(c.cx, c.cy)
+}
+
+// formatBytes returns the amount of bytes with a binary prefix.
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func NewPeers(cfg *config.View, peers *models.Peers) *Peers {
+	view := &Peers{
+		cfg:   cfg,
+		peers: peers,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultPeersColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	view.columns = make([]peersColumn, len(columns))
+
+	for i := range columns {
+		switch columns[i] {
+		case "ALIAS":
+			view.columns[i] = peersColumn{
+				width: 25,
+				name:  fmt.Sprintf("%-25s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						a1, _ := p1.ShortAlias()
+						a2, _ := p2.ShortAlias()
+						return models.StringSort(a1, a2, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					aliasColor := color.White(opts...)
+					alias, forced := p.ShortAlias()
+					if forced {
+						aliasColor = color.Cyan(opts...)
+					}
+					return aliasColor(fmt.Sprintf("%-25s", alias))
+				},
+			}
+		case "PUBKEY":
+			view.columns[i] = peersColumn{
+				width: 66,
+				name:  fmt.Sprintf("%-66s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.StringSort(p1.PubKey, p2.PubKey, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-66s", p.PubKey))
+				},
+			}
+		case "ADDRESS":
+			view.columns[i] = peersColumn{
+				width: 25,
+				name:  fmt.Sprintf("%-25s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.StringSort(p1.Address, p2.Address, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					address := p.Address
+					if len(address) > 25 {
+						address = address[:24] + "…"
+					}
+					return color.White(opts...)(fmt.Sprintf("%-25s", address))
+				},
+			}
+		case "DIR":
+			view.columns[i] = peersColumn{
+				width: 3,
+				name:  fmt.Sprintf("%-3s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.BoolSort(p1.Inbound, p2.Inbound, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					if p.Inbound {
+						return color.Cyan(opts...)(fmt.Sprintf("%-3s", "in"))
+					}
+					return color.Yellow(opts...)(fmt.Sprintf("%-3s", "out"))
+				},
+			}
+		case "PING":
+			view.columns[i] = peersColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.Int64Sort(int64(p1.PingTime), int64(p2.PingTime), order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					if p.PingTime == 0 {
+						return fmt.Sprintf("%8s", "")
+					}
+					ping := fmt.Sprintf("%8s", p.PingTime.Round(time.Millisecond))
+					if p.PingTime > time.Second {
+						return color.Red(opts...)(ping)
+					}
+					return color.White(opts...)(ping)
+				},
+			}
+		case "BYTES_SENT":
+			view.columns[i] = peersColumn{
+				width: 10,
+				name:  fmt.Sprintf("%10s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.UInt64Sort(p1.BytesSent, p2.BytesSent, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%10s", formatBytes(p.BytesSent)))
+				},
+			}
+		case "BYTES_RECV":
+			view.columns[i] = peersColumn{
+				width: 10,
+				name:  fmt.Sprintf("%10s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.UInt64Sort(p1.BytesRecv, p2.BytesRecv, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%10s", formatBytes(p.BytesRecv)))
+				},
+			}
+		case "SENT":
+			view.columns[i] = peersColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.Int64Sort(p1.SatSent, p2.SatSent, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return color.Cyan(opts...)(printer.Sprintf("%12d", p.SatSent))
+				},
+			}
+		case "RECEIVED":
+			view.columns[i] = peersColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.Int64Sort(p1.SatRecv, p2.SatRecv, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return color.Cyan(opts...)(printer.Sprintf("%12d", p.SatRecv))
+				},
+			}
+		case "FLAPS":
+			view.columns[i] = peersColumn{
+				width: 5,
+				name:  fmt.Sprintf("%5s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.Int32Sort(p1.FlapCount, p2.FlapCount, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					flaps := fmt.Sprintf("%5d", p.FlapCount)
+					if p.FlapCount > 10 {
+						return color.Red(opts...)(flaps)
+					}
+					return color.White(opts...)(flaps)
+				},
+			}
+		case "LAST_FLAP":
+			view.columns[i] = peersColumn{
+				width: 15,
+				name:  fmt.Sprintf("%-15s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.DateSort(&p1.LastFlap, &p2.LastFlap, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					if p.LastFlap.IsZero() {
+						return fmt.Sprintf("%15s", "")
+					}
+					return color.Cyan(opts...)(
+						fmt.Sprintf("%15s", p.LastFlap.Format("15:04:05 Jan _2")),
+					)
+				},
+			}
+		case "CHANNELS":
+			view.columns[i] = peersColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.PeersSort {
+					return func(p1, p2 *netmodels.Peer) bool {
+						return models.UInt32Sort(p1.NumChannels, p2.NumChannels, order)
+					}
+				},
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					if p.NumChannels == 0 {
+						return color.White(opts...)(fmt.Sprintf("%8s", "no"))
+					}
+					return color.Green(opts...)(fmt.Sprintf("%8d", p.NumChannels))
+				},
+			}
+		default:
+			view.columns[i] = peersColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(p *netmodels.Peer, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}
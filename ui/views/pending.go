@@ -0,0 +1,602 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PENDING         = "pending"
+	PENDING_COLUMNS = "pending_columns"
+	PENDING_FOOTER  = "pending_footer"
+)
+
+type Pending struct {
+	cfg *config.View
+
+	columns           []pendingColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	channels          *models.PendingChannels
+	transactions      *models.Transactions
+	info              *models.Info
+
+	ox, oy int
+	cx, cy int
+}
+
+type pendingColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.PendingChannelsSort
+	display func(*netmodels.Channel, ...color.Option) string
+}
+
+func (c Pending) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Pending) Name() string {
+	return PENDING
+}
+
+func (c *Pending) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Pending) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Pending) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Pending) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Pending) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Pending) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Pending) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.channels.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Pending) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.channels.Len()
+	return
+}
+
+func (c *Pending) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.channels.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Pending) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PENDING_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PENDING)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PENDING_FOOTER)
+}
+
+func (c *Pending) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(PENDING_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(PENDING, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(PENDING_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Channel",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c *Pending) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	c.view.Clear()
+	for _, item := range c.channels.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+	if c.oy+c.cy > c.channels.Len()-1 && c.cy > 0 {
+		c.cy = c.channels.Len() - 1 - c.oy
+		if c.cy < 0 {
+			c.cy = 0
+		}
+	}
+	c.view.SetOrigin(c.ox, c.oy)
+	c.view.SetCursor(c.cx, c.cy)
+}
+
+// blockInterval is the expected time between two blocks.
+const blockInterval = 10 * time.Minute
+
+// blocksLeft returns the number of blocks until the maturity height, the
+// number of blocks given by the backend is used when a height is unknown.
+func blocksLeft(info *models.Info, height uint32, blocks int32) int32 {
+	if height == 0 || info.Info == nil || info.BlockHeight == 0 {
+		return blocks
+	}
+	return int32(height) - int32(info.BlockHeight)
+}
+
+// estimatedTime returns the time the blocks are expected to be mined.
+func estimatedTime(blocks int32) time.Time {
+	return time.Now().Add(time.Duration(blocks) * blockInterval)
+}
+
+// pendingTxID returns the transaction the channel waits for, the closing
+// transaction when it is known or else the funding transaction.
+func pendingTxID(c *netmodels.Channel) string {
+	if c.Pending != nil && c.Pending.ClosingTxID != "" {
+		return c.Pending.ClosingTxID
+	}
+	if c.Status == netmodels.ChannelOpening {
+		return c.FundingTxID()
+	}
+	return ""
+}
+
+// confirmations returns the confirmations of a transaction of the wallet,
+// -1 when the wallet does not know it.
+func confirmations(transactions *models.Transactions, txid string) int32 {
+	if txid == "" {
+		return -1
+	}
+	tx := transactions.GetByTxHash(txid)
+	if tx == nil {
+		return -1
+	}
+	return tx.NumConfirmations
+}
+
+// maturity returns the number of blocks until the outputs of a force
+// closed channel can be swept.
+func maturity(info *models.Info, c *netmodels.Channel) int32 {
+	if c.Status != netmodels.ChannelForceClosing {
+		return 0
+	}
+	height := uint32(0)
+	if c.Pending != nil {
+		height = c.Pending.MaturityHeight
+	}
+	return blocksLeft(info, height, c.BlocksTilMaturity)
+}
+
+func anchorString(c *netmodels.Channel) string {
+	if c.Pending == nil {
+		return ""
+	}
+	switch c.Pending.Anchor {
+	case netmodels.AnchorLimbo:
+		return "limbo"
+	case netmodels.AnchorRecovered:
+		return "recovered"
+	case netmodels.AnchorLost:
+		return "lost"
+	default:
+		return ""
+	}
+}
+
+func pendingBalance(c *netmodels.Channel, balance func(*netmodels.PendingChannel) int64) int64 {
+	if c.Pending == nil {
+		return 0
+	}
+	return balance(c.Pending)
+}
+
+func NewPending(cfg *config.View, channels *models.PendingChannels, transactions *models.Transactions, info *models.Info) *Pending {
+	view := &Pending{
+		cfg:          cfg,
+		channels:     channels,
+		transactions: transactions,
+		info:         info,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultPendingColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	limbo := func(p *netmodels.PendingChannel) int64 { return p.LimboBalance }
+	recovered := func(p *netmodels.PendingChannel) int64 { return p.RecoveredBalance }
+
+	view.columns = make([]pendingColumn, len(columns))
+
+	for i := range columns {
+		switch columns[i] {
+		case "STATUS":
+			view.columns[i] = pendingColumn{
+				width: 13,
+				name:  fmt.Sprintf("%-13s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.IntSort(c1.Status, c2.Status, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return status(c, opts...)
+				},
+			}
+		case "ALIAS":
+			view.columns[i] = pendingColumn{
+				width: 25,
+				name:  fmt.Sprintf("%-25s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						a1, _ := c1.ShortAlias()
+						a2, _ := c2.ShortAlias()
+						return models.StringSort(a1, a2, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					aliasColor := color.White(opts...)
+					alias, forced := c.ShortAlias()
+					if forced {
+						aliasColor = color.Cyan(opts...)
+					}
+					return aliasColor(fmt.Sprintf("%-25s", alias))
+				},
+			}
+		case "CAP":
+			view.columns[i] = pendingColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int64Sort(c1.Capacity, c2.Capacity, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%12d", c.Capacity))
+				},
+			}
+		case "LOCAL":
+			view.columns[i] = pendingColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int64Sort(c1.LocalBalance, c2.LocalBalance, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.Cyan(opts...)(printer.Sprintf("%12d", c.LocalBalance))
+				},
+			}
+		case "TXID":
+			view.columns[i] = pendingColumn{
+				width: 64,
+				name:  fmt.Sprintf("%-64s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.StringSort(pendingTxID(c1), pendingTxID(c2), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-64s", pendingTxID(c)))
+				},
+			}
+		case "CONFIR":
+			view.columns[i] = pendingColumn{
+				width: 6,
+				name:  fmt.Sprintf("%6s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int32Sort(
+							confirmations(view.transactions, pendingTxID(c1)),
+							confirmations(view.transactions, pendingTxID(c2)),
+							order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					n := confirmations(view.transactions, pendingTxID(c))
+					if n < 0 {
+						return fmt.Sprintf("%6s", "")
+					}
+					if n == 0 {
+						return color.Yellow(opts...)(fmt.Sprintf("%6d", n))
+					}
+					return color.White(opts...)(printer.Sprintf("%6d", n))
+				},
+			}
+		case "COMMIT_FEE":
+			view.columns[i] = pendingColumn{
+				width: 10,
+				name:  fmt.Sprintf("%10s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int64Sort(c1.CommitFee, c2.CommitFee, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%10d", c.CommitFee))
+				},
+			}
+		case "ANCHOR":
+			view.columns[i] = pendingColumn{
+				width: 9,
+				name:  fmt.Sprintf("%-9s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.StringSort(anchorString(c1), anchorString(c2), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					anchor := fmt.Sprintf("%-9s", anchorString(c))
+					if c.Pending != nil && c.Pending.Anchor == netmodels.AnchorLost {
+						return color.Red(opts...)(anchor)
+					}
+					return color.White(opts...)(anchor)
+				},
+			}
+		case "LIMBO":
+			view.columns[i] = pendingColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int64Sort(pendingBalance(c1, limbo), pendingBalance(c2, limbo), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.Yellow(opts...)(printer.Sprintf("%12d", pendingBalance(c, limbo)))
+				},
+			}
+		case "RECOVERED":
+			view.columns[i] = pendingColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int64Sort(pendingBalance(c1, recovered), pendingBalance(c2, recovered), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.Green(opts...)(printer.Sprintf("%12d", pendingBalance(c, recovered)))
+				},
+			}
+		case "MATURITY":
+			view.columns[i] = pendingColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int32Sort(maturity(view.info, c1), maturity(view.info, c2), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					if c.Status != netmodels.ChannelForceClosing {
+						return fmt.Sprintf("%8s", "")
+					}
+					return color.White(opts...)(printer.Sprintf("%8d", maturity(view.info, c)))
+				},
+			}
+		case "ETA":
+			view.columns[i] = pendingColumn{
+				width: 12,
+				name:  fmt.Sprintf("%-12s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.Int32Sort(maturity(view.info, c1), maturity(view.info, c2), order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					blocks := maturity(view.info, c)
+					if c.Status != netmodels.ChannelForceClosing || blocks <= 0 {
+						return fmt.Sprintf("%-12s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%-12s", estimatedTime(blocks).Format("Jan _2 15:04")))
+				},
+			}
+		case "CHANNEL_POINT":
+			view.columns[i] = pendingColumn{
+				width: 68,
+				name:  fmt.Sprintf("%-68s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.StringSort(c1.ChannelPoint, c2.ChannelPoint, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-68s", c.ChannelPoint))
+				},
+			}
+		case "PUBKEY":
+			view.columns[i] = pendingColumn{
+				width: 66,
+				name:  fmt.Sprintf("%-66s", columns[i]),
+				sort: func(order models.Order) models.PendingChannelsSort {
+					return func(c1, c2 *netmodels.Channel) bool {
+						return models.StringSort(c1.RemotePubKey, c2.RemotePubKey, order)
+					}
+				},
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-66s", c.RemotePubKey))
+				},
+			}
+		default:
+			view.columns[i] = pendingColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(c *netmodels.Channel, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}
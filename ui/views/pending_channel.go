@@ -0,0 +1,237 @@
+package views
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PENDING_CHANNEL        = "pending_channel"
+	PENDING_CHANNEL_HEADER = "pending_channel_header"
+	PENDING_CHANNEL_FOOTER = "pending_channel_footer"
+)
+
+type PendingChannel struct {
+	view         *gocui.View
+	channels     *models.PendingChannels
+	transactions *models.Transactions
+	info         *models.Info
+}
+
+func (c PendingChannel) Name() string {
+	return PENDING_CHANNEL
+}
+
+func (c PendingChannel) Empty() bool {
+	return c.channels == nil
+}
+
+func (c *PendingChannel) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c PendingChannel) Origin() (int, int) {
+	return c.view.Origin()
+}
+
+func (c PendingChannel) Cursor() (int, int) {
+	return c.view.Cursor()
+}
+
+func (c PendingChannel) Speed() (int, int, int, int) {
+	return 1, 1, 1, 1
+}
+
+func (c PendingChannel) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = len(c.view.BufferLines()) - 1
+	return
+}
+
+func (c *PendingChannel) SetCursor(x, y int) error {
+	return c.view.SetCursor(x, y)
+}
+
+func (c *PendingChannel) SetOrigin(x, y int) error {
+	return c.view.SetOrigin(x, y)
+}
+
+func (c *PendingChannel) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	header, err := g.SetView(PENDING_CHANNEL_HEADER, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	header.Frame = false
+	header.BgColor = gocui.ColorGreen
+	header.FgColor = gocui.ColorBlack | gocui.AttrBold
+	header.Rewind()
+	fmt.Fprintln(header, "Pending channel")
+
+	v, err := g.SetView(PENDING_CHANNEL, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	v.Frame = false
+	c.view = v
+	c.display()
+
+	footer, err := g.SetView(PENDING_CHANNEL_FOOTER, x0-1, y1-2, x1, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s",
+		blackBg("F2"), "Menu",
+		blackBg("Enter"), "Pending",
+		blackBg("F10"), "Quit",
+	))
+	return nil
+}
+
+func (c PendingChannel) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PENDING_CHANNEL_HEADER)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PENDING_CHANNEL)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PENDING_CHANNEL_FOOTER)
+}
+
+func (c *PendingChannel) display() {
+	p := message.NewPrinter(language.English)
+	v := c.view
+	v.Clear()
+	channel := c.channels.Current()
+	if channel == nil {
+		return
+	}
+	green := color.Green()
+	cyan := color.Cyan()
+	fmt.Fprintln(v, green(" [ Pending channel ]"))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("             Status:"), status(channel))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("           Capacity:"), formatAmount(channel.Capacity))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("      Local Balance:"), formatAmount(channel.LocalBalance))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("     Remote Balance:"), formatAmount(channel.RemoteBalance))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("         Commit Fee:"), formatAmount(channel.CommitFee))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("      Channel Point:"), channel.ChannelPoint)
+	fmt.Fprintf(v, "%s %s%s\n",
+		cyan("     Funding TxHash:"), channel.FundingTxID(),
+		c.confirmations(channel.FundingTxID()))
+
+	if channel.Pending != nil {
+		pending := channel.Pending
+		if pending.ClosingTxID != "" {
+			fmt.Fprintf(v, "%s %s%s\n",
+				cyan("     Closing TxHash:"), pending.ClosingTxID,
+				c.confirmations(pending.ClosingTxID))
+		}
+		if anchor := anchorString(channel); anchor != "" {
+			fmt.Fprintf(v, "%s %s\n",
+				cyan("             Anchor:"), anchor)
+		}
+		if channel.Status == netmodels.ChannelForceClosing || pending.LimboBalance > 0 {
+			fmt.Fprintf(v, "%s %s\n",
+				cyan("      Limbo Balance:"), formatAmount(pending.LimboBalance))
+			fmt.Fprintf(v, "%s %s\n",
+				cyan("  Recovered Balance:"), formatAmount(pending.RecoveredBalance))
+		}
+	}
+	if channel.Status == netmodels.ChannelForceClosing {
+		height := uint32(0)
+		if channel.Pending != nil {
+			height = channel.Pending.MaturityHeight
+		}
+		fmt.Fprintf(v, "%s %s\n",
+			cyan("         Matured in:"), c.countdown(p, height, channel.BlocksTilMaturity))
+	}
+	fmt.Fprintln(v, "")
+
+	fmt.Fprintln(v, green(" [ Node ]"))
+	fmt.Fprintf(v, "%s %s\n",
+		cyan("         PubKey:"), channel.RemotePubKey)
+	if channel.Node != nil {
+		alias, forced := channel.ShortAlias()
+		if forced {
+			alias = cyan(alias)
+		}
+		fmt.Fprintf(v, "%s %s\n",
+			cyan("          Alias:"), alias)
+	}
+
+	if channel.Pending != nil && len(channel.Pending.HTLCs) > 0 {
+		fmt.Fprintln(v)
+		fmt.Fprintln(v, green(" [ Time locked HTLCs ]"))
+		for _, htlc := range channel.Pending.HTLCs {
+			fmt.Fprintf(v, "%s %t\n",
+				cyan("   Incoming:"), htlc.Incoming)
+			fmt.Fprintf(v, "%s %s\n",
+				cyan("     Amount:"), formatAmount(htlc.Amount))
+			fmt.Fprintf(v, "%s %s\n",
+				cyan("   Outpoint:"), htlc.Outpoint)
+			fmt.Fprintf(v, "%s %d\n",
+				cyan("      Stage:"), htlc.Stage)
+			fmt.Fprintf(v, "%s %d\n",
+				cyan("   Maturity:"), htlc.MaturityHeight)
+			fmt.Fprintf(v, "%s %s\n",
+				cyan(" Matured in:"), c.countdown(p, htlc.MaturityHeight, htlc.BlocksTilMaturity))
+			fmt.Fprintln(v)
+		}
+	}
+}
+
+// confirmations returns the confirmations of a transaction known by the
+// wallet.
+func (c *PendingChannel) confirmations(txid string) string {
+	n := confirmations(c.transactions, txid)
+	if n < 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d confirmations)", n)
+}
+
+// countdown returns the number of blocks until the maturity height and the
+// time they are expected to be mined.
+func (c *PendingChannel) countdown(p *message.Printer, height uint32, blocks int32) string {
+	left := blocksLeft(c.info, height, blocks)
+	if left <= 0 {
+		return "matured"
+	}
+	return p.Sprintf("%d blocks (~%s)", left, estimatedTime(left).Format("15:04 Jan _2"))
+}
+
+func NewPendingChannel(channels *models.PendingChannels, transactions *models.Transactions, info *models.Info) *PendingChannel {
+	return &PendingChannel{
+		channels:     channels,
+		transactions: transactions,
+		info:         info,
+	}
+}
@@ -0,0 +1,497 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	PROFIT         = "profit"
+	PROFIT_COLUMNS = "profit_columns"
+	PROFIT_FOOTER  = "profit_footer"
+)
+
+type Profit struct {
+	cfg *config.View
+
+	columns           []profitColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	profit            *models.Profit
+
+	ox, oy int
+	cx, cy int
+}
+
+type profitColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.ProfitSort
+	display func(*netmodels.ChannelProfit, ...color.Option) string
+}
+
+func (c Profit) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Profit) Name() string {
+	return PROFIT
+}
+
+func (c *Profit) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Profit) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Profit) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Profit) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Profit) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Profit) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Profit) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.profit.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Profit) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.profit.Len()
+	return
+}
+
+func (c *Profit) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.profit.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Profit) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(PROFIT_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(PROFIT)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(PROFIT_FOOTER)
+}
+
+func (c *Profit) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(PROFIT_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(PROFIT, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(PROFIT_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s",
+		blackBg("F2"), "Menu",
+		blackBg("F10"), "Quit",
+		"on-chain costs: fees paid by the wallet",
+	))
+	return nil
+}
+
+func (c *Profit) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	c.view.Clear()
+	for _, item := range c.profit.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+	if c.oy+c.cy > c.profit.Len()-1 && c.cy > 0 {
+		c.cy = c.profit.Len() - 1 - c.oy
+		if c.cy < 0 {
+			c.cy = 0
+		}
+	}
+	c.view.SetOrigin(c.ox, c.oy)
+	c.view.SetCursor(c.cx, c.cy)
+}
+
+// profitAmount returns the sats of an amount in millisatoshis.
+func profitAmount(msat int64) int64 {
+	return msat / 1000
+}
+
+func NewProfit(cfg *config.View, profit *models.Profit) *Profit {
+	view := &Profit{
+		cfg:    cfg,
+		profit: profit,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultProfitColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	view.columns = make([]profitColumn, len(columns))
+
+	// amount returns a column of an amount in satoshis.
+	amount := func(name string, value func(*netmodels.ChannelProfit) int64, colorize func(...color.Option) func(...interface{}) string) profitColumn {
+		return profitColumn{
+			width: 12,
+			name:  fmt.Sprintf("%12s", name),
+			sort: func(order models.Order) models.ProfitSort {
+				return func(p1, p2 *netmodels.ChannelProfit) bool {
+					return models.Int64Sort(value(p1), value(p2), order)
+				}
+			},
+			display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+				return colorize(opts...)(printer.Sprintf("%12d", value(p)))
+			},
+		}
+	}
+
+	for i := range columns {
+		switch columns[i] {
+		case "ALIAS":
+			view.columns[i] = profitColumn{
+				width: 25,
+				name:  fmt.Sprintf("%-25s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						a1, _ := p1.ShortAlias()
+						a2, _ := p2.ShortAlias()
+						return models.StringSort(a1, a2, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					aliasColor := color.White(opts...)
+					alias, forced := p.ShortAlias()
+					if forced {
+						aliasColor = color.Cyan(opts...)
+					}
+					return aliasColor(fmt.Sprintf("%-25s", alias))
+				},
+			}
+		case "STATUS":
+			view.columns[i] = profitColumn{
+				width: 6,
+				name:  fmt.Sprintf("%-6s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.BoolSort(!p1.Closed, !p2.Closed, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					if p.Closed {
+						return color.Red(opts...)("closed")
+					}
+					return color.Green(opts...)("open  ")
+				},
+			}
+		case "CAP":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return p.Capacity
+			}, color.White)
+		case "FEES_OUT":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return profitAmount(int64(p.FeesOutMsat))
+			}, color.Green)
+		case "FEES_IN":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return profitAmount(int64(p.FeesInMsat))
+			}, color.Cyan)
+		case "VOLUME_OUT":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return profitAmount(int64(p.VolumeOutMsat))
+			}, color.White)
+		case "VOLUME_IN":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return profitAmount(int64(p.VolumeInMsat))
+			}, color.White)
+		case "FORWARDS":
+			view.columns[i] = profitColumn{
+				width: 8,
+				name:  fmt.Sprintf("%8s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.IntSort(p1.Forwards, p2.Forwards, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%8d", p.Forwards))
+				},
+			}
+		case "REBALANCE":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return profitAmount(p.RebalanceMsat)
+			}, color.Yellow)
+		case "OPEN_COST":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return p.OpenCost
+			}, color.Yellow)
+		case "CLOSE_COST":
+			view.columns[i] = amount(columns[i], func(p *netmodels.ChannelProfit) int64 {
+				return p.CloseCost
+			}, color.Yellow)
+		case "CAPITAL_DAYS":
+			view.columns[i] = profitColumn{
+				width: 14,
+				name:  fmt.Sprintf("%14s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.Float64Sort(p1.CapitalDays, p2.CapitalDays, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					return color.White(opts...)(printer.Sprintf("%14d", int64(p.CapitalDays)))
+				},
+			}
+		case "YIELD_PPM":
+			view.columns[i] = profitColumn{
+				width: 9,
+				name:  fmt.Sprintf("%9s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.Int64Sort(p1.YieldPPM(), p2.YieldPPM(), order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					result := printer.Sprintf("%9d", p.YieldPPM())
+					if p.YieldPPM() < 0 {
+						return color.Red(opts...)(result)
+					}
+					return color.Cyan(opts...)(result)
+				},
+			}
+		case "NET":
+			view.columns[i] = profitColumn{
+				width: 12,
+				name:  fmt.Sprintf("%12s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.Int64Sort(p1.NetMsat(), p2.NetMsat(), order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					result := printer.Sprintf("%12d", profitAmount(p.NetMsat()))
+					if p.NetMsat() < 0 {
+						return color.Red(opts...)(result)
+					}
+					return color.Green(opts...)(result)
+				},
+			}
+		case "SCID":
+			view.columns[i] = profitColumn{
+				width: 14,
+				name:  fmt.Sprintf("%-14s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.UInt64Sort(p1.ID, p2.ID, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					if p.ID == 0 {
+						return fmt.Sprintf("%-14s", "")
+					}
+					return color.White(opts...)(fmt.Sprintf("%-14s", ToScid(p.ID)))
+				},
+			}
+		case "CHANNEL_POINT":
+			view.columns[i] = profitColumn{
+				width: 68,
+				name:  fmt.Sprintf("%-68s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.StringSort(p1.ChannelPoint, p2.ChannelPoint, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-68s", p.ChannelPoint))
+				},
+			}
+		case "PUBKEY":
+			view.columns[i] = profitColumn{
+				width: 66,
+				name:  fmt.Sprintf("%-66s", columns[i]),
+				sort: func(order models.Order) models.ProfitSort {
+					return func(p1, p2 *netmodels.ChannelProfit) bool {
+						return models.StringSort(p1.RemotePubKey, p2.RemotePubKey, order)
+					}
+				},
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-66s", p.RemotePubKey))
+				},
+			}
+		default:
+			view.columns[i] = profitColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(p *netmodels.ChannelProfit, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}
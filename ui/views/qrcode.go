@@ -0,0 +1,177 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/pkg/errors"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/edouardparis/lntop/ui/color"
+)
+
+const (
+	QRCODE = "qrcode"
+
+	// qrQuietZone is the number of light modules around the QR code, the
+	// scanners need it to find the code.
+	qrQuietZone = 2
+)
+
+// QRCode displays a content as a QR code over the main view, with the lines
+// of its report below the code.
+type QRCode struct {
+	view    *gocui.View
+	title   string
+	content string
+	report  func() []string
+}
+
+func (q QRCode) Name() string {
+	return QRCODE
+}
+
+// Opened returns true when the QR code is displayed.
+func (q QRCode) Opened() bool {
+	return q.content != ""
+}
+
+// Open displays the content as a QR code, report is called each time the
+// view is displayed.
+func (q *QRCode) Open(title, content string, report func() []string) {
+	*q = QRCode{title: title, content: content, report: report}
+}
+
+// Close hides the QR code.
+func (q *QRCode) Close(g *gocui.Gui) error {
+	q.content = ""
+	err := g.DeleteView(QRCODE)
+	if err != nil && err != gocui.ErrUnknownView {
+		return err
+	}
+	return nil
+}
+
+func (q *QRCode) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	lines := []string{}
+	if q.report != nil {
+		lines = q.report()
+	}
+	width := 60
+	for _, line := range lines {
+		if len(line)+3 > width {
+			width = len(line) + 3
+		}
+	}
+
+	// the code takes the space left by the lines of the report and the
+	// footer, it is replaced by a message when the space is too small.
+	code, err := qrCodeLines(q.content, x1-x0-3, y1-y0-len(lines)-4)
+	if err != nil {
+		code = []string{err.Error()}
+	}
+	for _, line := range code {
+		if len([]rune(line))+3 > width {
+			width = len([]rune(line)) + 3
+		}
+	}
+	if x1-x0 < width {
+		width = x1 - x0
+	}
+	height := len(code) + len(lines) + 4
+	if y1-y0 < height {
+		height = y1 - y0
+	}
+	x := x0 + (x1-x0-width)/2
+	y := y0 + (y1-y0-height)/2
+
+	v, err := g.SetView(QRCODE, x, y, x+width, y+height, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	v.Frame = true
+	v.Title = q.title
+	q.view = v
+
+	_, err = g.SetViewOnTop(QRCODE)
+	if err != nil {
+		return err
+	}
+
+	q.display(code, lines)
+	return nil
+}
+
+func (q *QRCode) display(code, lines []string) {
+	v := q.view
+	v.Clear()
+	blackBg := color.Black(color.Background)
+
+	for _, line := range code {
+		fmt.Fprintf(v, " %s\n", blackBg(line))
+	}
+	fmt.Fprintln(v)
+	for _, line := range lines {
+		fmt.Fprintf(v, " %s\n", line)
+	}
+	fmt.Fprintln(v)
+	fmt.Fprintf(v, " %s%s\n", blackBg("Esc"), "Close")
+}
+
+// qrCodeLines returns the lines drawing the QR code of the content with half
+// block characters, the light modules are drawn so that the code is shown
+// in white on black. A module is scaled to the largest square of characters
+// and half characters fitting in width and height.
+func qrCodeLines(content string, width, height int) ([]string, error) {
+	code, err := qrcode.New(content, qrcode.Low)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	code.DisableBorder = true
+	bitmap := code.Bitmap()
+
+	size := len(bitmap) + 2*qrQuietZone
+	scale := width / size
+	if 2*height/size < scale {
+		scale = 2 * height / size
+	}
+	if scale < 1 {
+		return nil, errors.Errorf("terminal too small for the QR code (%dx%d)", size, (size+1)/2)
+	}
+
+	// the modules out of the bitmap are the ones of the quiet zone.
+	light := func(x, y int) bool {
+		x, y = x/scale-qrQuietZone, y/scale-qrQuietZone
+		if x < 0 || y < 0 || x >= len(bitmap) || y >= len(bitmap) {
+			return true
+		}
+		return !bitmap[y][x]
+	}
+
+	lines := make([]string, 0, (size*scale+1)/2)
+	for y := 0; y < size*scale; y += 2 {
+		var line strings.Builder
+		for x := 0; x < size*scale; x++ {
+			top, bottom := light(x, y), y+1 < size*scale && light(x, y+1)
+			switch {
+			case top && bottom:
+				line.WriteRune('█')
+			case top:
+				line.WriteRune('▀')
+			case bottom:
+				line.WriteRune('▄')
+			default:
+				line.WriteRune(' ')
+			}
+		}
+		lines = append(lines, line.String())
+	}
+	return lines, nil
+}
+
+func NewQRCode() *QRCode {
+	return &QRCode{}
+}
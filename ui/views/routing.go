@@ -20,19 +20,6 @@ const (
 	ROUTING_FOOTER  = "routing_footer"
 )
 
-var DefaultRoutingColumns = []string{
-	"DIR",
-	"STATUS",
-	"IN_CHANNEL",
-	"IN_ALIAS",
-	"OUT_CHANNEL",
-	"OUT_ALIAS",
-	"AMOUNT",
-	"FEE",
-	"LAST UPDATE",
-	"DETAIL",
-}
-
 type Routing struct {
 	cfg *config.View
 
@@ -313,7 +300,7 @@ func NewRouting(cfg *config.View, routingEvents *models.RoutingLog, channels *mo
 
 	printer := message.NewPrinter(language.English)
 
-	columns := DefaultRoutingColumns
+	columns := config.DefaultRoutingColumns
 	if cfg != nil && len(cfg.Columns) != 0 {
 		columns = cfg.Columns
 	}
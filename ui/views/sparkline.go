@@ -0,0 +1,102 @@
+package views
+
+import (
+	"strings"
+	"time"
+
+	"github.com/edouardparis/lntop/network/options"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+// sparkBlocks are the levels of a sparkline from an empty to a full local
+// balance.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkTrend is the change of the local balance per capacity over the
+// period from which a channel is colored as draining or filling.
+const sparkTrend = 0.1
+
+// balancePeriod parses periods like "24h", "7d" or "30d", it returns the
+// default period if the period is invalid.
+func balancePeriod(period string, def time.Duration) time.Duration {
+	now := time.Now()
+	start, err := options.ParseTime("-"+period, now)
+	if err != nil || start == 0 {
+		return def
+	}
+	d := now.Sub(time.Unix(int64(start), 0))
+	if d <= 0 || d > models.MaxBalancePeriod {
+		return def
+	}
+	return d
+}
+
+// sparkline returns the local balance per capacity of the samples over the
+// period divided in width buckets, the periods without samples are blank.
+func sparkline(samples []models.BalanceSample, period time.Duration, width int) []float64 {
+	buckets := make([]float64, width)
+	counts := make([]int, width)
+	start := time.Now().Add(-period)
+	for _, s := range samples {
+		i := int(s.Time.Sub(start) * time.Duration(width) / period)
+		if i < 0 || i >= width {
+			continue
+		}
+		buckets[i] += s.Ratio()
+		counts[i]++
+	}
+	for i := range buckets {
+		if counts[i] == 0 {
+			buckets[i] = -1
+			continue
+		}
+		buckets[i] /= float64(counts[i])
+	}
+	return buckets
+}
+
+// trend returns the change of the local balance per capacity between the
+// first and the last buckets of the sparkline.
+func trend(buckets []float64) float64 {
+	first, last := -1.0, -1.0
+	for _, b := range buckets {
+		if b < 0 {
+			continue
+		}
+		if first < 0 {
+			first = b
+		}
+		last = b
+	}
+	if first < 0 {
+		return 0
+	}
+	return last - first
+}
+
+// formatSparkline renders the buckets with block characters, red when the
+// local balance is draining and green when it is filling.
+func formatSparkline(buckets []float64, opts ...color.Option) string {
+	var b strings.Builder
+	for _, v := range buckets {
+		if v < 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		i := int(v*float64(len(sparkBlocks)-1) + 0.5)
+		if i >= len(sparkBlocks) {
+			i = len(sparkBlocks) - 1
+		}
+		b.WriteRune(sparkBlocks[i])
+	}
+
+	t := trend(buckets)
+	switch {
+	case t <= -sparkTrend:
+		return color.Red(opts...)(b.String())
+	case t >= sparkTrend:
+		return color.Green(opts...)(b.String())
+	}
+	return color.Cyan(opts...)(b.String())
+}
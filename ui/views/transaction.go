@@ -95,9 +95,10 @@ func (c *Transaction) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
 	footer.FgColor = gocui.ColorBlack
 	footer.Rewind()
 	blackBg := color.Black(color.Background)
-	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s",
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s",
 		blackBg("F2"), "Menu",
 		blackBg("Enter"), "Transactions",
+		blackBg("B"), "Bump fee",
 		blackBg("F10"), "Quit",
 	))
 	return nil
@@ -131,6 +132,14 @@ func (c *Transaction) display() {
 		cyan("         Amount:"), transaction.Amount))
 	fmt.Fprintln(v, p.Sprintf("%s %d",
 		cyan("            Fee:"), transaction.TotalFees))
+	if transaction.VSize > 0 {
+		fmt.Fprintln(v, p.Sprintf("%s %d vB",
+			cyan("           Size:"), transaction.VSize))
+	}
+	if transaction.FeeRate() > 0 {
+		fmt.Fprintln(v, p.Sprintf("%s %.1f sat/vB",
+			cyan("       Fee rate:"), transaction.FeeRate()))
+	}
 	fmt.Fprintln(v, p.Sprintf("%s %d",
 		cyan("    BlockHeight:"), transaction.BlockHeight))
 	fmt.Fprintln(v, p.Sprintf("%s %d",
@@ -20,15 +20,6 @@ const (
 	TRANSACTIONS_FOOTER  = "transactions_footer"
 )
 
-var DefaultTransactionsColumns = []string{
-	"DATE",
-	"HEIGHT",
-	"CONFIR",
-	"AMOUNT",
-	"FEE",
-	"ADDRESSES",
-}
-
 type Transactions struct {
 	cfg *config.View
 
@@ -283,7 +274,7 @@ func NewTransactions(cfg *config.View, txs *models.Transactions) *Transactions {
 
 	printer := message.NewPrinter(language.English)
 
-	columns := DefaultTransactionsColumns
+	columns := config.DefaultTransactionsColumns
 	if cfg != nil && len(cfg.Columns) != 0 {
 		columns = cfg.Columns
 	}
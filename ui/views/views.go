@@ -30,8 +30,21 @@ type Views struct {
 	Channel      *Channel
 	Transactions *Transactions
 	Transaction  *Transaction
+	Invoices     *Invoices
+	Invoice      *Invoice
+	Payments     *Payments
+	Payment      *Payment
 	Routing      *Routing
 	FwdingHist   *FwdingHist
+	Peers        *Peers
+	Wallet       *Wallet
+	Pending      *Pending
+	PendingChan  *PendingChannel
+	Closed       *Closed
+	Profit       *Profit
+	Nodes        *Nodes
+	Dialog       *Dialog
+	QRCode       *QRCode
 }
 
 func (v Views) Get(vi *gocui.View) View {
@@ -49,10 +62,32 @@ func (v Views) Get(vi *gocui.View) View {
 		return v.Transactions.Wrap(vi)
 	case TRANSACTION:
 		return v.Transaction.Wrap(vi)
+	case INVOICES:
+		return v.Invoices.Wrap(vi)
+	case INVOICE:
+		return v.Invoice.Wrap(vi)
+	case PAYMENTS:
+		return v.Payments.Wrap(vi)
+	case PAYMENT:
+		return v.Payment.Wrap(vi)
 	case ROUTING:
 		return v.Routing.Wrap(vi)
 	case FWDINGHIST:
 		return v.FwdingHist.Wrap(vi)
+	case PEERS:
+		return v.Peers.Wrap(vi)
+	case WALLET:
+		return v.Wallet.Wrap(vi)
+	case PENDING:
+		return v.Pending.Wrap(vi)
+	case PENDING_CHANNEL:
+		return v.PendingChan.Wrap(vi)
+	case CLOSED:
+		return v.Closed.Wrap(vi)
+	case PROFIT:
+		return v.Profit.Wrap(vi)
+	case NODES:
+		return v.Nodes.Wrap(vi)
 	default:
 		return nil
 	}
@@ -90,6 +125,28 @@ func (v *Views) Layout(g *gocui.Gui, maxX, maxY int) error {
 		return err
 	}
 
+	if v.Dialog.Opened() {
+		err = v.Dialog.Set(g, 0, 6, maxX-1, maxY)
+		if err != nil {
+			return err
+		}
+
+		_, err = g.SetCurrentView(v.Dialog.Name())
+		return errors.WithStack(err)
+	}
+
+	// the QR code covers the header and the summary, a QR code of a
+	// payment request is otherwise too large for a terminal of 40 lines.
+	if v.QRCode.Opened() {
+		err = v.QRCode.Set(g, 0, 0, maxX-1, maxY)
+		if err != nil {
+			return err
+		}
+
+		_, err = g.SetCurrentView(v.QRCode.Name())
+		return errors.WithStack(err)
+	}
+
 	_, err = g.SetCurrentView(v.Main.Name())
 	if err != nil {
 		return errors.WithStack(err)
@@ -98,20 +155,40 @@ func (v *Views) Layout(g *gocui.Gui, maxX, maxY int) error {
 	return nil
 }
 
-func New(cfg config.Views, m *models.Models) *Views {
-	main := NewChannels(cfg.Channels, m.Channels)
-	return &Views{
-		Header:       NewHeader(m.Info),
+func New(cfg config.Views, m *models.Models, nodes *models.Nodes) *Views {
+	main := NewChannels(cfg.Channels, m.Channels, m.BalanceHistory)
+	views := &Views{
+		Header:       NewHeader(m.Info, m.Connection),
 		Menu:         NewMenu(),
 		Summary:      NewSummary(m.Info, m.ChannelsBalance, m.WalletBalance, m.Channels),
 		Channels:     main,
-		Channel:      NewChannel(m.Channels),
+		Channel:      NewChannel(m.Channels, m.BalanceHistory),
 		Transactions: NewTransactions(cfg.Transactions, m.Transactions),
 		Transaction:  NewTransaction(m.Transactions),
+		Invoices:     NewInvoices(cfg.Invoices, m.Invoices),
+		Invoice:      NewInvoice(m.Invoices),
+		Payments:     NewPayments(cfg.Payments, m.Payments),
+		Payment:      NewPayment(m.Payments),
 		Routing:      NewRouting(cfg.Routing, m.RoutingLog, m.Channels),
 		FwdingHist:   NewFwdingHist(cfg.FwdingHist, m.FwdingHist),
+		Peers:        NewPeers(cfg.Peers, m.Peers),
+		Wallet:       NewWallet(cfg.Wallet, m.Utxos),
+		Pending:      NewPending(cfg.Pending, m.PendingChannels, m.Transactions, m.Info),
+		PendingChan:  NewPendingChannel(m.PendingChannels, m.Transactions, m.Info),
+		Closed:       NewClosed(cfg.Closed, m.ClosedChannels),
+		Profit:       NewProfit(cfg.Profit, m.Profit),
+		Nodes:        NewNodes(nodes),
+		Dialog:       NewDialog(),
+		QRCode:       NewQRCode(),
 		Main:         main,
 	}
+
+	if nodes.Len() > 1 {
+		views.Header.Node = m.Name()
+		views.Menu.AddItem("NODES")
+	}
+
+	return views
 }
 
 func ToScid(id uint64) string {
@@ -0,0 +1,438 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"github.com/edouardparis/lntop/config"
+	netmodels "github.com/edouardparis/lntop/network/models"
+	"github.com/edouardparis/lntop/ui/color"
+	"github.com/edouardparis/lntop/ui/models"
+)
+
+const (
+	WALLET         = "wallet"
+	WALLET_COLUMNS = "wallet_columns"
+	WALLET_FOOTER  = "wallet_footer"
+)
+
+type Wallet struct {
+	cfg *config.View
+
+	columns           []walletColumn
+	columnHeadersView *gocui.View
+	view              *gocui.View
+	utxos             *models.Utxos
+
+	ox, oy int
+	cx, cy int
+}
+
+type walletColumn struct {
+	name    string
+	width   int
+	sorted  bool
+	sort    func(models.Order) models.UtxosSort
+	display func(*netmodels.Utxo, ...color.Option) string
+}
+
+func (c Wallet) Index() int {
+	_, oy := c.view.Origin()
+	_, cy := c.view.Cursor()
+	return cy + oy
+}
+
+func (c Wallet) Name() string {
+	return WALLET
+}
+
+func (c *Wallet) Wrap(v *gocui.View) View {
+	c.view = v
+	return c
+}
+
+func (c Wallet) currentColumnIndex() int {
+	x := c.ox + c.cx
+	index := 0
+	sum := 0
+	for i := range c.columns {
+		sum += c.columns[i].width + 1
+		if x < sum {
+			return index
+		}
+		index++
+	}
+	return index
+}
+
+func (c Wallet) Origin() (int, int) {
+	return c.ox, c.oy
+}
+
+func (c Wallet) Cursor() (int, int) {
+	return c.cx, c.cy
+}
+
+func (c *Wallet) SetCursor(cx, cy int) error {
+	if err := cursorCompat(c.columnHeadersView, cx, 0); err != nil {
+		return err
+	}
+	err := c.columnHeadersView.SetCursor(cx, 0)
+	if err != nil {
+		return err
+	}
+
+	if err := cursorCompat(c.view, cx, cy); err != nil {
+		return err
+	}
+	err = c.view.SetCursor(cx, cy)
+	if err != nil {
+		return err
+	}
+
+	c.cx, c.cy = cx, cy
+	return nil
+}
+
+func (c *Wallet) SetOrigin(ox, oy int) error {
+	err := c.columnHeadersView.SetOrigin(ox, 0)
+	if err != nil {
+		return err
+	}
+	err = c.view.SetOrigin(ox, oy)
+	if err != nil {
+		return err
+	}
+
+	c.ox, c.oy = ox, oy
+	return nil
+}
+
+func (c *Wallet) Speed() (int, int, int, int) {
+	current := c.currentColumnIndex()
+	up := 0
+	down := 0
+	if c.Index() > 0 {
+		up = 1
+	}
+	if c.Index() < c.utxos.Len()-1 {
+		down = 1
+	}
+	if current > len(c.columns)-1 {
+		return 0, c.columns[current-1].width + 1, down, up
+	}
+	if current == 0 {
+		return c.columns[0].width + 1, 0, down, up
+	}
+	return c.columns[current].width + 1,
+		c.columns[current-1].width + 1,
+		down, up
+}
+
+func (c *Wallet) Limits() (pageSize int, fullSize int) {
+	_, pageSize = c.view.Size()
+	fullSize = c.utxos.Len()
+	return
+}
+
+func (c *Wallet) Sort(column string, order models.Order) {
+	if column == "" {
+		index := c.currentColumnIndex()
+		if index >= len(c.columns) {
+			return
+		}
+		col := c.columns[index]
+		if col.sort == nil {
+			return
+		}
+
+		c.utxos.Sort(col.sort(order))
+		for i := range c.columns {
+			c.columns[i].sorted = (i == index)
+		}
+	}
+}
+
+func (c Wallet) Delete(g *gocui.Gui) error {
+	err := g.DeleteView(WALLET_COLUMNS)
+	if err != nil {
+		return err
+	}
+
+	err = g.DeleteView(WALLET)
+	if err != nil {
+		return err
+	}
+
+	return g.DeleteView(WALLET_FOOTER)
+}
+
+func (c *Wallet) Set(g *gocui.Gui, x0, y0, x1, y1 int) error {
+	var err error
+	setCursor := false
+	c.columnHeadersView, err = g.SetView(WALLET_COLUMNS, x0-1, y0, x1+2, y0+2, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.columnHeadersView.Frame = false
+	c.columnHeadersView.BgColor = gocui.ColorGreen
+	c.columnHeadersView.FgColor = gocui.ColorBlack
+
+	c.view, err = g.SetView(WALLET, x0-1, y0+1, x1+2, y1-1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+		setCursor = true
+	}
+	c.view.Frame = false
+	c.view.Autoscroll = false
+	c.view.SelBgColor = gocui.ColorCyan
+	c.view.SelFgColor = gocui.ColorBlack | gocui.AttrDim
+	c.view.Highlight = true
+	c.display()
+
+	if setCursor {
+		ox, oy := c.Origin()
+		err := c.SetOrigin(ox, oy)
+		if err != nil {
+			return err
+		}
+
+		cx, cy := c.Cursor()
+		err = c.SetCursor(cx, cy)
+		if err != nil {
+			return err
+		}
+	}
+
+	footer, err := g.SetView(WALLET_FOOTER, x0-1, y1-2, x1+2, y1, 0)
+	if err != nil {
+		if err != gocui.ErrUnknownView {
+			return err
+		}
+	}
+	footer.Frame = false
+	footer.BgColor = gocui.ColorCyan
+	footer.FgColor = gocui.ColorBlack
+	footer.Rewind()
+	blackBg := color.Black(color.Background)
+	fmt.Fprintln(footer, fmt.Sprintf("%s%s %s%s %s%s %s%s %s",
+		blackBg("F2"), "Menu",
+		blackBg("Space"), "Select",
+		blackBg("S"), "Send",
+		blackBg("F10"), "Quit",
+		c.selection(),
+	))
+	return nil
+}
+
+// selection returns the number and the amount of the selected outputs.
+func (c *Wallet) selection() string {
+	selected := c.utxos.Selected()
+	if len(selected) == 0 {
+		return ""
+	}
+	total := int64(0)
+	for _, utxo := range selected {
+		total += utxo.Amount
+	}
+	return message.NewPrinter(language.English).Sprintf("%d selected: %d sat", len(selected), total)
+}
+
+func (c *Wallet) display() {
+	c.columnHeadersView.Rewind()
+	var buffer bytes.Buffer
+	current := c.currentColumnIndex()
+	for i := range c.columns {
+		if current == i {
+			buffer.WriteString(color.Cyan(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		} else if c.columns[i].sorted {
+			buffer.WriteString(color.Magenta(color.Background)(c.columns[i].name))
+			buffer.WriteString(" ")
+			continue
+		}
+		buffer.WriteString(c.columns[i].name)
+		buffer.WriteString(" ")
+	}
+	fmt.Fprintln(c.columnHeadersView, buffer.String())
+
+	// the outputs are spent, the view is cleared to remove their rows and
+	// the cursor, reset by Clear, is restored.
+	c.view.Clear()
+	for _, item := range c.utxos.List() {
+		var buffer bytes.Buffer
+		for i := range c.columns {
+			var opt color.Option
+			if current == i {
+				opt = color.Bold
+			}
+			buffer.WriteString(c.columns[i].display(item, opt))
+			buffer.WriteString(" ")
+		}
+		fmt.Fprintln(c.view, buffer.String())
+	}
+	if c.oy+c.cy > c.utxos.Len()-1 && c.cy > 0 {
+		c.cy = c.utxos.Len() - 1 - c.oy
+		if c.cy < 0 {
+			c.cy = 0
+		}
+	}
+	c.view.SetOrigin(c.ox, c.oy)
+	c.view.SetCursor(c.cx, c.cy)
+}
+
+// addressTypeString returns the name of the type of an address.
+func addressTypeString(addressType int) string {
+	switch addressType {
+	case netmodels.AddressP2WPKH:
+		return "p2wpkh"
+	case netmodels.AddressNP2WKH:
+		return "np2wkh"
+	case netmodels.AddressP2TR:
+		return "p2tr"
+	default:
+		return "unknown"
+	}
+}
+
+func NewWallet(cfg *config.View, utxos *models.Utxos) *Wallet {
+	view := &Wallet{
+		cfg:   cfg,
+		utxos: utxos,
+	}
+
+	printer := message.NewPrinter(language.English)
+
+	columns := config.DefaultWalletColumns
+	if cfg != nil && len(cfg.Columns) != 0 {
+		columns = cfg.Columns
+	}
+
+	view.columns = make([]walletColumn, len(columns))
+
+	for i := range columns {
+		switch columns[i] {
+		case "SELECTED":
+			view.columns[i] = walletColumn{
+				width: 8,
+				name:  fmt.Sprintf("%-8s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.BoolSort(utxos.IsSelected(u1), utxos.IsSelected(u2), order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					if utxos.IsSelected(u) {
+						return color.Green(opts...)(fmt.Sprintf("%-8s", "[x]"))
+					}
+					return color.White(opts...)(fmt.Sprintf("%-8s", "[ ]"))
+				},
+			}
+		case "OUTPOINT":
+			view.columns[i] = walletColumn{
+				width: 22,
+				name:  fmt.Sprintf("%-22s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.StringSort(u1.Outpoint(), u2.Outpoint(), order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					outpoint := u.Outpoint()
+					if len(u.TxID) > 16 {
+						outpoint = fmt.Sprintf("%s…%s:%d", u.TxID[:8], u.TxID[len(u.TxID)-8:], u.OutputIndex)
+					}
+					return color.White(opts...)(fmt.Sprintf("%-22s", outpoint))
+				},
+			}
+		case "TXID":
+			view.columns[i] = walletColumn{
+				width: 64,
+				name:  fmt.Sprintf("%-64s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.StringSort(u1.TxID, u2.TxID, order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-64s", u.TxID))
+				},
+			}
+		case "AMOUNT":
+			view.columns[i] = walletColumn{
+				width: 13,
+				name:  fmt.Sprintf("%13s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.Int64Sort(u1.Amount, u2.Amount, order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					return color.Cyan(opts...)(printer.Sprintf("%13d", u.Amount))
+				},
+			}
+		case "CONFIRMATIONS":
+			view.columns[i] = walletColumn{
+				width: 13,
+				name:  fmt.Sprintf("%13s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.Int64Sort(u1.Confirmations, u2.Confirmations, order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					if u.Confirmations == 0 {
+						return color.Yellow(opts...)(fmt.Sprintf("%13s", "unconfirmed"))
+					}
+					return color.White(opts...)(printer.Sprintf("%13d", u.Confirmations))
+				},
+			}
+		case "TYPE":
+			view.columns[i] = walletColumn{
+				width: 7,
+				name:  fmt.Sprintf("%-7s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.IntSort(u1.AddressType, u2.AddressType, order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-7s", addressTypeString(u.AddressType)))
+				},
+			}
+		case "ADDRESS":
+			view.columns[i] = walletColumn{
+				width: 62,
+				name:  fmt.Sprintf("%-62s", columns[i]),
+				sort: func(order models.Order) models.UtxosSort {
+					return func(u1, u2 *netmodels.Utxo) bool {
+						return models.StringSort(u1.Address, u2.Address, order)
+					}
+				},
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					return color.White(opts...)(fmt.Sprintf("%-62s", u.Address))
+				},
+			}
+		default:
+			view.columns[i] = walletColumn{
+				name:  fmt.Sprintf("%-21s", columns[i]),
+				width: 21,
+				display: func(u *netmodels.Utxo, opts ...color.Option) string {
+					return "column does not exist"
+				},
+			}
+		}
+	}
+
+	return view
+}